@@ -10,10 +10,13 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bllooop/coinshop/internal/delivery/api"
 	"github.com/bllooop/coinshop/internal/repository"
 	"github.com/bllooop/coinshop/internal/usecase"
+	"github.com/bllooop/coinshop/pkg/oidc/oidctest"
+	"github.com/bllooop/coinshop/pkg/passhash"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
@@ -26,6 +29,7 @@ type AuthHandlerTestSuite struct {
 	pgContainer *PostgresContainer
 	db          *sqlx.DB
 	repository  *repository.Repository
+	hasher      passhash.Hasher
 	handler     *api.Handler
 }
 
@@ -60,9 +64,12 @@ func (suite *AuthHandlerTestSuite) SetupSuite() {
 	assert.NoError(suite.T(), err)
 
 	suite.repository = repository.NewRepository(db)
+	suite.hasher, err = passhash.New(passhash.Config{})
+	assert.NoError(suite.T(), err)
 
 	usecases := &usecase.Usecase{
-		Authorization: usecase.NewAuthUsecase(suite.repository),
+		Authorization: usecase.NewAuthUsecase(suite.repository, suite.hasher, 1000, 0, usecase.LoginConfig{}),
+		Audit:         usecase.NewAuditUsecase(suite.repository),
 	}
 
 	suite.handler = &api.Handler{Usecases: usecases}
@@ -98,7 +105,7 @@ func (suite *AuthHandlerTestSuite) TestSignUp() {
 }
 
 func (suite *AuthHandlerTestSuite) TestSignIn() {
-	pass, err := usecase.HashPassword("password1")
+	pass, err := suite.hasher.Hash("password1")
 	assert.NoError(suite.T(), err)
 	_, err = suite.db.Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
 		"name", 1000, pass)
@@ -129,6 +136,60 @@ func (suite *AuthHandlerTestSuite) TestSignIn() {
 	assert.Equal(suite.T(), "name", username)
 }
 
+func (suite *AuthHandlerTestSuite) TestOidcSignIn() {
+	idp := oidctest.NewServer("coinshop", "secret")
+	defer idp.Close()
+	idp.SetIdentity(oidctest.Identity{Subject: "abc", Email: "ivan@example.com", EmailVerified: true})
+
+	auth := usecase.NewAuthUsecase(suite.repository, suite.hasher, 1000, 0, usecase.LoginConfig{})
+	handler := &api.Handler{Usecases: &usecase.Usecase{
+		Authorization: auth,
+		Audit:         usecase.NewAuditUsecase(suite.repository),
+		Oidc: usecase.NewOidcUsecase(suite.repository, auth, usecase.OidcConfig{
+			Provider: idp.Config("http://localhost:8080/api/auth/oidc/callback"),
+			StateTTL: time.Minute,
+		}),
+	}}
+	r := gin.New()
+	r.GET("/api/auth/oidc/login", handler.OidcLogin)
+	r.GET("/api/auth/oidc/callback", handler.OidcCallback)
+
+	// имя уже занято, поэтому аккаунт пользователя провайдера получает имя с суффиксом
+	_, err := suite.db.Exec("INSERT INTO userlist (username, coins, password) VALUES ('ivan', 0, '')")
+	assert.NoError(suite.T(), err)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/api/auth/oidc/login", nil))
+		assert.Equal(suite.T(), http.StatusFound, w.Code)
+
+		callback, err := idp.Authorize(w.Header().Get("Location"))
+		assert.NoError(suite.T(), err)
+		req := httptest.NewRequest("GET", callback.RequestURI(), nil)
+		for _, cookie := range w.Result().Cookies() {
+			req.AddCookie(cookie)
+		}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.Contains(suite.T(), w.Body.String(), `"token"`)
+
+		// повторный возврат с тем же state отклоняется
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	}
+
+	var count, coins int
+	var username string
+	err = suite.db.QueryRow("SELECT count(*), min(username), min(coins) FROM userlist WHERE oidc_subject = 'abc'").
+		Scan(&count, &username, &coins)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, count, "повторный вход находит созданный аккаунт")
+	assert.True(suite.T(), strings.HasPrefix(username, "ivan_"))
+	assert.Equal(suite.T(), 1000, coins)
+}
+
 func TestAuthHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(AuthHandlerTestSuite))
 }
@@ -62,14 +62,14 @@ func (suite *ShopHandlerTestSuite) SetupSuite() {
 	suite.repository = repository.NewRepository(db)
 
 	usecases := &usecase.Usecase{
-		Shop: usecase.NewShopUsecase(suite.repository),
+		Shop: usecase.NewShopUsecase(suite.repository, domain.TransferLimits{}),
 	}
 
 	suite.handler = &api.Handler{Usecases: usecases}
 }
 
 func (suite *ShopHandlerTestSuite) SetupTest() {
-	_, err := suite.db.Exec("TRUNCATE TABLE userlist, transactions,purchases RESTART IDENTITY CASCADE")
+	_, err := suite.db.Exec("TRUNCATE TABLE userlist, transactions, purchases, orders RESTART IDENTITY CASCADE")
 	assert.NoError(suite.T(), err)
 }
 
@@ -185,7 +185,8 @@ func (suite *ShopHandlerTestSuite) TestGetInfo() {
 							{
 								"destination": 2,
 								"destination_username": "name2",
-								"amount": 10
+								"amount": 10,
+								"kind": "transfer"
 							}
 						]
 					}
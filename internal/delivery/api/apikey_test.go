@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_createApiKey(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockApiKeys)
+	expiresAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, 3, 20, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		accountId            string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			accountId: "2",
+			inputBody: `{"scopes":["coins:send"],"expires_at":"2025-06-01T00:00:00Z"}`,
+			mockBehavior: func(s *mock_usecase.MockApiKeys) {
+				s.EXPECT().CreateApiKey(1, 2, domain.ApiKeyInput{Scopes: []string{domain.ScopeCoinsSend}, ExpiresAt: &expiresAt}).
+					Return(domain.CreatedApiKey{
+						Key: "cs_0123456789ab_secret",
+						ApiKey: domain.ApiKey{Id: 5, ServiceAccountId: 2, Prefix: "0123456789ab",
+							Scopes: []string{domain.ScopeCoinsSend}, ExpiresAt: expiresAt, CreatedAt: createdAt},
+					}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"key":"cs_0123456789ab_secret","api_key":{"id":5,"service_account_id":2,"prefix":"0123456789ab",
+			"scopes":["coins:send"],"expires_at":"2025-06-01T00:00:00Z","created_at":"2025-03-20T12:00:00Z"}}`,
+		},
+		{
+			name:      "Неизвестное разрешение",
+			accountId: "2",
+			inputBody: `{"scopes":["everything"]}`,
+			mockBehavior: func(s *mock_usecase.MockApiKeys) {
+				s.EXPECT().CreateApiKey(1, 2, domain.ApiKeyInput{Scopes: []string{"everything"}}).
+					Return(domain.CreatedApiKey{}, domain.ErrUnknownScope)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"` + domain.ErrUnknownScope.Error() + `"}`,
+		},
+		{
+			name:      "Сервисный аккаунт не найден",
+			accountId: "9",
+			inputBody: `{"scopes":["coins:send"]}`,
+			mockBehavior: func(s *mock_usecase.MockApiKeys) {
+				s.EXPECT().CreateApiKey(1, 9, domain.ApiKeyInput{Scopes: []string{domain.ScopeCoinsSend}}).
+					Return(domain.CreatedApiKey{}, domain.ErrServiceAccountNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + domain.ErrServiceAccountNotFound.Error() + `"}`,
+		},
+		{
+			name:                 "Некорректный id",
+			accountId:            "abc",
+			inputBody:            `{"scopes":["coins:send"]}`,
+			mockBehavior:         func(s *mock_usecase.MockApiKeys) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Некорректный id сервисного аккаунта"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			apiKeys := mock_usecase.NewMockApiKeys(c)
+			testCase.mockBehavior(apiKeys)
+
+			usecases := &usecase.Usecase{ApiKeys: apiKeys, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/service-accounts/:id/keys", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.CreateApiKey)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/service-accounts/"+testCase.accountId+"/keys",
+				bytes.NewBufferString(testCase.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_revokeApiKey(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockApiKeys)
+	revokedAt := time.Date(2025, 3, 21, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_usecase.MockApiKeys) {
+				s.EXPECT().RevokeApiKey(5).Return(domain.ApiKey{Id: 5, ServiceAccountId: 2, Prefix: "0123456789ab",
+					Scopes: []string{domain.ScopeCoinsSend}, RevokedAt: &revokedAt}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":5,"service_account_id":2,"prefix":"0123456789ab","scopes":["coins:send"],
+			"expires_at":"0001-01-01T00:00:00Z","revoked_at":"2025-03-21T09:00:00Z","created_at":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "Уже отозван",
+			mockBehavior: func(s *mock_usecase.MockApiKeys) {
+				s.EXPECT().RevokeApiKey(5).Return(domain.ApiKey{}, domain.ErrApiKeyRevoked)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"` + domain.ErrApiKeyRevoked.Error() + `"}`,
+		},
+		{
+			name: "Не найден",
+			mockBehavior: func(s *mock_usecase.MockApiKeys) {
+				s.EXPECT().RevokeApiKey(5).Return(domain.ApiKey{}, domain.ErrApiKeyNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + domain.ErrApiKeyNotFound.Error() + `"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			apiKeys := mock_usecase.NewMockApiKeys(c)
+			testCase.mockBehavior(apiKeys)
+
+			usecases := &usecase.Usecase{ApiKeys: apiKeys, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/api-keys/:id/revoke", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.RevokeApiKey)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/api-keys/5/revoke", nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreateServiceAccount(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на создание сервисного аккаунта")
+	adminId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.ServiceAccountInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	account, err := h.Usecases.ApiKeys.CreateServiceAccount(adminId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Создан сервисный аккаунт %v", account.Id)
+	if !h.auditAction(c, domain.AuditServiceAccount, domain.AuditTargetServiceAccount, strconv.Itoa(account.Id), nil, account) {
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *Handler) GetServiceAccounts(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на просмотр сервисных аккаунтов")
+	accounts, err := h.Usecases.ApiKeys.GetServiceAccounts()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на просмотр сервисных аккаунтов")
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (h *Handler) CreateApiKey(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на выпуск ключа API")
+	adminId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	accountId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id сервисного аккаунта")
+		return
+	}
+	var input domain.ApiKeyInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	created, err := h.Usecases.ApiKeys.CreateApiKey(adminId, accountId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Выпущен ключ API %v для сервисного аккаунта %v", created.ApiKey.Id, accountId)
+	// в журнал попадает только описание ключа, секрет показывается один раз в ответе
+	if !h.auditAction(c, domain.AuditApiKeyCreate, domain.AuditTargetApiKey, strconv.Itoa(created.ApiKey.Id), nil, created.ApiKey) {
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+func (h *Handler) GetApiKeys(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на просмотр ключей API")
+	accountId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id сервисного аккаунта")
+		return
+	}
+	keys, err := h.Usecases.ApiKeys.GetApiKeys(accountId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на просмотр ключей API")
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *Handler) RevokeApiKey(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на отзыв ключа API")
+	keyId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id ключа API")
+		return
+	}
+	key, err := h.Usecases.ApiKeys.RevokeApiKey(keyId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Ключ API %v отозван", keyId)
+	if !h.auditAction(c, domain.AuditApiKeyRevoke, domain.AuditTargetApiKey, strconv.Itoa(keyId), nil, key) {
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// newAuditMock принимает любые события журнала аудита, для тестов, которые его не проверяют.
+func newAuditMock(c *gomock.Controller) *mock_usecase.MockAudit {
+	audit := mock_usecase.NewMockAudit(c)
+	audit.EXPECT().Record(gomock.Any()).Return(nil).AnyTimes()
+	return audit
+}
+
+func TestHandler_auditRecordsEvent(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	users := mock_usecase.NewMockUsers(c)
+	before := domain.UserInfo{Id: 3, UserName: "anna", Role: domain.RoleUser}
+	after := domain.UserInfo{Id: 3, UserName: "anna", Role: domain.RoleUser, BanReason: "спам"}
+	users.EXPECT().GetUser(3).Return(before, nil)
+	users.EXPECT().BanUser(1, 3, domain.BanInput{Reason: "спам"}).Return(after, nil)
+	audit := mock_usecase.NewMockAudit(c)
+	actorId := 1
+	audit.EXPECT().Record(domain.AuditEvent{
+		Action:     domain.AuditUserBan,
+		ActorId:    &actorId,
+		TargetType: domain.AuditTargetUser,
+		TargetId:   "3",
+		Before:     before,
+		After:      after,
+		IP:         "192.0.2.1",
+		RequestId:  "req-1",
+	}).Return(nil)
+
+	handler := Handler{&usecase.Usecase{Users: users, Audit: audit}}
+	r := gin.New()
+	r.POST("/api/admin/users/:id/ban", handler.requestId, func(c *gin.Context) {
+		c.Set("userId", 1)
+		handler.BanUser(c)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/admin/users/3/ban", bytes.NewBufferString(`{"reason":"спам"}`))
+	req.RemoteAddr = "192.0.2.1:4000"
+	req.Header.Set(requestIdHeader, "req-1")
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "req-1", w.Header().Get(requestIdHeader))
+}
+
+func TestHandler_auditRecordsBeforeState(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	actorId := 1
+	inventory := mock_usecase.NewMockInventory(c)
+	before := domain.Merch{Id: 2, Name: "cup", Price: 20, Stock: intPointer(3)}
+	after := domain.Merch{Id: 2, Name: "cup", Price: 20, Stock: intPointer(8)}
+	inventory.EXPECT().Restock("cup", 5).Return(domain.ItemStockChange{Item: after, Before: before}, nil)
+	treasury := mock_usecase.NewMockTreasury(c)
+	input := domain.CoinAdjustmentInput{Username: "intern", Amount: 200, Reason: "бонус"}
+	treasury.EXPECT().GrantCoins(1, input).Return(domain.CoinAdjustment{TransactionId: 7, BalanceBefore: 50, BalanceAfter: 250}, nil)
+	audit := mock_usecase.NewMockAudit(c)
+	audit.EXPECT().Record(domain.AuditEvent{
+		Action:     domain.AuditCatalogRestock,
+		ActorId:    &actorId,
+		TargetType: domain.AuditTargetItem,
+		TargetId:   "cup",
+		Before:     before,
+		After:      after,
+		IP:         "192.0.2.1",
+	}).Return(nil)
+	audit.EXPECT().Record(domain.AuditEvent{
+		Action:     domain.AuditCoinsGrant,
+		ActorId:    &actorId,
+		TargetType: domain.AuditTargetUser,
+		TargetId:   "intern",
+		Before:     map[string]interface{}{"coins": 50},
+		After:      map[string]interface{}{"coins": 250, "amount": 200, "reason": "бонус", "transaction_id": 7},
+		IP:         "192.0.2.1",
+	}).Return(nil)
+
+	handler := Handler{&usecase.Usecase{Inventory: inventory, Treasury: treasury, Audit: audit}}
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("userId", 1) })
+	r.POST("/api/admin/shop/:item/restock", handler.RestockItem)
+	r.POST("/api/admin/coins/grant", handler.GrantCoins)
+
+	for path, body := range map[string]string{
+		"/api/admin/shop/cup/restock": `{"quantity":5}`,
+		"/api/admin/coins/grant":      `{"username":"intern","amount":200,"reason":"бонус"}`,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.RemoteAddr = "192.0.2.1:4000"
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+	}
+}
+
+func TestHandler_auditFailure(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	inventory := mock_usecase.NewMockInventory(c)
+	inventory.EXPECT().Restock("cup", 5).Return(domain.ItemStockChange{
+		Item: domain.Merch{Id: 2, Name: "cup", Price: 20, Stock: intPointer(8)},
+	}, nil)
+	auth := mock_usecase.NewMockAuthorization(c)
+	auth.EXPECT().SignUser("anna", "password", "192.0.2.1").Return(domain.User{Id: 2, UserName: "anna"}, nil)
+	auth.EXPECT().GenerateToken(2).Return("token", nil)
+	audit := mock_usecase.NewMockAudit(c)
+	audit.EXPECT().Record(gomock.Any()).Return(errors.New("connection refused")).Times(2)
+
+	handler := Handler{&usecase.Usecase{Inventory: inventory, Authorization: auth, Audit: audit}}
+	r := gin.New()
+	r.POST("/api/admin/shop/:item/restock", handler.RestockItem)
+	r.POST("/api/auth/sign-in", handler.SignIn)
+
+	// административное действие без записи в журнале не подтверждается
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/admin/shop/cup/restock", bytes.NewBufferString(`{"quantity":5}`))
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 500, w.Code)
+	assert.JSONEq(t, `{"message":"действие выполнено, но не записано в журнал аудита"}`, w.Body.String())
+
+	// вход не зависит от записи в журнал
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/api/auth/sign-in", bytes.NewBufferString(`{"username":"anna","password":"password"}`))
+	req.RemoteAddr = "192.0.2.1:4000"
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
+
+func TestHandler_requestId(t *testing.T) {
+	r := gin.New()
+	r.GET("/", (&Handler{}).requestId, func(c *gin.Context) {
+		c.String(200, c.GetString(requestIdCtx))
+	})
+
+	for _, header := range []string{"", "bad id", string(bytes.Repeat([]byte("a"), maxRequestIdLength+1))} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(requestIdHeader, header)
+		r.ServeHTTP(w, req)
+
+		assert.Len(t, w.Body.String(), 32)
+		assert.NotEqual(t, header, w.Body.String())
+		assert.Equal(t, w.Body.String(), w.Header().Get(requestIdHeader))
+	}
+}
+
+func TestHandler_getAuditLog(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockAudit, filter domain.AuditFilter)
+
+	actorId := 1
+	from := time.Date(2025, 3, 18, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, 3, 18, 12, 0, 0, 0, time.UTC)
+	testTable := []struct {
+		name                 string
+		query                string
+		filter               domain.AuditFilter
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			query:  "?action=user.ban&actor_id=1&from=2025-03-18T03:00:00%2B03:00&limit=10",
+			filter: domain.AuditFilter{Action: domain.AuditUserBan, ActorId: &actorId, From: &from, Limit: 10},
+			mockBehavior: func(s *mock_usecase.MockAudit, filter domain.AuditFilter) {
+				s.EXPECT().GetAuditLog(filter).Return([]domain.AuditEntry{{
+					Id: 5, Action: domain.AuditUserBan, ActorId: &actorId, TargetType: domain.AuditTargetUser, TargetId: "3",
+					After: []byte(`{"id":3}`), IP: "192.0.2.1", RequestId: "req-1", CreatedAt: createdAt,
+					PrevHash: "aa", Hash: "bb",
+				}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `[{"id":5,"action":"user.ban","actor_id":1,"target_type":"user","target_id":"3",
+				"after":{"id":3},"ip":"192.0.2.1","request_id":"req-1","created_at":"2025-03-18T12:00:00Z",
+				"prev_hash":"aa","hash":"bb"}]`,
+		},
+		{
+			name:                 "Некорректное время",
+			query:                "?from=вчера",
+			mockBehavior:         func(s *mock_usecase.MockAudit, filter domain.AuditFilter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"время должно быть в формате RFC3339"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockAudit(c)
+			testCase.mockBehavior(repo, testCase.filter)
+
+			usecases := &usecase.Usecase{Audit: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.GET("/api/audit", handler.GetAuditLog)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/audit"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_verifyAuditLog(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	brokenAt := int64(4)
+	repo := mock_usecase.NewMockAudit(c)
+	repo.EXPECT().VerifyAuditLog().Return(domain.AuditVerification{Checked: 3, BrokenAt: &brokenAt}, nil)
+
+	handler := Handler{&usecase.Usecase{Audit: repo}}
+	r := gin.New()
+	r.GET("/api/audit/verify", handler.VerifyAuditLog)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/audit/verify", nil)
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"valid":false,"checked":3,"broken_at":4}`, w.Body.String())
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// audit записывает событие в журнал аудита от имени текущего пользователя, если он определен.
+// Используется для входов и запросов с ключами API: ошибка записи только логируется и не мешает запросу.
+func (h *Handler) audit(c *gin.Context, action, targetType, targetId string, before, after interface{}) {
+	h.recordAudit(c, action, targetType, targetId, before, after) // nolint:errcheck
+}
+
+// auditAction записывает в журнал административное действие или изменение профиля. Если записать
+// событие не удалось, отвечает кодом 500 вместо результата действия и возвращает false.
+func (h *Handler) auditAction(c *gin.Context, action, targetType, targetId string, before, after interface{}) bool {
+	if err := h.recordAudit(c, action, targetType, targetId, before, after); err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, domain.ErrAuditUnavailable.Error())
+		return false
+	}
+	return true
+}
+
+func (h *Handler) recordAudit(c *gin.Context, action, targetType, targetId string, before, after interface{}) error {
+	event := domain.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetId:   targetId,
+		Before:     before,
+		After:      after,
+		IP:         c.ClientIP(),
+		RequestId:  c.GetString(requestIdCtx),
+	}
+	if userId, err := getUserId(c); err == nil {
+		event.ActorId = &userId
+	}
+	if err := h.Usecases.Audit.Record(event); err != nil {
+		logger.Log.Error().Err(err).Str("action", action).Str("request_id", event.RequestId).
+			Msg("Не удалось записать событие в журнал аудита")
+		return err
+	}
+	return nil
+}
+
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на просмотр журнала аудита")
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	entries, err := h.Usecases.Audit.GetAuditLog(filter)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на просмотр журнала аудита")
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *Handler) VerifyAuditLog(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на проверку целостности журнала аудита")
+	result, err := h.Usecases.Audit.VerifyAuditLog()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	if !result.Valid {
+		logger.Log.Warn().Int64("broken_at", *result.BrokenAt).Msg("Цепочка хешей журнала аудита нарушена")
+	}
+	logger.Log.Info().Msg("Получен ответ на проверку журнала аудита")
+
+	c.JSON(http.StatusOK, result)
+}
+
+func parseAuditFilter(c *gin.Context) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetId:   c.Query("target_id"),
+	}
+	if actor := c.Query("actor_id"); actor != "" {
+		value, err := strconv.Atoi(actor)
+		if err != nil {
+			return filter, errors.New("actor_id должен быть числом")
+		}
+		filter.ActorId = &value
+	}
+	for param, dest := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if raw := c.Query(param); raw != "" {
+			value, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return filter, errors.New("время должно быть в формате RFC3339")
+			}
+			value = value.UTC()
+			*dest = &value
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return filter, errors.New("лимит должен быть положительным числом")
+		}
+		filter.Limit = value
+	}
+	if offset := c.Query("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return filter, errors.New("смещение должно быть неотрицательным числом")
+		}
+		filter.Offset = value
+	}
+	return filter, nil
+}
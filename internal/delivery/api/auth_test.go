@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/usecase"
@@ -33,7 +34,6 @@ func TestHandler_signUp(t *testing.T) {
 			inputUser: domain.User{
 				UserName: "test",
 				Password: "12345",
-				Coins:    intPointer(1000),
 			},
 			mockBehavior: func(s *mock_usecase.MockAuthorization, user domain.User) {
 				s.EXPECT().CreateUser(user).Return(1, nil)
@@ -47,7 +47,6 @@ func TestHandler_signUp(t *testing.T) {
 			inputUser: domain.User{
 				UserName: "test",
 				Password: "12345",
-				Coins:    intPointer(1000),
 			},
 			mockBehavior: func(s *mock_usecase.MockAuthorization, user domain.User) {
 				s.EXPECT().CreateUser(user).Return(0, errors.New("Internal Server Error"))
@@ -96,6 +95,7 @@ func TestHandler_signUp(t *testing.T) {
 
 func TestHandler_signIn(t *testing.T) {
 	type mockBehavior func(s *mock_usecase.MockAuthorization, username, password string)
+	locked := &domain.LoginThrottledError{Until: time.Now().Add(15 * time.Minute).Truncate(time.Second), Locked: true}
 
 	testTable := []struct {
 		name                 string
@@ -112,24 +112,23 @@ func TestHandler_signIn(t *testing.T) {
 			username:  "name",
 			password:  "12345",
 			mockBehavior: func(s *mock_usecase.MockAuthorization, username, password string) {
-				s.EXPECT().SignUser("name", "12345").Return(domain.User{Id: 1}, nil)
+				s.EXPECT().SignUser("name", "12345", "192.0.2.1").Return(domain.User{Id: 1}, nil)
 				s.EXPECT().GenerateToken(1).Return("valid.jwt.token", nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"token":"valid.jwt.token"}`,
 		},
 		{
-			name:      "Пользователь не найден - регистрация",
+			// вход не регистрирует пользователя: неизвестное имя неотличимо от неверного пароля
+			name:      "Неверные данные",
 			inputBody: `{"username":"notname", "password":"password123"}`,
 			username:  "notname",
 			password:  "password123",
 			mockBehavior: func(s *mock_usecase.MockAuthorization, username, password string) {
-				s.EXPECT().SignUser("notname", "password123").Return(domain.User{}, errors.New("пользователь не найден"))
-				s.EXPECT().CreateUser(domain.User{UserName: "notname", Password: "password123", Coins: intPointer(1000)}).Return(2, nil)
-				s.EXPECT().GenerateToken(2).Return("newuser.jwt.token", nil)
+				s.EXPECT().SignUser("notname", "password123", "192.0.2.1").Return(domain.User{}, domain.ErrInvalidCredentials)
 			},
-			expectedStatusCode:   200,
-			expectedResponseBody: `{"id":2, "token":"newuser.jwt.token"}`,
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"Ошибка авторизации: ` + domain.ErrInvalidCredentials.Error() + `"}`,
 		},
 		{
 			name:                 "Invalid JSON Input",
@@ -144,11 +143,22 @@ func TestHandler_signIn(t *testing.T) {
 			username:  "test",
 			password:  "12345",
 			mockBehavior: func(s *mock_usecase.MockAuthorization, username, password string) {
-				s.EXPECT().SignUser("test", "12345").Return(domain.User{}, errors.New("Internal Server Error"))
+				s.EXPECT().SignUser("test", "12345", "192.0.2.1").Return(domain.User{}, errors.New("Internal Server Error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"Ошибка авторизации: Internal Server Error"}`,
 		},
+		{
+			name:      "Вход заблокирован",
+			inputBody: `{"username":"test", "password":"12345"}`,
+			username:  "test",
+			password:  "12345",
+			mockBehavior: func(s *mock_usecase.MockAuthorization, username, password string) {
+				s.EXPECT().SignUser("test", "12345", "192.0.2.1").Return(domain.User{}, locked)
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: `{"message":"` + locked.Error() + `"}`,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -159,7 +169,7 @@ func TestHandler_signIn(t *testing.T) {
 			repo := mock_usecase.NewMockAuthorization(c)
 			testCase.mockBehavior(repo, testCase.username, testCase.password)
 
-			usecases := &usecase.Usecase{Authorization: repo}
+			usecases := &usecase.Usecase{Authorization: repo, Audit: newAuditMock(c)}
 			handler := Handler{usecases}
 			r := gin.New()
 			r.POST("/api/auth/sign-in", handler.SignIn)
@@ -179,3 +189,55 @@ func TestHandler_signIn(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_unlockLogin(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockAuthorization, input domain.LoginUnlockInput)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.LoginUnlockInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"username":"anna","ip":"10.0.0.1"}`,
+			input:     domain.LoginUnlockInput{Username: "anna", IP: "10.0.0.1"},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, input domain.LoginUnlockInput) {
+				s.EXPECT().UnlockLogin(input).Return(2, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"unlocked":2}`,
+		},
+		{
+			name:                 "Пустой запрос",
+			inputBody:            `{}`,
+			mockBehavior:         func(s *mock_usecase.MockAuthorization, input domain.LoginUnlockInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'LoginUnlockInput.Username' Error:Field validation for 'Username' failed on the 'required_without' tag\nKey: 'LoginUnlockInput.IP' Error:Field validation for 'IP' failed on the 'required_without' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockAuthorization(c)
+			testCase.mockBehavior(repo, testCase.input)
+
+			usecases := &usecase.Usecase{Authorization: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/login/unlock", handler.UnlockLogin)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/login/unlock", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package api
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/bllooop/coinshop/internal/domain"
 	logger "github.com/bllooop/coinshop/pkg/logging"
@@ -22,11 +26,9 @@ func (h *Handler) SignUp(c *gin.Context) {
 		return
 	}
 	logger.Log.Debug().Msgf("Успешно прочитаны никнейм: %s, пароль: %s", input.UserName, input.Password)
-	defaultCoins := 1000
-	input.Coins = &defaultCoins
 	id, err := h.Usecases.Authorization.CreateUser(input)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, errorStatus(err), err.Error())
 		logger.Log.Error().Err(err).Msg("")
 		return
 	}
@@ -41,7 +43,6 @@ func (h *Handler) SignIn(c *gin.Context) {
 	logger.Log.Info().Msg("Получили запрос на авторизацию пользователя")
 
 	var input domain.SignInInput
-	var inputCreate domain.User
 	if c.Request.Method != http.MethodPost {
 		logger.Log.Error().Msg("Требуется запрос POST")
 		newErrorResponse(c, http.StatusBadRequest, "Требуется запрос POST")
@@ -53,40 +54,98 @@ func (h *Handler) SignIn(c *gin.Context) {
 		return
 	}
 	logger.Log.Debug().Msgf("Успешно прочитаны никнейм: %s, пароль: %s", input.UserName, input.Password)
-	user, err := h.Usecases.Authorization.SignUser(input.UserName, input.Password)
+	user, err := h.Usecases.Authorization.SignUser(input.UserName, input.Password, c.ClientIP())
 	if err != nil {
-		if err.Error() == "пользователь не найден" {
-			logger.Log.Info().Msg("Создаем пользователя")
-			inputCreate.UserName = input.UserName
-			inputCreate.Password = input.Password
-			defaultCoins := 1000
-			inputCreate.Coins = &defaultCoins
-
-			id, err2 := h.Usecases.Authorization.CreateUser(inputCreate)
-			if err2 != nil {
-				logger.Log.Error().Err(err2).Msg("")
-				newErrorResponse(c, http.StatusInternalServerError, err2.Error())
-				return
-			}
-
-			token, tokenErr := h.Usecases.Authorization.GenerateToken(id)
-			if tokenErr != nil {
-				logger.Log.Error().Err(tokenErr).Msg("")
-				newErrorResponse(c, http.StatusInternalServerError, "Ошибка создания токена: "+tokenErr.Error())
-				return
+		var throttled *domain.LoginThrottledError
+		if errors.As(err, &throttled) {
+			logger.Log.Warn().Err(err).Str("ip", c.ClientIP()).Msg("")
+			retryAfter := int(math.Ceil(time.Until(throttled.Until).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			if throttled.Started {
+				h.audit(c, domain.AuditLoginLocked, domain.AuditTargetLogin, input.UserName, nil, map[string]interface{}{
+					"locked_until": throttled.Until,
+				})
 			}
-
-			c.JSON(http.StatusOK, map[string]interface{}{
-				"id":    id,
-				"token": token,
+			newErrorResponse(c, errorStatus(err), err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrUserBanned) {
+			h.audit(c, domain.AuditLoginFailed, domain.AuditTargetLogin, input.UserName, nil, map[string]interface{}{
+				"reason": err.Error(),
 			})
-			logger.Log.Info().Msg("Получили токен")
+			newErrorResponse(c, errorStatus(err), err.Error())
 			return
 		}
+		h.audit(c, domain.AuditLoginFailed, domain.AuditTargetLogin, input.UserName, nil, map[string]interface{}{
+			"reason": err.Error(),
+		})
 		newErrorResponse(c, http.StatusInternalServerError, "Ошибка авторизации: "+err.Error())
 		return
 	}
 
+	h.completeSignIn(c, user)
+}
+
+// completeSignIn выдает токен доступа или, если у пользователя включена двухфакторная аутентификация,
+// промежуточный токен для второго шага входа.
+func (h *Handler) completeSignIn(c *gin.Context, user domain.User) {
+	if user.TwoFactorEnabled {
+		twoFactorToken, err := h.Usecases.TwoFactor.StartTwoFactorSignIn(user.Id)
+		if err != nil {
+			logger.Log.Error().Err(err).Msg("")
+			newErrorResponse(c, errorStatus(err), err.Error())
+			return
+		}
+		logger.Log.Info().Msg("Первый шаг входа пройден, требуется код второго фактора")
+		c.JSON(http.StatusOK, map[string]interface{}{
+			"two_factor_required": true,
+			"two_factor_token":    twoFactorToken,
+		})
+		return
+	}
+	h.issueToken(c, user)
+}
+
+// SignInTwoFactor - второй шаг входа для пользователей с двухфакторной аутентификацией.
+func (h *Handler) SignInTwoFactor(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на проверку кода второго фактора")
+	var input domain.TwoFactorSignInInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := h.Usecases.TwoFactor.CompleteTwoFactorSignIn(input, c.ClientIP())
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		var throttled *domain.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			retryAfter := int(math.Ceil(time.Until(throttled.Until).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			if throttled.Started {
+				h.audit(c, domain.AuditLoginLocked, domain.AuditTargetLogin, "", nil, map[string]interface{}{
+					"locked_until": throttled.Until,
+				})
+			}
+			newErrorResponse(c, errorStatus(err), err.Error())
+		case errors.Is(err, domain.ErrTokenRevoked):
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		default:
+			if errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+				h.audit(c, domain.AuditLoginFailed, domain.AuditTargetLogin, "", nil, map[string]interface{}{
+					"reason": err.Error(),
+				})
+			}
+			newErrorResponse(c, errorStatus(err), err.Error())
+		}
+		return
+	}
+	h.issueToken(c, user)
+}
+
+// issueToken выдает токен доступа пользователю, прошедшему все шаги входа.
+func (h *Handler) issueToken(c *gin.Context, user domain.User) {
 	token, err := h.Usecases.Authorization.GenerateToken(user.Id)
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, "Ошибка создания токена: "+err.Error())
@@ -94,8 +153,43 @@ func (h *Handler) SignIn(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]interface{}{
+	// вход выполнен, дальше запрос выполняется от имени этого пользователя
+	c.Set(userCtx, user.Id)
+	h.audit(c, domain.AuditLogin, domain.AuditTargetUser, strconv.Itoa(user.Id), nil, nil)
+
+	response := map[string]interface{}{
 		"token": token,
-	})
+	}
+	// с таким токеном доступна только смена пароля
+	if user.PasswordResetRequired {
+		response["password_reset_required"] = true
+	}
+	c.JSON(http.StatusOK, response)
 	logger.Log.Info().Msg("Получили токен")
 }
+
+func (h *Handler) UnlockLogin(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на снятие блокировки входа")
+	var input domain.LoginUnlockInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	unlocked, err := h.Usecases.Authorization.UnlockLogin(input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Сброшено записей о попытках входа: %v", unlocked)
+	if !h.auditAction(c, domain.AuditLoginUnlock, domain.AuditTargetLogin, input.Username, nil, map[string]interface{}{
+		"ip": input.IP, "unlocked": unlocked,
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"unlocked": unlocked,
+	})
+}
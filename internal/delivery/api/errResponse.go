@@ -1,6 +1,10 @@
 package api
 
 import (
+	"errors"
+	"net/http"
+
+	"github.com/bllooop/coinshop/internal/domain"
 	logger "github.com/bllooop/coinshop/pkg/logging"
 	"github.com/gin-gonic/gin"
 )
@@ -13,3 +17,56 @@ func newErrorResponse(c *gin.Context, statusCode int, message string) {
 	logger.Log.Error().Msg(message)
 	c.AbortWithStatusJSON(statusCode, errorResponse{message})
 }
+
+// errorStatus сопоставляет доменные ошибки с HTTP-кодами, остальные ошибки считаются внутренними.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrItemNotFound), errors.Is(err, domain.ErrPurchaseNotFound),
+		errors.Is(err, domain.ErrRefundNotFound), errors.Is(err, domain.ErrVariantNotFound),
+		errors.Is(err, domain.ErrPromoNotFound), errors.Is(err, domain.ErrPromotionNotFound),
+		errors.Is(err, domain.ErrUserNotFound), errors.Is(err, domain.ErrScheduleNotFound),
+		errors.Is(err, domain.ErrPaymentRequestNotFound), errors.Is(err, domain.ErrEscrowNotFound),
+		errors.Is(err, domain.ErrApiKeyNotFound), errors.Is(err, domain.ErrServiceAccountNotFound),
+		errors.Is(err, domain.ErrOidcDisabled):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrNotEnoughCoins), errors.Is(err, domain.ErrEmptyOrder),
+		errors.Is(err, domain.ErrInvalidAmount), errors.Is(err, domain.ErrVariantRequired),
+		errors.Is(err, domain.ErrEmptyVariant), errors.Is(err, domain.ErrInvalidPrice),
+		errors.Is(err, domain.ErrInvalidPromotion), errors.Is(err, domain.ErrSelfTransfer),
+		errors.Is(err, domain.ErrInsufficientCoins), errors.Is(err, domain.ErrTransferTooLarge),
+		errors.Is(err, domain.ErrInvalidSchedule), errors.Is(err, domain.ErrEmptyBatch),
+		errors.Is(err, domain.ErrBatchTooLarge), errors.Is(err, domain.ErrAdjustmentReasonRequired),
+		errors.Is(err, domain.ErrInvalidEscrow), errors.Is(err, domain.ErrUsernameReserved),
+		errors.Is(err, domain.ErrSelfBan), errors.Is(err, domain.ErrUnknownScope),
+		errors.Is(err, domain.ErrInvalidApiKeyExpiry):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrOutOfStock), errors.Is(err, domain.ErrPurchaseLimit),
+		errors.Is(err, domain.ErrRefundWindowExpired), errors.Is(err, domain.ErrAlreadyRefunded),
+		errors.Is(err, domain.ErrRefundExists), errors.Is(err, domain.ErrRefundResolved),
+		errors.Is(err, domain.ErrInvalidStatusTransition), errors.Is(err, domain.ErrVariantExists),
+		errors.Is(err, domain.ErrPromoExpired), errors.Is(err, domain.ErrPromoExhausted),
+		errors.Is(err, domain.ErrPromoNotApplicable), errors.Is(err, domain.ErrPromotionExists),
+		errors.Is(err, domain.ErrDailySendLimit), errors.Is(err, domain.ErrDailyReceiveLimit),
+		errors.Is(err, domain.ErrScheduleCancelled), errors.Is(err, domain.ErrBalanceCapExceeded),
+		errors.Is(err, domain.ErrPaymentRequestResolved), errors.Is(err, domain.ErrPaymentRequestExpired),
+		errors.Is(err, domain.ErrEscrowResolved), errors.Is(err, domain.ErrUsernameTaken),
+		errors.Is(err, domain.ErrAccountHasEscrows), errors.Is(err, domain.ErrTwoFactorEnabled),
+		errors.Is(err, domain.ErrTwoFactorNotEnrolled), errors.Is(err, domain.ErrApiKeyRevoked),
+		errors.Is(err, domain.ErrServiceAccountExists), errors.Is(err, domain.ErrPurchaseShipped):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidApiKey), errors.Is(err, domain.ErrInvalidOidcState),
+		errors.Is(err, domain.ErrOidcLoginFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrEscrowActionForbidden), errors.Is(err, domain.ErrWrongPassword),
+		errors.Is(err, domain.ErrUserBanned), errors.Is(err, domain.ErrPasswordResetRequired),
+		errors.Is(err, domain.ErrInvalidTwoFactorCode), errors.Is(err, domain.ErrTwoFactorRequired),
+		errors.Is(err, domain.ErrApiKeyScope), errors.Is(err, domain.ErrReauthRequired):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTransferCooldown), errors.Is(err, domain.ErrLoginThrottled),
+		errors.Is(err, domain.ErrLoginLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrTreasuryUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
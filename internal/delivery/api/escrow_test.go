@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_createEscrow(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockEscrows, userId int, input domain.EscrowInput)
+	createdAt := time.Date(2025, 3, 13, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(48 * time.Hour)
+	arbiter := "vera"
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.EscrowInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"recipient":"boris","arbiter":"vera","amount":100,"message":"пари","timeout":"48h"}`,
+			input:     domain.EscrowInput{RecipientUsername: "boris", ArbiterUsername: "vera", Amount: 100, Message: "пари", Timeout: "48h"},
+			mockBehavior: func(s *mock_usecase.MockEscrows, userId int, input domain.EscrowInput) {
+				s.EXPECT().CreateEscrow(userId, input).Return(domain.Escrow{
+					Id: 7, SenderId: userId, SenderUsername: "anna", RecipientId: 2, RecipientUsername: "boris",
+					ArbiterUsername: &arbiter, Amount: 100, Message: "пари", Status: domain.EscrowHeld,
+					OnTimeout: domain.EscrowActionReturn, CreatedAt: createdAt, ExpiresAt: expiresAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":7, "sender":"anna", "recipient":"boris", "arbiter":"vera", "amount":100, "message":"пари",
+				"status":"held", "on_timeout":"return", "created_at":"2025-03-13T12:00:00Z", "expires_at":"2025-03-15T12:00:00Z"}`,
+		},
+		{
+			name:      "Некорректный срок",
+			inputBody: `{"recipient":"boris","amount":100,"timeout":"завтра"}`,
+			input:     domain.EscrowInput{RecipientUsername: "boris", Amount: 100, Timeout: "завтра"},
+			mockBehavior: func(s *mock_usecase.MockEscrows, userId int, input domain.EscrowInput) {
+				s.EXPECT().CreateEscrow(userId, input).Return(domain.Escrow{}, domain.ErrInvalidEscrow)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"` + domain.ErrInvalidEscrow.Error() + `"}`,
+		},
+		{
+			name:                 "Неизвестное действие по сроку",
+			inputBody:            `{"recipient":"boris","amount":100,"timeout":"1h","on_timeout":"burn"}`,
+			mockBehavior:         func(s *mock_usecase.MockEscrows, userId int, input domain.EscrowInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'EscrowInput.OnTimeout' Error:Field validation for 'OnTimeout' failed on the 'oneof' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockEscrows(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Escrows: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/escrows", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.CreateEscrow(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/escrows", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_releaseEscrow(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockEscrows, userId int)
+	createdAt := time.Date(2025, 3, 13, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(48 * time.Hour)
+	resolvedAt := createdAt.Add(time.Hour)
+
+	testTable := []struct {
+		name                 string
+		id                   string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			id:   "7",
+			mockBehavior: func(s *mock_usecase.MockEscrows, userId int) {
+				s.EXPECT().ReleaseEscrow(userId, 7).Return(domain.Escrow{
+					Id: 7, SenderUsername: "anna", RecipientUsername: "boris", Amount: 100, Status: domain.EscrowReleased,
+					OnTimeout: domain.EscrowActionReturn, TransactionId: intPointer(42), CreatedAt: createdAt,
+					ExpiresAt: expiresAt, ResolvedAt: &resolvedAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":7, "sender":"anna", "recipient":"boris", "amount":100, "status":"released", "on_timeout":"return",
+				"transaction_id":42, "created_at":"2025-03-13T12:00:00Z", "expires_at":"2025-03-15T12:00:00Z", "resolved_at":"2025-03-13T13:00:00Z"}`,
+		},
+		{
+			name: "Действие запрещено",
+			id:   "7",
+			mockBehavior: func(s *mock_usecase.MockEscrows, userId int) {
+				s.EXPECT().ReleaseEscrow(userId, 7).Return(domain.Escrow{}, domain.ErrEscrowActionForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"` + domain.ErrEscrowActionForbidden.Error() + `"}`,
+		},
+		{
+			name: "Уже завершен",
+			id:   "7",
+			mockBehavior: func(s *mock_usecase.MockEscrows, userId int) {
+				s.EXPECT().ReleaseEscrow(userId, 7).Return(domain.Escrow{}, domain.ErrEscrowResolved)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"` + domain.ErrEscrowResolved.Error() + `"}`,
+		},
+		{
+			name:                 "Некорректный id",
+			id:                   "abc",
+			mockBehavior:         func(s *mock_usecase.MockEscrows, userId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Некорректный id перевода с удержанием"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockEscrows(c)
+			testCase.mockBehavior(repo, 1)
+
+			usecases := &usecase.Usecase{Escrows: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/escrows/:id/release", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.ReleaseEscrow(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/escrows/"+testCase.id+"/release", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
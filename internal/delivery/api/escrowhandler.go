@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreateEscrow(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на создание перевода с удержанием")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.EscrowInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и получатель %s", userId, input.RecipientUsername)
+	escrow, err := h.Usecases.Escrows.CreateEscrow(userId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на создание перевода с удержанием")
+
+	c.JSON(http.StatusOK, escrow)
+}
+
+func (h *Handler) GetEscrows(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на список переводов с удержанием")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", domain.EscrowHeld, domain.EscrowReleased, domain.EscrowReturned:
+	default:
+		newErrorResponse(c, http.StatusBadRequest, "Неизвестный статус перевода с удержанием")
+		return
+	}
+	escrows, err := h.Usecases.Escrows.GetEscrows(userId, status)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос списка переводов с удержанием")
+
+	c.JSON(http.StatusOK, escrows)
+}
+
+func (h *Handler) ReleaseEscrow(c *gin.Context) {
+	h.settleEscrow(c, h.Usecases.Escrows.ReleaseEscrow)
+}
+
+func (h *Handler) ReturnEscrow(c *gin.Context) {
+	h.settleEscrow(c, h.Usecases.Escrows.ReturnEscrow)
+}
+
+func (h *Handler) settleEscrow(c *gin.Context, settle func(userId, escrowId int) (domain.Escrow, error)) {
+	logger.Log.Info().Msg("Получили запрос на завершение перевода с удержанием")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	escrowId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id перевода с удержанием")
+		return
+	}
+	escrow, err := settle(userId, escrowId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Перевод с удержанием %v переведен в статус %s", escrow.Id, escrow.Status)
+
+	c.JSON(http.StatusOK, escrow)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_updatePurchaseStatus(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockFulfillment, adminId int, input domain.PurchaseStatusInput)
+	purchaseDate := time.Date(2025, 2, 5, 12, 0, 0, 0, time.UTC)
+	shippedAt := time.Date(2025, 2, 6, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		purchaseId           string
+		inputBody            string
+		inputStatus          domain.PurchaseStatusInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "OK",
+			purchaseId:  "3",
+			inputBody:   `{"status":"shipped"}`,
+			inputStatus: domain.PurchaseStatusInput{Status: domain.PurchaseShipped},
+			mockBehavior: func(s *mock_usecase.MockFulfillment, adminId int, input domain.PurchaseStatusInput) {
+				s.EXPECT().UpdatePurchaseStatus(adminId, 3, input).Return(domain.Purchase{
+					Id: 3, ItemName: "cup", Price: 20, BasePrice: 20, Quantity: 1, PurchaseDate: purchaseDate,
+					Status: domain.PurchaseShipped, ShippedAt: &shippedAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":3, "item_name":"cup", "price":20, "base_price":20, "quantity":1, "purchase_date":"2025-02-05T12:00:00Z",
+				"status":"shipped", "shipped_at":"2025-02-06T12:00:00Z"}`,
+		},
+		{
+			name:        "Недопустимый переход",
+			purchaseId:  "3",
+			inputBody:   `{"status":"shipped"}`,
+			inputStatus: domain.PurchaseStatusInput{Status: domain.PurchaseShipped},
+			mockBehavior: func(s *mock_usecase.MockFulfillment, adminId int, input domain.PurchaseStatusInput) {
+				s.EXPECT().UpdatePurchaseStatus(adminId, 3, input).Return(domain.Purchase{}, domain.ErrInvalidStatusTransition)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"недопустимый переход статуса покупки"}`,
+		},
+		{
+			name:                 "Неизвестный статус",
+			purchaseId:           "3",
+			inputBody:            `{"status":"pending"}`,
+			mockBehavior:         func(s *mock_usecase.MockFulfillment, adminId int, input domain.PurchaseStatusInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'PurchaseStatusInput.Status' Error:Field validation for 'Status' failed on the 'oneof' tag"}`,
+		},
+		{
+			name:                 "Некорректный id",
+			purchaseId:           "abc",
+			inputBody:            `{"status":"packed"}`,
+			mockBehavior:         func(s *mock_usecase.MockFulfillment, adminId int, input domain.PurchaseStatusInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Некорректный id покупки"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockFulfillment(c)
+			testCase.mockBehavior(repo, 9, testCase.inputStatus)
+
+			usecases := &usecase.Usecase{Fulfillment: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.PUT("/api/admin/purchases/:id/status", func(c *gin.Context) {
+				c.Set("userId", 9)
+				handler.UpdatePurchaseStatus(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/admin/purchases/"+testCase.purchaseId+"/status",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetPurchasesByStatus(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на список покупок для выдачи")
+	status := c.DefaultQuery("status", domain.PurchasePending)
+	switch status {
+	case domain.PurchasePending, domain.PurchasePacked, domain.PurchaseShipped, domain.PurchaseCancelled:
+	default:
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный статус покупки")
+		return
+	}
+	purchases, err := h.Usecases.Fulfillment.GetPurchasesByStatus(status)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос списка покупок для выдачи")
+
+	c.JSON(http.StatusOK, purchases)
+}
+
+func (h *Handler) UpdatePurchaseStatus(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на изменение статуса покупки")
+	adminId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	purchaseId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id покупки")
+		return
+	}
+	var input domain.PurchaseStatusInput
+	if err = c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	purchase, err := h.Usecases.Fulfillment.UpdatePurchaseStatus(adminId, purchaseId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Покупка %v переведена в статус %s", purchase.Id, purchase.Status)
+
+	c.JSON(http.StatusOK, purchase)
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"expvar"
+
+	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/usecase"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -14,28 +17,107 @@ func NewHandler(usecases *usecase.Usecase) *Handler {
 	return &Handler{Usecases: usecases}
 }
 
-func (h *Handler) InitRoutes() *gin.Engine {
+// InitRoutes создает роутер. Адрес клиента берется из X-Forwarded-For и X-Real-IP только для запросов
+// от прокси из trustedProxies; без них используется адрес соединения, чтобы клиент не мог подменить
+// адрес, по которому считаются лимиты запросов и попытки входа.
+func (h *Handler) InitRoutes(limits RateLimits, trustedProxies []string) (*gin.Engine, error) {
 	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", requestIdHeader},
+		ExposeHeaders:    []string{requestIdHeader, "Deprecation", "Link"},
 		AllowCredentials: true,
-	}))
-	api := router.Group("/api")
+	}), h.requestId)
+	router.GET(apiPrefix+"/openapi.json", h.OpenAPISpec)
+	h.registerRoutes(routeGroup{RouterGroup: router.Group(apiPrefix)}, limits)
+	h.registerRoutes(routeGroup{RouterGroup: router.Group(legacyApiPrefix, h.deprecated), legacy: true}, limits)
+	return router, nil
+}
+
+// registerRoutes регистрирует маршруты API в группе версии.
+func (h *Handler) registerRoutes(api routeGroup, limits RateLimits) {
+	auth := api.Group("/auth", h.rateLimit(limits.Store, "auth", limits.Auth, clientIPKey))
 	{
-		auth := api.Group("/auth")
-		{
-			auth.POST("sign-up", h.SignUp)
-			auth.POST("sign-in", h.SignIn)
-		}
-		authorized := api.Group("/", h.authIdentity)
-		//authorized.Use(h.AuthMiddleware)
+		auth.POST("sign-up", h.SignUp)
+		auth.POST("sign-in", h.SignIn)
+		auth.POST("sign-in/2fa", h.SignInTwoFactor)
+		auth.GET("oidc/login", h.OidcLogin)
+		auth.GET("oidc/callback", h.OidcCallback)
+	}
+	authorized := api.Group("", h.authIdentity, h.rateLimit(limits.Store, "api", limits.User, userKey))
+	{
+		authorized.POST("/coins/send", h.SendCoin)
+		authorized.POST("/coins/send/batch", h.SendCoinBatch)
+		authorized.GET("/info", h.GetInfo)
+		authorized.POST("/shop/:item/buy", h.BuyItem)
+		authorized.POST("/orders", h.CreateOrder)
+		authorized.GET("/purchases", h.GetPurchases)
+		authorized.GET("/transactions", h.GetTransactions)
+		authorized.POST("/schedules", h.CreateSchedule)
+		authorized.GET("/schedules", h.GetSchedules)
+		authorized.DELETE("/schedules/:id", h.CancelSchedule)
+		authorized.GET("/schedules/:id/runs", h.GetScheduleRuns)
+		authorized.POST("/payment-requests", h.CreatePaymentRequest)
+		authorized.GET("/payment-requests", h.GetPaymentRequests)
+		authorized.POST("/payment-requests/:id/accept", h.AcceptPaymentRequest)
+		authorized.POST("/payment-requests/:id/decline", h.DeclinePaymentRequest)
+		authorized.POST("/payment-requests/:id/cancel", h.CancelPaymentRequest)
+		authorized.POST("/escrows", h.CreateEscrow)
+		authorized.GET("/escrows", h.GetEscrows)
+		authorized.POST("/escrows/:id/release", h.ReleaseEscrow)
+		authorized.POST("/escrows/:id/return", h.ReturnEscrow)
+		authorized.PUT("/profile/password", h.ChangePassword)
+		authorized.PUT("/profile/username", h.ChangeUsername)
+		authorized.DELETE("/profile", h.DeleteAccount)
+		authorized.POST("/profile/2fa/enroll", h.EnrollTwoFactor)
+		authorized.POST("/profile/2fa/confirm", h.ConfirmTwoFactor)
+		authorized.POST("/profile/2fa/recovery-codes", h.RegenerateRecoveryCodes)
+		authorized.DELETE("/profile/2fa", h.DisableTwoFactor)
+		authorized.POST("/purchases/:id/refund", h.RequestRefund)
+		authorized.GET("/shop", h.GetItems)
+	}
+	admin := api.Group("/admin", h.authIdentity, h.rateLimit(limits.Store, "admin", limits.Admin, userKey),
+		h.requireRole(domain.RoleAdmin))
+	{
+		admin.POST("/shop/:item/restock", h.RestockItem)
+		admin.PUT("/shop/:item/stock", h.UpdateItemStock)
+		admin.POST("/shop/:item/variants", h.CreateVariant)
+		admin.PUT("/shop/:item/variants/:id/stock", h.UpdateVariantStock)
+		admin.GET("/refunds", h.GetRefunds)
+		admin.POST("/refunds/:id/approve", h.ApproveRefund)
+		admin.POST("/refunds/:id/reject", h.RejectRefund)
+		admin.POST("/purchases/:id/refund", h.ForceRefund)
+		admin.GET("/purchases", h.GetPurchasesByStatus)
+		admin.PUT("/purchases/:id/status", h.UpdatePurchaseStatus)
+		admin.GET("/promotions", h.GetPromotions)
+		admin.POST("/promotions", h.CreatePromotion)
+		admin.POST("/promotions/:id/end", h.EndPromotion)
+		admin.POST("/coins/grant", h.GrantCoins)
+		admin.POST("/coins/clawback", h.ClawbackCoins)
+		admin.POST("/login/unlock", h.UnlockLogin)
+		admin.GET("/metrics", gin.WrapH(expvar.Handler()))
+		users := admin.Group("/users")
 		{
-			authorized.POST("/sendCoin", h.SendCoin)
-			authorized.GET("/info", h.GetInfo)
-			authorized.PUT("/buy/:item", h.BuyItem)
+			users.GET("", h.SearchUsers)
+			users.GET("/:id/summary", h.GetUserSummaryByAdmin)
+			users.POST("/:id/ban", h.BanUser)
+			users.POST("/:id/unban", h.UnbanUser)
+			users.POST("/:id/password-reset", h.ResetUserPassword)
 		}
+		admin.GET("/service-accounts", h.GetServiceAccounts)
+		admin.POST("/service-accounts", h.CreateServiceAccount)
+		admin.GET("/service-accounts/:id/keys", h.GetApiKeys)
+		admin.POST("/service-accounts/:id/keys", h.CreateApiKey)
+		admin.POST("/api-keys/:id/revoke", h.RevokeApiKey)
+	}
+	audit := api.Group("/audit", h.authIdentity, h.rateLimit(limits.Store, "admin", limits.Admin, userKey),
+		h.requireRole(domain.RoleAuditor))
+	{
+		audit.GET("", h.GetAuditLog)
+		audit.GET("/verify", h.VerifyAuditLog)
 	}
-	return router
 }
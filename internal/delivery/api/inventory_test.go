@@ -0,0 +1,277 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_getItems(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockInventory)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_usecase.MockInventory) {
+				s.EXPECT().GetItems().Return([]domain.Merch{
+					{Id: 1, Name: "cup", Price: 20, Stock: intPointer(100)},
+					{Id: 2, Name: "pink-hoody", Price: 500, Stock: intPointer(20), PerUserLimit: intPointer(1),
+						Variants: []domain.ItemVariant{{Id: 1, ItemId: 2, Size: "XL", PriceDelta: 50, Stock: intPointer(5)}}},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `[
+				{"name":"cup", "price":20, "stock":100, "per_user_limit":null},
+				{"name":"pink-hoody", "price":500, "stock":20, "per_user_limit":1,
+					"variants":[{"id":1, "size":"XL", "price_delta":50, "stock":5}]}
+			]`,
+		},
+		{
+			name: "Ошибка выполнения запроса",
+			mockBehavior: func(s *mock_usecase.MockInventory) {
+				s.EXPECT().GetItems().Return(nil, errors.New("Internal Server Error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"Internal Server Error"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockInventory(c)
+			testCase.mockBehavior(repo)
+
+			usecases := &usecase.Usecase{Inventory: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.GET("/api/shop", handler.GetItems)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/shop", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_restockItem(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockInventory, name string, quantity int)
+
+	testTable := []struct {
+		name                 string
+		inputName            string
+		inputBody            string
+		inputQuantity        int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:          "OK",
+			inputName:     "cup",
+			inputBody:     `{"quantity":5}`,
+			inputQuantity: 5,
+			mockBehavior: func(s *mock_usecase.MockInventory, name string, quantity int) {
+				s.EXPECT().Restock(name, quantity).Return(domain.ItemStockChange{
+					Item:   domain.Merch{Id: 1, Name: "cup", Price: 20, Stock: intPointer(5)},
+					Before: domain.Merch{Id: 1, Name: "cup", Price: 20},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"name":"cup", "price":20, "stock":5, "per_user_limit":null}`,
+		},
+		{
+			name:                 "Неположительное количество",
+			inputName:            "cup",
+			inputBody:            `{"quantity":-5}`,
+			mockBehavior:         func(s *mock_usecase.MockInventory, name string, quantity int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'RestockInput.Quantity' Error:Field validation for 'Quantity' failed on the 'gt' tag"}`,
+		},
+		{
+			name:          "Товар не найден",
+			inputName:     "mug",
+			inputBody:     `{"quantity":5}`,
+			inputQuantity: 5,
+			mockBehavior: func(s *mock_usecase.MockInventory, name string, quantity int) {
+				s.EXPECT().Restock(name, quantity).Return(domain.ItemStockChange{}, domain.ErrItemNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"товар не найден"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockInventory(c)
+			testCase.mockBehavior(repo, testCase.inputName, testCase.inputQuantity)
+
+			usecases := &usecase.Usecase{Inventory: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/shop/:item/restock", handler.RestockItem)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/shop/"+testCase.inputName+"/restock",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateItemStock(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockInventory, name string, input domain.ItemStockInput)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.ItemStockInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"stock":3, "per_user_limit":1}`,
+			input:     domain.ItemStockInput{Stock: intPointer(3), PerUserLimit: intPointer(1)},
+			mockBehavior: func(s *mock_usecase.MockInventory, name string, input domain.ItemStockInput) {
+				s.EXPECT().UpdateItemStock(name, input).Return(domain.ItemStockChange{
+					Item:   domain.Merch{Id: 10, Name: name, Price: 500, Stock: intPointer(3), PerUserLimit: intPointer(1)},
+					Before: domain.Merch{Id: 10, Name: name, Price: 500, Stock: intPointer(8)},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"name":"pink-hoody", "price":500, "stock":3, "per_user_limit":1}`,
+		},
+		{
+			name:                 "Отрицательный остаток",
+			inputBody:            `{"stock":-1}`,
+			mockBehavior:         func(s *mock_usecase.MockInventory, name string, input domain.ItemStockInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'ItemStockInput.Stock' Error:Field validation for 'Stock' failed on the 'gte' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockInventory(c)
+			testCase.mockBehavior(repo, "pink-hoody", testCase.input)
+
+			usecases := &usecase.Usecase{Inventory: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.PUT("/api/admin/shop/:item/stock", handler.UpdateItemStock)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/admin/shop/pink-hoody/stock",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_createVariant(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockInventory, name string, input domain.VariantInput)
+
+	testTable := []struct {
+		name                 string
+		inputName            string
+		inputBody            string
+		input                domain.VariantInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputName: "hoody",
+			inputBody: `{"size":"XL","colour":"black","price_delta":50,"stock":10}`,
+			input: domain.VariantInput{
+				VariantSelector: domain.VariantSelector{Size: "XL", Colour: "black"},
+				PriceDelta:      50,
+				Stock:           intPointer(10),
+			},
+			mockBehavior: func(s *mock_usecase.MockInventory, name string, input domain.VariantInput) {
+				s.EXPECT().CreateVariant(name, input).Return(domain.ItemVariant{
+					Id: 1, ItemId: 6, Size: "XL", Colour: "black", PriceDelta: 50, Stock: intPointer(10),
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1, "size":"XL", "colour":"black", "price_delta":50, "stock":10}`,
+		},
+		{
+			name:      "Вариант уже существует",
+			inputName: "hoody",
+			inputBody: `{"size":"XL"}`,
+			input:     domain.VariantInput{VariantSelector: domain.VariantSelector{Size: "XL"}},
+			mockBehavior: func(s *mock_usecase.MockInventory, name string, input domain.VariantInput) {
+				s.EXPECT().CreateVariant(name, input).Return(domain.ItemVariant{}, domain.ErrVariantExists)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"такой вариант товара уже существует"}`,
+		},
+		{
+			name:      "Отрицательная цена",
+			inputName: "pen",
+			inputBody: `{"colour":"red","price_delta":-100}`,
+			input: domain.VariantInput{
+				VariantSelector: domain.VariantSelector{Colour: "red"},
+				PriceDelta:      -100,
+			},
+			mockBehavior: func(s *mock_usecase.MockInventory, name string, input domain.VariantInput) {
+				s.EXPECT().CreateVariant(name, input).Return(domain.ItemVariant{}, domain.ErrInvalidPrice)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"цена варианта не может быть отрицательной"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockInventory(c)
+			testCase.mockBehavior(repo, testCase.inputName, testCase.input)
+
+			usecases := &usecase.Usecase{Inventory: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/shop/:item/variants", handler.CreateVariant)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/shop/"+testCase.inputName+"/variants",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
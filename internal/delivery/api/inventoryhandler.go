@@ -0,0 +1,124 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetItems(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на список товаров")
+	items, err := h.Usecases.Inventory.GetItems()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос списка товаров")
+
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *Handler) RestockItem(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на пополнение запаса товара")
+	var input domain.RestockInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := c.Param("item")
+	logger.Log.Debug().Msgf("Успешно прочитаны название предмета %s и количество %v", name, input.Quantity)
+	change, err := h.Usecases.Inventory.Restock(name, input.Quantity)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на пополнение запаса товара")
+	if !h.auditAction(c, domain.AuditCatalogRestock, domain.AuditTargetItem, name, change.Before, change.Item) {
+		return
+	}
+
+	c.JSON(http.StatusOK, change.Item)
+}
+
+func (h *Handler) UpdateItemStock(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на изменение остатка товара")
+	var input domain.ItemStockInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := c.Param("item")
+	logger.Log.Debug().Msgf("Успешно прочитано название предмета %s", name)
+	change, err := h.Usecases.Inventory.UpdateItemStock(name, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на изменение остатка товара")
+	if !h.auditAction(c, domain.AuditCatalogStock, domain.AuditTargetItem, name, change.Before, change.Item) {
+		return
+	}
+
+	c.JSON(http.StatusOK, change.Item)
+}
+
+func (h *Handler) CreateVariant(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на создание варианта товара")
+	var input domain.VariantInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := c.Param("item")
+	logger.Log.Debug().Msgf("Успешно прочитано название предмета %s", name)
+	variant, err := h.Usecases.Inventory.CreateVariant(name, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на создание варианта товара")
+	if !h.auditAction(c, domain.AuditCatalogVariant, domain.AuditTargetItem, name, nil, variant) {
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+func (h *Handler) UpdateVariantStock(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на изменение остатка варианта товара")
+	variantId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id варианта")
+		return
+	}
+	var input domain.VariantStockInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	name := c.Param("item")
+	logger.Log.Debug().Msgf("Успешно прочитаны название предмета %s и id варианта %v", name, variantId)
+	change, err := h.Usecases.Inventory.UpdateVariantStock(name, variantId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на изменение остатка варианта товара")
+	if !h.auditAction(c, domain.AuditCatalogVariantStock, domain.AuditTargetVariant, strconv.Itoa(variantId), change.Before, change.Variant) {
+		return
+	}
+
+	c.JSON(http.StatusOK, change.Variant)
+}
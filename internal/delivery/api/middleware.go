@@ -1,18 +1,48 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/bllooop/coinshop/pkg/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 const (
 	authorizationHeader = "Authorization"
+	apiKeyScheme        = "ApiKey"
 	userCtx             = "userId"
+	tokenIssuedCtx      = "tokenIssuedAt"
+	roleCtx             = "userRole"
+	apiKeyCtx           = "apiKeyId"
+	requestIdCtx        = "requestId"
+	requestIdHeader     = "X-Request-ID"
+	// maxRequestIdLength ограничивает id, пришедший от клиента или прокси, размером поля в журнале аудита.
+	maxRequestIdLength = 64
+
+	passwordChangePath = apiPrefix + "/profile/password"
 )
 
+// apiKeyRoutes сопоставляет маршруты v1 разрешениям ключей API. Маршруты, которых здесь нет, ключам недоступны.
+var apiKeyRoutes = map[string]string{
+	"POST " + apiPrefix + "/coins/send":        domain.ScopeCoinsSend,
+	"POST " + apiPrefix + "/coins/send/batch":  domain.ScopeCoinsSend,
+	"GET " + apiPrefix + "/info":               domain.ScopeInfoRead,
+	"GET " + apiPrefix + "/transactions":       domain.ScopeInfoRead,
+	"GET " + apiPrefix + "/purchases":          domain.ScopeInfoRead,
+	"GET " + apiPrefix + "/shop":               domain.ScopeShopRead,
+	"POST " + apiPrefix + "/admin/coins/grant": domain.ScopeCoinsGrant,
+}
+
 func (h *Handler) authIdentity(c *gin.Context) {
 	header := c.GetHeader(authorizationHeader)
 	if header == "" {
@@ -31,14 +61,136 @@ func (h *Handler) authIdentity(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	userId, err := h.Usecases.Authorization.ParseToken(headerSplit[1])
-	if err != nil {
+	if headerSplit[0] == apiKeyScheme {
+		h.apiKeyIdentity(c, headerSplit[1])
+		return
+	}
+	session, err := h.Usecases.Authorization.ParseToken(headerSplit[1])
+	switch {
+	case errors.Is(err, domain.ErrPasswordResetRequired) && routeKey(c) == http.MethodPut+" "+passwordChangePath:
+		// после принудительного сброса токен годится только для смены пароля
+	case errors.Is(err, domain.ErrUserBanned), errors.Is(err, domain.ErrPasswordResetRequired):
+		newErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	case err != nil:
 		newErrorResponse(c, http.StatusUnauthorized, err.Error())
 		c.Abort()
 		return
 	}
-	c.Set(userCtx, userId)
+	c.Set(userCtx, session.UserId)
+	c.Set(tokenIssuedCtx, session.IssuedAt)
+}
+
+// apiKeyIdentity выполняет запрос от имени пользователя сервисного аккаунта, если разрешения ключа
+// включают маршрут запроса. Каждое использование ключа записывается в журнал аудита.
+func (h *Handler) apiKeyIdentity(c *gin.Context, key string) {
+	identity, err := h.Usecases.ApiKeys.AuthenticateApiKey(key)
+	if err != nil {
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	scope, ok := apiKeyRoutes[routeKey(c)]
+	if !ok || !slices.Contains(identity.Scopes, scope) {
+		newErrorResponse(c, http.StatusForbidden, domain.ErrApiKeyScope.Error())
+		return
+	}
+	c.Set(userCtx, identity.UserId)
+	c.Set(apiKeyCtx, identity.KeyId)
+	h.audit(c, domain.AuditApiKeyUse, domain.AuditTargetApiKey, strconv.Itoa(identity.KeyId), nil,
+		map[string]interface{}{"service_account_id": identity.ServiceAccountId, "route": routeKey(c)})
+}
+
+// requireRole пропускает запрос дальше, только если роль пользователя входит в список.
+// Запросы с ключом API уже допущены к маршруту по разрешениям ключа в apiKeyIdentity,
+// у пользователя сервисного аккаунта роль service. Должен стоять после authIdentity.
+func (h *Handler) requireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := getUserId(c)
+		if err != nil {
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if _, viaApiKey := c.Get(apiKeyCtx); viaApiKey {
+			c.Set(roleCtx, domain.RoleService)
+			return
+		}
+		role, err := h.Usecases.Authorization.GetUserRole(userId)
+		if err != nil {
+			newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !slices.Contains(roles, role) {
+			newErrorResponse(c, http.StatusForbidden, "Недостаточно прав")
+			return
+		}
+		if err = h.Usecases.TwoFactor.CheckRolePolicy(userId, role); err != nil {
+			newErrorResponse(c, errorStatus(err), err.Error())
+			return
+		}
+		c.Set(roleCtx, role)
+	}
 }
+
+// RateLimits задает лимиты частоты запросов для групп маршрутов. Без Store лимиты не применяются.
+type RateLimits struct {
+	Store ratelimit.Store
+	// Auth ограничивает запросы к /api/auth по IP-адресу клиента.
+	Auth ratelimit.Limit
+	// User ограничивает запросы авторизованного пользователя.
+	User ratelimit.Limit
+	// Admin ограничивает запросы администратора к /api/admin.
+	Admin ratelimit.Limit
+}
+
+// rateLimit отклоняет запрос с кодом 429 и заголовком Retry-After, если в корзине группы
+// для ключа запроса нет токенов. При ошибке хранилища запрос пропускается.
+func (h *Handler) rateLimit(store ratelimit.Store, group string, limit ratelimit.Limit, key func(c *gin.Context) string) gin.HandlerFunc {
+	if store == nil || !limit.Enabled() {
+		return func(c *gin.Context) {}
+	}
+	return func(c *gin.Context) {
+		result, err := store.Take(group+":"+key(c), limit, time.Now())
+		if err != nil {
+			logger.Log.Error().Err(err).Str("group", group).Msg("Не удалось проверить лимит запросов")
+			return
+		}
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(result.RetryAfter.Seconds())), 1)))
+			newErrorResponse(c, http.StatusTooManyRequests, "Слишком много запросов, повторите позже")
+			return
+		}
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	}
+}
+
+func clientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// userKey должен использоваться после authIdentity.
+func userKey(c *gin.Context) string {
+	userId, err := getUserId(c)
+	if err != nil {
+		return clientIPKey(c)
+	}
+	return "user:" + strconv.Itoa(userId)
+}
+
+// requestId берет id запроса из заголовка X-Request-ID или создает новый и возвращает его в ответе,
+// чтобы записи журнала аудита можно было сопоставить с логами прокси.
+func (h *Handler) requestId(c *gin.Context) {
+	id := c.GetHeader(requestIdHeader)
+	if id == "" || len(id) > maxRequestIdLength || strings.ContainsFunc(id, func(r rune) bool { return r < '!' || r > '~' }) {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			logger.Log.Error().Err(err).Msg("Не удалось создать id запроса")
+		}
+		id = hex.EncodeToString(buf)
+	}
+	c.Set(requestIdCtx, id)
+	c.Header(requestIdHeader, id)
+}
+
 func getUserId(c *gin.Context) (int, error) {
 	id, ok := c.Get(userCtx)
 	if !ok {
@@ -4,10 +4,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/usecase"
 	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/bllooop/coinshop/pkg/ratelimit"
 	"github.com/gin-gonic/gin"
 
 	"github.com/stretchr/testify/assert"
@@ -32,7 +36,7 @@ func TestHandler_authIdentity(t *testing.T) {
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehavior: func(r *mock_usecase.MockAuthorization, token string) {
-				r.EXPECT().ParseToken(token).Return(1, nil)
+				r.EXPECT().ParseToken(token).Return(domain.Session{UserId: 1}, nil)
 			},
 			expectedStatusCode:   http.StatusOK,
 			expectedResponseBody: "1",
@@ -61,11 +65,33 @@ func TestHandler_authIdentity(t *testing.T) {
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehavior: func(r *mock_usecase.MockAuthorization, token string) {
-				r.EXPECT().ParseToken(token).Return(0, errors.New("Некорректный ввод токена"))
+				r.EXPECT().ParseToken(token).Return(domain.Session{}, errors.New("Некорректный ввод токена"))
 			},
 			expectedStatusCode:   http.StatusUnauthorized,
 			expectedResponseBody: `{"message":"Некорректный ввод токена"}`,
 		},
+		{
+			name:        "Пользователь заблокирован",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			token:       "token",
+			mockBehavior: func(r *mock_usecase.MockAuthorization, token string) {
+				r.EXPECT().ParseToken(token).Return(domain.Session{}, domain.ErrUserBanned)
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedResponseBody: `{"message":"` + domain.ErrUserBanned.Error() + `"}`,
+		},
+		{
+			name:        "Требуется смена пароля",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			token:       "token",
+			mockBehavior: func(r *mock_usecase.MockAuthorization, token string) {
+				r.EXPECT().ParseToken(token).Return(domain.Session{UserId: 1}, domain.ErrPasswordResetRequired)
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedResponseBody: `{"message":"` + domain.ErrPasswordResetRequired.Error() + `"}`,
+		},
 	}
 
 	for _, test := range testTable {
@@ -97,6 +123,263 @@ func TestHandler_authIdentity(t *testing.T) {
 	}
 }
 
+func TestHandler_authIdentityPasswordReset(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_usecase.NewMockAuthorization(c)
+	repo.EXPECT().ParseToken("token").Return(domain.Session{UserId: 1}, domain.ErrPasswordResetRequired)
+
+	usecases := &usecase.Usecase{Authorization: repo}
+	handler := Handler{usecases}
+
+	r := gin.New()
+	r.PUT(passwordChangePath, handler.authIdentity, func(c *gin.Context) {
+		id, _ := c.Get(userCtx)
+		c.String(http.StatusOK, "%d", id)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", passwordChangePath, nil)
+	req.Header.Set("Authorization", "Bearer token")
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Body.String())
+}
+
+func TestHandler_authIdentityApiKey(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockApiKeys, key string)
+	sender := domain.ApiKeyIdentity{KeyId: 7, UserId: 3, Scopes: []string{domain.ScopeCoinsSend}}
+
+	testTable := []struct {
+		name                 string
+		method               string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Ok",
+			method: "POST",
+			path:   "/api/sendCoin",
+			mockBehavior: func(s *mock_usecase.MockApiKeys, key string) {
+				s.EXPECT().AuthenticateApiKey(key).Return(sender, nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: "3 7",
+		},
+		{
+			name:   "Нет разрешения",
+			method: "GET",
+			path:   "/api/info",
+			mockBehavior: func(s *mock_usecase.MockApiKeys, key string) {
+				s.EXPECT().AuthenticateApiKey(key).Return(sender, nil)
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedResponseBody: `{"message":"` + domain.ErrApiKeyScope.Error() + `"}`,
+		},
+		{
+			name:   "Маршрут недоступен ключам",
+			method: "DELETE",
+			path:   "/api/profile",
+			mockBehavior: func(s *mock_usecase.MockApiKeys, key string) {
+				s.EXPECT().AuthenticateApiKey(key).Return(sender, nil)
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedResponseBody: `{"message":"` + domain.ErrApiKeyScope.Error() + `"}`,
+		},
+		{
+			name:   "Недействительный ключ",
+			method: "POST",
+			path:   "/api/sendCoin",
+			mockBehavior: func(s *mock_usecase.MockApiKeys, key string) {
+				s.EXPECT().AuthenticateApiKey(key).Return(domain.ApiKeyIdentity{}, domain.ErrInvalidApiKey)
+			},
+			expectedStatusCode:   http.StatusUnauthorized,
+			expectedResponseBody: `{"message":"` + domain.ErrInvalidApiKey.Error() + `"}`,
+		},
+		{
+			name:   "Пользователь заблокирован",
+			method: "POST",
+			path:   "/api/sendCoin",
+			mockBehavior: func(s *mock_usecase.MockApiKeys, key string) {
+				s.EXPECT().AuthenticateApiKey(key).Return(domain.ApiKeyIdentity{}, domain.ErrUserBanned)
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedResponseBody: `{"message":"` + domain.ErrUserBanned.Error() + `"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			key := "cs_0123456789ab_secret"
+			apiKeys := mock_usecase.NewMockApiKeys(c)
+			test.mockBehavior(apiKeys, key)
+
+			usecases := &usecase.Usecase{Authorization: mock_usecase.NewMockAuthorization(c), ApiKeys: apiKeys,
+				Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+
+			// маршруты регистрируются так же, как в InitRoutes, чтобы FullPath совпадал с таблицей разрешений
+			r := gin.New()
+			authorized := r.Group("/api").Group("/", handler.authIdentity)
+			respond := func(c *gin.Context) {
+				id, _ := c.Get(userCtx)
+				keyId, _ := c.Get(apiKeyCtx)
+				c.String(http.StatusOK, "%d %d", id, keyId)
+			}
+			authorized.POST("/sendCoin", respond)
+			authorized.GET("/info", respond)
+			authorized.DELETE("/profile", respond)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Set("Authorization", "ApiKey "+key)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			if test.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, test.expectedResponseBody, w.Body.String())
+			} else {
+				assert.JSONEq(t, test.expectedResponseBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_requireRoleApiKey(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	apiKeys := mock_usecase.NewMockApiKeys(c)
+	apiKeys.EXPECT().AuthenticateApiKey("cs_0123456789ab_secret").
+		Return(domain.ApiKeyIdentity{KeyId: 7, ServiceAccountId: 2, UserId: 4, Scopes: []string{domain.ScopeCoinsGrant}}, nil)
+	audit := mock_usecase.NewMockAudit(c)
+	actorId := 4
+	audit.EXPECT().Record(gomock.Any()).DoAndReturn(func(event domain.AuditEvent) error {
+		assert.Equal(t, domain.AuditApiKeyUse, event.Action)
+		assert.Equal(t, &actorId, event.ActorId)
+		assert.Equal(t, domain.AuditTargetApiKey, event.TargetType)
+		assert.Equal(t, "7", event.TargetId)
+		assert.Equal(t, map[string]interface{}{"service_account_id": 2, "route": "POST /api/v1/admin/coins/grant"}, event.After)
+		return nil
+	})
+
+	// доступ к маршруту дает разрешение ключа: роль пользователя сервисного аккаунта и политика
+	// двухфакторной аутентификации не проверяются, GetUserRole и CheckRolePolicy не вызываются
+	usecases := &usecase.Usecase{Authorization: mock_usecase.NewMockAuthorization(c), ApiKeys: apiKeys,
+		TwoFactor: mock_usecase.NewMockTwoFactor(c), Audit: audit}
+	handler := Handler{usecases}
+
+	r := gin.New()
+	r.Group(apiPrefix+"/admin", handler.authIdentity, handler.requireRole(domain.RoleAdmin)).
+		POST("/coins/grant", func(c *gin.Context) {
+			role, _ := c.Get(roleCtx)
+			c.String(http.StatusOK, "%s", role)
+		})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", apiPrefix+"/admin/coins/grant", nil)
+	req.Header.Set("Authorization", "ApiKey cs_0123456789ab_secret")
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.RoleService, w.Body.String())
+}
+
+func TestHandler_requireRole(t *testing.T) {
+	type mockBehavior func(r *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, userId int)
+
+	testTable := []struct {
+		name                 string
+		userId               int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Ok",
+			userId: 1,
+			mockBehavior: func(r *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, userId int) {
+				r.EXPECT().GetUserRole(userId).Return(domain.RoleAdmin, nil)
+				tf.EXPECT().CheckRolePolicy(userId, domain.RoleAdmin).Return(nil)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: domain.RoleAdmin,
+		},
+		{
+			name:   "Не включена двухфакторная аутентификация",
+			userId: 4,
+			mockBehavior: func(r *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, userId int) {
+				r.EXPECT().GetUserRole(userId).Return(domain.RoleAdmin, nil)
+				tf.EXPECT().CheckRolePolicy(userId, domain.RoleAdmin).Return(domain.ErrTwoFactorRequired)
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedResponseBody: `{"message":"` + domain.ErrTwoFactorRequired.Error() + `"}`,
+		},
+		{
+			name:   "Недостаточно прав",
+			userId: 2,
+			mockBehavior: func(r *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, userId int) {
+				r.EXPECT().GetUserRole(userId).Return(domain.RoleUser, nil)
+			},
+			expectedStatusCode:   http.StatusForbidden,
+			expectedResponseBody: `{"message":"Недостаточно прав"}`,
+		},
+		{
+			name:   "Ошибка получения роли",
+			userId: 3,
+			mockBehavior: func(r *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, userId int) {
+				r.EXPECT().GetUserRole(userId).Return("", errors.New("пользователь не найден"))
+			},
+			expectedStatusCode:   http.StatusInternalServerError,
+			expectedResponseBody: `{"message":"пользователь не найден"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockAuthorization(c)
+			twoFactor := mock_usecase.NewMockTwoFactor(c)
+			test.mockBehavior(repo, twoFactor, test.userId)
+
+			usecases := &usecase.Usecase{Authorization: repo, TwoFactor: twoFactor}
+			handler := Handler{usecases}
+
+			r := gin.New()
+			r.GET("/admin", func(c *gin.Context) {
+				c.Set(userCtx, test.userId)
+			}, handler.requireRole(domain.RoleAdmin), func(c *gin.Context) {
+				role, _ := c.Get(roleCtx)
+				c.String(http.StatusOK, "%s", role)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			if test.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, test.expectedResponseBody, w.Body.String())
+			} else {
+				assert.JSONEq(t, test.expectedResponseBody, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestGetUserId(t *testing.T) {
 	testTable := []struct {
 		name       string
@@ -137,3 +420,80 @@ func TestGetUserId(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_rateLimit(t *testing.T) {
+	handler := Handler{&usecase.Usecase{}}
+	r := gin.New()
+	r.GET("/limited", func(c *gin.Context) {
+		c.Set(userCtx, 1)
+	}, handler.rateLimit(ratelimit.NewMemoryStore(), "api", ratelimit.Limit{Rate: 0.5, Burst: 2}, userKey), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for i := 1; i >= 0; i-- {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/limited", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, strconv.Itoa(i), w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/limited", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"message":"Слишком много запросов, повторите позже"}`, w.Body.String())
+}
+
+func TestHandler_rateLimitForwardedFor(t *testing.T) {
+	testTable := []struct {
+		name           string
+		trustedProxies []string
+		secondStatus   int
+	}{
+		{
+			// без доверенных прокси X-Forwarded-For не меняет ключ лимита: второй запрос отклоняется
+			name:         "Прокси не заданы",
+			secondStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:           "Запрос от доверенного прокси",
+			trustedProxies: []string{"192.0.2.0/24"},
+			secondStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			handler := Handler{&usecase.Usecase{}}
+			r, err := handler.InitRoutes(RateLimits{Store: ratelimit.NewMemoryStore(), Auth: ratelimit.Limit{Rate: 0.01, Burst: 1}},
+				test.trustedProxies)
+			assert.NoError(t, err)
+
+			statuses := make([]int, 0, 2)
+			for _, forwardedFor := range []string{"203.0.113.1", "203.0.113.2"} {
+				w := httptest.NewRecorder()
+				// тело некорректно, поэтому запрос, прошедший лимит, завершается кодом 400
+				req := httptest.NewRequest("POST", apiPrefix+"/auth/sign-in", strings.NewReader("{"))
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				r.ServeHTTP(w, req)
+				statuses = append(statuses, w.Code)
+			}
+			assert.Equal(t, []int{http.StatusBadRequest, test.secondStatus}, statuses)
+		})
+	}
+}
+
+func TestHandler_rateLimitDisabled(t *testing.T) {
+	handler := Handler{&usecase.Usecase{}}
+	r := gin.New()
+	r.GET("/limited", handler.rateLimit(ratelimit.NewMemoryStore(), "auth", ratelimit.Limit{}, clientIPKey), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/limited", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
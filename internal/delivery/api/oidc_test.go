@@ -0,0 +1,180 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_oidcLogin(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	oidc := mock_usecase.NewMockOidc(c)
+	oidc.EXPECT().StartOidcLogin().Return(domain.OidcLogin{URL: "https://idp.example.com/authorize?state=abc", State: "abc"}, nil)
+
+	handler := Handler{&usecase.Usecase{Oidc: oidc}}
+	r := gin.New()
+	r.GET("/api/auth/oidc/login", handler.OidcLogin)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/auth/oidc/login", nil))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://idp.example.com/authorize?state=abc", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, oidcStateCookie, cookies[0].Name)
+		assert.Equal(t, "abc", cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	}
+}
+
+func TestHandler_oidcLoginDisabled(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	oidc := mock_usecase.NewMockOidc(c)
+	oidc.EXPECT().StartOidcLogin().Return(domain.OidcLogin{}, domain.ErrOidcDisabled)
+
+	handler := Handler{&usecase.Usecase{Oidc: oidc}}
+	r := gin.New()
+	r.GET("/api/auth/oidc/login", handler.OidcLogin)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/auth/oidc/login", nil))
+
+	assert.Equal(t, 404, w.Code)
+	assert.JSONEq(t, `{"message":"`+domain.ErrOidcDisabled.Error()+`"}`, w.Body.String())
+}
+
+func TestHandler_oidcCallback(t *testing.T) {
+	type mockBehavior func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, o *mock_usecase.MockOidc, audit *mock_usecase.MockAudit)
+	input := domain.OidcCallbackInput{State: "abc", Code: "code1"}
+	loginFailed := fmt.Errorf("%w: нет id_token", domain.ErrOidcLoginFailed)
+
+	testTable := []struct {
+		name                 string
+		query                string
+		cookie               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			query:  "?state=abc&code=code1",
+			cookie: "abc",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, o *mock_usecase.MockOidc, audit *mock_usecase.MockAudit) {
+				o.EXPECT().CompleteOidcLogin(input).Return(domain.User{Id: 1, UserName: "ivan"}, nil)
+				a.EXPECT().GenerateToken(1).Return("valid.jwt.token", nil)
+				audit.EXPECT().Record(gomock.Cond(func(e domain.AuditEvent) bool {
+					return e.Action == domain.AuditLogin && e.TargetId == "1"
+				})).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"valid.jwt.token"}`,
+		},
+		{
+			name:   "Требуется второй фактор",
+			query:  "?state=abc&code=code1",
+			cookie: "abc",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, o *mock_usecase.MockOidc, audit *mock_usecase.MockAudit) {
+				o.EXPECT().CompleteOidcLogin(input).Return(domain.User{Id: 1, TwoFactorEnabled: true}, nil)
+				tf.EXPECT().StartTwoFactorSignIn(1).Return("intermediate.jwt.token", nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"two_factor_required":true,"two_factor_token":"intermediate.jwt.token"}`,
+		},
+		{
+			name:   "state не совпадает с cookie",
+			query:  "?state=abc&code=code1",
+			cookie: "other",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, o *mock_usecase.MockOidc, audit *mock_usecase.MockAudit) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"` + domain.ErrInvalidOidcState.Error() + `"}`,
+		},
+		{
+			name:  "Нет cookie",
+			query: "?state=abc&code=code1",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, o *mock_usecase.MockOidc, audit *mock_usecase.MockAudit) {
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"` + domain.ErrInvalidOidcState.Error() + `"}`,
+		},
+		{
+			name:   "Провайдер не выдал токен",
+			query:  "?state=abc&code=code1",
+			cookie: "abc",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, o *mock_usecase.MockOidc, audit *mock_usecase.MockAudit) {
+				o.EXPECT().CompleteOidcLogin(input).Return(domain.User{}, loginFailed)
+				audit.EXPECT().Record(gomock.Cond(func(e domain.AuditEvent) bool {
+					return e.Action == domain.AuditLoginFailed
+				})).Return(nil)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"` + loginFailed.Error() + `"}`,
+		},
+		{
+			name:   "Пользователь заблокирован",
+			query:  "?state=abc&code=code1",
+			cookie: "abc",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, o *mock_usecase.MockOidc, audit *mock_usecase.MockAudit) {
+				o.EXPECT().CompleteOidcLogin(input).Return(domain.User{}, domain.ErrUserBanned)
+				audit.EXPECT().Record(gomock.Cond(func(e domain.AuditEvent) bool {
+					return e.Action == domain.AuditLoginFailed
+				})).Return(nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"` + domain.ErrUserBanned.Error() + `"}`,
+		},
+		{
+			name:   "Вход устарел",
+			query:  "?state=abc&code=code1",
+			cookie: "abc",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, o *mock_usecase.MockOidc, audit *mock_usecase.MockAudit) {
+				o.EXPECT().CompleteOidcLogin(input).Return(domain.User{}, domain.ErrInvalidOidcState)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"` + domain.ErrInvalidOidcState.Error() + `"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_usecase.NewMockAuthorization(c)
+			twoFactor := mock_usecase.NewMockTwoFactor(c)
+			oidc := mock_usecase.NewMockOidc(c)
+			audit := mock_usecase.NewMockAudit(c)
+			testCase.mockBehavior(auth, twoFactor, oidc, audit)
+
+			usecases := &usecase.Usecase{Authorization: auth, TwoFactor: twoFactor, Oidc: oidc, Audit: audit}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.GET("/api/auth/oidc/callback", handler.OidcCallback)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/auth/oidc/callback"+testCase.query, nil)
+			if testCase.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: testCase.cookie})
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcStateCookie привязывает вход к браузеру, в котором он начат: state из адреса возврата
+	// принимается, только если совпадает с этой cookie. Путь cookie покрывает и /api/v1, и устаревший /api.
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = legacyApiPrefix
+)
+
+// OidcLogin перенаправляет пользователя на страницу входа провайдера OpenID Connect.
+func (h *Handler) OidcLogin(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на вход через внешнего провайдера")
+	login, err := h.Usecases.Oidc.StartOidcLogin()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, login.State, 0, oidcCookiePath, "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, login.URL)
+}
+
+// OidcCallback принимает пользователя, вернувшегося от провайдера, и завершает вход так же,
+// как вход по паролю: выдает токен доступа или промежуточный токен второго фактора.
+func (h *Handler) OidcCallback(c *gin.Context) {
+	logger.Log.Info().Msg("Получили ответ внешнего провайдера входа")
+	var input domain.OidcCallbackInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	state, err := c.Cookie(oidcStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, oidcCookiePath, "", c.Request.TLS != nil, true)
+	if err != nil || input.State == "" || state != input.State {
+		logger.Log.Warn().Str("ip", c.ClientIP()).Msg("state не совпадает с cookie")
+		newErrorResponse(c, http.StatusUnauthorized, domain.ErrInvalidOidcState.Error())
+		return
+	}
+
+	user, err := h.Usecases.Oidc.CompleteOidcLogin(input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		if errors.Is(err, domain.ErrOidcLoginFailed) || errors.Is(err, domain.ErrUserBanned) {
+			h.audit(c, domain.AuditLoginFailed, domain.AuditTargetLogin, "", nil, map[string]interface{}{
+				"reason": err.Error(), "method": "oidc",
+			})
+		}
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	h.completeSignIn(c, user)
+}
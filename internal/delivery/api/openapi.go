@@ -0,0 +1,569 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// apiOperation описывает маршрут v1 в спецификации OpenAPI. Путь указывается в формате gin
+// относительно префикса версии, тела запроса и ответа - значениями типов, из которых строятся схемы.
+type apiOperation struct {
+	method, path, tag, summary string
+	query                      []apiParam
+	// queryForm - структура с тегами form, поля которой принимаются в строке запроса.
+	queryForm interface{}
+	request   interface{}
+	// optionalBody - тело запроса можно не передавать.
+	optionalBody bool
+	// upload - тело запроса можно передать CSV-файлом в поле file формы multipart/form-data.
+	upload   bool
+	response interface{}
+	redirect bool
+	public   bool
+}
+
+type apiParam struct {
+	name, typ, description string
+	enum                   []string
+}
+
+// Ответы, которые обработчики собирают из map. Типы нужны только для описания схем.
+type (
+	idResponse struct {
+		Id int `json:"id"`
+	}
+	// signInResponse - ответ на вход: токен доступа, а при включенной двухфакторной аутентификации
+	// промежуточный токен для второго шага. Поле id возвращается при первом входе с автоматической регистрацией.
+	signInResponse struct {
+		Id                    int    `json:"id,omitempty"`
+		Token                 string `json:"token,omitempty"`
+		PasswordResetRequired bool   `json:"password_reset_required,omitempty"`
+		TwoFactorRequired     bool   `json:"two_factor_required,omitempty"`
+		TwoFactorToken        string `json:"two_factor_token,omitempty"`
+	}
+	tokenResponse struct {
+		Token string `json:"token"`
+	}
+	usernameResponse struct {
+		UserName string `json:"username"`
+	}
+	transactionIdResponse struct {
+		TransactionId int `json:"transaction_id"`
+	}
+	recoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	unlockResponse struct {
+		Unlocked int `json:"unlocked"`
+	}
+	temporaryPasswordResponse struct {
+		TemporaryPassword string `json:"temporary_password"`
+	}
+)
+
+var (
+	limitParam     = apiParam{name: "limit", typ: "integer", description: "Количество записей на странице"}
+	offsetParam    = apiParam{name: "offset", typ: "integer", description: "Смещение от начала выборки"}
+	cursorParam    = apiParam{name: "cursor", typ: "string", description: "Значение next_cursor из предыдущего ответа"}
+	directionParam = apiParam{name: "direction", typ: "string", description: "in - входящие, out - исходящие",
+		enum: []string{domain.DirectionIn, domain.DirectionOut}}
+)
+
+// apiOperations - все маршруты v1. Тест сверяет таблицу с маршрутами, зарегистрированными в InitRoutes.
+var apiOperations = []apiOperation{
+	{method: http.MethodPost, path: "/auth/sign-up", tag: "auth", summary: "Регистрация пользователя",
+		request: domain.User{}, response: idResponse{}, public: true},
+	{method: http.MethodPost, path: "/auth/sign-in", tag: "auth", summary: "Вход по имени пользователя и паролю",
+		request: domain.SignInInput{}, response: signInResponse{}, public: true},
+	{method: http.MethodPost, path: "/auth/sign-in/2fa", tag: "auth", summary: "Второй шаг входа с кодом второго фактора",
+		request: domain.TwoFactorSignInInput{}, response: signInResponse{}, public: true},
+	{method: http.MethodGet, path: "/auth/oidc/login", tag: "auth", summary: "Перенаправление на вход через провайдера OpenID Connect",
+		redirect: true, public: true},
+	{method: http.MethodGet, path: "/auth/oidc/callback", tag: "auth", summary: "Возврат от провайдера OpenID Connect",
+		queryForm: domain.OidcCallbackInput{}, response: signInResponse{}, public: true},
+
+	{method: http.MethodPost, path: "/coins/send", tag: "coins", summary: "Перевод монет пользователю",
+		request: domain.Transactions{}, response: idResponse{}},
+	{method: http.MethodPost, path: "/coins/send/batch", tag: "coins", summary: "Пакетный перевод монет",
+		request: domain.BatchTransferInput{}, upload: true, response: domain.BatchTransferResult{}},
+	{method: http.MethodGet, path: "/info", tag: "coins", summary: "Баланс, покупки и история переводов",
+		response: domain.UserSummary{}},
+	{method: http.MethodGet, path: "/transactions", tag: "coins", summary: "История переводов",
+		query: []apiParam{limitParam, directionParam, {name: "category", typ: "string", description: "Категория перевода",
+			enum: []string{domain.TransferThanks, domain.TransferBet, domain.TransferRefund, domain.TransferGift}}, cursorParam},
+		response: domain.TransactionHistory{}},
+	{method: http.MethodPost, path: "/schedules", tag: "coins", summary: "Создание расписания перевода",
+		request: domain.ScheduleInput{}, response: domain.ScheduledTransfer{}},
+	{method: http.MethodGet, path: "/schedules", tag: "coins", summary: "Расписания переводов пользователя",
+		response: []domain.ScheduledTransfer{}},
+	{method: http.MethodDelete, path: "/schedules/:id", tag: "coins", summary: "Отмена расписания перевода",
+		response: idResponse{}},
+	{method: http.MethodGet, path: "/schedules/:id/runs", tag: "coins", summary: "История запусков расписания",
+		response: []domain.ScheduleRun{}},
+	{method: http.MethodPost, path: "/payment-requests", tag: "coins", summary: "Запрос монет у пользователя",
+		request: domain.PaymentRequestInput{}, response: domain.PaymentRequest{}},
+	{method: http.MethodGet, path: "/payment-requests", tag: "coins", summary: "Запросы на перевод",
+		query: []apiParam{directionParam, {name: "status", typ: "string", enum: []string{domain.PaymentRequestPending,
+			domain.PaymentRequestAccepted, domain.PaymentRequestDeclined, domain.PaymentRequestCancelled, domain.PaymentRequestExpired}}},
+		response: []domain.PaymentRequest{}},
+	{method: http.MethodPost, path: "/payment-requests/:id/accept", tag: "coins", summary: "Оплата запроса на перевод",
+		response: domain.PaymentRequest{}},
+	{method: http.MethodPost, path: "/payment-requests/:id/decline", tag: "coins", summary: "Отклонение запроса на перевод",
+		response: domain.PaymentRequest{}},
+	{method: http.MethodPost, path: "/payment-requests/:id/cancel", tag: "coins", summary: "Отмена своего запроса на перевод",
+		response: domain.PaymentRequest{}},
+	{method: http.MethodPost, path: "/escrows", tag: "coins", summary: "Перевод с удержанием",
+		request: domain.EscrowInput{}, response: domain.Escrow{}},
+	{method: http.MethodGet, path: "/escrows", tag: "coins", summary: "Переводы с удержанием",
+		query: []apiParam{{name: "status", typ: "string",
+			enum: []string{domain.EscrowHeld, domain.EscrowReleased, domain.EscrowReturned}}},
+		response: []domain.Escrow{}},
+	{method: http.MethodPost, path: "/escrows/:id/release", tag: "coins", summary: "Выплата удержанных монет получателю",
+		response: domain.Escrow{}},
+	{method: http.MethodPost, path: "/escrows/:id/return", tag: "coins", summary: "Возврат удержанных монет отправителю",
+		response: domain.Escrow{}},
+
+	{method: http.MethodGet, path: "/shop", tag: "shop", summary: "Каталог товаров",
+		response: []domain.Merch{}},
+	{method: http.MethodPost, path: "/shop/:item/buy", tag: "shop", summary: "Покупка товара",
+		queryForm: domain.BuyOptions{}, response: idResponse{}},
+	{method: http.MethodPost, path: "/orders", tag: "shop", summary: "Заказ нескольких товаров",
+		request: domain.OrderInput{}, response: idResponse{}},
+	{method: http.MethodGet, path: "/purchases", tag: "shop", summary: "История покупок",
+		query: []apiParam{limitParam,
+			{name: "from", typ: "string", description: "Начало периода: дата или время RFC3339"},
+			{name: "to", typ: "string", description: "Конец периода: дата (включительно) или время RFC3339"}, cursorParam},
+		response: domain.PurchaseHistory{}},
+	{method: http.MethodPost, path: "/purchases/:id/refund", tag: "shop", summary: "Заявка на возврат покупки",
+		request: domain.RefundInput{}, optionalBody: true, response: idResponse{}},
+
+	{method: http.MethodPut, path: "/profile/password", tag: "profile", summary: "Смена пароля",
+		request: domain.PasswordChangeInput{}, response: tokenResponse{}},
+	{method: http.MethodPut, path: "/profile/username", tag: "profile", summary: "Смена имени пользователя",
+		request: domain.UsernameChangeInput{}, response: usernameResponse{}},
+	{method: http.MethodDelete, path: "/profile", tag: "profile", summary: "Удаление аккаунта",
+		request: domain.AccountDeleteInput{}, response: idResponse{}},
+	{method: http.MethodPost, path: "/profile/2fa/enroll", tag: "profile", summary: "Настройка двухфакторной аутентификации",
+		response: domain.TwoFactorEnrollment{}},
+	{method: http.MethodPost, path: "/profile/2fa/confirm", tag: "profile", summary: "Включение двухфакторной аутентификации",
+		request: domain.TwoFactorCodeInput{}, response: recoveryCodesResponse{}},
+	{method: http.MethodPost, path: "/profile/2fa/recovery-codes", tag: "profile", summary: "Новые коды восстановления",
+		request: domain.TwoFactorCodeInput{}, response: recoveryCodesResponse{}},
+	{method: http.MethodDelete, path: "/profile/2fa", tag: "profile", summary: "Отключение двухфакторной аутентификации",
+		request: domain.TwoFactorDisableInput{}, response: idResponse{}},
+
+	{method: http.MethodPost, path: "/admin/shop/:item/restock", tag: "admin", summary: "Пополнение остатка товара",
+		request: domain.RestockInput{}, response: domain.Merch{}},
+	{method: http.MethodPut, path: "/admin/shop/:item/stock", tag: "admin", summary: "Остаток и лимит покупки товара",
+		request: domain.ItemStockInput{}, response: domain.Merch{}},
+	{method: http.MethodPost, path: "/admin/shop/:item/variants", tag: "admin", summary: "Добавление варианта товара",
+		request: domain.VariantInput{}, response: domain.ItemVariant{}},
+	{method: http.MethodPut, path: "/admin/shop/:item/variants/:id/stock", tag: "admin", summary: "Остаток варианта товара",
+		request: domain.VariantStockInput{}, response: domain.ItemVariant{}},
+	{method: http.MethodGet, path: "/admin/refunds", tag: "admin", summary: "Заявки на возврат",
+		query: []apiParam{{name: "status", typ: "string",
+			enum: []string{domain.RefundRequested, domain.RefundApproved, domain.RefundRejected}}},
+		response: []domain.Refund{}},
+	{method: http.MethodPost, path: "/admin/refunds/:id/approve", tag: "admin", summary: "Одобрение возврата",
+		response: domain.Refund{}},
+	{method: http.MethodPost, path: "/admin/refunds/:id/reject", tag: "admin", summary: "Отклонение возврата",
+		response: domain.Refund{}},
+	{method: http.MethodPost, path: "/admin/purchases/:id/refund", tag: "admin", summary: "Возврат покупки администратором",
+		request: domain.RefundInput{}, optionalBody: true, response: domain.Refund{}},
+	{method: http.MethodGet, path: "/admin/purchases", tag: "admin", summary: "Покупки по статусу выдачи",
+		query: []apiParam{{name: "status", typ: "string", description: "По умолчанию pending",
+			enum: []string{domain.PurchasePending, domain.PurchasePacked, domain.PurchaseShipped, domain.PurchaseCancelled}}},
+		response: []domain.Purchase{}},
+	{method: http.MethodPut, path: "/admin/purchases/:id/status", tag: "admin", summary: "Смена статуса выдачи покупки",
+		request: domain.PurchaseStatusInput{}, response: domain.Purchase{}},
+	{method: http.MethodGet, path: "/admin/promotions", tag: "admin", summary: "Акции",
+		response: []domain.Promotion{}},
+	{method: http.MethodPost, path: "/admin/promotions", tag: "admin", summary: "Создание акции",
+		request: domain.PromotionInput{}, response: domain.Promotion{}},
+	{method: http.MethodPost, path: "/admin/promotions/:id/end", tag: "admin", summary: "Завершение акции",
+		response: domain.Promotion{}},
+	{method: http.MethodPost, path: "/admin/coins/grant", tag: "admin", summary: "Начисление монет",
+		request: domain.CoinAdjustmentInput{}, response: transactionIdResponse{}},
+	{method: http.MethodPost, path: "/admin/coins/clawback", tag: "admin", summary: "Списание монет",
+		request: domain.CoinAdjustmentInput{}, response: transactionIdResponse{}},
+	{method: http.MethodPost, path: "/admin/login/unlock", tag: "admin", summary: "Снятие блокировки входа",
+		request: domain.LoginUnlockInput{}, response: unlockResponse{}},
+	{method: http.MethodGet, path: "/admin/metrics", tag: "admin", summary: "Метрики сервиса",
+		response: map[string]interface{}{}},
+	{method: http.MethodGet, path: "/admin/users", tag: "admin", summary: "Поиск пользователей",
+		query: []apiParam{{name: "q", typ: "string", description: "Часть имени пользователя"},
+			{name: "role", typ: "string", enum: []string{domain.RoleUser, domain.RoleAdmin, domain.RoleAuditor, domain.RoleService, domain.RoleDeleted}},
+			{name: "status", typ: "string", enum: []string{domain.UserStatusActive, domain.UserStatusBanned, domain.UserStatusDeleted}},
+			limitParam, offsetParam},
+		response: []domain.UserInfo{}},
+	{method: http.MethodGet, path: "/admin/users/:id/summary", tag: "admin", summary: "Баланс и история пользователя",
+		response: domain.UserSummary{}},
+	{method: http.MethodPost, path: "/admin/users/:id/ban", tag: "admin", summary: "Блокировка пользователя",
+		request: domain.BanInput{}, response: domain.UserInfo{}},
+	{method: http.MethodPost, path: "/admin/users/:id/unban", tag: "admin", summary: "Разблокировка пользователя",
+		response: domain.UserInfo{}},
+	{method: http.MethodPost, path: "/admin/users/:id/password-reset", tag: "admin", summary: "Сброс пароля пользователя",
+		response: temporaryPasswordResponse{}},
+	{method: http.MethodGet, path: "/admin/service-accounts", tag: "admin", summary: "Сервисные аккаунты",
+		response: []domain.ServiceAccount{}},
+	{method: http.MethodPost, path: "/admin/service-accounts", tag: "admin", summary: "Создание сервисного аккаунта",
+		request: domain.ServiceAccountInput{}, response: domain.ServiceAccount{}},
+	{method: http.MethodGet, path: "/admin/service-accounts/:id/keys", tag: "admin", summary: "Ключи API сервисного аккаунта",
+		response: []domain.ApiKey{}},
+	{method: http.MethodPost, path: "/admin/service-accounts/:id/keys", tag: "admin", summary: "Выпуск ключа API",
+		request: domain.ApiKeyInput{}, response: domain.CreatedApiKey{}},
+	{method: http.MethodPost, path: "/admin/api-keys/:id/revoke", tag: "admin", summary: "Отзыв ключа API",
+		response: domain.ApiKey{}},
+
+	{method: http.MethodGet, path: "/audit", tag: "audit", summary: "Журнал аудита",
+		query: []apiParam{{name: "action", typ: "string"}, {name: "actor_id", typ: "integer"},
+			{name: "target_type", typ: "string"}, {name: "target_id", typ: "string"},
+			{name: "from", typ: "string", description: "Время RFC3339"}, {name: "to", typ: "string", description: "Время RFC3339"},
+			limitParam, offsetParam},
+		response: []domain.AuditEntry{}},
+	{method: http.MethodGet, path: "/audit/verify", tag: "audit", summary: "Проверка целостности журнала аудита",
+		response: domain.AuditVerification{}},
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  []byte
+)
+
+// OpenAPISpec отдает спецификацию OpenAPI маршрутов v1.
+func (h *Handler) OpenAPISpec(c *gin.Context) {
+	openAPIOnce.Do(func() {
+		var err error
+		if openAPIDoc, err = json.Marshal(buildOpenAPISpec(apiOperations)); err != nil {
+			panic(err)
+		}
+	})
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPIDoc)
+}
+
+func buildOpenAPISpec(operations []apiOperation) map[string]interface{} {
+	schemas := newSchemaBuilder(operations)
+	paths := map[string]map[string]interface{}{}
+	for _, op := range operations {
+		path := openAPIPath(op.path)
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(op.method)] = schemas.operation(op)
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Coinshop API",
+			"version": "1.0.0",
+			"description": "Маршруты без версии (/api/...) остаются устаревшими псевдонимами /api/v1: " +
+				"их ответы содержат заголовки Deprecation и Link с путем v1.",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": apiPrefix}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": authorizationHeader,
+					"description": "Заголовок вида \"" + apiKeyScheme + " <ключ>\". Ключу доступны только маршруты с x-api-key-scope."},
+			},
+		},
+	}
+}
+
+// openAPIPath переводит путь gin в путь OpenAPI: /shop/:item/buy -> /shop/{item}/buy.
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// schemaBuilder строит схемы OpenAPI из типов по тегам json и binding. Поле запроса обязательно,
+// если binding содержит required, поле ответа - если в json нет omitempty.
+type schemaBuilder struct {
+	components map[string]interface{}
+	requests   map[reflect.Type]bool
+}
+
+func newSchemaBuilder(operations []apiOperation) *schemaBuilder {
+	b := &schemaBuilder{components: map[string]interface{}{}, requests: map[reflect.Type]bool{}}
+	for _, op := range operations {
+		if op.request != nil {
+			b.requests[reflect.TypeOf(op.request)] = true
+		}
+	}
+	return b
+}
+
+func (b *schemaBuilder) operation(op apiOperation) map[string]interface{} {
+	operation := map[string]interface{}{
+		"tags":        []string{op.tag},
+		"summary":     op.summary,
+		"operationId": strings.ToLower(op.method) + strings.NewReplacer("/", "_", ":", "", "-", "_").Replace(op.path),
+	}
+
+	var parameters []interface{}
+	for _, segment := range strings.Split(op.path, "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			typ := "string"
+			if name == "id" {
+				typ = "integer"
+			}
+			parameters = append(parameters, map[string]interface{}{
+				"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": typ},
+			})
+		}
+	}
+	query := op.query
+	if op.queryForm != nil {
+		query = append(query, formParams(reflect.TypeOf(op.queryForm))...)
+	}
+	for _, param := range query {
+		schema := map[string]interface{}{"type": param.typ}
+		if len(param.enum) > 0 {
+			schema["enum"] = param.enum
+		}
+		parameter := map[string]interface{}{"name": param.name, "in": "query", "schema": schema}
+		if param.description != "" {
+			parameter["description"] = param.description
+		}
+		parameters = append(parameters, parameter)
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if op.request != nil {
+		content := map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": b.schema(reflect.TypeOf(op.request))},
+		}
+		if op.upload {
+			content["multipart/form-data"] = map[string]interface{}{"schema": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"file"},
+				"properties": map[string]interface{}{
+					"file": map[string]interface{}{"type": "string", "format": "binary",
+						"description": "CSV со столбцами destination_username, amount и необязательными message, category"},
+					"mode": b.fieldSchema(reflect.TypeOf(domain.BatchTransferInput{}), "Mode"),
+				},
+			}}
+		}
+		operation["requestBody"] = map[string]interface{}{"required": !op.optionalBody, "content": content}
+	}
+
+	responses := map[string]interface{}{
+		"default": map[string]interface{}{
+			"description": "Ошибка",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": b.schema(reflect.TypeOf(errorResponse{}))},
+			},
+		},
+	}
+	if op.redirect {
+		responses[strconv.Itoa(http.StatusFound)] = map[string]interface{}{
+			"description": "Перенаправление на страницу входа провайдера",
+			"headers": map[string]interface{}{
+				"Location": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+			},
+		}
+	} else {
+		responses[strconv.Itoa(http.StatusOK)] = map[string]interface{}{
+			"description": "Успешный ответ",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": b.schema(reflect.TypeOf(op.response))},
+			},
+		}
+	}
+	operation["responses"] = responses
+
+	if !op.public {
+		security := []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+		if scope, ok := apiKeyRoutes[op.method+" "+apiPrefix+op.path]; ok {
+			security = append(security, map[string]interface{}{"apiKeyAuth": []string{}})
+			operation["x-api-key-scope"] = scope
+		}
+		operation["security"] = security
+	}
+	return operation
+}
+
+// formParams описывает поля структуры с тегами form как параметры строки запроса.
+func formParams(t reflect.Type) []apiParam {
+	var params []apiParam
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			params = append(params, formParams(field.Type)...)
+			continue
+		}
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+		params = append(params, apiParam{name: name, typ: "string"})
+	}
+	return params
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schema возвращает схему типа. Структуры выносятся в components и подставляются ссылкой.
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawJSONType:
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.schema(t.Elem())
+		if _, ref := schema["$ref"]; !ref {
+			schema["nullable"] = true
+		}
+		return schema
+	case reflect.Struct:
+		name := componentName(t)
+		if _, ok := b.components[name]; !ok {
+			// место занимается до обхода полей, чтобы рекурсивные типы не зацикливались
+			b.components[name] = nil
+			b.components[name] = b.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+func componentName(t reflect.Type) string {
+	return strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+}
+
+func (b *schemaBuilder) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	b.collectFields(t, b.requests[t], properties, &required)
+	object := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// collectFields добавляет поля структуры в properties. Поля встроенных структур без имени в json
+// поднимаются на уровень выше, как их сериализует encoding/json.
+func (b *schemaBuilder) collectFields(t reflect.Type, request bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			b.collectFields(field.Type, request, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		omitempty := strings.Contains(options, "omitempty")
+		rules := strings.Split(field.Tag.Get("binding"), ",")
+
+		schema := b.fieldSchema(t, field.Name)
+		// пустой срез или map без omitempty сериализуется в null
+		if !request && !omitempty && (field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Map) &&
+			field.Type != rawJSONType {
+			schema["nullable"] = true
+		}
+		properties[name] = schema
+		if hasRule(rules, "required") || !request && !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// fieldSchema возвращает схему поля с ограничениями из тега binding.
+func (b *schemaBuilder) fieldSchema(t reflect.Type, fieldName string) map[string]interface{} {
+	field, _ := t.FieldByName(fieldName)
+	schema := b.schema(field.Type)
+	kind := field.Type.Kind()
+	if kind == reflect.Pointer {
+		kind = field.Type.Elem().Kind()
+	}
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "oneof":
+			schema["enum"] = strings.Fields(value)
+		case "min", "gte", "gt", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			if key == "gt" {
+				n++
+			}
+			schema[bindingLimit(key, kind)] = n
+		}
+	}
+	return schema
+}
+
+func bindingLimit(rule string, kind reflect.Kind) string {
+	upper := rule == "max"
+	switch kind {
+	case reflect.String:
+		if upper {
+			return "maxLength"
+		}
+		return "minLength"
+	case reflect.Slice, reflect.Array:
+		if upper {
+			return "maxItems"
+		}
+		return "minItems"
+	}
+	if upper {
+		return "maximum"
+	}
+	return "minimum"
+}
+
+func hasRule(rules []string, name string) bool {
+	for _, rule := range rules {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,468 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// initRoutes собирает роутер так же, как сервер, без лимитов запросов и доверенных прокси.
+func initRoutes(t *testing.T, handler Handler) *gin.Engine {
+	r, err := handler.InitRoutes(RateLimits{}, nil)
+	assert.NoError(t, err)
+	return r
+}
+
+func fetchOpenAPISpec(t *testing.T, r *gin.Engine) map[string]interface{} {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", apiPrefix+"/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var spec map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	return spec
+}
+
+func TestOpenAPI_routes(t *testing.T) {
+	handler := Handler{&usecase.Usecase{}}
+	r := initRoutes(t, handler)
+
+	documented := map[string]bool{}
+	for _, op := range apiOperations {
+		key := op.method + " " + op.path
+		assert.False(t, documented[key], "маршрут описан дважды: %s", key)
+		documented[key] = true
+	}
+
+	registered := map[string]bool{}
+	var legacy []string
+	for _, route := range r.Routes() {
+		if route.Path == apiPrefix+"/openapi.json" {
+			continue
+		}
+		if path, ok := strings.CutPrefix(route.Path, apiPrefix+"/"); ok {
+			registered[route.Method+" /"+path] = true
+			continue
+		}
+		path, ok := strings.CutPrefix(route.Path, legacyApiPrefix+"/")
+		assert.True(t, ok, "маршрут вне /api: %s %s", route.Method, route.Path)
+		legacy = append(legacy, route.Method+" /"+path)
+	}
+
+	assert.Equal(t, documented, registered, "спецификация расходится с маршрутами v1")
+	assert.Len(t, legacy, len(registered))
+	for _, route := range legacy {
+		if successor, ok := successorRoutes[route]; ok {
+			route = successor
+		}
+		assert.True(t, registered[route], "у маршрута /api нет пары в v1: %s", route)
+	}
+}
+
+func TestOpenAPI_spec(t *testing.T) {
+	handler := Handler{&usecase.Usecase{}}
+	spec := fetchOpenAPISpec(t, initRoutes(t, handler))
+
+	assert.Equal(t, "3.0.3", spec["openapi"])
+	paths, _ := spec["paths"].(map[string]interface{})
+	assert.Len(t, paths, countPaths(apiOperations))
+
+	post, _ := paths["/coins/send"].(map[string]interface{})["post"].(map[string]interface{})
+	assert.Equal(t, domain.ScopeCoinsSend, post["x-api-key-scope"])
+	buy, _ := paths["/shop/{item}/buy"].(map[string]interface{})["post"].(map[string]interface{})
+	assert.NotNil(t, buy["parameters"])
+	assert.Nil(t, buy["x-api-key-scope"])
+	signIn, _ := paths["/auth/sign-in"].(map[string]interface{})["post"].(map[string]interface{})
+	assert.Nil(t, signIn["security"])
+
+	var refs []string
+	collectRefs(spec, &refs)
+	assert.NotEmpty(t, refs)
+	for _, ref := range refs {
+		assert.NotNil(t, resolveRef(spec, ref), "ссылка не найдена: %s", ref)
+	}
+}
+
+func countPaths(operations []apiOperation) int {
+	paths := map[string]bool{}
+	for _, op := range operations {
+		paths[op.path] = true
+	}
+	return len(paths)
+}
+
+func TestOpenAPI_responses(t *testing.T) {
+	type mockBehavior func(a *mock_usecase.MockAuthorization, s *mock_usecase.MockShop, i *mock_usecase.MockInventory)
+
+	stock := 5
+	source := 2
+	sourceName := "sender"
+	timestamp := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name               string
+		method             string
+		path               string
+		specPath           string
+		inputBody          string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+	}{
+		{
+			name:     "Информация о пользователе",
+			method:   "GET",
+			path:     "/info",
+			specPath: "/info",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, s *mock_usecase.MockShop, i *mock_usecase.MockInventory) {
+				s.EXPECT().GetUserSummary(1).Return(&domain.UserSummary{
+					UserName:       "user",
+					Coins:          900,
+					HeldCoins:      50,
+					PendingEscrows: []domain.Escrow{{Id: 1, SenderUsername: "user", RecipientUsername: "friend", Amount: 50, Status: domain.EscrowHeld, OnTimeout: "release", CreatedAt: timestamp, ExpiresAt: timestamp}},
+					PurchasedItems: []domain.PurchasedItem{{ItemName: "t-shirt", Size: "M", Quantity: 1}},
+					TransactionsSummary: domain.TransactionsSummary{
+						ReceivedCoins: []domain.Transactions{{SourceUsername: &sourceName, Amount: 10, Timestamp: &timestamp}},
+					},
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:      "Перевод монет",
+			method:    "POST",
+			path:      "/coins/send",
+			specPath:  "/coins/send",
+			inputBody: `{"destination_username":"friend","amount":10}`,
+			mockBehavior: func(a *mock_usecase.MockAuthorization, s *mock_usecase.MockShop, i *mock_usecase.MockInventory) {
+				s.EXPECT().SendCoin(1, domain.Transactions{DestinationUsername: "friend", Amount: 10}).Return(3, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:     "Каталог",
+			method:   "GET",
+			path:     "/shop",
+			specPath: "/shop",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, s *mock_usecase.MockShop, i *mock_usecase.MockInventory) {
+				i.EXPECT().GetItems().Return([]domain.Merch{
+					{Name: "cup", Price: 20},
+					{Name: "t-shirt", Price: 80, Category: "clothes", Stock: &stock,
+						Variants: []domain.ItemVariant{{Id: 1, Size: "M", PriceDelta: 5, Stock: &stock}}},
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:     "История переводов",
+			method:   "GET",
+			path:     "/transactions?direction=in",
+			specPath: "/transactions",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, s *mock_usecase.MockShop, i *mock_usecase.MockInventory) {
+				s.EXPECT().GetTransactions(1, domain.TransactionFilter{Direction: domain.DirectionIn}).
+					Return(&domain.TransactionHistory{
+						Transactions: []domain.Transactions{{Source: &source, SourceUsername: &sourceName, Amount: 10,
+							Kind: "transfer", Category: domain.TransferThanks, Timestamp: &timestamp}},
+						NextCursor: "cursor",
+					}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:      "Покупка товара",
+			method:    "POST",
+			path:      "/shop/t-shirt/buy?size=M",
+			specPath:  "/shop/{item}/buy",
+			inputBody: "",
+			mockBehavior: func(a *mock_usecase.MockAuthorization, s *mock_usecase.MockShop, i *mock_usecase.MockInventory) {
+				s.EXPECT().BuyItem(1, "t-shirt", domain.BuyOptions{VariantSelector: domain.VariantSelector{Size: "M"}}).
+					Return(4, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Ошибка",
+			method:             "POST",
+			path:               "/coins/send",
+			specPath:           "/coins/send",
+			inputBody:          `{"destination_username":"friend"}`,
+			mockBehavior:       func(a *mock_usecase.MockAuthorization, s *mock_usecase.MockShop, i *mock_usecase.MockInventory) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_usecase.NewMockAuthorization(c)
+			shop := mock_usecase.NewMockShop(c)
+			inventory := mock_usecase.NewMockInventory(c)
+			auth.EXPECT().ParseToken("token").Return(domain.Session{UserId: 1}, nil)
+			test.mockBehavior(auth, shop, inventory)
+
+			handler := Handler{&usecase.Usecase{Authorization: auth, Shop: shop, Inventory: inventory}}
+			r := initRoutes(t, handler)
+			spec := fetchOpenAPISpec(t, r)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, apiPrefix+test.path, strings.NewReader(test.inputBody))
+			req.Header.Set("Authorization", "Bearer token")
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Empty(t, w.Header().Get("Deprecation"))
+			schema := responseSchema(spec, test.specPath, test.method, w.Code)
+			assert.NotNil(t, schema)
+			var body interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Empty(t, validateSchema(spec, schema, body, "$"), w.Body.String())
+		})
+	}
+}
+
+func TestOpenAPI_signInResponse(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mock_usecase.NewMockAuthorization(c)
+	audit := mock_usecase.NewMockAudit(c)
+	auth.EXPECT().SignUser("user", "password", gomock.Any()).Return(domain.User{Id: 1, UserName: "user"}, nil)
+	auth.EXPECT().GenerateToken(1).Return("token", nil)
+	audit.EXPECT().Record(gomock.Any()).Return(nil)
+
+	handler := Handler{&usecase.Usecase{Authorization: auth, Audit: audit}}
+	r := initRoutes(t, handler)
+	spec := fetchOpenAPISpec(t, r)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", apiPrefix+"/auth/sign-in", strings.NewReader(`{"username":"user","password":"password"}`))
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Empty(t, validateSchema(spec, responseSchema(spec, "/auth/sign-in", "POST", w.Code), body, "$"))
+}
+
+func TestOpenAPI_legacyRoutes(t *testing.T) {
+	testTable := []struct {
+		name         string
+		method       string
+		path         string
+		expectedLink string
+	}{
+		{
+			name:         "Переименованный маршрут",
+			method:       "PUT",
+			path:         "/api/buy/t-shirt",
+			expectedLink: "</api/v1/shop/t-shirt/buy>; rel=\"successor-version\"",
+		},
+		{
+			name:         "Маршрут с тем же путем",
+			method:       "GET",
+			path:         "/api/info",
+			expectedLink: "</api/v1/info>; rel=\"successor-version\"",
+		},
+		{
+			name:         "Параметр с пробелом",
+			method:       "POST",
+			path:         "/api/paymentRequests/1%202/accept",
+			expectedLink: "</api/v1/payment-requests/1%202/accept>; rel=\"successor-version\"",
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_usecase.NewMockAuthorization(c)
+			auth.EXPECT().ParseToken("token").Return(domain.Session{}, domain.ErrUserBanned)
+
+			handler := Handler{&usecase.Usecase{Authorization: auth}}
+			r := initRoutes(t, handler)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+
+			r.ServeHTTP(w, req)
+
+			// заголовки выставляются до проверки авторизации
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Equal(t, "true", w.Header().Get("Deprecation"))
+			assert.Equal(t, test.expectedLink, w.Header().Get("Link"))
+		})
+	}
+}
+
+func responseSchema(spec map[string]interface{}, path, method string, status int) map[string]interface{} {
+	operation, _ := lookup(spec, "paths", path, strings.ToLower(method)).(map[string]interface{})
+	responses, _ := operation["responses"].(map[string]interface{})
+	response, ok := responses[fmt.Sprint(status)]
+	if !ok {
+		response = responses["default"]
+	}
+	schema, _ := lookup(response, "content", "application/json", "schema").(map[string]interface{})
+	return schema
+}
+
+func lookup(value interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func collectRefs(value interface{}, refs *[]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if ref, ok := item.(string); ok && key == "$ref" {
+				*refs = append(*refs, ref)
+			}
+			collectRefs(item, refs)
+		}
+	case []interface{}:
+		for _, item := range value {
+			collectRefs(item, refs)
+		}
+	}
+}
+
+func resolveRef(spec map[string]interface{}, ref string) map[string]interface{} {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	schema, _ := lookup(spec, strings.Split(path, "/")...).(map[string]interface{})
+	return schema
+}
+
+// validateSchema проверяет значение по схеме OpenAPI: поддерживаются $ref, type, nullable, enum,
+// required, properties, additionalProperties и items. Свойства, которых нет в схеме, считаются ошибкой.
+func validateSchema(spec, schema map[string]interface{}, value interface{}, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved := resolveRef(spec, ref)
+		if resolved == nil {
+			return []string{at + ": ссылка не найдена " + ref}
+		}
+		return validateSchema(spec, resolved, value, at)
+	}
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable || schema["type"] == nil {
+			return nil
+		}
+		return []string{at + ": null не допускается"}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			found = found || item == value
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: значение %v не входит в enum", at, value)}
+		}
+	}
+
+	var errs []string
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{at + ": ожидается объект"}
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: нет обязательного поля %s", at, name))
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, item := range object {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				property = additional
+			}
+			if property == nil {
+				errs = append(errs, fmt.Sprintf("%s: поле %s не описано", at, name))
+				continue
+			}
+			errs = append(errs, validateSchema(spec, property, item, at+"."+name)...)
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return []string{at + ": ожидается массив"}
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range array {
+			errs = append(errs, validateSchema(spec, items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			errs = append(errs, at+": ожидается строка")
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			errs = append(errs, at+": ожидается целое число")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			errs = append(errs, at+": ожидается число")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, at+": ожидается логическое значение")
+		}
+	}
+	return errs
+}
+
+func TestValidateSchema(t *testing.T) {
+	spec := buildOpenAPISpec(apiOperations)
+	data, err := json.Marshal(spec)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &spec))
+	ref := map[string]interface{}{"$ref": "#/components/schemas/IdResponse"}
+
+	testTable := []struct {
+		name   string
+		body   string
+		errors int
+	}{
+		{name: "OK", body: `{"id":1}`},
+		{name: "Нет обязательного поля", body: `{}`, errors: 1},
+		{name: "Неверный тип", body: `{"id":"1"}`, errors: 1},
+		{name: "Лишнее поле", body: `{"id":1,"token":"token"}`, errors: 1},
+		{name: "Не объект", body: `[1]`, errors: 1},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			var body interface{}
+			assert.NoError(t, json.Unmarshal([]byte(test.body), &body))
+			assert.Len(t, validateSchema(spec, ref, body, "$"), test.errors)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_createPaymentRequest(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockPaymentRequests, userId int, input domain.PaymentRequestInput)
+	createdAt := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.PaymentRequestInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"username":"boris","amount":40,"message":"за обед"}`,
+			input:     domain.PaymentRequestInput{PayerUsername: "boris", Amount: 40, Message: "за обед"},
+			mockBehavior: func(s *mock_usecase.MockPaymentRequests, userId int, input domain.PaymentRequestInput) {
+				s.EXPECT().CreatePaymentRequest(userId, input).Return(domain.PaymentRequest{
+					Id: 5, RequesterId: userId, RequesterUsername: "anna", PayerId: 2, PayerUsername: "boris", Amount: 40,
+					Message: "за обед", Status: domain.PaymentRequestPending, CreatedAt: createdAt, ExpiresAt: expiresAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":5, "requester":"anna", "payer":"boris", "amount":40, "message":"за обед", "status":"pending",
+				"created_at":"2025-03-12T12:00:00Z", "expires_at":"2025-03-15T12:00:00Z"}`,
+		},
+		{
+			name:      "Плательщик не найден",
+			inputBody: `{"username":"ghost","amount":40}`,
+			input:     domain.PaymentRequestInput{PayerUsername: "ghost", Amount: 40},
+			mockBehavior: func(s *mock_usecase.MockPaymentRequests, userId int, input domain.PaymentRequestInput) {
+				s.EXPECT().CreatePaymentRequest(userId, input).Return(domain.PaymentRequest{}, domain.ErrUserNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + domain.ErrUserNotFound.Error() + `"}`,
+		},
+		{
+			name:                 "Нулевая сумма",
+			inputBody:            `{"username":"boris","amount":0}`,
+			mockBehavior:         func(s *mock_usecase.MockPaymentRequests, userId int, input domain.PaymentRequestInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'PaymentRequestInput.Amount' Error:Field validation for 'Amount' failed on the 'required' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockPaymentRequests(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{PaymentRequests: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/paymentRequests", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.CreatePaymentRequest(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/paymentRequests", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getPaymentRequests(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockPaymentRequests, userId int)
+
+	testTable := []struct {
+		name                 string
+		query                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			query: "?direction=in&status=pending",
+			mockBehavior: func(s *mock_usecase.MockPaymentRequests, userId int) {
+				s.EXPECT().GetPaymentRequests(userId, domain.PaymentRequestFilter{Direction: domain.DirectionIn, Status: domain.PaymentRequestPending}).
+					Return([]domain.PaymentRequest{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[]`,
+		},
+		{
+			name:                 "Неизвестный статус",
+			query:                "?status=paid",
+			mockBehavior:         func(s *mock_usecase.MockPaymentRequests, userId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"неизвестный статус запроса на перевод"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockPaymentRequests(c)
+			testCase.mockBehavior(repo, 1)
+
+			usecases := &usecase.Usecase{PaymentRequests: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.GET("/api/paymentRequests", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.GetPaymentRequests(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/paymentRequests"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_acceptPaymentRequest(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockPaymentRequests, userId int)
+	createdAt := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+	resolvedAt := createdAt.Add(time.Hour)
+
+	testTable := []struct {
+		name                 string
+		id                   string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			id:   "5",
+			mockBehavior: func(s *mock_usecase.MockPaymentRequests, userId int) {
+				s.EXPECT().AcceptPaymentRequest(userId, 5).Return(domain.PaymentRequest{
+					Id: 5, RequesterUsername: "anna", PayerUsername: "boris", Amount: 40, Status: domain.PaymentRequestAccepted,
+					TransactionId: intPointer(9), CreatedAt: createdAt, ExpiresAt: expiresAt, ResolvedAt: &resolvedAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":5, "requester":"anna", "payer":"boris", "amount":40, "status":"accepted", "transaction_id":9,
+				"created_at":"2025-03-12T12:00:00Z", "expires_at":"2025-03-15T12:00:00Z", "resolved_at":"2025-03-12T13:00:00Z"}`,
+		},
+		{
+			name: "Запрос истек",
+			id:   "5",
+			mockBehavior: func(s *mock_usecase.MockPaymentRequests, userId int) {
+				s.EXPECT().AcceptPaymentRequest(userId, 5).Return(domain.PaymentRequest{}, domain.ErrPaymentRequestExpired)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"` + domain.ErrPaymentRequestExpired.Error() + `"}`,
+		},
+		{
+			name: "Недостаточно монет",
+			id:   "5",
+			mockBehavior: func(s *mock_usecase.MockPaymentRequests, userId int) {
+				s.EXPECT().AcceptPaymentRequest(userId, 5).Return(domain.PaymentRequest{}, domain.ErrInsufficientCoins)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"` + domain.ErrInsufficientCoins.Error() + `"}`,
+		},
+		{
+			name:                 "Некорректный id",
+			id:                   "abc",
+			mockBehavior:         func(s *mock_usecase.MockPaymentRequests, userId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Некорректный id запроса на перевод"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockPaymentRequests(c)
+			testCase.mockBehavior(repo, 2)
+
+			usecases := &usecase.Usecase{PaymentRequests: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/paymentRequests/:id/accept", func(c *gin.Context) {
+				c.Set("userId", 2)
+				handler.AcceptPaymentRequest(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/paymentRequests/"+testCase.id+"/accept", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreatePaymentRequest(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на создание запроса на перевод")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.PaymentRequestInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и плательщик %s", userId, input.PayerUsername)
+	request, err := h.Usecases.PaymentRequests.CreatePaymentRequest(userId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на создание запроса на перевод")
+
+	c.JSON(http.StatusOK, request)
+}
+
+func (h *Handler) GetPaymentRequests(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на список запросов на перевод")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filter, err := parsePaymentRequestFilter(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	requests, err := h.Usecases.PaymentRequests.GetPaymentRequests(userId, filter)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос списка запросов на перевод")
+
+	c.JSON(http.StatusOK, requests)
+}
+
+func (h *Handler) AcceptPaymentRequest(c *gin.Context) {
+	h.resolvePaymentRequest(c, h.Usecases.PaymentRequests.AcceptPaymentRequest)
+}
+
+func (h *Handler) DeclinePaymentRequest(c *gin.Context) {
+	h.resolvePaymentRequest(c, h.Usecases.PaymentRequests.DeclinePaymentRequest)
+}
+
+func (h *Handler) CancelPaymentRequest(c *gin.Context) {
+	h.resolvePaymentRequest(c, h.Usecases.PaymentRequests.CancelPaymentRequest)
+}
+
+func (h *Handler) resolvePaymentRequest(c *gin.Context, resolve func(userId, requestId int) (domain.PaymentRequest, error)) {
+	logger.Log.Info().Msg("Получили запрос на обработку запроса на перевод")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	requestId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id запроса на перевод")
+		return
+	}
+	request, err := resolve(userId, requestId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Запрос на перевод %v переведен в статус %s", request.Id, request.Status)
+
+	c.JSON(http.StatusOK, request)
+}
+
+func parsePaymentRequestFilter(c *gin.Context) (domain.PaymentRequestFilter, error) {
+	var filter domain.PaymentRequestFilter
+	switch direction := c.Query("direction"); direction {
+	case "", domain.DirectionIn, domain.DirectionOut:
+		filter.Direction = direction
+	default:
+		return filter, errors.New("направление должно быть in или out")
+	}
+	switch status := c.Query("status"); status {
+	case "", domain.PaymentRequestPending, domain.PaymentRequestAccepted, domain.PaymentRequestDeclined,
+		domain.PaymentRequestCancelled, domain.PaymentRequestExpired:
+		filter.Status = status
+	default:
+		return filter, errors.New("неизвестный статус запроса на перевод")
+	}
+	return filter, nil
+}
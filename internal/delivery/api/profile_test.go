@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_changePassword(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockAuthorization, userId int, input domain.PasswordChangeInput)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.PasswordChangeInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"old_password":"qwerty","new_password":"secret"}`,
+			input:     domain.PasswordChangeInput{OldPassword: "qwerty", NewPassword: "secret"},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, userId int, input domain.PasswordChangeInput) {
+				s.EXPECT().ChangePassword(userId, input).Return("token", nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"token"}`,
+		},
+		{
+			name:      "Неверный текущий пароль",
+			inputBody: `{"old_password":"wrong","new_password":"secret"}`,
+			input:     domain.PasswordChangeInput{OldPassword: "wrong", NewPassword: "secret"},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, userId int, input domain.PasswordChangeInput) {
+				s.EXPECT().ChangePassword(userId, input).Return("", domain.ErrWrongPassword)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"` + domain.ErrWrongPassword.Error() + `"}`,
+		},
+		{
+			name:                 "Нет нового пароля",
+			inputBody:            `{"old_password":"qwerty"}`,
+			mockBehavior:         func(s *mock_usecase.MockAuthorization, userId int, input domain.PasswordChangeInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'PasswordChangeInput.NewPassword' Error:Field validation for 'NewPassword' failed on the 'required' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockAuthorization(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Authorization: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.PUT("/api/profile/password", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.ChangePassword(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/profile/password", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_changeUsername(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockAuthorization, userId int, input domain.UsernameChangeInput)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.UsernameChangeInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"username":"boris"}`,
+			input:     domain.UsernameChangeInput{UserName: "boris"},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, userId int, input domain.UsernameChangeInput) {
+				s.EXPECT().ChangeUsername(userId, input).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"username":"boris"}`,
+		},
+		{
+			name:      "Имя занято",
+			inputBody: `{"username":"boris"}`,
+			input:     domain.UsernameChangeInput{UserName: "boris"},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, userId int, input domain.UsernameChangeInput) {
+				s.EXPECT().ChangeUsername(userId, input).Return(domain.ErrUsernameTaken)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"` + domain.ErrUsernameTaken.Error() + `"}`,
+		},
+		{
+			name:      "Зарезервированное имя",
+			inputBody: `{"username":"deleted_7"}`,
+			input:     domain.UsernameChangeInput{UserName: "deleted_7"},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, userId int, input domain.UsernameChangeInput) {
+				s.EXPECT().ChangeUsername(userId, input).Return(domain.ErrUsernameReserved)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"` + domain.ErrUsernameReserved.Error() + `"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockAuthorization(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Authorization: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.PUT("/api/profile/username", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.ChangeUsername(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/profile/username", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_deleteAccount(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockAuthorization, userId int, input domain.AccountDeleteInput)
+	issuedAt := time.Date(2025, 3, 24, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.AccountDeleteInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"password":"qwerty"}`,
+			input:     domain.AccountDeleteInput{Password: "qwerty", TokenIssuedAt: issuedAt},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, userId int, input domain.AccountDeleteInput) {
+				s.EXPECT().DeleteAccount(userId, input).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:      "Есть переводы с удержанием",
+			inputBody: `{"password":"qwerty"}`,
+			input:     domain.AccountDeleteInput{Password: "qwerty", TokenIssuedAt: issuedAt},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, userId int, input domain.AccountDeleteInput) {
+				s.EXPECT().DeleteAccount(userId, input).Return(domain.ErrAccountHasEscrows)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"` + domain.ErrAccountHasEscrows.Error() + `"}`,
+		},
+		{
+			// пользователь без пароля подтверждает удаление недавним входом, время входа передается из токена
+			name:      "Без пароля - нужен повторный вход",
+			inputBody: `{}`,
+			input:     domain.AccountDeleteInput{TokenIssuedAt: issuedAt},
+			mockBehavior: func(s *mock_usecase.MockAuthorization, userId int, input domain.AccountDeleteInput) {
+				s.EXPECT().DeleteAccount(userId, input).Return(domain.ErrReauthRequired)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"` + domain.ErrReauthRequired.Error() + `"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockAuthorization(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Authorization: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.DELETE("/api/profile", func(c *gin.Context) {
+				c.Set("userId", 1)
+				c.Set("tokenIssuedAt", issuedAt)
+				handler.DeleteAccount(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/api/profile", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
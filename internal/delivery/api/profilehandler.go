@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) ChangePassword(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на смену пароля")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.PasswordChangeInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	token, err := h.Usecases.Authorization.ChangePassword(userId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Пароль изменен, выданные ранее токены отозваны")
+	if !h.auditAction(c, domain.AuditPasswordChange, domain.AuditTargetUser, strconv.Itoa(userId), nil, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"token": token,
+	})
+}
+
+func (h *Handler) ChangeUsername(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на смену имени пользователя")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.UsernameChangeInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и новое имя %s", userId, input.UserName)
+	if err = h.Usecases.Authorization.ChangeUsername(userId, input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Имя пользователя изменено")
+	if !h.auditAction(c, domain.AuditUsernameChange, domain.AuditTargetUser, strconv.Itoa(userId), nil, map[string]interface{}{
+		"username": strings.TrimSpace(input.UserName),
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"username": strings.TrimSpace(input.UserName),
+	})
+}
+
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на удаление аккаунта")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.AccountDeleteInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.TokenIssuedAt = c.GetTime(tokenIssuedCtx)
+	if err = h.Usecases.Authorization.DeleteAccount(userId, input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Аккаунт пользователя %v удален", userId)
+	if !h.auditAction(c, domain.AuditAccountDelete, domain.AuditTargetUser, strconv.Itoa(userId), nil, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": userId,
+	})
+}
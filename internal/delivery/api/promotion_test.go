@@ -0,0 +1,91 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_createPromotion(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockPromotions, input domain.PromotionInput)
+	startsAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC)
+	code := "SPRING"
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.PromotionInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			inputBody: `{"name":"Весна","kind":"percent","value":15,"category":"clothes","code":"spring",
+				"usage_limit":100,"starts_at":"2025-03-01T00:00:00Z","ends_at":"2025-03-08T00:00:00Z"}`,
+			input: domain.PromotionInput{
+				Name: "Весна", Kind: domain.DiscountPercent, Value: 15, Category: "clothes", Code: "spring",
+				UsageLimit: intPointer(100), StartsAt: startsAt, EndsAt: endsAt,
+			},
+			mockBehavior: func(s *mock_usecase.MockPromotions, input domain.PromotionInput) {
+				category := "clothes"
+				s.EXPECT().CreatePromotion(input).Return(domain.Promotion{
+					Id: 1, Name: "Весна", Kind: domain.DiscountPercent, Value: 15, Category: &category, Code: &code,
+					UsageLimit: intPointer(100), StartsAt: startsAt, EndsAt: endsAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":1, "name":"Весна", "kind":"percent", "value":15, "category":"clothes", "code":"SPRING",
+				"usage_limit":100, "used_count":0, "starts_at":"2025-03-01T00:00:00Z", "ends_at":"2025-03-08T00:00:00Z"}`,
+		},
+		{
+			name:                 "Неизвестный вид скидки",
+			inputBody:            `{"name":"Весна","kind":"gift","value":15,"starts_at":"2025-03-01T00:00:00Z","ends_at":"2025-03-08T00:00:00Z"}`,
+			mockBehavior:         func(s *mock_usecase.MockPromotions, input domain.PromotionInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'PromotionInput.Kind' Error:Field validation for 'Kind' failed on the 'oneof' tag"}`,
+		},
+		{
+			name:      "Промокод уже существует",
+			inputBody: `{"name":"Весна","kind":"fixed","value":5,"code":"SPRING","starts_at":"2025-03-01T00:00:00Z","ends_at":"2025-03-08T00:00:00Z"}`,
+			input: domain.PromotionInput{
+				Name: "Весна", Kind: domain.DiscountFixed, Value: 5, Code: "SPRING", StartsAt: startsAt, EndsAt: endsAt,
+			},
+			mockBehavior: func(s *mock_usecase.MockPromotions, input domain.PromotionInput) {
+				s.EXPECT().CreatePromotion(input).Return(domain.Promotion{}, domain.ErrPromotionExists)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"промокод уже существует"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockPromotions(c)
+			testCase.mockBehavior(repo, testCase.input)
+
+			usecases := &usecase.Usecase{Promotions: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/promotions", handler.CreatePromotion)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/promotions", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreatePromotion(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на создание акции")
+	var input domain.PromotionInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитана акция %s", input.Name)
+	promotion, err := h.Usecases.Promotions.CreatePromotion(input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на создание акции")
+	if !h.auditAction(c, domain.AuditPromotionCreate, domain.AuditTargetPromotion, strconv.Itoa(promotion.Id), nil, promotion) {
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+func (h *Handler) GetPromotions(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на список акций")
+	promotions, err := h.Usecases.Promotions.GetPromotions()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос списка акций")
+
+	c.JSON(http.StatusOK, promotions)
+}
+
+func (h *Handler) EndPromotion(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на завершение акции")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id акции")
+		return
+	}
+	promotion, err := h.Usecases.Promotions.EndPromotion(id)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на завершение акции")
+	if !h.auditAction(c, domain.AuditPromotionEnd, domain.AuditTargetPromotion, strconv.Itoa(id), nil, promotion) {
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
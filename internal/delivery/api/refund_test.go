@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_requestRefund(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockRefunds, userId int)
+
+	testTable := []struct {
+		name                 string
+		purchaseId           string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:       "OK",
+			purchaseId: "3",
+			inputBody:  `{"reason":"не подошел размер"}`,
+			mockBehavior: func(s *mock_usecase.MockRefunds, userId int) {
+				s.EXPECT().RequestRefund(userId, 3, "не подошел размер").Return(1, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:       "Без причины",
+			purchaseId: "3",
+			mockBehavior: func(s *mock_usecase.MockRefunds, userId int) {
+				s.EXPECT().RequestRefund(userId, 3, "").Return(1, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:                 "Некорректный id",
+			purchaseId:           "abc",
+			mockBehavior:         func(s *mock_usecase.MockRefunds, userId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Некорректный id покупки"}`,
+		},
+		{
+			name:       "Срок возврата истек",
+			purchaseId: "3",
+			mockBehavior: func(s *mock_usecase.MockRefunds, userId int) {
+				s.EXPECT().RequestRefund(userId, 3, "").Return(0, domain.ErrRefundWindowExpired)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"срок отмены покупки истек"}`,
+		},
+		{
+			name:       "Чужая покупка",
+			purchaseId: "4",
+			mockBehavior: func(s *mock_usecase.MockRefunds, userId int) {
+				s.EXPECT().RequestRefund(userId, 4, "").Return(0, domain.ErrPurchaseNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"покупка не найдена"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockRefunds(c)
+			testCase.mockBehavior(repo, 1)
+
+			usecases := &usecase.Usecase{Refunds: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/purchases/:id/refund", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.RequestRefund(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/purchases/"+testCase.purchaseId+"/refund",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_approveRefund(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockRefunds, adminId int)
+	resolvedAt := time.Date(2025, 2, 6, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		refundId             string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:     "OK",
+			refundId: "1",
+			mockBehavior: func(s *mock_usecase.MockRefunds, adminId int) {
+				s.EXPECT().ApproveRefund(adminId, 1).Return(domain.Refund{
+					Id: 1, PurchaseId: 3, UserId: 2, Amount: 40, Status: domain.RefundApproved,
+					TransactionId: intPointer(7), ResolvedBy: intPointer(adminId),
+					CreatedAt: resolvedAt.Add(-time.Hour), ResolvedAt: &resolvedAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":1, "purchase_id":3, "user_id":2, "amount":40, "status":"approved",
+				"transaction_id":7, "resolved_by":9, "created_at":"2025-02-06T11:00:00Z", "resolved_at":"2025-02-06T12:00:00Z"}`,
+		},
+		{
+			name:     "Заявка уже рассмотрена",
+			refundId: "1",
+			mockBehavior: func(s *mock_usecase.MockRefunds, adminId int) {
+				s.EXPECT().ApproveRefund(adminId, 1).Return(domain.Refund{}, domain.ErrRefundResolved)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"заявка на возврат уже рассмотрена"}`,
+		},
+		{
+			name:     "Заявка не найдена",
+			refundId: "2",
+			mockBehavior: func(s *mock_usecase.MockRefunds, adminId int) {
+				s.EXPECT().ApproveRefund(adminId, 2).Return(domain.Refund{}, domain.ErrRefundNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"заявка на возврат не найдена"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockRefunds(c)
+			testCase.mockBehavior(repo, 9)
+
+			usecases := &usecase.Usecase{Refunds: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/refunds/:id/approve", func(c *gin.Context) {
+				c.Set("userId", 9)
+				handler.ApproveRefund(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/refunds/"+testCase.refundId+"/approve", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getRefunds(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockRefunds)
+
+	testTable := []struct {
+		name                 string
+		query                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			query: "?status=requested",
+			mockBehavior: func(s *mock_usecase.MockRefunds) {
+				s.EXPECT().GetRefunds(domain.RefundRequested).Return([]domain.Refund{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[]`,
+		},
+		{
+			name:                 "Некорректный статус",
+			query:                "?status=unknown",
+			mockBehavior:         func(s *mock_usecase.MockRefunds) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Некорректный статус заявки"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockRefunds(c)
+			testCase.mockBehavior(repo)
+
+			usecases := &usecase.Usecase{Refunds: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.GET("/api/admin/refunds", handler.GetRefunds)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/admin/refunds"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) RequestRefund(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на возврат покупки")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	purchaseId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id покупки")
+		return
+	}
+	// причина необязательна, поэтому пустое тело запроса допускается
+	var input domain.RefundInput
+	if c.Request.ContentLength != 0 {
+		if err = c.ShouldBindJSON(&input); err != nil {
+			logger.Log.Error().Err(err).Msg("")
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и id покупки %v", userId, purchaseId)
+	id, err := h.Usecases.Refunds.RequestRefund(userId, purchaseId, input.Reason)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос возврата покупки")
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": id,
+	})
+}
+
+func (h *Handler) GetRefunds(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на список заявок на возврат")
+	status := c.Query("status")
+	switch status {
+	case "", domain.RefundRequested, domain.RefundApproved, domain.RefundRejected:
+	default:
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный статус заявки")
+		return
+	}
+	refunds, err := h.Usecases.Refunds.GetRefunds(status)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос списка заявок на возврат")
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+func (h *Handler) ApproveRefund(c *gin.Context) {
+	h.resolveRefund(c, h.Usecases.Refunds.ApproveRefund)
+}
+
+func (h *Handler) RejectRefund(c *gin.Context) {
+	h.resolveRefund(c, h.Usecases.Refunds.RejectRefund)
+}
+
+func (h *Handler) resolveRefund(c *gin.Context, resolve func(adminId, refundId int) (domain.Refund, error)) {
+	logger.Log.Info().Msg("Получили запрос на рассмотрение заявки на возврат")
+	adminId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	refundId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id заявки")
+		return
+	}
+	refund, err := resolve(adminId, refundId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Заявка на возврат %v переведена в статус %s", refund.Id, refund.Status)
+
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *Handler) ForceRefund(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на принудительный возврат покупки")
+	adminId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	purchaseId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id покупки")
+		return
+	}
+	// причина необязательна, поэтому пустое тело запроса допускается
+	var input domain.RefundInput
+	if c.Request.ContentLength != 0 {
+		if err = c.ShouldBindJSON(&input); err != nil {
+			logger.Log.Error().Err(err).Msg("")
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	refund, err := h.Usecases.Refunds.ForceRefund(adminId, purchaseId, input.Reason)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на принудительный возврат покупки")
+
+	c.JSON(http.StatusOK, refund)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiPrefix = "/api/v1"
+	// legacyApiPrefix - пути без версии. Они остаются псевдонимами маршрутов v1 и помечаются устаревшими.
+	legacyApiPrefix = "/api"
+)
+
+// legacyRoutes - маршруты v1, которые в /api называются иначе: "метод путь v1" -> "метод прежний путь".
+// Пути указаны относительно префикса версии, остальные маршруты в /api совпадают с v1.
+var legacyRoutes = map[string]string{
+	"POST /coins/send":                   "POST /sendCoin",
+	"POST /coins/send/batch":             "POST /sendCoin/batch",
+	"POST /shop/:item/buy":               "PUT /buy/:item",
+	"POST /payment-requests":             "POST /paymentRequests",
+	"GET /payment-requests":              "GET /paymentRequests",
+	"POST /payment-requests/:id/accept":  "POST /paymentRequests/:id/accept",
+	"POST /payment-requests/:id/decline": "POST /paymentRequests/:id/decline",
+	"POST /payment-requests/:id/cancel":  "POST /paymentRequests/:id/cancel",
+}
+
+// successorRoutes - обратная таблица legacyRoutes.
+var successorRoutes = func() map[string]string {
+	routes := make(map[string]string, len(legacyRoutes))
+	for successor, legacy := range legacyRoutes {
+		routes[legacy] = successor
+	}
+	return routes
+}()
+
+// routeGroup регистрирует маршруты v1. В группе /api маршруты из legacyRoutes регистрируются
+// под прежними методом и путем, чтобы оба набора маршрутов описывались одним registerRoutes.
+type routeGroup struct {
+	*gin.RouterGroup
+	legacy bool
+	// prefix - путь группы относительно префикса версии.
+	prefix string
+}
+
+func (g routeGroup) Group(relativePath string, handlers ...gin.HandlerFunc) routeGroup {
+	return routeGroup{
+		RouterGroup: g.RouterGroup.Group(relativePath, handlers...),
+		legacy:      g.legacy,
+		prefix:      joinRoutePath(g.prefix, relativePath),
+	}
+}
+
+func (g routeGroup) Handle(method, relativePath string, handlers ...gin.HandlerFunc) {
+	if g.legacy {
+		if legacy, ok := legacyRoutes[method+" "+joinRoutePath(g.prefix, relativePath)]; ok {
+			method, relativePath, _ = strings.Cut(legacy, " ")
+			relativePath = strings.TrimPrefix(relativePath, g.prefix)
+		}
+	}
+	g.RouterGroup.Handle(method, relativePath, handlers...)
+}
+
+func (g routeGroup) GET(relativePath string, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodGet, relativePath, handlers...)
+}
+
+func (g routeGroup) POST(relativePath string, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPost, relativePath, handlers...)
+}
+
+func (g routeGroup) PUT(relativePath string, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodPut, relativePath, handlers...)
+}
+
+func (g routeGroup) DELETE(relativePath string, handlers ...gin.HandlerFunc) {
+	g.Handle(http.MethodDelete, relativePath, handlers...)
+}
+
+func joinRoutePath(prefix, relativePath string) string {
+	if relativePath == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(relativePath, "/")
+}
+
+// routeKey возвращает маршрут запроса в виде "метод путь v1". Маршрут из /api приводится к маршруту v1,
+// поэтому таблицы маршрутов в middleware описывают только v1.
+func routeKey(c *gin.Context) string {
+	fullPath := c.FullPath()
+	relativePath, legacy := strings.CutPrefix(fullPath, legacyApiPrefix)
+	if !legacy || fullPath == apiPrefix || strings.HasPrefix(fullPath, apiPrefix+"/") {
+		return c.Request.Method + " " + fullPath
+	}
+	key := c.Request.Method + " " + relativePath
+	if successor, ok := successorRoutes[key]; ok {
+		key = successor
+	}
+	method, relativePath, _ := strings.Cut(key, " ")
+	return method + " " + apiPrefix + relativePath
+}
+
+// deprecated помечает ответы на запросы к путям без версии заголовком Deprecation и указывает
+// в заголовке Link соответствующий путь v1.
+func (h *Handler) deprecated(c *gin.Context) {
+	c.Header("Deprecation", "true")
+	if c.FullPath() == "" {
+		return
+	}
+	_, successor, _ := strings.Cut(routeKey(c), " ")
+	for _, param := range c.Params {
+		successor = strings.Replace(successor, ":"+param.Key, url.PathEscape(param.Value), 1)
+	}
+	c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+}
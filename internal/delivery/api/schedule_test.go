@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_createSchedule(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockSchedules, userId int, input domain.ScheduleInput)
+	nextRunAt := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.ScheduleInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"destination_username":"intern","amount":50,"category":"gift","cron":"0 9 * * 1"}`,
+			input:     domain.ScheduleInput{DestinationUsername: "intern", Amount: 50, Category: domain.TransferGift, Cron: "0 9 * * 1"},
+			mockBehavior: func(s *mock_usecase.MockSchedules, userId int, input domain.ScheduleInput) {
+				s.EXPECT().CreateSchedule(userId, input).Return(domain.ScheduledTransfer{
+					Id: 3, UserId: userId, DestinationId: 2, DestinationUsername: "intern", Amount: 50, Category: domain.TransferGift,
+					Cron: "0 9 * * 1", NextRunAt: nextRunAt, Status: domain.ScheduleActive, CreatedAt: createdAt,
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":3, "destination_username":"intern", "amount":50, "category":"gift", "cron":"0 9 * * 1",
+				"next_run_at":"2025-03-10T09:00:00Z", "status":"active", "created_at":"2025-03-09T12:00:00Z"}`,
+		},
+		{
+			name:      "Некорректное расписание",
+			inputBody: `{"destination_username":"intern","amount":50,"interval":"5m"}`,
+			input:     domain.ScheduleInput{DestinationUsername: "intern", Amount: 50, Interval: "5m"},
+			mockBehavior: func(s *mock_usecase.MockSchedules, userId int, input domain.ScheduleInput) {
+				s.EXPECT().CreateSchedule(userId, input).
+					Return(domain.ScheduledTransfer{}, fmt.Errorf("%w: интервал должен быть не меньше 1h0m0s", domain.ErrInvalidSchedule))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"некорректное расписание перевода: интервал должен быть не меньше 1h0m0s"}`,
+		},
+		{
+			name:                 "Нулевая сумма",
+			inputBody:            `{"destination_username":"intern","amount":0,"cron":"@weekly"}`,
+			mockBehavior:         func(s *mock_usecase.MockSchedules, userId int, input domain.ScheduleInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'ScheduleInput.Amount' Error:Field validation for 'Amount' failed on the 'required' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockSchedules(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Schedules: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/schedules", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.CreateSchedule(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/schedules", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_cancelSchedule(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockSchedules, userId, scheduleId int)
+
+	testTable := []struct {
+		name                 string
+		scheduleId           string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:       "OK",
+			scheduleId: "3",
+			mockBehavior: func(s *mock_usecase.MockSchedules, userId, scheduleId int) {
+				s.EXPECT().CancelSchedule(userId, scheduleId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":3}`,
+		},
+		{
+			name:       "Расписание не найдено",
+			scheduleId: "3",
+			mockBehavior: func(s *mock_usecase.MockSchedules, userId, scheduleId int) {
+				s.EXPECT().CancelSchedule(userId, scheduleId).Return(domain.ErrScheduleNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"расписание перевода не найдено"}`,
+		},
+		{
+			name:       "Уже отменено",
+			scheduleId: "3",
+			mockBehavior: func(s *mock_usecase.MockSchedules, userId, scheduleId int) {
+				s.EXPECT().CancelSchedule(userId, scheduleId).Return(domain.ErrScheduleCancelled)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"расписание перевода уже отменено"}`,
+		},
+		{
+			name:                 "Некорректный id",
+			scheduleId:           "abc",
+			mockBehavior:         func(s *mock_usecase.MockSchedules, userId, scheduleId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Некорректный id расписания"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockSchedules(c)
+			testCase.mockBehavior(repo, 1, 3)
+
+			usecases := &usecase.Usecase{Schedules: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.DELETE("/api/schedules/:id", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.CancelSchedule(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/api/schedules/"+testCase.scheduleId, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getScheduleRuns(t *testing.T) {
+	runAt := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_usecase.NewMockSchedules(c)
+	repo.EXPECT().GetScheduleRuns(1, 3).Return([]domain.ScheduleRun{
+		{Id: 2, ScheduleId: 3, RunAt: runAt.AddDate(0, 0, 7), Error: domain.ErrInsufficientCoins.Error()},
+		{Id: 1, ScheduleId: 3, RunAt: runAt, TransactionId: intPointer(10)},
+	}, nil)
+
+	usecases := &usecase.Usecase{Schedules: repo}
+	handler := Handler{usecases}
+	r := gin.New()
+	r.GET("/api/schedules/:id/runs", func(c *gin.Context) {
+		c.Set("userId", 1)
+		handler.GetScheduleRuns(c)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/schedules/3/runs", nil)
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `[
+		{"id":2, "schedule_id":3, "run_at":"2025-03-17T09:00:00Z", "error":"количество отправки выше количества текущих монет"},
+		{"id":1, "schedule_id":3, "run_at":"2025-03-10T09:00:00Z", "transaction_id":10}
+	]`, w.Body.String())
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreateSchedule(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на создание расписания перевода")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.ScheduleInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и получатель %s", userId, input.DestinationUsername)
+	schedule, err := h.Usecases.Schedules.CreateSchedule(userId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на создание расписания перевода")
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *Handler) GetSchedules(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на список расписаний переводов")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	schedules, err := h.Usecases.Schedules.GetSchedules(userId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос списка расписаний переводов")
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (h *Handler) CancelSchedule(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на отмену расписания перевода")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id расписания")
+		return
+	}
+	if err = h.Usecases.Schedules.CancelSchedule(userId, id); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на отмену расписания перевода")
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": id,
+	})
+}
+
+func (h *Handler) GetScheduleRuns(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на историю выполнения расписания")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id расписания")
+		return
+	}
+	runs, err := h.Usecases.Schedules.GetScheduleRuns(userId, id)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос истории выполнения расписания")
+
+	c.JSON(http.StatusOK, runs)
+}
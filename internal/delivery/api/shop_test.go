@@ -5,9 +5,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/usecase"
@@ -43,6 +46,58 @@ func TestHandler_sendCoin(t *testing.T) {
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"id":1}`,
 		},
+		{
+			name:        "Сообщение и категория",
+			inputBody:   `{"destination_username":"name", "amount":10, "message":"спасибо за помощь", "category":"thanks"}`,
+			inputUserId: 1,
+			inputTransactions: domain.Transactions{
+				DestinationUsername: "name",
+				Amount:              10,
+				Message:             "спасибо за помощь",
+				Category:            domain.TransferThanks,
+			},
+			mockBehavior: func(s *mock_usecase.MockShop, userid int, transactions domain.Transactions) {
+				s.EXPECT().SendCoin(userid, transactions).Return(1, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:        "Слишком частые переводы",
+			inputBody:   `{"destination_username":"name", "amount":10}`,
+			inputUserId: 1,
+			inputTransactions: domain.Transactions{
+				DestinationUsername: "name",
+				Amount:              10,
+			},
+			mockBehavior: func(s *mock_usecase.MockShop, userid int, transactions domain.Transactions) {
+				s.EXPECT().SendCoin(userid, transactions).Return(0, domain.ErrTransferCooldown)
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: `{"message":"переводы этому получателю слишком частые, попробуйте позже"}`,
+		},
+		{
+			name:        "Превышен дневной лимит",
+			inputBody:   `{"destination_username":"name", "amount":10}`,
+			inputUserId: 1,
+			inputTransactions: domain.Transactions{
+				DestinationUsername: "name",
+				Amount:              10,
+			},
+			mockBehavior: func(s *mock_usecase.MockShop, userid int, transactions domain.Transactions) {
+				s.EXPECT().SendCoin(userid, transactions).Return(0, domain.ErrDailySendLimit)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"превышен дневной лимит отправки монет"}`,
+		},
+		{
+			name:                 "Неизвестная категория",
+			inputBody:            `{"destination_username":"name", "amount":10, "category":"salary"}`,
+			inputUserId:          1,
+			mockBehavior:         func(s *mock_usecase.MockShop, userid int, transactions domain.Transactions) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'Transactions.Category' Error:Field validation for 'Category' failed on the 'oneof' tag"}`,
+		},
 		{
 			name:        "Ошибка во время выполнения запроса",
 			inputBody:   `{"destination_username":"name", "amount":10}`,
@@ -118,6 +173,7 @@ func TestHandler_buyItem(t *testing.T) {
 	testTable := []struct {
 		name                 string
 		inputName            string
+		inputQuery           string
 		inputUserId          int
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
@@ -128,7 +184,7 @@ func TestHandler_buyItem(t *testing.T) {
 			inputName:   "cup",
 			inputUserId: 1,
 			mockBehavior: func(s *mock_usecase.MockShop, name string, userId int) {
-				s.EXPECT().BuyItem(userId, name).Return(1, nil)
+				s.EXPECT().BuyItem(userId, name, domain.BuyOptions{}).Return(1, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"id":1}`,
@@ -138,11 +194,52 @@ func TestHandler_buyItem(t *testing.T) {
 			inputName:   "cup",
 			inputUserId: 1,
 			mockBehavior: func(s *mock_usecase.MockShop, name string, userId int) {
-				s.EXPECT().BuyItem(userId, name).Return(0, errors.New("Internal Server Error"))
+				s.EXPECT().BuyItem(userId, name, domain.BuyOptions{}).Return(0, errors.New("Internal Server Error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"Internal Server Error"}`,
 		},
+		{
+			name:        "Товар закончился",
+			inputName:   "pink-hoody",
+			inputUserId: 1,
+			mockBehavior: func(s *mock_usecase.MockShop, name string, userId int) {
+				s.EXPECT().BuyItem(userId, name, domain.BuyOptions{}).Return(0, domain.ErrOutOfStock)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"товар закончился"}`,
+		},
+		{
+			name:        "Превышен лимит на пользователя",
+			inputName:   "pink-hoody",
+			inputUserId: 1,
+			mockBehavior: func(s *mock_usecase.MockShop, name string, userId int) {
+				s.EXPECT().BuyItem(userId, name, domain.BuyOptions{}).Return(0, domain.ErrPurchaseLimit)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"превышен лимит покупок товара на пользователя"}`,
+		},
+		{
+			name:        "Выбран вариант",
+			inputName:   "hoody",
+			inputQuery:  "?size=M&colour=black",
+			inputUserId: 1,
+			mockBehavior: func(s *mock_usecase.MockShop, name string, userId int) {
+				s.EXPECT().BuyItem(userId, name, domain.BuyOptions{VariantSelector: domain.VariantSelector{Size: "M", Colour: "black"}}).Return(2, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":2}`,
+		},
+		{
+			name:        "Вариант не выбран",
+			inputName:   "hoody",
+			inputUserId: 1,
+			mockBehavior: func(s *mock_usecase.MockShop, name string, userId int) {
+				s.EXPECT().BuyItem(userId, name, domain.BuyOptions{}).Return(0, domain.ErrVariantRequired)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"необходимо выбрать вариант товара"}`,
+		},
 	}
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
@@ -161,7 +258,7 @@ func TestHandler_buyItem(t *testing.T) {
 			})
 
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("PUT", "/api/buy/"+testCase.inputName, nil)
+			req := httptest.NewRequest("PUT", "/api/buy/"+testCase.inputName+testCase.inputQuery, nil)
 
 			r.ServeHTTP(w, req)
 			assert.Equal(t, w.Code, testCase.expectedStatusCode)
@@ -173,6 +270,102 @@ func TestHandler_buyItem(t *testing.T) {
 		})
 	}
 }
+func TestHandler_createOrder(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockShop, userId int, input domain.OrderInput)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		inputUserId          int
+		input                domain.OrderInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "OK",
+			inputBody:   `{"items":[{"item":"cup","quantity":2},{"item":"pen","quantity":1}]}`,
+			inputUserId: 1,
+			input: domain.OrderInput{Items: []domain.OrderLine{
+				{ItemName: "cup", Quantity: 2},
+				{ItemName: "pen", Quantity: 1},
+			}},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, input domain.OrderInput) {
+				s.EXPECT().CreateOrder(userId, input).Return(5, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":5}`,
+		},
+		{
+			name:                 "Пустая корзина",
+			inputBody:            `{"items":[]}`,
+			inputUserId:          1,
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, input domain.OrderInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'OrderInput.Items' Error:Field validation for 'Items' failed on the 'min' tag"}`,
+		},
+		{
+			name:                 "Неположительное количество",
+			inputBody:            `{"items":[{"item":"cup","quantity":0}]}`,
+			inputUserId:          1,
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, input domain.OrderInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'OrderInput.Items[0].Quantity' Error:Field validation for 'Quantity' failed on the 'required' tag"}`,
+		},
+		{
+			name:        "Недостаточно монет",
+			inputBody:   `{"items":[{"item":"pink-hoody","quantity":3}]}`,
+			inputUserId: 1,
+			input: domain.OrderInput{Items: []domain.OrderLine{
+				{ItemName: "pink-hoody", Quantity: 3},
+			}},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, input domain.OrderInput) {
+				s.EXPECT().CreateOrder(userId, input).Return(0, domain.ErrNotEnoughCoins)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"цена товара выше количества текущих монет"}`,
+		},
+		{
+			name:        "Товар закончился",
+			inputBody:   `{"items":[{"item":"cup","quantity":300}]}`,
+			inputUserId: 1,
+			input: domain.OrderInput{Items: []domain.OrderLine{
+				{ItemName: "cup", Quantity: 300},
+			}},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, input domain.OrderInput) {
+				s.EXPECT().CreateOrder(userId, input).Return(0, fmt.Errorf("%w: cup", domain.ErrOutOfStock))
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"товар закончился: cup"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockShop(c)
+			testCase.mockBehavior(repo, testCase.inputUserId, testCase.input)
+
+			usecases := &usecase.Usecase{Shop: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/orders", func(c *gin.Context) {
+				c.Set("userId", testCase.inputUserId)
+				handler.CreateOrder(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/orders",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
 func TestHandler_infoSummary(t *testing.T) {
 	type mockBehavior func(s *mock_usecase.MockShop, userId int)
 
@@ -274,6 +467,361 @@ func TestHandler_infoSummary(t *testing.T) {
 	}
 }
 
+func TestHandler_getPurchases(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockShop, userId int, filter domain.PurchaseFilter)
+
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 11, 0, 0, 0, 0, time.UTC)
+	cursor := domain.Cursor{Time: time.Date(2025, 2, 5, 12, 0, 0, 0, time.UTC), Id: 7}
+	packedAt := time.Date(2025, 2, 6, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		query                string
+		inputUserId          int
+		inputFilter          domain.PurchaseFilter
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "OK",
+			query:       "?limit=1",
+			inputUserId: 1,
+			inputFilter: domain.PurchaseFilter{Limit: 1},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, filter domain.PurchaseFilter) {
+				s.EXPECT().GetPurchases(userId, filter).Return(&domain.PurchaseHistory{
+					Purchases: []domain.Purchase{
+						{Id: 7, OrderId: intPointer(3), ItemName: "cup", Price: 18, BasePrice: 20, PromotionId: intPointer(1), Quantity: 2, PurchaseDate: cursor.Time, Status: domain.PurchasePacked, PackedAt: &packedAt},
+					},
+					NextCursor: cursor.Encode(),
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{
+				"purchases": [{"id":7, "order_id":3, "item_name":"cup", "price":18, "base_price":20, "promotion_id":1, "quantity":2, "purchase_date":"2025-02-05T12:00:00Z", "status":"packed", "packed_at":"2025-02-06T09:00:00Z"}],
+				"next_cursor": "` + cursor.Encode() + `"
+			}`,
+		},
+		{
+			name:        "Фильтр по датам и курсор",
+			query:       "?from=2025-02-01&to=2025-02-10&cursor=" + cursor.Encode(),
+			inputUserId: 1,
+			inputFilter: domain.PurchaseFilter{From: &from, To: &to, Cursor: &cursor},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, filter domain.PurchaseFilter) {
+				s.EXPECT().GetPurchases(userId, filter).Return(&domain.PurchaseHistory{
+					Purchases: []domain.Purchase{},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"purchases": []}`,
+		},
+		{
+			name:                 "Некорректный лимит",
+			query:                "?limit=abc",
+			inputUserId:          1,
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, filter domain.PurchaseFilter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"лимит должен быть положительным числом"}`,
+		},
+		{
+			name:                 "Некорректная дата",
+			query:                "?from=01.02.2025",
+			inputUserId:          1,
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, filter domain.PurchaseFilter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"дата должна быть в формате YYYY-MM-DD или RFC3339"}`,
+		},
+		{
+			name:                 "Некорректный курсор",
+			query:                "?cursor=!!!",
+			inputUserId:          1,
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, filter domain.PurchaseFilter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"некорректный курсор"}`,
+		},
+		{
+			name:        "Ошибка выполнения запроса",
+			inputUserId: 1,
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, filter domain.PurchaseFilter) {
+				s.EXPECT().GetPurchases(userId, filter).Return(nil, errors.New("Internal Server Error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"Internal Server Error"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockShop(c)
+			testCase.mockBehavior(repo, testCase.inputUserId, testCase.inputFilter)
+
+			usecases := &usecase.Usecase{Shop: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.GET("/api/purchases", func(c *gin.Context) {
+				c.Set("userId", testCase.inputUserId)
+				handler.GetPurchases(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/purchases"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getTransactions(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockShop, userId int, filter domain.TransactionFilter)
+
+	transferTime := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	cursor := domain.Cursor{Time: transferTime, Id: 4}
+	sourceUsername := "user"
+
+	testTable := []struct {
+		name                 string
+		query                string
+		inputUserId          int
+		inputFilter          domain.TransactionFilter
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "OK",
+			query:       "?limit=1&direction=in&category=thanks",
+			inputUserId: 2,
+			inputFilter: domain.TransactionFilter{Limit: 1, Direction: domain.DirectionIn, Category: domain.TransferThanks},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, filter domain.TransactionFilter) {
+				s.EXPECT().GetTransactions(userId, filter).Return(&domain.TransactionHistory{
+					Transactions: []domain.Transactions{
+						{
+							Id: 4, Source: intPointer(1), SourceUsername: &sourceUsername, Destination: intPointer(2),
+							DestinationUsername: "friend", Amount: 10, Kind: domain.TransactionTransfer,
+							Message: "спасибо", Category: domain.TransferThanks, Timestamp: &transferTime,
+						},
+					},
+					NextCursor: cursor.Encode(),
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{
+				"transactions": [{"source":1, "source_username":"user", "destination":2, "destination_username":"friend", "amount":10,
+					"kind":"transfer", "message":"спасибо", "category":"thanks", "timestamp":"2025-03-08T12:00:00Z"}],
+				"next_cursor": "` + cursor.Encode() + `"
+			}`,
+		},
+		{
+			name:        "Курсор",
+			query:       "?cursor=" + cursor.Encode(),
+			inputUserId: 2,
+			inputFilter: domain.TransactionFilter{Cursor: &cursor},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, filter domain.TransactionFilter) {
+				s.EXPECT().GetTransactions(userId, filter).Return(&domain.TransactionHistory{
+					Transactions: []domain.Transactions{},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"transactions": []}`,
+		},
+		{
+			name:                 "Некорректное направление",
+			query:                "?direction=both",
+			inputUserId:          2,
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, filter domain.TransactionFilter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"направление должно быть in или out"}`,
+		},
+		{
+			name:                 "Неизвестная категория",
+			query:                "?category=salary",
+			inputUserId:          2,
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, filter domain.TransactionFilter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"неизвестная категория перевода"}`,
+		},
+		{
+			name:        "Ошибка выполнения запроса",
+			inputUserId: 2,
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, filter domain.TransactionFilter) {
+				s.EXPECT().GetTransactions(userId, filter).Return(nil, errors.New("Internal Server Error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"Internal Server Error"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockShop(c)
+			testCase.mockBehavior(repo, testCase.inputUserId, testCase.inputFilter)
+
+			usecases := &usecase.Usecase{Shop: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.GET("/api/transactions", func(c *gin.Context) {
+				c.Set("userId", testCase.inputUserId)
+				handler.GetTransactions(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/transactions"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
 func intPointer(s int) *int {
 	return &s
 }
+
+func TestHandler_sendCoinBatch(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockShop, userId int, input domain.BatchTransferInput)
+
+	testTable := []struct {
+		name                 string
+		contentType          string
+		body                 func() *bytes.Buffer
+		input                domain.BatchTransferInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "JSON",
+			contentType: "application/json",
+			body: func() *bytes.Buffer {
+				return bytes.NewBufferString(`{"mode":"best_effort","transfers":[
+					{"destination_username":"anna","amount":30},
+					{"destination_username":"ghost","amount":50}]}`)
+			},
+			input: domain.BatchTransferInput{Mode: domain.BatchBestEffort, Transfers: []domain.Transactions{
+				{DestinationUsername: "anna", Amount: 30},
+				{DestinationUsername: "ghost", Amount: 50},
+			}},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, input domain.BatchTransferInput) {
+				s.EXPECT().SendCoinBatch(userId, input).Return(domain.BatchTransferResult{
+					Mode: domain.BatchBestEffort, Succeeded: 1, Failed: 1,
+					Results: []domain.BatchTransferItem{
+						{DestinationUsername: "anna", Amount: 30, TransactionId: intPointer(7)},
+						{DestinationUsername: "ghost", Amount: 50, Error: domain.ErrUserNotFound.Error()},
+					},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"mode":"best_effort", "succeeded":1, "failed":1, "results":[
+				{"destination_username":"anna", "amount":30, "transaction_id":7},
+				{"destination_username":"ghost", "amount":50, "error":"получатель не найден"}]}`,
+		},
+		{
+			name: "CSV",
+			body: func() *bytes.Buffer {
+				return batchCSVForm(t, "atomic", "destination_username,amount,message,category\nanna,30\nboris, 50,премия,gift\n")
+			},
+			input: domain.BatchTransferInput{Mode: domain.BatchAtomic, Transfers: []domain.Transactions{
+				{DestinationUsername: "anna", Amount: 30},
+				{DestinationUsername: "boris", Amount: 50, Message: "премия", Category: domain.TransferGift},
+			}},
+			mockBehavior: func(s *mock_usecase.MockShop, userId int, input domain.BatchTransferInput) {
+				s.EXPECT().SendCoinBatch(userId, input).Return(domain.BatchTransferResult{
+					Mode: domain.BatchAtomic, Succeeded: 2,
+					Results: []domain.BatchTransferItem{
+						{DestinationUsername: "anna", Amount: 30, TransactionId: intPointer(7)},
+						{DestinationUsername: "boris", Amount: 50, TransactionId: intPointer(8)},
+					},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"mode":"atomic", "succeeded":2, "failed":0, "results":[
+				{"destination_username":"anna", "amount":30, "transaction_id":7},
+				{"destination_username":"boris", "amount":50, "transaction_id":8}]}`,
+		},
+		{
+			name: "Некорректная сумма в CSV",
+			body: func() *bytes.Buffer {
+				return batchCSVForm(t, "", "anna,тридцать\n")
+			},
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, input domain.BatchTransferInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"строка 1: сумма должна быть числом"}`,
+		},
+		{
+			name:        "Пустой пакет",
+			contentType: "application/json",
+			body: func() *bytes.Buffer {
+				return bytes.NewBufferString(`{"transfers":[]}`)
+			},
+			mockBehavior:         func(s *mock_usecase.MockShop, userId int, input domain.BatchTransferInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'BatchTransferInput.Transfers' Error:Field validation for 'Transfers' failed on the 'min' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockShop(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Shop: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/sendCoin/batch", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.SendCoinBatch(c)
+			})
+
+			w := httptest.NewRecorder()
+			body := testCase.body()
+			req := httptest.NewRequest("POST", "/api/sendCoin/batch", body)
+			contentType := testCase.contentType
+			if contentType == "" {
+				contentType = batchFormContentType
+			}
+			req.Header.Set("Content-Type", contentType)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+const batchFormBoundary = "batch-boundary"
+
+var batchFormContentType = "multipart/form-data; boundary=" + batchFormBoundary
+
+func batchCSVForm(t *testing.T, mode, content string) *bytes.Buffer {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writer.SetBoundary(batchFormBoundary); err != nil {
+		t.Fatal(err)
+	}
+	if mode != "" {
+		if err := writer.WriteField("mode", mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file, err := writer.CreateFormFile("file", "payroll.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = file.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
@@ -2,12 +2,17 @@ package api
 
 import (
 	"database/sql"
+	"encoding/csv"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/bllooop/coinshop/internal/domain"
 	logger "github.com/bllooop/coinshop/pkg/logging"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 func (h *Handler) SendCoin(c *gin.Context) {
@@ -40,7 +45,7 @@ func (h *Handler) SendCoin(c *gin.Context) {
 	id, err := h.Usecases.Shop.SendCoin(userId, input)
 	if err != nil {
 		logger.Log.Error().Err(err).Msg("")
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, errorStatus(err), err.Error())
 		return
 	}
 	logger.Log.Info().Msg("Получен ответ на отправку монет")
@@ -50,13 +55,83 @@ func (h *Handler) SendCoin(c *gin.Context) {
 	})
 }
 
-func (h *Handler) BuyItem(c *gin.Context) {
-	logger.Log.Info().Msg("Получили запрос на покупку товара")
-	if c.Request.Method != "PUT" {
-		logger.Log.Error().Msg("Требуется запрос PUT")
-		newErrorResponse(c, http.StatusBadRequest, "Требуется запрос PUT")
+func (h *Handler) SendCoinBatch(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на пакетную отправку монет")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.BatchTransferInput
+	if c.ContentType() == gin.MIMEMultipartPOSTForm {
+		err = bindBatchCSV(c, &input)
+	} else {
+		err = c.ShouldBindJSON(&input)
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и количество переводов %v", userId, len(input.Transfers))
+	result, err := h.Usecases.Shop.SendCoinBatch(userId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
 		return
 	}
+	logger.Log.Info().Msg("Получен ответ на пакетную отправку монет")
+
+	c.JSON(http.StatusOK, result)
+}
+
+// bindBatchCSV читает переводы из файла file формы со столбцами
+// destination_username, amount и необязательными message, category. Строка заголовка допускается.
+func bindBatchCSV(c *gin.Context, input *domain.BatchTransferInput) error {
+	input.Mode = c.PostForm("mode")
+	header, err := c.FormFile("file")
+	if err != nil {
+		return errors.New("необходимо загрузить CSV-файл в поле file")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("некорректный CSV-файл: %w", err)
+	}
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && record[0] == "destination_username" {
+			continue
+		}
+		if len(record) < 2 || len(record) > 4 {
+			return fmt.Errorf("строка %d: ожидается от 2 до 4 столбцов", i+1)
+		}
+		amount, err := strconv.Atoi(record[1])
+		if err != nil {
+			return fmt.Errorf("строка %d: сумма должна быть числом", i+1)
+		}
+		transfer := domain.Transactions{DestinationUsername: record[0], Amount: amount}
+		if len(record) > 2 {
+			transfer.Message = record[2]
+		}
+		if len(record) > 3 {
+			transfer.Category = record[3]
+		}
+		input.Transfers = append(input.Transfers, transfer)
+	}
+	return binding.Validator.ValidateStruct(input)
+}
+
+func (h *Handler) BuyItem(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на покупку товара")
 	userId, err := getUserId(c)
 	if err != nil {
 		logger.Log.Error().Err(err).Msg("")
@@ -64,12 +139,18 @@ func (h *Handler) BuyItem(c *gin.Context) {
 		return
 	}
 	name := c.Param("item")
+	var options domain.BuyOptions
+	if err = c.ShouldBindQuery(&options); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	logger.Log.Debug().Msgf("Успешно прочитаны название предмета %s и id  %v", name, userId)
 
-	id, err := h.Usecases.Shop.BuyItem(userId, name)
+	id, err := h.Usecases.Shop.BuyItem(userId, name, options)
 	if err != nil {
 		logger.Log.Error().Err(err).Msg("")
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newErrorResponse(c, errorStatus(err), err.Error())
 		return
 	}
 	logger.Log.Info().Msg("Получен ответ на покупку товара")
@@ -79,6 +160,34 @@ func (h *Handler) BuyItem(c *gin.Context) {
 	})
 }
 
+func (h *Handler) CreateOrder(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на оформление заказа")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.OrderInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и количество позиций %v", userId, len(input.Items))
+	id, err := h.Usecases.Shop.CreateOrder(userId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на оформление заказа")
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": id,
+	})
+}
+
 func (h *Handler) GetInfo(c *gin.Context) {
 	logger.Log.Info().Msg("Получили запрос на информацию о пользователе")
 	if c.Request.Method != http.MethodGet {
@@ -108,3 +217,137 @@ func (h *Handler) GetInfo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, lists)
 }
+
+func (h *Handler) GetPurchases(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на историю покупок")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filter, err := parsePurchaseFilter(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и лимит %v", userId, filter.Limit)
+	history, err := h.Usecases.Shop.GetPurchases(userId, filter)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос истории покупок")
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *Handler) GetTransactions(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на историю переводов")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитаны id %v и лимит %v", userId, filter.Limit)
+	history, err := h.Usecases.Shop.GetTransactions(userId, filter)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на запрос истории переводов")
+
+	c.JSON(http.StatusOK, history)
+}
+
+func parseTransactionFilter(c *gin.Context) (domain.TransactionFilter, error) {
+	var filter domain.TransactionFilter
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return filter, errors.New("лимит должен быть положительным числом")
+		}
+		filter.Limit = value
+	}
+	switch direction := c.Query("direction"); direction {
+	case "", domain.DirectionIn, domain.DirectionOut:
+		filter.Direction = direction
+	default:
+		return filter, errors.New("направление должно быть in или out")
+	}
+	switch category := c.Query("category"); category {
+	case "", domain.TransferThanks, domain.TransferBet, domain.TransferRefund, domain.TransferGift:
+		filter.Category = category
+	default:
+		return filter, errors.New("неизвестная категория перевода")
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		value, err := domain.DecodeCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.Cursor = value
+	}
+	return filter, nil
+}
+
+func parsePurchaseFilter(c *gin.Context) (domain.PurchaseFilter, error) {
+	var filter domain.PurchaseFilter
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return filter, errors.New("лимит должен быть положительным числом")
+		}
+		filter.Limit = value
+	}
+	if from := c.Query("from"); from != "" {
+		value, _, err := parseDateParam(from)
+		if err != nil {
+			return filter, err
+		}
+		filter.From = &value
+	}
+	if to := c.Query("to"); to != "" {
+		value, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return filter, err
+		}
+		// дата без времени включает весь указанный день
+		if dateOnly {
+			value = value.AddDate(0, 0, 1)
+		}
+		filter.To = &value
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, errors.New("дата начала должна быть раньше даты окончания")
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		value, err := domain.DecodeCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.Cursor = value
+	}
+	return filter, nil
+}
+
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, false, errors.New("дата должна быть в формате YYYY-MM-DD или RFC3339")
+	}
+	return t, true, nil
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_grantCoins(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockTreasury, adminId int, input domain.CoinAdjustmentInput)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.CoinAdjustmentInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"username":"intern","amount":200,"reason":"победа в хакатоне"}`,
+			input:     domain.CoinAdjustmentInput{Username: "intern", Amount: 200, Reason: "победа в хакатоне"},
+			mockBehavior: func(s *mock_usecase.MockTreasury, adminId int, input domain.CoinAdjustmentInput) {
+				s.EXPECT().GrantCoins(adminId, input).Return(domain.CoinAdjustment{TransactionId: 7, BalanceBefore: 50, BalanceAfter: 250}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"transaction_id":7}`,
+		},
+		{
+			name:      "Пользователь не найден",
+			inputBody: `{"username":"ghost","amount":200,"reason":"бонус"}`,
+			input:     domain.CoinAdjustmentInput{Username: "ghost", Amount: 200, Reason: "бонус"},
+			mockBehavior: func(s *mock_usecase.MockTreasury, adminId int, input domain.CoinAdjustmentInput) {
+				s.EXPECT().GrantCoins(adminId, input).Return(domain.CoinAdjustment{}, domain.ErrUserNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + domain.ErrUserNotFound.Error() + `"}`,
+		},
+		{
+			name:                 "Без причины",
+			inputBody:            `{"username":"intern","amount":200}`,
+			mockBehavior:         func(s *mock_usecase.MockTreasury, adminId int, input domain.CoinAdjustmentInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'CoinAdjustmentInput.Reason' Error:Field validation for 'Reason' failed on the 'required' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockTreasury(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Treasury: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/coins/grant", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.GrantCoins(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/coins/grant", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_clawbackCoins(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockTreasury, adminId int, input domain.CoinAdjustmentInput)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                domain.CoinAdjustmentInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"username":"intern","amount":100,"reason":"ошибочное начисление"}`,
+			input:     domain.CoinAdjustmentInput{Username: "intern", Amount: 100, Reason: "ошибочное начисление"},
+			mockBehavior: func(s *mock_usecase.MockTreasury, adminId int, input domain.CoinAdjustmentInput) {
+				s.EXPECT().ClawbackCoins(adminId, input).Return(domain.CoinAdjustment{TransactionId: 8, BalanceBefore: 150, BalanceAfter: 50}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"transaction_id":8}`,
+		},
+		{
+			name:      "Недостаточно монет",
+			inputBody: `{"username":"intern","amount":5000,"reason":"ошибочное начисление"}`,
+			input:     domain.CoinAdjustmentInput{Username: "intern", Amount: 5000, Reason: "ошибочное начисление"},
+			mockBehavior: func(s *mock_usecase.MockTreasury, adminId int, input domain.CoinAdjustmentInput) {
+				s.EXPECT().ClawbackCoins(adminId, input).Return(domain.CoinAdjustment{}, domain.ErrInsufficientCoins)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"` + domain.ErrInsufficientCoins.Error() + `"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockTreasury(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Treasury: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/coins/clawback", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.ClawbackCoins(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/admin/coins/clawback", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
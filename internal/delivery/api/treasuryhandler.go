@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GrantCoins(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на начисление монет из казны")
+	adminId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.CoinAdjustmentInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитано начисление %d монет пользователю %s", input.Amount, input.Username)
+	adjustment, err := h.Usecases.Treasury.GrantCoins(adminId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на начисление монет")
+	if !h.auditAction(c, domain.AuditCoinsGrant, domain.AuditTargetUser, input.Username, map[string]interface{}{
+		"coins": adjustment.BalanceBefore,
+	}, map[string]interface{}{
+		"coins": adjustment.BalanceAfter, "amount": input.Amount, "reason": input.Reason, "transaction_id": adjustment.TransactionId,
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"transaction_id": adjustment.TransactionId,
+	})
+}
+
+func (h *Handler) ClawbackCoins(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на списание монет в казну")
+	adminId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.CoinAdjustmentInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	logger.Log.Debug().Msgf("Успешно прочитано списание %d монет у пользователя %s", input.Amount, input.Username)
+	adjustment, err := h.Usecases.Treasury.ClawbackCoins(adminId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на списание монет")
+	if !h.auditAction(c, domain.AuditCoinsClawback, domain.AuditTargetUser, input.Username, map[string]interface{}{
+		"coins": adjustment.BalanceBefore,
+	}, map[string]interface{}{
+		"coins": adjustment.BalanceAfter, "amount": input.Amount, "reason": input.Reason, "transaction_id": adjustment.TransactionId,
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"transaction_id": adjustment.TransactionId,
+	})
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_signInTwoFactorRequired(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mock_usecase.NewMockAuthorization(c)
+	twoFactor := mock_usecase.NewMockTwoFactor(c)
+	auth.EXPECT().SignUser("admin", "12345", "192.0.2.1").Return(domain.User{Id: 1, TwoFactorEnabled: true}, nil)
+	twoFactor.EXPECT().StartTwoFactorSignIn(1).Return("intermediate.jwt.token", nil)
+
+	// токен доступа и событие входа появляются только после второго шага
+	usecases := &usecase.Usecase{Authorization: auth, TwoFactor: twoFactor}
+	handler := Handler{usecases}
+	r := gin.New()
+	r.POST("/api/auth/sign-in", handler.SignIn)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/auth/sign-in", bytes.NewBufferString(`{"username":"admin","password":"12345"}`))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"two_factor_required":true,"two_factor_token":"intermediate.jwt.token"}`, w.Body.String())
+}
+
+func TestHandler_signInTwoFactor(t *testing.T) {
+	type mockBehavior func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit)
+	locked := &domain.LoginThrottledError{Until: time.Now().Add(15 * time.Minute), Locked: true, Started: true}
+	input := domain.TwoFactorSignInInput{Token: "intermediate.jwt.token", Code: "123456"}
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"two_factor_token":"intermediate.jwt.token","code":"123456"}`,
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit) {
+				tf.EXPECT().CompleteTwoFactorSignIn(input, "192.0.2.1").Return(domain.User{Id: 1, TwoFactorEnabled: true}, nil)
+				a.EXPECT().GenerateToken(1).Return("valid.jwt.token", nil)
+				audit.EXPECT().Record(gomock.Cond(func(e domain.AuditEvent) bool {
+					return e.Action == domain.AuditLogin && e.TargetId == "1"
+				})).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"token":"valid.jwt.token"}`,
+		},
+		{
+			name:      "Неверный код",
+			inputBody: `{"two_factor_token":"intermediate.jwt.token","code":"123456"}`,
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit) {
+				tf.EXPECT().CompleteTwoFactorSignIn(input, "192.0.2.1").Return(domain.User{}, domain.ErrInvalidTwoFactorCode)
+				audit.EXPECT().Record(gomock.Cond(func(e domain.AuditEvent) bool {
+					return e.Action == domain.AuditLoginFailed
+				})).Return(nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"` + domain.ErrInvalidTwoFactorCode.Error() + `"}`,
+		},
+		{
+			name:      "Токен недействителен",
+			inputBody: `{"two_factor_token":"intermediate.jwt.token","code":"123456"}`,
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit) {
+				tf.EXPECT().CompleteTwoFactorSignIn(input, "192.0.2.1").Return(domain.User{}, domain.ErrTokenRevoked)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"` + domain.ErrTokenRevoked.Error() + `"}`,
+		},
+		{
+			name:      "Вход заблокирован",
+			inputBody: `{"two_factor_token":"intermediate.jwt.token","code":"123456"}`,
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit) {
+				tf.EXPECT().CompleteTwoFactorSignIn(input, "192.0.2.1").Return(domain.User{}, locked)
+				audit.EXPECT().Record(gomock.Cond(func(e domain.AuditEvent) bool {
+					return e.Action == domain.AuditLoginLocked
+				})).Return(nil)
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: `{"message":"` + locked.Error() + `"}`,
+		},
+		{
+			name:      "Нет кода",
+			inputBody: `{"two_factor_token":"intermediate.jwt.token"}`,
+			mockBehavior: func(a *mock_usecase.MockAuthorization, tf *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'TwoFactorSignInInput.Code' Error:Field validation for 'Code' failed on the 'required' tag"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_usecase.NewMockAuthorization(c)
+			twoFactor := mock_usecase.NewMockTwoFactor(c)
+			audit := mock_usecase.NewMockAudit(c)
+			testCase.mockBehavior(auth, twoFactor, audit)
+
+			usecases := &usecase.Usecase{Authorization: auth, TwoFactor: twoFactor, Audit: audit}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/auth/sign-in/2fa", handler.SignInTwoFactor)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/auth/sign-in/2fa", bytes.NewBufferString(testCase.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+			if testCase.expectedStatusCode == 429 {
+				assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestHandler_confirmTwoFactor(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockTwoFactor, userId int)
+
+	testTable := []struct {
+		name                 string
+		userId               int
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(s *mock_usecase.MockTwoFactor, userId int) {
+				s.EXPECT().ConfirmTwoFactor(userId, "123456").Return([]string{"ABCDE-FGHIJ", "KLMNO-PQRST"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"recovery_codes":["ABCDE-FGHIJ","KLMNO-PQRST"]}`,
+		},
+		{
+			name:      "Не настроена",
+			userId:    2,
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(s *mock_usecase.MockTwoFactor, userId int) {
+				s.EXPECT().ConfirmTwoFactor(userId, "123456").Return(nil, domain.ErrTwoFactorNotEnrolled)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"` + domain.ErrTwoFactorNotEnrolled.Error() + `"}`,
+		},
+		{
+			name:      "Неверный код",
+			userId:    3,
+			inputBody: `{"code":"000000"}`,
+			mockBehavior: func(s *mock_usecase.MockTwoFactor, userId int) {
+				s.EXPECT().ConfirmTwoFactor(userId, "000000").Return(nil, domain.ErrInvalidTwoFactorCode)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"` + domain.ErrInvalidTwoFactorCode.Error() + `"}`,
+		},
+		{
+			name:      "Ошибка сервиса",
+			userId:    4,
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(s *mock_usecase.MockTwoFactor, userId int) {
+				s.EXPECT().ConfirmTwoFactor(userId, "123456").Return(nil, errors.New("ошибка базы данных"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"ошибка базы данных"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			twoFactor := mock_usecase.NewMockTwoFactor(c)
+			testCase.mockBehavior(twoFactor, testCase.userId)
+
+			usecases := &usecase.Usecase{TwoFactor: twoFactor, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/profile/2fa/confirm", func(c *gin.Context) {
+				c.Set(userCtx, testCase.userId)
+			}, handler.ConfirmTwoFactor)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/profile/2fa/confirm", bytes.NewBufferString(testCase.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_regenerateRecoveryCodes(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit)
+	locked := &domain.LoginThrottledError{Until: time.Now().Add(15 * time.Minute), Locked: true, Started: true}
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"code":"123456"}`,
+			mockBehavior: func(s *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit) {
+				s.EXPECT().RegenerateRecoveryCodes(1, "123456", "192.0.2.1").Return([]string{"ABCDE-FGHIJ"}, nil)
+				audit.EXPECT().Record(gomock.Cond(func(e domain.AuditEvent) bool {
+					return e.Action == domain.AuditRecoveryCodes
+				})).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"recovery_codes":["ABCDE-FGHIJ"]}`,
+		},
+		{
+			name:      "Неверный код",
+			inputBody: `{"code":"000000"}`,
+			mockBehavior: func(s *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit) {
+				s.EXPECT().RegenerateRecoveryCodes(1, "000000", "192.0.2.1").Return(nil, domain.ErrInvalidTwoFactorCode)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"` + domain.ErrInvalidTwoFactorCode.Error() + `"}`,
+		},
+		{
+			name:      "Вход заблокирован",
+			inputBody: `{"code":"000000"}`,
+			mockBehavior: func(s *mock_usecase.MockTwoFactor, audit *mock_usecase.MockAudit) {
+				s.EXPECT().RegenerateRecoveryCodes(1, "000000", "192.0.2.1").Return(nil, locked)
+				audit.EXPECT().Record(gomock.Cond(func(e domain.AuditEvent) bool {
+					return e.Action == domain.AuditLoginLocked
+				})).Return(nil)
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: `{"message":"` + locked.Error() + `"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			twoFactor := mock_usecase.NewMockTwoFactor(c)
+			audit := mock_usecase.NewMockAudit(c)
+			testCase.mockBehavior(twoFactor, audit)
+
+			usecases := &usecase.Usecase{TwoFactor: twoFactor, Audit: audit}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/profile/2fa/recovery-codes", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.RegenerateRecoveryCodes)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/profile/2fa/recovery-codes", bytes.NewBufferString(testCase.inputBody))
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+			if testCase.expectedStatusCode == 429 {
+				assert.NotEmpty(t, w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на настройку двухфакторной аутентификации")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	enrollment, err := h.Usecases.TwoFactor.EnrollTwoFactor(userId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Секрет второго фактора создан, ожидается подтверждение")
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на подтверждение двухфакторной аутентификации")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.TwoFactorCodeInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	codes, err := h.Usecases.TwoFactor.ConfirmTwoFactor(userId, input.Code)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Двухфакторная аутентификация включена для пользователя %v", userId)
+	if !h.auditAction(c, domain.AuditTwoFactorEnable, domain.AuditTargetUser, strconv.Itoa(userId), nil, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на выпуск новых кодов восстановления")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.TwoFactorCodeInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	codes, err := h.Usecases.TwoFactor.RegenerateRecoveryCodes(userId, input.Code, c.ClientIP())
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		var throttled *domain.LoginThrottledError
+		if errors.As(err, &throttled) {
+			retryAfter := int(math.Ceil(time.Until(throttled.Until).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+			if throttled.Started {
+				h.audit(c, domain.AuditLoginLocked, domain.AuditTargetLogin, "", nil, map[string]interface{}{
+					"locked_until": throttled.Until,
+				})
+			}
+		}
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Коды восстановления заменены")
+	if !h.auditAction(c, domain.AuditRecoveryCodes, domain.AuditTargetUser, strconv.Itoa(userId), nil, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на отключение двухфакторной аутентификации")
+	userId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var input domain.TwoFactorDisableInput
+	if err = c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err = h.Usecases.TwoFactor.DisableTwoFactor(userId, input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Двухфакторная аутентификация отключена для пользователя %v", userId)
+	if !h.auditAction(c, domain.AuditTwoFactorDisable, domain.AuditTargetUser, strconv.Itoa(userId), nil, nil) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"id": userId,
+	})
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/usecase"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_searchUsers(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockUsers, filter domain.UserFilter)
+
+	testTable := []struct {
+		name                 string
+		query                string
+		filter               domain.UserFilter
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			query:  "?q=ann&status=active&limit=10&offset=20",
+			filter: domain.UserFilter{Query: "ann", Status: domain.UserStatusActive, Limit: 10, Offset: 20},
+			mockBehavior: func(s *mock_usecase.MockUsers, filter domain.UserFilter) {
+				s.EXPECT().SearchUsers(filter).Return([]domain.UserInfo{
+					{Id: 3, UserName: "anna", Role: domain.RoleUser, Coins: 150},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"id":3,"username":"anna","role":"user","coins":150,"password_reset_required":false}]`,
+		},
+		{
+			name:                 "Неизвестный статус",
+			query:                "?status=frozen",
+			mockBehavior:         func(s *mock_usecase.MockUsers, filter domain.UserFilter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"статус должен быть active, banned или deleted"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockUsers(c)
+			testCase.mockBehavior(repo, testCase.filter)
+
+			usecases := &usecase.Usecase{Users: repo}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.GET("/api/admin/users", handler.SearchUsers)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/admin/users"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_banUser(t *testing.T) {
+	type mockBehavior func(s *mock_usecase.MockUsers, adminId int, input domain.BanInput)
+
+	bannedAt := time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC)
+	testTable := []struct {
+		name                 string
+		path                 string
+		inputBody            string
+		input                domain.BanInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			path:      "/api/admin/users/3/ban",
+			inputBody: `{"reason":"спам"}`,
+			input:     domain.BanInput{Reason: "спам"},
+			mockBehavior: func(s *mock_usecase.MockUsers, adminId int, input domain.BanInput) {
+				s.EXPECT().GetUser(3).Return(domain.UserInfo{Id: 3, UserName: "anna", Role: domain.RoleUser}, nil)
+				s.EXPECT().BanUser(adminId, 3, input).Return(domain.UserInfo{
+					Id: 3, UserName: "anna", Role: domain.RoleUser, BannedAt: &bannedAt, BanReason: "спам",
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":3,"username":"anna","role":"user","coins":0,"banned_at":"2025-03-17T12:00:00Z",
+				"ban_reason":"спам","password_reset_required":false}`,
+		},
+		{
+			name:      "Блокировка самого себя",
+			path:      "/api/admin/users/1/ban",
+			inputBody: `{"reason":"тест"}`,
+			input:     domain.BanInput{Reason: "тест"},
+			mockBehavior: func(s *mock_usecase.MockUsers, adminId int, input domain.BanInput) {
+				s.EXPECT().GetUser(1).Return(domain.UserInfo{Id: 1, UserName: "admin", Role: domain.RoleAdmin}, nil)
+				s.EXPECT().BanUser(adminId, 1, input).Return(domain.UserInfo{}, domain.ErrSelfBan)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"` + domain.ErrSelfBan.Error() + `"}`,
+		},
+		{
+			name:      "Пользователь не найден",
+			path:      "/api/admin/users/99/ban",
+			inputBody: `{"reason":"спам"}`,
+			input:     domain.BanInput{Reason: "спам"},
+			mockBehavior: func(s *mock_usecase.MockUsers, adminId int, input domain.BanInput) {
+				s.EXPECT().GetUser(99).Return(domain.UserInfo{}, domain.ErrUserNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + domain.ErrUserNotFound.Error() + `"}`,
+		},
+		{
+			name:                 "Без причины",
+			path:                 "/api/admin/users/3/ban",
+			inputBody:            `{}`,
+			mockBehavior:         func(s *mock_usecase.MockUsers, adminId int, input domain.BanInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"Key: 'BanInput.Reason' Error:Field validation for 'Reason' failed on the 'required' tag"}`,
+		},
+	}
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_usecase.NewMockUsers(c)
+			testCase.mockBehavior(repo, 1, testCase.input)
+
+			usecases := &usecase.Usecase{Users: repo, Audit: newAuditMock(c)}
+			handler := Handler{usecases}
+			r := gin.New()
+			r.POST("/api/admin/users/:id/ban", func(c *gin.Context) {
+				c.Set("userId", 1)
+				handler.BanUser(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", testCase.path, bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.JSONEq(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_resetUserPassword(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_usecase.NewMockUsers(c)
+	repo.EXPECT().ResetPassword(3).Return("tmp-password", nil)
+
+	usecases := &usecase.Usecase{Users: repo, Audit: newAuditMock(c)}
+	handler := Handler{usecases}
+	r := gin.New()
+	r.POST("/api/admin/users/:id/password-reset", handler.ResetUserPassword)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/admin/users/3/password-reset", nil)
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"temporary_password":"tmp-password"}`, w.Body.String())
+}
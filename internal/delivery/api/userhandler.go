@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) SearchUsers(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на поиск пользователей")
+	filter, err := parseUserFilter(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	users, err := h.Usecases.Users.SearchUsers(filter)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на поиск пользователей")
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *Handler) GetUserSummaryByAdmin(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на сводку по пользователю от администратора")
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id пользователя")
+		return
+	}
+	summary, err := h.Usecases.Users.GetUserSummary(userId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msg("Получен ответ на сводку по пользователю")
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *Handler) BanUser(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на блокировку пользователя")
+	adminId, err := getUserId(c)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id пользователя")
+		return
+	}
+	var input domain.BanInput
+	if err := c.BindJSON(&input); err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	before, err := h.Usecases.Users.GetUser(userId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	user, err := h.Usecases.Users.BanUser(adminId, userId, input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Пользователь %v заблокирован администратором %v", user.Id, adminId)
+	if !h.auditAction(c, domain.AuditUserBan, domain.AuditTargetUser, strconv.Itoa(userId), before, user) {
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handler) UnbanUser(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на разблокировку пользователя")
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id пользователя")
+		return
+	}
+	before, err := h.Usecases.Users.GetUser(userId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	user, err := h.Usecases.Users.UnbanUser(userId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Пользователь %v разблокирован", user.Id)
+	if !h.auditAction(c, domain.AuditUserUnban, domain.AuditTargetUser, strconv.Itoa(userId), before, user) {
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handler) ResetUserPassword(c *gin.Context) {
+	logger.Log.Info().Msg("Получили запрос на сброс пароля пользователя")
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "Некорректный id пользователя")
+		return
+	}
+	password, err := h.Usecases.Users.ResetPassword(userId)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		newErrorResponse(c, errorStatus(err), err.Error())
+		return
+	}
+	logger.Log.Info().Msgf("Пароль пользователя %v сброшен", userId)
+	// временный пароль в журнал не попадает
+	if !h.auditAction(c, domain.AuditUserPasswordReset, domain.AuditTargetUser, strconv.Itoa(userId), nil,
+		map[string]interface{}{"password_reset_required": true}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"temporary_password": password,
+	})
+}
+
+func parseUserFilter(c *gin.Context) (domain.UserFilter, error) {
+	filter := domain.UserFilter{Query: c.Query("q")}
+	switch role := c.Query("role"); role {
+	case "", domain.RoleUser, domain.RoleAdmin, domain.RoleAuditor, domain.RoleService, domain.RoleDeleted:
+		filter.Role = role
+	default:
+		return filter, errors.New("неизвестная роль пользователя")
+	}
+	switch status := c.Query("status"); status {
+	case "", domain.UserStatusActive, domain.UserStatusBanned, domain.UserStatusDeleted:
+		filter.Status = status
+	default:
+		return filter, errors.New("статус должен быть active, banned или deleted")
+	}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			return filter, errors.New("лимит должен быть положительным числом")
+		}
+		filter.Limit = value
+	}
+	if offset := c.Query("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			return filter, errors.New("смещение должно быть неотрицательным числом")
+		}
+		filter.Offset = value
+	}
+	return filter, nil
+}
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// Разрешения ключей API. Ключ дает доступ только к маршрутам, сопоставленным его разрешениям.
+const (
+	ScopeCoinsSend  = "coins:send"
+	ScopeCoinsGrant = "coins:grant"
+	ScopeInfoRead   = "info:read"
+	ScopeShopRead   = "shop:read"
+)
+
+var ApiKeyScopes = []string{ScopeCoinsSend, ScopeCoinsGrant, ScopeInfoRead, ScopeShopRead}
+
+// ApiKeyPrefix начинает каждый ключ, чтобы его можно было узнать в логах и при сканировании репозиториев.
+const ApiKeyPrefix = "cs"
+
+// ServiceAccount - учетная запись интеграции. Запросы с ее ключами выполняются от имени собственного
+// пользователя аккаунта UserId с ролью service и именем svc_<Name>.
+type ServiceAccount struct {
+	Id          int       `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	UserId      int       `json:"user_id" db:"user_id"`
+	UserName    string    `json:"username" db:"username"`
+	CreatedBy   *int      `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type ServiceAccountInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// ApiKey описывает ключ без секрета: после создания ключ узнается только по префиксу.
+type ApiKey struct {
+	Id               int        `json:"id" db:"id"`
+	ServiceAccountId int        `json:"service_account_id" db:"service_account_id"`
+	Prefix           string     `json:"prefix" db:"prefix"`
+	Scopes           []string   `json:"scopes" db:"-"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	LastUsedAt       *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedBy        *int       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+type ApiKeyInput struct {
+	Scopes []string `json:"scopes" binding:"required"`
+	// ExpiresAt - срок действия ключа, по умолчанию задается конфигурацией.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedApiKey содержит секрет ключа, который показывается только один раз.
+type CreatedApiKey struct {
+	Key    string `json:"key"`
+	ApiKey ApiKey `json:"api_key"`
+}
+
+// ApiKeyCredential - данные ключа для проверки запроса.
+type ApiKeyCredential struct {
+	ApiKey
+	KeyHash     string
+	UserId      int
+	UserBanned  bool
+	UserDeleted bool
+}
+
+// ApiKeyIdentity - результат проверки ключа: от чьего имени выполняется запрос и что ему разрешено.
+type ApiKeyIdentity struct {
+	KeyId            int
+	ServiceAccountId int
+	UserId           int
+	Scopes           []string
+}
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AuditGenesisHash - prev_hash первой записи журнала.
+var AuditGenesisHash = strings.Repeat("0", 64)
+
+// Действия, которые записываются в журнал аудита.
+const (
+	AuditCoinsGrant          = "coins.grant"
+	AuditCoinsClawback       = "coins.clawback"
+	AuditUserBan             = "user.ban"
+	AuditUserUnban           = "user.unban"
+	AuditUserPasswordReset   = "user.password_reset"
+	AuditCatalogRestock      = "catalog.restock"
+	AuditCatalogStock        = "catalog.stock_update"
+	AuditCatalogVariant      = "catalog.variant_create"
+	AuditCatalogVariantStock = "catalog.variant_stock_update"
+	AuditPromotionCreate     = "promotion.create"
+	AuditPromotionEnd        = "promotion.end"
+	AuditPasswordChange      = "profile.password_change"
+	AuditUsernameChange      = "profile.username_change"
+	AuditAccountDelete       = "profile.delete"
+	AuditTwoFactorEnable     = "two_factor.enable"
+	AuditTwoFactorDisable    = "two_factor.disable"
+	AuditRecoveryCodes       = "two_factor.recovery_codes"
+	AuditLogin               = "login.success"
+	AuditLoginFailed         = "login.failed"
+	AuditLoginLocked         = "login.locked"
+	AuditLoginUnlock         = "login.unlock"
+	AuditServiceAccount      = "service_account.create"
+	AuditApiKeyCreate        = "api_key.create"
+	AuditApiKeyRevoke        = "api_key.revoke"
+	AuditApiKeyUse           = "api_key.use"
+)
+
+// AuditChained сообщает, входит ли запись с действием action в цепочку хешей. Входы и использование
+// ключей API происходят на каждый запрос и записываются без хеша, чтобы не ждать общую блокировку цепочки.
+func AuditChained(action string) bool {
+	switch action {
+	case AuditLogin, AuditLoginFailed, AuditLoginLocked, AuditApiKeyUse:
+		return false
+	}
+	return true
+}
+
+// Типы объектов, над которыми выполняется действие.
+const (
+	AuditTargetUser           = "user"
+	AuditTargetItem           = "item"
+	AuditTargetVariant        = "variant"
+	AuditTargetPromotion      = "promotion"
+	AuditTargetLogin          = "login"
+	AuditTargetServiceAccount = "service_account"
+	AuditTargetApiKey         = "api_key"
+)
+
+// AuditEvent - событие для записи в журнал. Before и After сериализуются в JSON.
+type AuditEvent struct {
+	Action     string
+	ActorId    *int
+	TargetType string
+	TargetId   string
+	Before     interface{}
+	After      interface{}
+	IP         string
+	RequestId  string
+}
+
+// AuditEntry - запись журнала аудита. Hash вычисляется от содержимого записи и хеша предыдущей,
+// поэтому изменение или удаление любой записи разрывает цепочку. У записей вне цепочки хешей нет.
+type AuditEntry struct {
+	Id         int64           `json:"id" db:"id"`
+	Action     string          `json:"action" db:"action"`
+	ActorId    *int            `json:"actor_id,omitempty" db:"actor_id"`
+	TargetType string          `json:"target_type" db:"target_type"`
+	TargetId   string          `json:"target_id" db:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty" db:"-"`
+	After      json.RawMessage `json:"after,omitempty" db:"-"`
+	IP         string          `json:"ip" db:"ip"`
+	RequestId  string          `json:"request_id" db:"request_id"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	PrevHash   string          `json:"prev_hash,omitempty" db:"-"`
+	Hash       string          `json:"hash,omitempty" db:"-"`
+}
+
+// ComputeHash возвращает SHA-256 от хеша предыдущей записи и содержимого записи в hex.
+func (e AuditEntry) ComputeHash() string {
+	// порядок полей фиксирован структурой, время приводится к UTC, чтобы хеш не зависел от часового пояса
+	payload, _ := json.Marshal(struct {
+		Action     string          `json:"action"`
+		ActorId    *int            `json:"actor_id"`
+		TargetType string          `json:"target_type"`
+		TargetId   string          `json:"target_id"`
+		Before     json.RawMessage `json:"before"`
+		After      json.RawMessage `json:"after"`
+		IP         string          `json:"ip"`
+		RequestId  string          `json:"request_id"`
+		CreatedAt  string          `json:"created_at"`
+	}{e.Action, e.ActorId, e.TargetType, e.TargetId, e.Before, e.After, e.IP, e.RequestId,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano)})
+	sum := sha256.Sum256(append([]byte(e.PrevHash+"\n"), payload...))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter - параметры выборки журнала. Записи возвращаются от новых к старым.
+type AuditFilter struct {
+	Action     string
+	ActorId    *int
+	TargetType string
+	TargetId   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// AuditVerification - результат проверки цепочки хешей журнала.
+type AuditVerification struct {
+	Valid   bool `json:"valid"`
+	Checked int  `json:"checked"`
+	// BrokenAt - id первой записи, на которой цепочка не сходится.
+	BrokenAt *int64 `json:"broken_at,omitempty"`
+}
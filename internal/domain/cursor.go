@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Cursor указывает на последнюю выданную запись постраничного списка: следующая страница
+// начинается строго после пары (Time, Id) в порядке убывания.
+type Cursor struct {
+	Time time.Time
+	Id   int
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.Time.UnixMicro(), c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("некорректный курсор")
+	}
+	var micro int64
+	var id int
+	if _, err = fmt.Sscanf(string(raw), "%d:%d", &micro, &id); err != nil {
+		return nil, errors.New("некорректный курсор")
+	}
+	return &Cursor{
+		Time: time.UnixMicro(micro).UTC(),
+		Id:   id,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package domain
+
+import "errors"
+
+var (
+	ErrItemNotFound   = errors.New("товар не найден")
+	ErrNotEnoughCoins = errors.New("цена товара выше количества текущих монет")
+	ErrOutOfStock     = errors.New("товар закончился")
+	ErrPurchaseLimit  = errors.New("превышен лимит покупок товара на пользователя")
+	ErrEmptyOrder     = errors.New("заказ не содержит товаров")
+	ErrInvalidAmount  = errors.New("количество должно быть положительным")
+
+	ErrVariantNotFound = errors.New("вариант товара не найден")
+	ErrVariantRequired = errors.New("необходимо выбрать вариант товара")
+	ErrVariantExists   = errors.New("такой вариант товара уже существует")
+	ErrEmptyVariant    = errors.New("вариант должен задавать размер или цвет")
+	ErrInvalidPrice    = errors.New("цена варианта не может быть отрицательной")
+
+	ErrPromoNotFound      = errors.New("промокод не найден")
+	ErrPromoExpired       = errors.New("промокод не действует")
+	ErrPromoExhausted     = errors.New("промокод исчерпан")
+	ErrPromoNotApplicable = errors.New("промокод не применим к товарам заказа")
+	ErrPromotionExists    = errors.New("промокод уже существует")
+	ErrInvalidPromotion   = errors.New("некорректные параметры акции")
+	ErrPromotionNotFound  = errors.New("акция не найдена")
+
+	ErrUserNotFound       = errors.New("получатель не найден")
+	ErrSelfTransfer       = errors.New("нельзя отправить монеты самому себе")
+	ErrInsufficientCoins  = errors.New("количество отправки выше количества текущих монет")
+	ErrTransferTooLarge   = errors.New("сумма перевода превышает допустимую")
+	ErrDailySendLimit     = errors.New("превышен дневной лимит отправки монет")
+	ErrDailyReceiveLimit  = errors.New("получатель превысил дневной лимит поступлений")
+	ErrTransferCooldown   = errors.New("переводы этому получателю слишком частые, попробуйте позже")
+	ErrBalanceCapExceeded = errors.New("баланс получателя превысит максимально допустимый")
+
+	ErrPaymentRequestNotFound = errors.New("запрос на перевод не найден")
+	ErrPaymentRequestResolved = errors.New("запрос на перевод уже обработан")
+	ErrPaymentRequestExpired  = errors.New("срок действия запроса на перевод истек")
+
+	ErrInvalidEscrow         = errors.New("некорректные параметры перевода с удержанием")
+	ErrEscrowNotFound        = errors.New("перевод с удержанием не найден")
+	ErrEscrowResolved        = errors.New("перевод с удержанием уже завершен")
+	ErrEscrowActionForbidden = errors.New("это действие недоступно участнику перевода с удержанием")
+
+	ErrWrongPassword         = errors.New("неверный текущий пароль")
+	ErrInvalidCredentials    = errors.New("неккоретные данные")
+	ErrUsernameTaken         = errors.New("имя пользователя уже занято")
+	ErrUsernameReserved      = errors.New("это имя пользователя зарезервировано")
+	ErrTokenRevoked          = errors.New("токен отозван")
+	ErrAccountHasEscrows     = errors.New("перед удалением аккаунта необходимо завершить переводы с удержанием")
+	ErrUserBanned            = errors.New("пользователь заблокирован")
+	ErrPasswordResetRequired = errors.New("необходимо сменить пароль")
+	ErrSelfBan               = errors.New("нельзя заблокировать самого себя")
+	ErrTwoFactorEnabled      = errors.New("двухфакторная аутентификация уже включена")
+	ErrTwoFactorNotEnrolled  = errors.New("двухфакторная аутентификация не настроена")
+	ErrInvalidTwoFactorCode  = errors.New("неверный код подтверждения")
+	ErrTwoFactorRequired     = errors.New("для этой роли необходимо включить двухфакторную аутентификацию")
+	ErrLoginThrottled        = errors.New("слишком частые попытки входа, повторите позже")
+	ErrLoginLocked           = errors.New("вход временно заблокирован из-за неудачных попыток")
+
+	ErrInvalidApiKey          = errors.New("недействительный ключ API")
+	ErrApiKeyScope            = errors.New("ключу API не разрешен этот запрос")
+	ErrUnknownScope           = errors.New("неизвестное разрешение ключа API")
+	ErrInvalidApiKeyExpiry    = errors.New("некорректный срок действия ключа API")
+	ErrApiKeyNotFound         = errors.New("ключ API не найден")
+	ErrApiKeyRevoked          = errors.New("ключ API уже отозван")
+	ErrServiceAccountExists   = errors.New("сервисный аккаунт с таким именем уже существует")
+	ErrServiceAccountNotFound = errors.New("сервисный аккаунт не найден")
+
+	ErrOidcDisabled     = errors.New("вход через внешнего провайдера не настроен")
+	ErrInvalidOidcState = errors.New("вход через внешнего провайдера не начат или устарел")
+	ErrOidcLoginFailed  = errors.New("не удалось войти через внешнего провайдера")
+
+	ErrTreasuryUnavailable      = errors.New("служебный счет казны недоступен")
+	ErrAdjustmentReasonRequired = errors.New("необходимо указать причину корректировки")
+
+	ErrEmptyBatch    = errors.New("пакет не содержит переводов")
+	ErrBatchTooLarge = errors.New("слишком много переводов в пакете")
+	ErrBatchAborted  = errors.New("перевод отменен из-за ошибки в пакете")
+
+	ErrInvalidSchedule   = errors.New("некорректное расписание перевода")
+	ErrScheduleNotFound  = errors.New("расписание перевода не найдено")
+	ErrScheduleCancelled = errors.New("расписание перевода уже отменено")
+
+	ErrPurchaseNotFound    = errors.New("покупка не найдена")
+	ErrRefundWindowExpired = errors.New("срок отмены покупки истек")
+	ErrAlreadyRefunded     = errors.New("покупка уже возвращена")
+	ErrRefundExists        = errors.New("заявка на возврат уже создана")
+	ErrRefundNotFound      = errors.New("заявка на возврат не найдена")
+	ErrRefundResolved      = errors.New("заявка на возврат уже рассмотрена")
+	ErrPurchaseShipped     = errors.New("нельзя вернуть отправленный заказ")
+
+	ErrInvalidStatusTransition = errors.New("недопустимый переход статуса покупки")
+
+	ErrAuditUnavailable = errors.New("действие выполнено, но не записано в журнал аудита")
+
+	ErrReauthRequired = errors.New("требуется повторный вход через внешнего провайдера")
+)
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+const (
+	EscrowHeld     = "held"
+	EscrowReleased = "released"
+	EscrowReturned = "returned"
+)
+
+// Действия с удерживаемыми монетами: release передает их получателю, return возвращает отправителю.
+const (
+	EscrowActionRelease = "release"
+	EscrowActionReturn  = "return"
+)
+
+// Escrow - перевод с удержанием: монеты списываются у отправителя при создании и передаются
+// получателю или возвращаются отправителю по подтверждению участника, арбитра или по истечении срока.
+type Escrow struct {
+	Id                int        `json:"id" db:"id"`
+	SenderId          int        `json:"-" db:"sender_id"`
+	SenderUsername    string     `json:"sender" db:"sender_username"`
+	RecipientId       int        `json:"-" db:"recipient_id"`
+	RecipientUsername string     `json:"recipient" db:"recipient_username"`
+	ArbiterId         *int       `json:"-" db:"arbiter_id"`
+	ArbiterUsername   *string    `json:"arbiter,omitempty" db:"arbiter_username"`
+	Amount            int        `json:"amount" db:"amount"`
+	Message           string     `json:"message,omitempty" db:"message"`
+	Status            string     `json:"status" db:"status"`
+	OnTimeout         string     `json:"on_timeout" db:"on_timeout"`
+	TransactionId     *int       `json:"transaction_id,omitempty" db:"transaction_id"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt         time.Time  `json:"expires_at" db:"expires_at"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+	ResolvedBy        *int       `json:"-" db:"resolved_by"`
+}
+
+// EscrowInput задает срок удержания длительностью Go (например, 72h) и действие по его истечении,
+// по умолчанию монеты возвращаются отправителю.
+type EscrowInput struct {
+	RecipientUsername string `json:"recipient" binding:"required"`
+	ArbiterUsername   string `json:"arbiter"`
+	Amount            int    `json:"amount" binding:"required,min=1"`
+	Message           string `json:"message" binding:"max=200"`
+	Timeout           string `json:"timeout" binding:"required"`
+	OnTimeout         string `json:"on_timeout" binding:"omitempty,oneof=release return"`
+}
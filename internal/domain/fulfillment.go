@@ -0,0 +1,13 @@
+package domain
+
+const (
+	PurchasePending   = "pending"
+	PurchasePacked    = "packed"
+	PurchaseShipped   = "shipped"
+	PurchaseCancelled = "cancelled"
+)
+
+type PurchaseStatusInput struct {
+	Status string `json:"status" binding:"required,oneof=packed shipped cancelled"`
+	Reason string `json:"reason" binding:"max=500"`
+}
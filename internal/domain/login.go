@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Области учета неудачных попыток входа: по имени пользователя и по IP-адресу клиента.
+const (
+	LoginScopeUsername = "username"
+	LoginScopeIP       = "ip"
+)
+
+type LoginAttempt struct {
+	Scope         string     `json:"scope" db:"scope"`
+	Value         string     `json:"value" db:"value"`
+	Failures      int        `json:"failures" db:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at" db:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+}
+
+// LoginUnlockInput снимает блокировку входа с пользователя, IP-адреса или с обоих.
+type LoginUnlockInput struct {
+	Username string `json:"username" binding:"required_without=IP"`
+	IP       string `json:"ip" binding:"required_without=Username"`
+}
+
+// LoginThrottledError сообщает, что вход временно недоступен, и время, после которого его можно повторить.
+// Оборачивает ErrLoginLocked при блокировке и ErrLoginThrottled при задержке между попытками.
+type LoginThrottledError struct {
+	Until  time.Time
+	Locked bool
+	// Started - блокировка наступила на этой попытке входа.
+	Started bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s до %s", e.Unwrap().Error(), e.Until.Format(time.RFC3339))
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	if e.Locked {
+		return ErrLoginLocked
+	}
+	return ErrLoginThrottled
+}
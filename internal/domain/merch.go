@@ -5,11 +5,45 @@ import (
 )
 
 type Merch struct {
-	Id    int    `json:"-" db:"id"`
-	Name  string `json:"name" binding:"required"`
-	Price string `json:"price" binding:"required"`
+	Id           int           `json:"-" db:"id"`
+	Name         string        `json:"name" binding:"required"`
+	Price        int           `json:"price" binding:"required"`
+	Category     string        `json:"category,omitempty" db:"category"`
+	Stock        *int          `json:"stock" db:"stock"`
+	PerUserLimit *int          `json:"per_user_limit" db:"per_user_limit"`
+	Variants     []ItemVariant `json:"variants,omitempty" db:"-"`
 }
 
+type RestockInput struct {
+	Quantity int `json:"quantity" binding:"required,gt=0"`
+}
+
+// ItemStockInput задает остаток и лимит на пользователя целиком:
+// отсутствующее значение означает отсутствие ограничения.
+type ItemStockInput struct {
+	Stock        *int `json:"stock" binding:"omitempty,gte=0"`
+	PerUserLimit *int `json:"per_user_limit" binding:"omitempty,gt=0"`
+}
+
+// ItemStockChange - товар после изменения остатка и его состояние до изменения,
+// прочитанное в той же транзакции.
+type ItemStockChange struct {
+	Item   Merch
+	Before Merch
+}
+
+const (
+	TransactionTransfer      = "transfer"
+	TransactionRefund        = "refund"
+	TransactionSignupBonus   = "signup_bonus"
+	TransactionGrant         = "grant"
+	TransactionClawback      = "clawback"
+	TransactionAllowance     = "allowance"
+	TransactionExpiry        = "expiry"
+	TransactionEscrow        = "escrow"
+	TransactionAccountClosed = "account_closed"
+)
+
 type Transactions struct {
 	Id                  int        `json:"-" db:"id"`
 	Source              *int       `json:"source,omitempty"`
@@ -17,18 +51,28 @@ type Transactions struct {
 	Destination         *int       `json:"destination,omitempty"`
 	DestinationUsername string     `json:"destination_username,omitempty" db:"destination_username"`
 	Amount              int        `json:"amount" binding:"required"`
+	Kind                string     `json:"kind,omitempty" db:"kind"`
+	PurchaseId          *int       `json:"purchase_id,omitempty" db:"purchase_id"`
+	Message             string     `json:"message,omitempty" db:"message" binding:"max=200"`
+	Category            string     `json:"category,omitempty" db:"category" binding:"omitempty,oneof=thanks bet refund gift"`
 	Timestamp           *time.Time `json:"timestamp,omitempty" `
 }
 
+// UserSummary.Coins - доступный баланс: монеты, удерживаемые в исходящих переводах
+// с удержанием, в него не входят и выводятся отдельно в HeldCoins.
 type UserSummary struct {
 	UserName            string              `json:"username"`
 	Coins               int                 `json:"coins"`
+	HeldCoins           int                 `json:"held_coins,omitempty"`
+	PendingEscrows      []Escrow            `json:"pending_escrows,omitempty"`
 	PurchasedItems      []PurchasedItem     `json:"purchased_items"`
 	TransactionsSummary TransactionsSummary `json:"transactions_summary"`
 }
 
 type PurchasedItem struct {
 	ItemName string `json:"item_name"  db:"item_name"`
+	Size     string `json:"size,omitempty"  db:"size"`
+	Colour   string `json:"colour,omitempty"  db:"colour"`
 	Quantity int    `json:"quantity"  db:"quantity"`
 }
 type TransactionsSummary struct {
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// OidcState - незавершенный вход через провайдера OpenID Connect. Хранится до возврата пользователя
+// от провайдера, чтобы проверить state, nonce из ID token и передать code_verifier при обмене кода.
+type OidcState struct {
+	State        string    `db:"state"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// OidcLogin - адрес провайдера, на который перенаправляется пользователь, и state этого входа.
+type OidcLogin struct {
+	URL   string
+	State string
+}
+
+// OidcCallbackInput - параметры, с которыми провайдер возвращает пользователя.
+type OidcCallbackInput struct {
+	State            string `form:"state"`
+	Code             string `form:"code"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
@@ -0,0 +1,12 @@
+package domain
+
+type OrderLine struct {
+	ItemName string `json:"item" binding:"required"`
+	VariantSelector
+	Quantity int `json:"quantity" binding:"required,gt=0"`
+}
+
+type OrderInput struct {
+	Items     []OrderLine `json:"items" binding:"required,min=1,dive"`
+	PromoCode string      `json:"promo_code" binding:"max=50"`
+}
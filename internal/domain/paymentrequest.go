@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+const (
+	PaymentRequestPending   = "pending"
+	PaymentRequestAccepted  = "accepted"
+	PaymentRequestDeclined  = "declined"
+	PaymentRequestCancelled = "cancelled"
+	PaymentRequestExpired   = "expired"
+)
+
+// PaymentRequest - запрос монет: Requester просит Payer перевести ему Amount монет.
+// При принятии выполняется обычный перевод, его id сохраняется в TransactionId.
+type PaymentRequest struct {
+	Id                int        `json:"id" db:"id"`
+	RequesterId       int        `json:"-" db:"requester_id"`
+	RequesterUsername string     `json:"requester" db:"requester_username"`
+	PayerId           int        `json:"-" db:"payer_id"`
+	PayerUsername     string     `json:"payer" db:"payer_username"`
+	Amount            int        `json:"amount" db:"amount"`
+	Message           string     `json:"message,omitempty" db:"message"`
+	Status            string     `json:"status" db:"status"`
+	TransactionId     *int       `json:"transaction_id,omitempty" db:"transaction_id"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt         time.Time  `json:"expires_at" db:"expires_at"`
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+type PaymentRequestInput struct {
+	PayerUsername string `json:"username" binding:"required"`
+	Amount        int    `json:"amount" binding:"required,min=1"`
+	Message       string `json:"message" binding:"max=200"`
+}
+
+// PaymentRequestFilter отбирает запросы пользователя: DirectionIn - адресованные ему,
+// DirectionOut - созданные им. Пустые поля не ограничивают выборку.
+type PaymentRequestFilter struct {
+	Direction string
+	Status    string
+}
@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+const (
+	DiscountPercent = "percent"
+	DiscountFixed   = "fixed"
+)
+
+// Promotion описывает скидку на товар, категорию или весь магазин в пределах периода.
+// Акция без кода применяется автоматически, акция с кодом только при его вводе.
+type Promotion struct {
+	Id         int       `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	Kind       string    `json:"kind" db:"kind"`
+	Value      int       `json:"value" db:"value"`
+	ItemId     *int      `json:"-" db:"item_id"`
+	ItemName   *string   `json:"item,omitempty" db:"item_name"`
+	Category   *string   `json:"category,omitempty" db:"category"`
+	Code       *string   `json:"code,omitempty" db:"code"`
+	UsageLimit *int      `json:"usage_limit,omitempty" db:"usage_limit"`
+	UsedCount  int       `json:"used_count" db:"used_count"`
+	StartsAt   time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt     time.Time `json:"ends_at" db:"ends_at"`
+}
+
+func (p Promotion) IsActive(now time.Time) bool {
+	return !now.Before(p.StartsAt) && now.Before(p.EndsAt)
+}
+
+func (p Promotion) Matches(item Merch) bool {
+	if p.ItemId != nil {
+		return *p.ItemId == item.Id
+	}
+	if p.Category != nil {
+		return *p.Category == item.Category
+	}
+	return true
+}
+
+// Apply возвращает цену единицы товара со скидкой, цена не опускается ниже нуля.
+func (p Promotion) Apply(price int) int {
+	discounted := price
+	switch p.Kind {
+	case DiscountPercent:
+		discounted = price - price*p.Value/100
+	case DiscountFixed:
+		discounted = price - p.Value
+	}
+	if discounted < 0 {
+		return 0
+	}
+	return discounted
+}
+
+type PromotionInput struct {
+	Name       string    `json:"name" binding:"required,max=100"`
+	Kind       string    `json:"kind" binding:"required,oneof=percent fixed"`
+	Value      int       `json:"value" binding:"required,gt=0"`
+	Item       string    `json:"item" binding:"max=50"`
+	Category   string    `json:"category" binding:"max=30"`
+	Code       string    `json:"code" binding:"max=50"`
+	UsageLimit *int      `json:"usage_limit" binding:"omitempty,gt=0"`
+	StartsAt   time.Time `json:"starts_at" binding:"required"`
+	EndsAt     time.Time `json:"ends_at" binding:"required"`
+}
+
+// BuyOptions задает вариант товара и промокод для покупки одной единицы товара.
+type BuyOptions struct {
+	VariantSelector
+	PromoCode string `form:"promo_code" binding:"max=50"`
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+)
+
+type Purchase struct {
+	Id           int        `json:"id" db:"id"`
+	UserId       int        `json:"-" db:"user_id"`
+	OrderId      *int       `json:"order_id,omitempty" db:"order_id"`
+	ItemName     string     `json:"item_name" db:"item_name"`
+	Size         string     `json:"size,omitempty" db:"size"`
+	Colour       string     `json:"colour,omitempty" db:"colour"`
+	Price        int        `json:"price" db:"price"`
+	BasePrice    int        `json:"base_price" db:"base_price"`
+	PromotionId  *int       `json:"promotion_id,omitempty" db:"promotion_id"`
+	Quantity     int        `json:"quantity" db:"quantity"`
+	PurchaseDate time.Time  `json:"purchase_date" db:"purchase_date"`
+	RefundedAt   *time.Time `json:"refunded_at,omitempty" db:"refunded_at"`
+	Status       string     `json:"status" db:"status"`
+	PackedAt     *time.Time `json:"packed_at,omitempty" db:"packed_at"`
+	ShippedAt    *time.Time `json:"shipped_at,omitempty" db:"shipped_at"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+}
+
+type PurchaseFilter struct {
+	Limit  int
+	From   *time.Time
+	To     *time.Time
+	Cursor *Cursor
+}
+
+type PurchaseHistory struct {
+	Purchases  []Purchase `json:"purchases"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+const (
+	RefundRequested = "requested"
+	RefundApproved  = "approved"
+	RefundRejected  = "rejected"
+)
+
+type Refund struct {
+	Id            int        `json:"id" db:"id"`
+	PurchaseId    int        `json:"purchase_id" db:"purchase_id"`
+	UserId        int        `json:"user_id" db:"user_id"`
+	Amount        int        `json:"amount" db:"amount"`
+	Status        string     `json:"status" db:"status"`
+	Reason        string     `json:"reason,omitempty" db:"reason"`
+	TransactionId *int       `json:"transaction_id,omitempty" db:"transaction_id"`
+	ResolvedBy    *int       `json:"resolved_by,omitempty" db:"resolved_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+type RefundInput struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
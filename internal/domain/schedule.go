@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+const (
+	ScheduleActive    = "active"
+	ScheduleCancelled = "cancelled"
+)
+
+// ScheduledTransfer описывает повторяющийся перевод: задается либо cron-выражение,
+// либо интервал в формате длительности Go (например, 168h).
+type ScheduledTransfer struct {
+	Id                  int        `json:"id" db:"id"`
+	UserId              int        `json:"-" db:"user_id"`
+	DestinationId       int        `json:"-" db:"destination_id"`
+	DestinationUsername string     `json:"destination_username" db:"destination_username"`
+	Amount              int        `json:"amount" db:"amount"`
+	Message             string     `json:"message,omitempty" db:"message"`
+	Category            string     `json:"category,omitempty" db:"category"`
+	Cron                string     `json:"cron,omitempty" db:"cron"`
+	Interval            string     `json:"interval,omitempty" db:"repeat_every"`
+	NextRunAt           time.Time  `json:"next_run_at" db:"next_run_at"`
+	LastRunAt           *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	Status              string     `json:"status" db:"status"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	CancelledAt         *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
+}
+
+type ScheduleInput struct {
+	DestinationUsername string     `json:"destination_username" binding:"required"`
+	Amount              int        `json:"amount" binding:"required,min=1"`
+	Message             string     `json:"message" binding:"max=200"`
+	Category            string     `json:"category" binding:"omitempty,oneof=thanks bet refund gift"`
+	Cron                string     `json:"cron"`
+	Interval            string     `json:"interval"`
+	StartAt             *time.Time `json:"start_at"`
+}
+
+// ScheduleRun - результат одного выполнения расписания: id транзакции при успехе или текст ошибки.
+type ScheduleRun struct {
+	Id            int       `json:"id" db:"id"`
+	ScheduleId    int       `json:"schedule_id" db:"schedule_id"`
+	RunAt         time.Time `json:"run_at" db:"run_at"`
+	TransactionId *int      `json:"transaction_id,omitempty" db:"transaction_id"`
+	Error         string    `json:"error,omitempty" db:"error"`
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	TransferThanks = "thanks"
+	TransferBet    = "bet"
+	TransferRefund = "refund"
+	TransferGift   = "gift"
+)
+
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+)
+
+// TransactionFilter отбирает переводы пользователя: Direction ограничивает входящими
+// или исходящими, пустые поля не ограничивают выборку.
+type TransactionFilter struct {
+	Limit     int
+	Direction string
+	Category  string
+	Cursor    *Cursor
+}
+
+type TransactionHistory struct {
+	Transactions []Transactions `json:"transactions"`
+	NextCursor   string         `json:"next_cursor,omitempty"`
+}
+
+// TransferLimits ограничивает переводы между пользователями, нулевое значение отключает правило.
+// Дневные лимиты считаются за скользящие 24 часа.
+type TransferLimits struct {
+	MaxAmount     int
+	DailyOutbound int
+	DailyInbound  int
+	Cooldown      time.Duration
+	// MaxBalance - максимальный баланс получателя после перевода.
+	MaxBalance int
+}
+
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+)
+
+// BatchTransferInput - пакет переводов: в режиме atomic выполняются все переводы или ни один,
+// в режиме best_effort каждый перевод выполняется независимо.
+type BatchTransferInput struct {
+	Mode      string         `json:"mode" form:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Transfers []Transactions `json:"transfers" binding:"required,min=1,dive"`
+}
+
+type BatchTransferItem struct {
+	DestinationUsername string `json:"destination_username"`
+	Amount              int    `json:"amount"`
+	TransactionId       *int   `json:"transaction_id,omitempty"`
+	Error               string `json:"error,omitempty"`
+}
+
+type BatchTransferResult struct {
+	Mode      string              `json:"mode"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Results   []BatchTransferItem `json:"results"`
+}
+
+// BatchItemError указывает, на каком переводе пакета произошла ошибка.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("перевод %d: %v", e.Index+1, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
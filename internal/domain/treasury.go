@@ -0,0 +1,16 @@
+package domain
+
+// CoinAdjustmentInput - начисление или списание монет администратором, причина обязательна.
+type CoinAdjustmentInput struct {
+	Username string `json:"username" binding:"required"`
+	Amount   int    `json:"amount" binding:"required,min=1"`
+	Reason   string `json:"reason" binding:"required,max=200"`
+}
+
+// CoinAdjustment - результат начисления или списания: id транзакции и баланс пользователя
+// до и после изменения, прочитанный в той же транзакции.
+type CoinAdjustment struct {
+	TransactionId int `json:"transaction_id"`
+	BalanceBefore int `json:"balance_before"`
+	BalanceAfter  int `json:"balance_after"`
+}
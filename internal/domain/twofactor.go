@@ -0,0 +1,38 @@
+package domain
+
+// RecoveryCodeCount - сколько одноразовых кодов восстановления выдается при включении двухфакторной аутентификации.
+const RecoveryCodeCount = 10
+
+// TwoFactorState - настройки двухфакторной аутентификации пользователя. Пока Enabled равно false,
+// Secret содержит секрет, ожидающий подтверждения первым кодом.
+type TwoFactorState struct {
+	UserName string  `db:"username"`
+	Role     string  `db:"role"`
+	Secret   *string `db:"totp_secret"`
+	Enabled  bool    `db:"totp_enabled"`
+}
+
+// TwoFactorEnrollment - данные для добавления учетной записи в приложение-аутентификатор.
+// URI кодируется в QR-код, Secret вводится вручную, если сканировать QR-код нечем.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorSignInInput - второй шаг входа: промежуточный токен из ответа sign-in и код из приложения
+// или код восстановления.
+type TwoFactorSignInInput struct {
+	Token string `json:"two_factor_token" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// TwoFactorDisableInput - пароль и код второго фактора. У пользователя без пароля, входящего через
+// внешнего провайдера, отключение подтверждается только кодом.
+type TwoFactorDisableInput struct {
+	Password string `json:"password"`
+	Code     string `json:"code" binding:"required"`
+}
@@ -1,13 +1,101 @@
 package domain
 
+import "time"
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+	// RoleAuditor - доступ только на чтение журнала аудита.
+	RoleAuditor = "auditor"
+	// RoleTreasury - служебный счет казны, от которого выпускаются и на который списываются монеты.
+	// Войти под ним нельзя, переводы ему недоступны.
+	RoleTreasury = "treasury"
+	// RoleDeleted - удаленный аккаунт. Запись остается анонимной, чтобы сохранить историю переводов других пользователей.
+	RoleDeleted = "deleted"
+	// RoleService - пользователь сервисного аккаунта. Действует только через ключи API, войти под ним нельзя.
+	RoleService = "service"
+
+	TreasuryUsername = "treasury"
+	// DeletedUsernamePrefix - префикс имени удаленного аккаунта, за ним следует id пользователя.
+	DeletedUsernamePrefix = "deleted_"
+	// ServiceUsernamePrefix - префикс имени пользователя сервисного аккаунта, за ним следует имя аккаунта.
+	ServiceUsernamePrefix = "svc_"
+)
+
 type User struct {
-	Id       int    `json:"-" db:"id"`
-	UserName string `json:"username"`
-	Password string `json:"password"`
-	Coins    *int   `json:"coins"`
+	Id                    int    `json:"-" db:"id"`
+	UserName              string `json:"username"`
+	Password              string `json:"password"`
+	Coins                 *int   `json:"coins"`
+	Banned                bool   `json:"-" db:"banned"`
+	PasswordResetRequired bool   `json:"-" db:"password_reset_required"`
+	TwoFactorEnabled      bool   `json:"-" db:"totp_enabled"`
+}
+
+// TokenState - состояние пользователя, от которого зависит, принимаются ли его токены.
+type TokenState struct {
+	Version               int  `db:"token_version"`
+	Banned                bool `db:"banned"`
+	PasswordResetRequired bool `db:"password_reset_required"`
+}
+
+// Статусы аккаунта для поиска пользователей администратором.
+const (
+	UserStatusActive  = "active"
+	UserStatusBanned  = "banned"
+	UserStatusDeleted = "deleted"
+)
+
+// UserInfo - сведения о пользователе для администратора.
+type UserInfo struct {
+	Id                    int        `json:"id" db:"id"`
+	UserName              string     `json:"username" db:"username"`
+	Role                  string     `json:"role" db:"role"`
+	Coins                 int        `json:"coins" db:"coins"`
+	LastLoginAt           *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+	BannedAt              *time.Time `json:"banned_at,omitempty" db:"banned_at"`
+	BanReason             string     `json:"ban_reason,omitempty" db:"ban_reason"`
+	PasswordResetRequired bool       `json:"password_reset_required" db:"password_reset_required"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// UserFilter - параметры поиска пользователей: Query ищет по части имени без учета регистра.
+type UserFilter struct {
+	Query  string
+	Role   string
+	Status string
+	Limit  int
+	Offset int
+}
+
+type BanInput struct {
+	Reason string `json:"reason" binding:"required,max=200"`
 }
 
 type SignInInput struct {
 	UserName string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
+
+type PasswordChangeInput struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type UsernameChangeInput struct {
+	UserName string `json:"username" binding:"required,max=255"`
+}
+
+// AccountDeleteInput подтверждает удаление аккаунта паролем. Пользователю без пароля, входящему через
+// внешнего провайдера, пароль не нужен: удаление подтверждается недавним входом, время которого
+// берется из токена (TokenIssuedAt).
+type AccountDeleteInput struct {
+	Password      string    `json:"password"`
+	TokenIssuedAt time.Time `json:"-"`
+}
+
+// Session - пользователь, от имени которого выполняется запрос, и время выдачи его токена.
+type Session struct {
+	UserId   int
+	IssuedAt time.Time
+}
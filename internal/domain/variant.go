@@ -0,0 +1,42 @@
+package domain
+
+// VariantSelector выбирает вариант товара по размеру и цвету,
+// пустой селектор означает товар без вариантов.
+type VariantSelector struct {
+	Size   string `json:"size,omitempty" form:"size" binding:"max=20"`
+	Colour string `json:"colour,omitempty" form:"colour" binding:"max=30"`
+}
+
+func (v VariantSelector) IsEmpty() bool {
+	return v.Size == "" && v.Colour == ""
+}
+
+type ItemVariant struct {
+	Id         int    `json:"id" db:"id"`
+	ItemId     int    `json:"-" db:"item_id"`
+	Size       string `json:"size,omitempty" db:"size"`
+	Colour     string `json:"colour,omitempty" db:"colour"`
+	PriceDelta int    `json:"price_delta" db:"price_delta"`
+	Stock      *int   `json:"stock" db:"stock"`
+}
+
+// VariantStockChange - вариант после изменения остатка и его состояние до изменения,
+// прочитанное в той же транзакции.
+type VariantStockChange struct {
+	Variant ItemVariant
+	Before  ItemVariant
+}
+
+func (v ItemVariant) Selector() VariantSelector {
+	return VariantSelector{Size: v.Size, Colour: v.Colour}
+}
+
+type VariantInput struct {
+	VariantSelector
+	PriceDelta int  `json:"price_delta"`
+	Stock      *int `json:"stock" binding:"omitempty,gte=0"`
+}
+
+type VariantStockInput struct {
+	Stock *int `json:"stock" binding:"omitempty,gte=0"`
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var apiKeyRows = []string{"id", "service_account_id", "prefix", "scopes", "expires_at", "revoked_at", "last_used_at", "created_by", "created_at"}
+
+func TestApiKeyPostgres_CreateServiceAccount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewApiKeyPostgres(sqlx.NewDb(db, "postgres"))
+
+	input := domain.ServiceAccountInput{Name: "slack-bot", Description: "уведомления"}
+	userQuery := fmt.Sprintf("INSERT INTO %s \\(username, password, coins, role\\) VALUES \\(\\$1, '', 0, \\$2\\)", userListTable)
+	insertQuery := fmt.Sprintf("INSERT INTO %s (.+) ON CONFLICT \\(name\\) DO NOTHING RETURNING id", serviceAccountsTable)
+	selectQuery := fmt.Sprintf("SELECT (.+) FROM %s sa JOIN %s u ON u.id = sa.user_id WHERE sa.id = \\$1",
+		serviceAccountsTable, userListTable)
+
+	t.Run("Ok", func(t *testing.T) {
+		createdAt := time.Date(2025, 3, 23, 12, 0, 0, 0, time.UTC)
+		mock.ExpectBegin()
+		mock.ExpectQuery(userQuery).WithArgs("svc_slack-bot", domain.RoleService).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+		mock.ExpectQuery(insertQuery).WithArgs("slack-bot", "уведомления", 8, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectCommit()
+		mock.ExpectQuery(selectQuery).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "user_id", "username", "created_by", "created_at"}).
+				AddRow(2, "slack-bot", "уведомления", 8, "svc_slack-bot", 1, createdAt))
+
+		got, err := r.CreateServiceAccount(1, input)
+		assert.NoError(t, err)
+		adminId := 1
+		assert.Equal(t, domain.ServiceAccount{Id: 2, Name: "slack-bot", Description: "уведомления", UserId: 8,
+			UserName: "svc_slack-bot", CreatedBy: &adminId, CreatedAt: createdAt}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("Имя пользователя занято", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(userQuery).WithArgs("svc_slack-bot", domain.RoleService).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		_, err := r.CreateServiceAccount(1, input)
+		assert.ErrorIs(t, err, domain.ErrServiceAccountExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("Имя аккаунта занято", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(userQuery).WithArgs("svc_slack-bot", domain.RoleService).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+		mock.ExpectQuery(insertQuery).WithArgs("slack-bot", "уведомления", 8, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		_, err := r.CreateServiceAccount(1, input)
+		assert.ErrorIs(t, err, domain.ErrServiceAccountExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestApiKeyPostgres_CreateApiKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewApiKeyPostgres(sqlx.NewDb(db, "postgres"))
+
+	expiresAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, 3, 20, 12, 0, 0, 0, time.UTC)
+	query := fmt.Sprintf("INSERT INTO %s AS k (.+) SELECT id, (.+) FROM %s WHERE id = \\$1 RETURNING (.+)", apiKeysTable, serviceAccountsTable)
+	mock.ExpectQuery(query).
+		WithArgs(2, "0123456789ab", "hash", "coins:send info:read", expiresAt, 1).
+		WillReturnRows(sqlmock.NewRows(apiKeyRows).
+			AddRow(5, 2, "0123456789ab", "coins:send info:read", expiresAt, nil, nil, 1, createdAt))
+
+	got, err := r.CreateApiKey(1, 2, "0123456789ab", "hash", []string{domain.ScopeCoinsSend, domain.ScopeInfoRead}, expiresAt)
+	assert.NoError(t, err)
+	adminId := 1
+	assert.Equal(t, domain.ApiKey{Id: 5, ServiceAccountId: 2, Prefix: "0123456789ab",
+		Scopes: []string{domain.ScopeCoinsSend, domain.ScopeInfoRead}, ExpiresAt: expiresAt, CreatedBy: &adminId, CreatedAt: createdAt}, got)
+
+	// сервисного аккаунта нет - вставлять нечего
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(apiKeyRows))
+	_, err = r.CreateApiKey(1, 9, "0123456789ab", "hash", []string{domain.ScopeCoinsSend}, expiresAt)
+	assert.ErrorIs(t, err, domain.ErrServiceAccountNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApiKeyPostgres_RevokeApiKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewApiKeyPostgres(sqlx.NewDb(db, "postgres"))
+
+	at := time.Date(2025, 3, 21, 9, 0, 0, 0, time.UTC)
+	updateQuery := fmt.Sprintf("UPDATE %s AS k SET revoked_at = \\$1 WHERE k.id = \\$2 AND k.revoked_at IS NULL", apiKeysTable)
+	existsQuery := fmt.Sprintf("SELECT EXISTS \\(SELECT 1 FROM %s WHERE id = \\$1\\)", apiKeysTable)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mock.ExpectQuery(updateQuery).WithArgs(at, 5).
+					WillReturnRows(sqlmock.NewRows(apiKeyRows).AddRow(5, 2, "0123456789ab", "coins:send", at, at, nil, 1, at))
+			},
+		},
+		{
+			name: "Уже отозван",
+			mock: func() {
+				mock.ExpectQuery(updateQuery).WithArgs(at, 5).WillReturnRows(sqlmock.NewRows(apiKeyRows))
+				mock.ExpectQuery(existsQuery).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			wantErr: domain.ErrApiKeyRevoked,
+		},
+		{
+			name: "Не найден",
+			mock: func() {
+				mock.ExpectQuery(updateQuery).WithArgs(at, 5).WillReturnRows(sqlmock.NewRows(apiKeyRows))
+				mock.ExpectQuery(existsQuery).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			wantErr: domain.ErrApiKeyNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.RevokeApiKey(5, at)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, &at, got.RevokedAt)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestApiKeyPostgres_GetApiKeyCredential(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewApiKeyPostgres(sqlx.NewDb(db, "postgres"))
+
+	expiresAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	query := fmt.Sprintf("SELECT (.+) FROM %s k JOIN %s sa ON (.+) JOIN %s u ON (.+) WHERE k.prefix = \\$1",
+		apiKeysTable, serviceAccountsTable, userListTable)
+	mock.ExpectQuery(query).WithArgs("0123456789ab").
+		WillReturnRows(sqlmock.NewRows(append(apiKeyRows, "key_hash", "user_id", "user_banned", "user_deleted")).
+			AddRow(5, 2, "0123456789ab", "coins:send", expiresAt, nil, nil, nil, expiresAt, "hash", 3, false, false))
+
+	got, err := r.GetApiKeyCredential("0123456789ab")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, got.Id)
+	assert.Equal(t, []string{domain.ScopeCoinsSend}, got.Scopes)
+	assert.Equal(t, "hash", got.KeyHash)
+	assert.Equal(t, 3, got.UserId)
+
+	mock.ExpectQuery(query).WithArgs("ffffffffffff").WillReturnRows(sqlmock.NewRows(apiKeyRows))
+	_, err = r.GetApiKeyCredential("ffffffffffff")
+	assert.ErrorIs(t, err, domain.ErrInvalidApiKey)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
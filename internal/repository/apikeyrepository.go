@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type ApiKeyPostgres struct {
+	db *sqlx.DB
+}
+
+func NewApiKeyPostgres(db *sqlx.DB) *ApiKeyPostgres {
+	return &ApiKeyPostgres{
+		db: db,
+	}
+}
+
+const (
+	serviceAccountColumns = `sa.id, sa.name, sa.description, sa.user_id, u.username, sa.created_by, sa.created_at`
+	apiKeyColumns         = `k.id, k.service_account_id, k.prefix, k.scopes, k.expires_at, k.revoked_at, k.last_used_at,
+	k.created_by, k.created_at`
+)
+
+// apiKeyRow хранит разрешения в том виде, в каком они лежат в базе, - через пробел.
+type apiKeyRow struct {
+	domain.ApiKey
+	Scopes string `db:"scopes"`
+}
+
+func (row apiKeyRow) toApiKey() domain.ApiKey {
+	key := row.ApiKey
+	key.Scopes = strings.Fields(row.Scopes)
+	return key
+}
+
+// CreateServiceAccount создает сервисный аккаунт вместе с его собственным пользователем svc_<name>
+// с ролью service. Пароля у пользователя нет, поэтому войти под ним нельзя, а пользователи-люди
+// к сервисным аккаунтам не привязываются.
+func (r *ApiKeyPostgres) CreateServiceAccount(adminId int, input domain.ServiceAccountInput) (domain.ServiceAccount, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.ServiceAccount{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	var userId int
+	userQuery := fmt.Sprintf(`INSERT INTO %s (username, password, coins, role) VALUES ($1, '', 0, $2)
+	ON CONFLICT (username) DO NOTHING RETURNING id`, userListTable)
+	err = tr.QueryRowx(userQuery, domain.ServiceUsernamePrefix+input.Name, domain.RoleService).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ServiceAccount{}, domain.ErrServiceAccountExists
+		}
+		return domain.ServiceAccount{}, err
+	}
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (name, description, user_id, created_by) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (name) DO NOTHING RETURNING id`, serviceAccountsTable)
+	if err = tr.QueryRowx(query, input.Name, input.Description, userId, adminId).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ServiceAccount{}, domain.ErrServiceAccountExists
+		}
+		return domain.ServiceAccount{}, err
+	}
+	if err = tr.Commit(); err != nil {
+		return domain.ServiceAccount{}, err
+	}
+	logger.Log.Debug().Int("id", id).Int("user_id", userId).Msg("Успешно создан сервисный аккаунт")
+	return r.GetServiceAccount(id)
+}
+
+func (r *ApiKeyPostgres) GetServiceAccount(id int) (domain.ServiceAccount, error) {
+	var account domain.ServiceAccount
+	query := fmt.Sprintf(`SELECT %s FROM %s sa JOIN %s u ON u.id = sa.user_id WHERE sa.id = $1`,
+		serviceAccountColumns, serviceAccountsTable, userListTable)
+	if err := r.db.Get(&account, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ServiceAccount{}, domain.ErrServiceAccountNotFound
+		}
+		return domain.ServiceAccount{}, err
+	}
+	return account, nil
+}
+
+func (r *ApiKeyPostgres) GetServiceAccounts() ([]domain.ServiceAccount, error) {
+	accounts := []domain.ServiceAccount{}
+	query := fmt.Sprintf(`SELECT %s FROM %s sa JOIN %s u ON u.id = sa.user_id ORDER BY sa.id`,
+		serviceAccountColumns, serviceAccountsTable, userListTable)
+	if err := r.db.Select(&accounts, query); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// CreateApiKey сохраняет хеш нового ключа сервисного аккаунта.
+func (r *ApiKeyPostgres) CreateApiKey(adminId, accountId int, prefix, keyHash string, scopes []string, expiresAt time.Time) (domain.ApiKey, error) {
+	var row apiKeyRow
+	query := fmt.Sprintf(`INSERT INTO %s AS k (service_account_id, prefix, key_hash, scopes, expires_at, created_by)
+	SELECT id, $2, $3, $4, $5, $6 FROM %s WHERE id = $1
+	RETURNING %s`, apiKeysTable, serviceAccountsTable, apiKeyColumns)
+	err := r.db.QueryRowx(query, accountId, prefix, keyHash, strings.Join(scopes, " "), expiresAt, adminId).StructScan(&row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ApiKey{}, domain.ErrServiceAccountNotFound
+		}
+		return domain.ApiKey{}, err
+	}
+	logger.Log.Debug().Int("id", row.Id).Str("prefix", prefix).Msg("Успешно создан ключ API")
+	return row.toApiKey(), nil
+}
+
+func (r *ApiKeyPostgres) GetApiKeys(accountId int) ([]domain.ApiKey, error) {
+	if _, err := r.GetServiceAccount(accountId); err != nil {
+		return nil, err
+	}
+	var rows []apiKeyRow
+	query := fmt.Sprintf(`SELECT %s FROM %s k WHERE k.service_account_id = $1 ORDER BY k.id`, apiKeyColumns, apiKeysTable)
+	if err := r.db.Select(&rows, query, accountId); err != nil {
+		return nil, err
+	}
+	keys := make([]domain.ApiKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.toApiKey())
+	}
+	return keys, nil
+}
+
+// RevokeApiKey отзывает ключ. Отозванный ключ остается в списке, чтобы было видно, когда он действовал.
+func (r *ApiKeyPostgres) RevokeApiKey(keyId int, at time.Time) (domain.ApiKey, error) {
+	var row apiKeyRow
+	query := fmt.Sprintf(`UPDATE %s AS k SET revoked_at = $1 WHERE k.id = $2 AND k.revoked_at IS NULL RETURNING %s`,
+		apiKeysTable, apiKeyColumns)
+	err := r.db.QueryRowx(query, at, keyId).StructScan(&row)
+	if err == nil {
+		logger.Log.Debug().Int("id", keyId).Msg("Ключ API отозван")
+		return row.toApiKey(), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return domain.ApiKey{}, err
+	}
+	var exists bool
+	existsQuery := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1)", apiKeysTable)
+	if err = r.db.QueryRowx(existsQuery, keyId).Scan(&exists); err != nil {
+		return domain.ApiKey{}, err
+	}
+	if exists {
+		return domain.ApiKey{}, domain.ErrApiKeyRevoked
+	}
+	return domain.ApiKey{}, domain.ErrApiKeyNotFound
+}
+
+// GetApiKeyCredential возвращает ключ с указанным префиксом вместе с состоянием пользователя сервисного аккаунта.
+func (r *ApiKeyPostgres) GetApiKeyCredential(prefix string) (domain.ApiKeyCredential, error) {
+	var row struct {
+		apiKeyRow
+		KeyHash     string `db:"key_hash"`
+		UserId      int    `db:"user_id"`
+		UserBanned  bool   `db:"user_banned"`
+		UserDeleted bool   `db:"user_deleted"`
+	}
+	query := fmt.Sprintf(`SELECT %s, k.key_hash, u.id AS user_id, u.banned_at IS NOT NULL AS user_banned,
+	u.deleted_at IS NOT NULL AS user_deleted
+	FROM %s k JOIN %s sa ON sa.id = k.service_account_id JOIN %s u ON u.id = sa.user_id
+	WHERE k.prefix = $1`, apiKeyColumns, apiKeysTable, serviceAccountsTable, userListTable)
+	if err := r.db.Get(&row, query, prefix); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ApiKeyCredential{}, domain.ErrInvalidApiKey
+		}
+		return domain.ApiKeyCredential{}, err
+	}
+	return domain.ApiKeyCredential{
+		ApiKey:      row.toApiKey(),
+		KeyHash:     row.KeyHash,
+		UserId:      row.UserId,
+		UserBanned:  row.UserBanned,
+		UserDeleted: row.UserDeleted,
+	}, nil
+}
+
+// TouchApiKey отмечает использование ключа не чаще раза в минуту, чтобы не писать в базу на каждый запрос.
+func (r *ApiKeyPostgres) TouchApiKey(keyId int, at time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET last_used_at = $1
+	WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $1 - interval '1 minute')`, apiKeysTable)
+	_, err := r.db.Exec(query, at, keyId)
+	return err
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var auditRows = []string{"id", "action", "actor_id", "target_type", "target_id", "before", "after", "ip", "request_id", "created_at", "prev_hash", "hash"}
+
+func TestAuditPostgres_AppendAuditEntry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewAuditPostgres(sqlx.NewDb(db, "postgres"))
+
+	actorId := 1
+	createdAt := time.Date(2025, 3, 18, 12, 0, 0, 0, time.UTC)
+	entry := domain.AuditEntry{
+		Action: domain.AuditUserBan, ActorId: &actorId, TargetType: domain.AuditTargetUser, TargetId: "3",
+		After: json.RawMessage(`{"id":3}`), IP: "192.0.2.1", RequestId: "req-1", CreatedAt: createdAt,
+	}
+	prevHash := domain.AuditGenesisHash[:63] + "1"
+	want := entry
+	want.Id = 8
+	want.PrevHash = prevHash
+	want.Hash = want.ComputeHash()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(auditLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(fmt.Sprintf("SELECT hash FROM %s WHERE hash IS NOT NULL ORDER BY id DESC LIMIT 1", auditLogTable)).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(prevHash))
+	mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+) RETURNING id", auditLogTable)).
+		WithArgs(domain.AuditUserBan, &actorId, domain.AuditTargetUser, "3", nil, `{"id":3}`, "192.0.2.1", "req-1",
+			createdAt, prevHash, want.Hash).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	got, err := r.AppendAuditEntry(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditPostgres_AppendFirstAuditEntry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewAuditPostgres(sqlx.NewDb(db, "postgres"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(auditLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(fmt.Sprintf("SELECT hash FROM %s", auditLogTable)).WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+	mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+) RETURNING id", auditLogTable)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	got, err := r.AppendAuditEntry(domain.AuditEntry{Action: domain.AuditUserBan})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.AuditGenesisHash, got.PrevHash)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditPostgres_InsertUnchainedAuditEntry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewAuditPostgres(sqlx.NewDb(db, "postgres"))
+
+	actorId := 2
+	createdAt := time.Date(2025, 3, 24, 12, 0, 0, 0, time.UTC)
+	entry := domain.AuditEntry{
+		Action: domain.AuditLogin, ActorId: &actorId, TargetType: domain.AuditTargetUser, TargetId: "2",
+		IP: "192.0.2.1", RequestId: "req-1", CreatedAt: createdAt,
+	}
+	// без блокировки и без чтения хеша последней записи
+	mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s \\(action, actor_id, target_type, target_id, before, after, ip, request_id, created_at\\) (.+) RETURNING id", auditLogTable)).
+		WithArgs(domain.AuditLogin, &actorId, domain.AuditTargetUser, "2", nil, nil, "192.0.2.1", "req-1", createdAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+
+	got, err := r.InsertUnchainedAuditEntry(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), got.Id)
+	assert.Empty(t, got.PrevHash)
+	assert.Empty(t, got.Hash)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditPostgres_GetAuditChain(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewAuditPostgres(sqlx.NewDb(db, "postgres"))
+
+	createdAt := time.Date(2025, 3, 18, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE id > \\$1 AND hash IS NOT NULL ORDER BY id LIMIT \\$2", auditLogTable)).
+		WithArgs(int64(10), 2).
+		WillReturnRows(sqlmock.NewRows(auditRows).
+			AddRow(11, domain.AuditUserUnban, 2, domain.AuditTargetUser, "2", nil, nil, "192.0.2.1", "req-2", createdAt, "aa", "bb").
+			AddRow(12, domain.AuditUserBan, 1, domain.AuditTargetUser, "2", `{"id":2}`, `{"id":2,"ban_reason":"спам"}`,
+				"192.0.2.1", "req-3", createdAt, "bb", "cc"))
+
+	got, err := r.GetAuditChain(10, 2)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Nil(t, got[0].Before)
+	assert.Equal(t, 2, *got[0].ActorId)
+	assert.JSONEq(t, `{"id":2}`, string(got[1].Before))
+	assert.JSONEq(t, `{"id":2,"ban_reason":"спам"}`, string(got[1].After))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditPostgres_GetAuditLogUnchained(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewAuditPostgres(sqlx.NewDb(db, "postgres"))
+
+	createdAt := time.Date(2025, 3, 24, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE true AND action = \\$1 ORDER BY id DESC", auditLogTable)).
+		WithArgs(domain.AuditLogin, 50, 0).
+		WillReturnRows(sqlmock.NewRows(auditRows).
+			AddRow(9, domain.AuditLogin, 2, domain.AuditTargetUser, "2", nil, nil, "192.0.2.1", "req-1", createdAt, nil, nil))
+
+	got, err := r.GetAuditLog(domain.AuditFilter{Action: domain.AuditLogin, Limit: 50})
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Empty(t, got[0].PrevHash)
+	assert.Empty(t, got[0].Hash)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// auditLockKey - ключ advisory-блокировки, под которой записи журнала добавляются строго по очереди,
+// чтобы каждая ссылалась на хеш предыдущей.
+const auditLockKey = 7305001
+
+const auditColumns = `id, action, actor_id, target_type, target_id, before, after, ip, request_id, created_at, prev_hash, hash`
+
+// auditRow читает before и after через sql.NullString: json.RawMessage не умеет сканировать NULL.
+// prev_hash и hash пусты у записей вне цепочки.
+type auditRow struct {
+	domain.AuditEntry
+	Before   sql.NullString `db:"before"`
+	After    sql.NullString `db:"after"`
+	PrevHash sql.NullString `db:"prev_hash"`
+	Hash     sql.NullString `db:"hash"`
+}
+
+func (r auditRow) entry() domain.AuditEntry {
+	entry := r.AuditEntry
+	if r.Before.Valid {
+		entry.Before = json.RawMessage(r.Before.String)
+	}
+	if r.After.Valid {
+		entry.After = json.RawMessage(r.After.String)
+	}
+	entry.PrevHash = r.PrevHash.String
+	entry.Hash = r.Hash.String
+	return entry
+}
+
+func auditEntries(rows []auditRow) []domain.AuditEntry {
+	entries := make([]domain.AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.entry())
+	}
+	return entries
+}
+
+type AuditPostgres struct {
+	db *sqlx.DB
+}
+
+func NewAuditPostgres(db *sqlx.DB) *AuditPostgres {
+	return &AuditPostgres{
+		db: db,
+	}
+}
+
+// AppendAuditEntry дописывает запись в конец цепочки: берет хеш последней записи и вычисляет хеш новой.
+func (r *AuditPostgres) AppendAuditEntry(entry domain.AuditEntry) (domain.AuditEntry, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.AuditEntry{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	if _, err = tr.Exec(`SELECT pg_advisory_xact_lock($1)`, auditLockKey); err != nil {
+		return domain.AuditEntry{}, err
+	}
+	var prevHashes []string
+	query := fmt.Sprintf(`SELECT hash FROM %s WHERE hash IS NOT NULL ORDER BY id DESC LIMIT 1`, auditLogTable)
+	if err = tr.Select(&prevHashes, query); err != nil {
+		return domain.AuditEntry{}, err
+	}
+	entry.PrevHash = domain.AuditGenesisHash
+	if len(prevHashes) > 0 {
+		entry.PrevHash = prevHashes[0]
+	}
+	entry.Hash = entry.ComputeHash()
+
+	query = fmt.Sprintf(`INSERT INTO %s (action, actor_id, target_type, target_id, before, after, ip, request_id, created_at, prev_hash, hash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`, auditLogTable)
+	if err = tr.QueryRowx(query, entry.Action, entry.ActorId, entry.TargetType, entry.TargetId, nullJSON(entry.Before),
+		nullJSON(entry.After), entry.IP, entry.RequestId, entry.CreatedAt, entry.PrevHash, entry.Hash).Scan(&entry.Id); err != nil {
+		return domain.AuditEntry{}, err
+	}
+	return entry, tr.Commit()
+}
+
+// InsertUnchainedAuditEntry добавляет запись вне цепочки: без хеша и без блокировки журнала.
+func (r *AuditPostgres) InsertUnchainedAuditEntry(entry domain.AuditEntry) (domain.AuditEntry, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (action, actor_id, target_type, target_id, before, after, ip, request_id, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`, auditLogTable)
+	if err := r.db.QueryRowx(query, entry.Action, entry.ActorId, entry.TargetType, entry.TargetId, nullJSON(entry.Before),
+		nullJSON(entry.After), entry.IP, entry.RequestId, entry.CreatedAt).Scan(&entry.Id); err != nil {
+		return domain.AuditEntry{}, err
+	}
+	return entry, nil
+}
+
+// GetAuditLog возвращает записи журнала по фильтру от новых к старым.
+func (r *AuditPostgres) GetAuditLog(filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	conditions := []string{"true"}
+	args := []interface{}{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.ActorId != nil {
+		add("actor_id = $%d", *filter.ActorId)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetId != "" {
+		add("target_id = $%d", filter.TargetId)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY id DESC LIMIT $%d OFFSET $%d`,
+		auditColumns, auditLogTable, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	var rows []auditRow
+	if err := r.db.Select(&rows, query, args...); err != nil {
+		return nil, err
+	}
+	return auditEntries(rows), nil
+}
+
+// GetAuditChain возвращает до limit записей цепочки с id больше afterId в порядке добавления.
+func (r *AuditPostgres) GetAuditChain(afterId int64, limit int) ([]domain.AuditEntry, error) {
+	var rows []auditRow
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id > $1 AND hash IS NOT NULL ORDER BY id LIMIT $2`, auditColumns, auditLogTable)
+	if err := r.db.Select(&rows, query, afterId, limit); err != nil {
+		return nil, err
+	}
+	return auditEntries(rows), nil
+}
+
+// nullJSON сохраняет пустое значение как NULL, а не как пустую строку, которая не является корректным json.
+func nullJSON(value []byte) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
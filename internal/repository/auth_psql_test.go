@@ -6,6 +6,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 )
@@ -30,9 +31,17 @@ func TestAuthPostgres_Create(t *testing.T) {
 		{
 			name: "Ok",
 			mock: func() {
+				mock.ExpectBegin()
 				rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 				mock.ExpectQuery("INSERT INTO userlist").
-					WithArgs("username", "123", 1000).WillReturnRows(rows)
+					WithArgs("username", "123", 0).WillReturnRows(rows)
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE role", userListTable)).
+					WithArgs(domain.RoleTreasury).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(99))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", transactionsTable)).
+					WithArgs(99, 1, 1000, sqlmock.AnyArg(), domain.TransactionSignupBonus, sqlmock.AnyArg(), nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				expectCreditCoins(mock, 1, 1000)
+				mock.ExpectCommit()
 			},
 			input: domain.User{
 				UserName: "username",
@@ -41,12 +50,50 @@ func TestAuthPostgres_Create(t *testing.T) {
 			},
 			want: 1,
 		},
+		{
+			name: "Без бонуса за регистрацию",
+			mock: func() {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(2)
+				mock.ExpectQuery("INSERT INTO userlist").
+					WithArgs("username", "123", 0).WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+			input: domain.User{
+				UserName: "username",
+				Password: "123",
+				Coins:    IntPointer(0),
+			},
+			want: 2,
+		},
+		{
+			name: "Счет казны недоступен",
+			mock: func() {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(3)
+				mock.ExpectQuery("INSERT INTO userlist").
+					WithArgs("username", "123", 0).WillReturnRows(rows)
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE role", userListTable)).
+					WithArgs(domain.RoleTreasury).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery("INSERT INTO userlist").
+					WithArgs(domain.TreasuryUsername, domain.RoleTreasury).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			input: domain.User{
+				UserName: "username",
+				Password: "123",
+				Coins:    IntPointer(1000),
+			},
+			wantErr: true,
+		},
 		{
 			name: "Пустые поля вводных данных",
 			mock: func() {
+				mock.ExpectBegin()
 				rows := sqlmock.NewRows([]string{"id"})
 				mock.ExpectQuery("INSERT INTO userlist").
-					WithArgs("", "123", 1000).WillReturnRows(rows)
+					WithArgs("", "123", 0).WillReturnRows(rows)
+				mock.ExpectRollback()
 			},
 			input: domain.User{
 				UserName: "",
@@ -61,7 +108,7 @@ func TestAuthPostgres_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mock()
 
-			got, err := r.CreateUser(tt.input)
+			got, err := r.CreateUser(tt.input, nil)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -97,8 +144,8 @@ func TestAuthPostgres_SignUser(t *testing.T) {
 		{
 			name: "Ok",
 			mock: func() {
-				rows := sqlmock.NewRows([]string{"id", "username", "password"}).
-					AddRow(1, "test", "password")
+				rows := sqlmock.NewRows([]string{"id", "username", "password", "banned", "password_reset_required", "totp_enabled"}).
+					AddRow(1, "test", "password", false, false, false)
 				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s", userListTable)).
 					WithArgs("test").WillReturnRows(rows)
 			},
@@ -119,6 +166,16 @@ func TestAuthPostgres_SignUser(t *testing.T) {
 			input:   args{"not"},
 			wantErr: true,
 		},
+		{
+			name: "Пользователь сервисного аккаунта",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"id", "username", "password", "banned", "password_reset_required", "totp_enabled"})
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE username=\\$1 AND role <> '%s'", userListTable, domain.RoleService)).
+					WithArgs("svc_hr").WillReturnRows(rows)
+			},
+			input:   args{"svc_hr"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -137,6 +194,197 @@ func TestAuthPostgres_SignUser(t *testing.T) {
 	}
 }
 
+func TestAuthPostgres_GetUserRole(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "postgres")
+	r := NewAuthPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   int
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"role"}).AddRow(domain.RoleAdmin)
+				mock.ExpectQuery(fmt.Sprintf("SELECT role FROM %s", userListTable)).
+					WithArgs(1).WillReturnRows(rows)
+			},
+			input: 1,
+			want:  domain.RoleAdmin,
+		},
+		{
+			name: "Пользователь не найден",
+			mock: func() {
+				rows := sqlmock.NewRows([]string{"role"})
+				mock.ExpectQuery(fmt.Sprintf("SELECT role FROM %s", userListTable)).
+					WithArgs(2).WillReturnRows(rows)
+			},
+			input:   2,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.GetUserRole(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthPostgres_UpdateUsername(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewAuthPostgres(sqlx.NewDb(db, "postgres"))
+
+	tests := []struct {
+		name     string
+		affected int64
+		dbErr    error
+		wantErr  error
+	}{
+		{name: "OK", affected: 1},
+		{name: "Имя занято", affected: 0, wantErr: domain.ErrUsernameTaken},
+		{name: "Имя занято параллельно", dbErr: &pgconn.PgError{Code: uniqueViolation}, wantErr: domain.ErrUsernameTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect := mock.ExpectExec(fmt.Sprintf("UPDATE %s SET username = (.+)", userListTable)).
+				WithArgs("boris", 1)
+			if tt.dbErr != nil {
+				expect.WillReturnError(tt.dbErr)
+			} else {
+				expect.WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			}
+
+			err := r.UpdateUsername(1, "boris")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthPostgres_RehashPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewAuthPostgres(sqlx.NewDb(db, "postgres"))
+
+	// версия токенов не меняется, а хеш заменяется, только если пароль не успели сменить
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET password = \\$1 WHERE id = \\$2 AND password = \\$3", userListTable)).
+		WithArgs("$argon2id$new", 1, "$2a$10$old").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, r.RehashPassword(1, "$2a$10$old", "$argon2id$new"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuthPostgres_DeleteUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewAuthPostgres(sqlx.NewDb(db, "postgres"))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(30))
+				mock.ExpectQuery(fmt.Sprintf("SELECT EXISTS \\(SELECT 1 FROM %s", escrowsTable)).
+					WithArgs(domain.EscrowHeld, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET arbiter_id = NULL", escrowsTable)).
+					WithArgs(domain.EscrowHeld, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status = (.+)", paymentRequestsTable)).
+					WithArgs(domain.PaymentRequestCancelled, sqlmock.AnyArg(), domain.PaymentRequestPending, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status = (.+)", schedulesTable)).
+					WithArgs(domain.ScheduleCancelled, sqlmock.AnyArg(), domain.ScheduleActive, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE role = (.+)", userListTable)).
+					WithArgs(domain.RoleTreasury).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(99))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+					WithArgs(1, 99, 30, sqlmock.AnyArg(), domain.TransactionAccountClosed, "закрытие аккаунта").
+					WillReturnResult(sqlmock.NewResult(5, 1))
+				expectDebitCoins(mock, 1, 30)
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET username = (.+)", userListTable)).
+					WithArgs(domain.DeletedUsernamePrefix, domain.RoleDeleted, sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE user_id = \\$1", recoveryCodesTable)).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Есть переводы с удержанием",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(30))
+				mock.ExpectQuery(fmt.Sprintf("SELECT EXISTS \\(SELECT 1 FROM %s", escrowsTable)).
+					WithArgs(domain.EscrowHeld, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrAccountHasEscrows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.DeleteUser(1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func IntPointer(s int) *int {
 	return &s
 }
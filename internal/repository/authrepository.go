@@ -4,8 +4,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -19,30 +21,216 @@ func NewAuthPostgres(db *sqlx.DB) *AuthPostgres {
 	}
 }
 
-func (r *AuthPostgres) CreateUser(user domain.User) (int, error) {
+// CreateUser создает пользователя с нулевым балансом и начисляет бонус за регистрацию
+// из казны в той же транзакции, если user.Coins больше нуля. Бонус сгорает в bonusExpiresAt, если оно задано.
+func (r *AuthPostgres) CreateUser(user domain.User, bonusExpiresAt *time.Time) (int, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
 	var id int
 	query := fmt.Sprintf(`INSERT INTO %s (username,password,coins) VALUES ($1,$2,$3) RETURNING id`, userListTable)
-	row := r.db.QueryRowx(query, user.UserName, user.Password, user.Coins)
+	row := tr.QueryRowx(query, user.UserName, user.Password, 0)
 	if err := row.Scan(&id); err != nil {
 		return 0, err
 	}
-	return id, nil
+	if err := grantSignupBonus(tr, id, user.Coins, bonusExpiresAt); err != nil {
+		return 0, err
+	}
+	return id, tr.Commit()
 }
 
+// grantSignupBonus начисляет новому пользователю бонус за регистрацию со счета казны.
+func grantSignupBonus(tr *sqlx.Tx, userId int, coins *int, expiresAt *time.Time) error {
+	if coins == nil || *coins <= 0 {
+		return nil
+	}
+	treasuryId, err := treasuryUserId(tr)
+	if err != nil {
+		return err
+	}
+	_, err = issueCoins(tr, treasuryId, userId, *coins, domain.TransactionSignupBonus, "бонус за регистрацию", nil, expiresAt)
+	return err
+}
+
+// SignUser ищет пользователя для входа по паролю. Пользователи сервисных аккаунтов действуют
+// только через ключи API, для входа их как будто нет.
 func (r *AuthPostgres) SignUser(username string) (domain.User, error) {
 	var user domain.User
-	query := fmt.Sprintf(`SELECT id,username,password FROM %s WHERE username=$1`, userListTable)
+	query := fmt.Sprintf(`SELECT id,username,password,banned_at IS NOT NULL,password_reset_required,totp_enabled
+	FROM %s WHERE username=$1 AND role <> '%s'`, userListTable, domain.RoleService)
 	res := r.db.QueryRowx(query, username)
-	err := res.Scan(&user.Id, &user.UserName, &user.Password)
+	err := res.Scan(&user.Id, &user.UserName, &user.Password, &user.Banned, &user.PasswordResetRequired, &user.TwoFactorEnabled)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return domain.User{}, errors.New("пользователь не найден")
+			return domain.User{}, domain.ErrUserNotFound
 		}
 		return domain.User{}, err
 	}
 	return user, nil
 }
 
+func (r *AuthPostgres) UpdateLastLogin(userId int, at time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET last_login_at = $1 WHERE id = $2`, userListTable)
+	_, err := r.db.Exec(query, at, userId)
+	return err
+}
+
+func (r *AuthPostgres) GetUserRole(userId int) (string, error) {
+	var role string
+	query := fmt.Sprintf(`SELECT role FROM %s WHERE id=$1`, userListTable)
+	if err := r.db.QueryRowx(query, userId).Scan(&role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errors.New("пользователь не найден")
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+func (r *AuthPostgres) GetUser(userId int) (domain.User, error) {
+	var user domain.User
+	query := fmt.Sprintf(`SELECT id,username,password FROM %s WHERE id=$1 AND deleted_at IS NULL`, userListTable)
+	if err := r.db.QueryRowx(query, userId).Scan(&user.Id, &user.UserName, &user.Password); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.ErrUserNotFound
+		}
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// GetTokenState возвращает текущую версию токенов пользователя и ограничения аккаунта.
+// Токены с другой версией считаются отозванными.
+func (r *AuthPostgres) GetTokenState(userId int) (domain.TokenState, error) {
+	var state domain.TokenState
+	query := fmt.Sprintf(`SELECT token_version, banned_at IS NOT NULL AS banned, password_reset_required
+	FROM %s WHERE id=$1 AND deleted_at IS NULL`, userListTable)
+	if err := r.db.Get(&state, query, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.TokenState{}, domain.ErrUserNotFound
+		}
+		return domain.TokenState{}, err
+	}
+	return state, nil
+}
+
+// UpdatePassword сохраняет новый хеш пароля, снимает требование сменить пароль и увеличивает
+// версию токенов, отзывая выданные ранее. Возвращает новую версию токенов.
+func (r *AuthPostgres) UpdatePassword(userId int, passwordHash string) (int, error) {
+	var version int
+	query := fmt.Sprintf(`UPDATE %s SET password = $1, password_reset_required = false, token_version = token_version + 1
+	WHERE id = $2 AND deleted_at IS NULL RETURNING token_version`, userListTable)
+	if err := r.db.QueryRowx(query, passwordHash, userId).Scan(&version); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrUserNotFound
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+// RehashPassword заменяет хеш того же пароля, не отзывая токены. Если пароль успел смениться,
+// хеш не меняется.
+func (r *AuthPostgres) RehashPassword(userId int, oldHash, newHash string) error {
+	query := fmt.Sprintf(`UPDATE %s SET password = $1 WHERE id = $2 AND password = $3`, userListTable)
+	_, err := r.db.Exec(query, newHash, userId, oldHash)
+	return err
+}
+
+// UpdateUsername меняет имя пользователя. Если имя заняли параллельно, между проверкой
+// NOT EXISTS и записью, нарушение уникальности тоже возвращается как domain.ErrUsernameTaken.
+func (r *AuthPostgres) UpdateUsername(userId int, username string) error {
+	query := fmt.Sprintf(`UPDATE %s SET username = $1
+	WHERE id = $2 AND deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM %s WHERE username = $1 AND id <> $2)`, userListTable, userListTable)
+	result, err := r.db.Exec(query, username, userId)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrUsernameTaken
+		}
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrUsernameTaken
+	}
+	return nil
+}
+
+// DeleteUser анонимизирует аккаунт вместо удаления записи, чтобы переводы остались в истории
+// других пользователей. Остаток монет списывается на счет казны, ожидающие запросы на перевод
+// и расписания отменяются, выданные токены отзываются. Аккаунт с незавершенными переводами
+// с удержанием удалить нельзя.
+func (r *AuthPostgres) DeleteUser(userId int) error {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	var coins int
+	lockQuery := fmt.Sprintf("SELECT coins FROM %s WHERE id = $1 AND %s FOR UPDATE", userListTable, activeUserFilter)
+	if err = tr.QueryRowx(lockQuery, userId).Scan(&coins); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrUserNotFound
+		}
+		return err
+	}
+	var hasEscrows bool
+	escrowsQuery := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE status = $1 AND (sender_id = $2 OR recipient_id = $2))", escrowsTable)
+	if err = tr.QueryRowx(escrowsQuery, domain.EscrowHeld, userId).Scan(&hasEscrows); err != nil {
+		return err
+	}
+	if hasEscrows {
+		return domain.ErrAccountHasEscrows
+	}
+	now := time.Now()
+	arbiterQuery := fmt.Sprintf("UPDATE %s SET arbiter_id = NULL WHERE status = $1 AND arbiter_id = $2", escrowsTable)
+	if _, err = tr.Exec(arbiterQuery, domain.EscrowHeld, userId); err != nil {
+		return err
+	}
+	requestsQuery := fmt.Sprintf(`UPDATE %s SET status = $1, resolved_at = $2
+	WHERE status = $3 AND (requester_id = $4 OR payer_id = $4)`, paymentRequestsTable)
+	if _, err = tr.Exec(requestsQuery, domain.PaymentRequestCancelled, now, domain.PaymentRequestPending, userId); err != nil {
+		return err
+	}
+	schedulesQuery := fmt.Sprintf(`UPDATE %s SET status = $1, cancelled_at = $2
+	WHERE status = $3 AND (user_id = $4 OR destination_id = $4)`, schedulesTable)
+	if _, err = tr.Exec(schedulesQuery, domain.ScheduleCancelled, now, domain.ScheduleActive, userId); err != nil {
+		return err
+	}
+	if coins > 0 {
+		treasuryId, err := treasuryUserId(tr)
+		if err != nil {
+			return err
+		}
+		transactionQuery := fmt.Sprintf(`INSERT INTO %s (source, destination, amount, transaction_time, kind, message)
+		VALUES ($1, $2, $3, $4, $5, $6)`, transactionsTable)
+		if _, err = tr.Exec(transactionQuery, userId, treasuryId, coins, now, domain.TransactionAccountClosed, "закрытие аккаунта"); err != nil {
+			return err
+		}
+		if _, err = debitCoins(tr, userId, coins); err != nil {
+			return err
+		}
+	}
+	anonymiseQuery := fmt.Sprintf(`UPDATE %s SET username = $1 || id::text, password = '', role = $2, deleted_at = $3,
+	token_version = token_version + 1, totp_secret = NULL, totp_enabled = false, oidc_issuer = NULL, oidc_subject = NULL
+	WHERE id = $4`, userListTable)
+	if _, err = tr.Exec(anonymiseQuery, domain.DeletedUsernamePrefix, domain.RoleDeleted, now, userId); err != nil {
+		return err
+	}
+	if err = replaceRecoveryCodes(tr, userId, nil); err != nil {
+		return err
+	}
+	logger.Log.Debug().Int("id", userId).Msg("Успешно удален аккаунт пользователя")
+	return tr.Commit()
+}
+
 func (r *AuthPostgres) DB() *sqlx.DB {
 	return r.db
 }
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Монеты на балансе пользователя учитываются партиями: каждое поступление создает партию,
+// а списания расходуют партии от старых к новым. Партии с expires_at сгорают, если не потрачены.
+// Списание и сжигание требуют, чтобы строка пользователя уже была заблокирована в транзакции tr:
+// партии отдельно не блокируются.
+//
+// Израсходованные на покупку или удержание части партий со сроком действия запоминаются в spent_coin_lots,
+// чтобы при возврате монеты вернулись с тем же сроком, а не превратились в бессрочные.
+
+// Владельцы записей spent_coin_lots.
+const (
+	spentOnPurchase = "purchase_id"
+	spentOnEscrow   = "escrow_id"
+)
+
+// spentLot - часть партии, израсходованная списанием. ExpiresAt пуст у бессрочных партий.
+type spentLot struct {
+	Amount    int        `db:"amount"`
+	ExpiresAt *time.Time `db:"expires_at"`
+}
+
+// creditCoins зачисляет монеты на баланс пользователя новой партией.
+func creditCoins(tr *sqlx.Tx, userId, amount int, transactionId *int, expiresAt *time.Time) error {
+	depositQuery := fmt.Sprintf("UPDATE %s SET coins = coins + $1 WHERE id = $2", userListTable)
+	if _, err := tr.Exec(depositQuery, amount, userId); err != nil {
+		return err
+	}
+	lotQuery := fmt.Sprintf(`INSERT INTO %s (user_id, amount, remaining, transaction_id, expires_at)
+	VALUES ($1, $2, $2, $3, $4)`, coinLotsTable)
+	_, err := tr.Exec(lotQuery, userId, amount, transactionId, expiresAt)
+	return err
+}
+
+// debitCoins списывает монеты с баланса пользователя, расходуя партии в порядке поступления,
+// и возвращает израсходованные части партий в том же порядке.
+// Монеты, поступившие до введения партий, партиям не принадлежат и баланс не ограничивают.
+func debitCoins(tr *sqlx.Tx, userId, amount int) ([]spentLot, error) {
+	withdrawQuery := fmt.Sprintf("UPDATE %s SET coins = coins - $1 WHERE id = $2", userListTable)
+	if _, err := tr.Exec(withdrawQuery, amount, userId); err != nil {
+		return nil, err
+	}
+	var spent []spentLot
+	consumeQuery := fmt.Sprintf(`WITH consumed AS (
+		UPDATE %s l SET remaining = l.remaining - LEAST(l.remaining, $2 - o.spent_before)
+		FROM (SELECT id, remaining, SUM(remaining) OVER (ORDER BY id) - remaining AS spent_before
+			FROM %s WHERE user_id = $1 AND remaining > 0) o
+		WHERE l.id = o.id AND o.spent_before < $2
+		RETURNING l.id, LEAST(o.remaining, $2 - o.spent_before) AS amount, l.expires_at
+	)
+	SELECT amount, expires_at FROM consumed ORDER BY id`, coinLotsTable, coinLotsTable)
+	if err := tr.Select(&spent, consumeQuery, userId, amount); err != nil {
+		return nil, err
+	}
+	return spent, nil
+}
+
+// splitSpentLots делит израсходованные части партий между позициями заказа с суммами amounts
+// в порядке позиций: первая позиция оплачена первыми списанными монетами.
+func splitSpentLots(spent []spentLot, amounts []int) [][]spentLot {
+	parts := make([][]spentLot, len(amounts))
+	i, left := 0, 0
+	if len(spent) > 0 {
+		left = spent[0].Amount
+	}
+	for line, amount := range amounts {
+		for amount > 0 && i < len(spent) {
+			part := min(amount, left)
+			parts[line] = append(parts[line], spentLot{Amount: part, ExpiresAt: spent[i].ExpiresAt})
+			amount -= part
+			if left -= part; left == 0 {
+				if i++; i < len(spent) {
+					left = spent[i].Amount
+				}
+			}
+		}
+	}
+	return parts
+}
+
+// recordSpentLots запоминает части партий со сроком действия, израсходованные на покупку или удержание.
+func recordSpentLots(tr *sqlx.Tx, owner string, ownerId int, spent []spentLot) error {
+	query := fmt.Sprintf("INSERT INTO %s (%s, amount, expires_at) VALUES ($1, $2, $3)", spentCoinLotsTable, owner)
+	for _, lot := range spent {
+		if lot.ExpiresAt == nil || lot.Amount == 0 {
+			continue
+		}
+		if _, err := tr.Exec(query, ownerId, lot.Amount, *lot.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreCoins возвращает пользователю amount монет, потраченных на покупку или удержание:
+// части со сроком действия зачисляются партиями с тем же сроком, остаток - бессрочной партией.
+func restoreCoins(tr *sqlx.Tx, userId, amount int, owner string, ownerId int, transactionId *int) error {
+	var spent []spentLot
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1 RETURNING amount, expires_at", spentCoinLotsTable, owner)
+	if err := tr.Select(&spent, query, ownerId); err != nil {
+		return err
+	}
+	for _, lot := range spent {
+		part := min(lot.Amount, amount)
+		if part == 0 {
+			break
+		}
+		if err := creditCoins(tr, userId, part, transactionId, lot.ExpiresAt); err != nil {
+			return err
+		}
+		amount -= part
+	}
+	if amount == 0 {
+		return nil
+	}
+	return creditCoins(tr, userId, amount, transactionId, nil)
+}
+
+// transferSpentLots передает монеты, потраченные на удержание, получателю: записи о частях партий
+// удаляются, а получатель, как при обычном переводе, получает бессрочную партию.
+func transferSpentLots(tr *sqlx.Tx, userId, amount int, owner string, ownerId int, transactionId *int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = $1", spentCoinLotsTable, owner)
+	if _, err := tr.Exec(query, ownerId); err != nil {
+		return err
+	}
+	// переведенные монеты не сгорают: срок действия есть только у монет, выпущенных казной
+	return creditCoins(tr, userId, amount, transactionId, nil)
+}
+
+// expireCoins обнуляет просроченные партии пользователя и возвращает сгоревшую сумму.
+func expireCoins(tr *sqlx.Tx, userId int, now time.Time) (int, error) {
+	var expired int
+	query := fmt.Sprintf(`WITH expired AS (
+		SELECT id, remaining FROM %s WHERE user_id = $1 AND remaining > 0 AND expires_at <= $2
+	), cleared AS (
+		UPDATE %s l SET remaining = 0 FROM expired e WHERE l.id = e.id
+	)
+	SELECT COALESCE(SUM(remaining), 0) FROM expired`, coinLotsTable, coinLotsTable)
+	if err := tr.QueryRowx(query, userId, now).Scan(&expired); err != nil {
+		return 0, err
+	}
+	if expired == 0 {
+		return 0, nil
+	}
+	withdrawQuery := fmt.Sprintf("UPDATE %s SET coins = coins - $1 WHERE id = $2", userListTable)
+	if _, err := tr.Exec(withdrawQuery, expired, userId); err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectCreditCoins(mock sqlmock.Sqlmock, userId, amount int) {
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET coins = coins \\+ (.+)", userListTable)).
+		WithArgs(amount, userId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s (.+)", coinLotsTable)).
+		WithArgs(userId, amount, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func expectDebitCoins(mock sqlmock.Sqlmock, userId, amount int) {
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET coins = coins - (.+)", userListTable)).
+		WithArgs(amount, userId).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(fmt.Sprintf("WITH consumed AS \\(\\s+UPDATE %s l SET remaining = l.remaining - LEAST", coinLotsTable)).
+		WithArgs(userId, amount).
+		WillReturnRows(sqlmock.NewRows([]string{"amount", "expires_at"}))
+}
+
+func expectRestoreCoins(mock sqlmock.Sqlmock, owner string, ownerId int) {
+	mock.ExpectQuery(fmt.Sprintf("DELETE FROM %s WHERE %s = (.+) RETURNING amount, expires_at", spentCoinLotsTable, owner)).
+		WithArgs(ownerId).
+		WillReturnRows(sqlmock.NewRows([]string{"amount", "expires_at"}))
+}
+
+func TestCoinLots_expireCoins(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+	now := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		mock func()
+		want int
+	}{
+		{
+			name: "Монеты сгорели",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("WITH expired AS (.+) FROM %s WHERE user_id = (.+)", coinLotsTable)).
+					WithArgs(2, now).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(150))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET coins = coins - (.+)", userListTable)).
+					WithArgs(150, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: 150,
+		},
+		{
+			name: "Просроченных партий нет",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("WITH expired AS (.+) FROM %s WHERE user_id = (.+)", coinLotsTable)).
+					WithArgs(2, now).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+				mock.ExpectCommit()
+			},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			tr, err := sqlxDB.Beginx()
+			assert.NoError(t, err)
+			got, err := expireCoins(tr, 2, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, tr.Commit())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCoinLots_splitSpentLots(t *testing.T) {
+	expiresAt := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	spent := []spentLot{{Amount: 30, ExpiresAt: &expiresAt}, {Amount: 50}}
+
+	got := splitSpentLots(spent, []int{20, 60})
+	assert.Equal(t, [][]spentLot{
+		{{Amount: 20, ExpiresAt: &expiresAt}},
+		{{Amount: 10, ExpiresAt: &expiresAt}, {Amount: 50}},
+	}, got)
+}
+
+func TestCoinLots_restoreCoins(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+	expiresAt := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	transactionId := 7
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf("DELETE FROM %s WHERE %s = (.+) RETURNING amount, expires_at", spentCoinLotsTable, spentOnPurchase)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"amount", "expires_at"}).AddRow(30, expiresAt))
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET coins = coins \\+ (.+)", userListTable)).
+		WithArgs(30, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s (.+)", coinLotsTable)).
+		WithArgs(1, 30, &transactionId, &expiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectCreditCoins(mock, 1, 10)
+	mock.ExpectCommit()
+
+	tr, err := sqlxDB.Beginx()
+	assert.NoError(t, err)
+	assert.NoError(t, restoreCoins(tr, 1, 40, spentOnPurchase, 3, &transactionId))
+	assert.NoError(t, tr.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
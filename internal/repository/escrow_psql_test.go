@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var escrowRowColumns = []string{"id", "sender_id", "sender_username", "recipient_id", "recipient_username",
+	"arbiter_id", "arbiter_username", "amount", "message", "status", "on_timeout", "transaction_id",
+	"created_at", "expires_at", "resolved_at", "resolved_by"}
+
+func TestEscrowPostgres_CreateEscrow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewEscrowPostgres(sqlx.NewDb(db, "postgres"))
+
+	createdAt := time.Date(2025, 3, 13, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(48 * time.Hour)
+	arbiter := "vera"
+	input := domain.Escrow{SenderId: 1, RecipientUsername: "boris", ArbiterUsername: &arbiter, Amount: 100,
+		Message: "пари", OnTimeout: domain.EscrowActionReturn, CreatedAt: createdAt, ExpiresAt: expiresAt}
+	arbiterId := 3
+
+	tests := []struct {
+		name    string
+		mock    func()
+		limits  domain.TransferLimits
+		want    domain.Escrow
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("boris").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("vera").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(500))
+				expectDebitCoins(mock, 1, 100)
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", escrowsTable)).
+					WithArgs(1, 2, &arbiterId, 100, "пари", domain.EscrowHeld, domain.EscrowActionReturn, createdAt, expiresAt).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectCommit()
+			},
+			want: domain.Escrow{Id: 7, SenderId: 1, RecipientId: 2, RecipientUsername: "boris", ArbiterId: &arbiterId,
+				ArbiterUsername: &arbiter, Amount: 100, Message: "пари", Status: domain.EscrowHeld,
+				OnTimeout: domain.EscrowActionReturn, CreatedAt: createdAt, ExpiresAt: expiresAt},
+		},
+		{
+			name: "Недостаточно монет",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("boris").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("vera").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(50))
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrInsufficientCoins,
+		},
+		{
+			name: "Арбитр - участник перевода",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("boris").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("vera").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrInvalidEscrow,
+		},
+		{
+			name:   "Превышен дневной лимит отправителя",
+			limits: domain.TransferLimits{DailyOutbound: 150},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("boris").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("vera").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(500))
+				mock.ExpectQuery(fmt.Sprintf("SELECT \\(SELECT COALESCE(.+) FROM %s WHERE source = (.+) FROM %s WHERE sender_id = (.+)", transactionsTable, escrowsTable)).
+					WithArgs(1, domain.TransactionTransfer, createdAt.Add(-24*time.Hour), domain.EscrowReturned).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(60))
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrDailySendLimit,
+		},
+		{
+			name: "Получатель не найден",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("boris").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CreateEscrow(input, tt.limits)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestEscrowPostgres_SettleEscrow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewEscrowPostgres(sqlx.NewDb(db, "postgres"))
+
+	createdAt := time.Date(2025, 3, 13, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(48 * time.Hour)
+	resolvedAt := createdAt.Add(time.Hour)
+	resolvedBy := 1
+	transactionId := 42
+
+	tests := []struct {
+		name       string
+		action     string
+		resolvedBy *int
+		mock       func()
+		limits     domain.TransferLimits
+		want       domain.Escrow
+		wantErr    error
+	}{
+		{
+			name:       "Выпуск получателю",
+			action:     domain.EscrowActionRelease,
+			resolvedBy: &resolvedBy,
+			limits:     domain.TransferLimits{DailyInbound: 200, MaxBalance: 150},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT sender_id, recipient_id, amount, message FROM %s WHERE (.+) FOR UPDATE", escrowsTable)).
+					WithArgs(7, domain.EscrowHeld).
+					WillReturnRows(sqlmock.NewRows([]string{"sender_id", "recipient_id", "amount", "message"}).AddRow(1, 2, 100, "пари"))
+				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(50))
+				mock.ExpectQuery(fmt.Sprintf("SELECT COALESCE(.+) FROM %s WHERE destination = (.+)", transactionsTable)).
+					WithArgs(2, domain.TransactionTransfer, sqlmock.AnyArg(), domain.TransactionEscrow).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(100))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+					WithArgs(1, 2, 100, sqlmock.AnyArg(), domain.TransactionEscrow, "пари").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(transactionId))
+				// части партий, потраченные на удержание, переходят к получателю и больше не хранятся
+				mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE %s = (.+)", spentCoinLotsTable, spentOnEscrow)).
+					WithArgs(7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectCreditCoins(mock, 2, 100)
+				mock.ExpectQuery(fmt.Sprintf("WITH updated AS \\(\\s+UPDATE %s", escrowsTable)).
+					WithArgs(domain.EscrowReleased, &transactionId, sqlmock.AnyArg(), &resolvedBy, 7).
+					WillReturnRows(sqlmock.NewRows(escrowRowColumns).
+						AddRow(7, 1, "anna", 2, "boris", nil, nil, 100, "пари", domain.EscrowReleased, domain.EscrowActionReturn,
+							transactionId, createdAt, expiresAt, resolvedAt, resolvedBy))
+				mock.ExpectCommit()
+			},
+			want: domain.Escrow{Id: 7, SenderId: 1, SenderUsername: "anna", RecipientId: 2, RecipientUsername: "boris",
+				Amount: 100, Message: "пари", Status: domain.EscrowReleased, OnTimeout: domain.EscrowActionReturn,
+				TransactionId: &transactionId, CreatedAt: createdAt, ExpiresAt: expiresAt, ResolvedAt: &resolvedAt, ResolvedBy: &resolvedBy},
+		},
+		{
+			name:   "Возврат по сроку",
+			action: domain.EscrowActionReturn,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT sender_id, recipient_id, amount, message FROM %s WHERE (.+) FOR UPDATE", escrowsTable)).
+					WithArgs(7, domain.EscrowHeld).
+					WillReturnRows(sqlmock.NewRows([]string{"sender_id", "recipient_id", "amount", "message"}).AddRow(1, 2, 100, ""))
+				expectRestoreCoins(mock, spentOnEscrow, 7)
+				expectCreditCoins(mock, 1, 100)
+				mock.ExpectQuery(fmt.Sprintf("WITH updated AS \\(\\s+UPDATE %s", escrowsTable)).
+					WithArgs(domain.EscrowReturned, nil, sqlmock.AnyArg(), nil, 7).
+					WillReturnRows(sqlmock.NewRows(escrowRowColumns).
+						AddRow(7, 1, "anna", 2, "boris", nil, nil, 100, "", domain.EscrowReturned, domain.EscrowActionReturn,
+							nil, createdAt, expiresAt, resolvedAt, nil))
+				mock.ExpectCommit()
+			},
+			want: domain.Escrow{Id: 7, SenderId: 1, SenderUsername: "anna", RecipientId: 2, RecipientUsername: "boris",
+				Amount: 100, Status: domain.EscrowReturned, OnTimeout: domain.EscrowActionReturn,
+				CreatedAt: createdAt, ExpiresAt: expiresAt, ResolvedAt: &resolvedAt},
+		},
+		{
+			name:       "Баланс получателя превысит максимум",
+			action:     domain.EscrowActionRelease,
+			resolvedBy: &resolvedBy,
+			limits:     domain.TransferLimits{MaxBalance: 120},
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT sender_id, recipient_id, amount, message FROM %s WHERE (.+) FOR UPDATE", escrowsTable)).
+					WithArgs(7, domain.EscrowHeld).
+					WillReturnRows(sqlmock.NewRows([]string{"sender_id", "recipient_id", "amount", "message"}).AddRow(1, 2, 100, "пари"))
+				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(50))
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrBalanceCapExceeded,
+		},
+		{
+			name:   "Уже завершен",
+			action: domain.EscrowActionReturn,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT sender_id, recipient_id, amount, message FROM %s WHERE (.+) FOR UPDATE", escrowsTable)).
+					WithArgs(7, domain.EscrowHeld).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrEscrowResolved,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.SettleEscrow(7, tt.action, tt.resolvedBy, tt.limits)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type EscrowPostgres struct {
+	db *sqlx.DB
+}
+
+func NewEscrowPostgres(db *sqlx.DB) *EscrowPostgres {
+	return &EscrowPostgres{
+		db: db,
+	}
+}
+
+// escrowColumns перечисляет поля перевода с удержанием для запросов с псевдонимами
+// e (escrows), присоединенных через escrowJoins.
+const escrowColumns = `e.id, e.sender_id, su.username AS sender_username, e.recipient_id, ru.username AS recipient_username,
+	e.arbiter_id, au.username AS arbiter_username, e.amount, e.message, e.status, e.on_timeout, e.transaction_id,
+	e.created_at, e.expires_at, e.resolved_at, e.resolved_by`
+
+const escrowJoins = `JOIN userlist su ON e.sender_id = su.id JOIN userlist ru ON e.recipient_id = ru.id
+	LEFT JOIN userlist au ON e.arbiter_id = au.id`
+
+// CreateEscrow списывает монеты у отправителя и создает удержание в одной транзакции.
+// Дневной лимит отправителя и пауза между переводами проверяются при создании,
+// лимиты получателя - при зачислении монет.
+func (r *EscrowPostgres) CreateEscrow(escrow domain.Escrow, limits domain.TransferLimits) (domain.Escrow, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.Escrow{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	if escrow.RecipientId, err = escrowParticipantId(tr, escrow.RecipientUsername); err != nil {
+		return domain.Escrow{}, err
+	}
+	if escrow.RecipientId == escrow.SenderId {
+		return domain.Escrow{}, domain.ErrSelfTransfer
+	}
+	if escrow.ArbiterUsername != nil {
+		arbiterId, err := escrowParticipantId(tr, *escrow.ArbiterUsername)
+		if err != nil {
+			return domain.Escrow{}, err
+		}
+		if arbiterId == escrow.SenderId || arbiterId == escrow.RecipientId {
+			return domain.Escrow{}, fmt.Errorf("%w: арбитр не может быть участником перевода", domain.ErrInvalidEscrow)
+		}
+		escrow.ArbiterId = &arbiterId
+	}
+	var balance int
+	balanceQuery := fmt.Sprintf("SELECT coins FROM %s WHERE id = $1 FOR UPDATE", userListTable)
+	if err = tr.QueryRowx(balanceQuery, escrow.SenderId).Scan(&balance); err != nil {
+		return domain.Escrow{}, err
+	}
+	if balance < escrow.Amount {
+		return domain.Escrow{}, domain.ErrInsufficientCoins
+	}
+	if err = checkDailyLimit(tr, "source", escrow.SenderId, escrow.Amount, escrow.CreatedAt, limits.DailyOutbound); err != nil {
+		return domain.Escrow{}, err
+	}
+	if err = checkTransferCooldown(tr, escrow.SenderId, escrow.RecipientId, escrow.CreatedAt, limits.Cooldown); err != nil {
+		return domain.Escrow{}, err
+	}
+	spent, err := debitCoins(tr, escrow.SenderId, escrow.Amount)
+	if err != nil {
+		return domain.Escrow{}, err
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (sender_id, recipient_id, arbiter_id, amount, message, status, on_timeout, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`, escrowsTable)
+	err = tr.QueryRowx(query, escrow.SenderId, escrow.RecipientId, escrow.ArbiterId, escrow.Amount, escrow.Message,
+		domain.EscrowHeld, escrow.OnTimeout, escrow.CreatedAt, escrow.ExpiresAt).Scan(&escrow.Id)
+	if err != nil {
+		return domain.Escrow{}, err
+	}
+	if err = recordSpentLots(tr, spentOnEscrow, escrow.Id, spent); err != nil {
+		return domain.Escrow{}, err
+	}
+	escrow.Status = domain.EscrowHeld
+	logger.Log.Debug().Int("id", escrow.Id).Msg("Успешно создан перевод с удержанием")
+	return escrow, tr.Commit()
+}
+
+func (r *EscrowPostgres) GetEscrows(userId int, status string) ([]domain.Escrow, error) {
+	escrows := []domain.Escrow{}
+	query := fmt.Sprintf(`SELECT %s FROM %s e %s
+	WHERE (e.sender_id = $1 OR e.recipient_id = $1 OR e.arbiter_id = $1) AND ($2 = '' OR e.status = $2)
+	ORDER BY e.created_at DESC, e.id DESC`, escrowColumns, escrowsTable, escrowJoins)
+	if err := r.db.Select(&escrows, query, userId, status); err != nil {
+		return nil, err
+	}
+	logger.Log.Debug().Int("count", len(escrows)).Msg("Успешно получены переводы с удержанием")
+	return escrows, nil
+}
+
+func (r *EscrowPostgres) GetEscrow(escrowId int) (domain.Escrow, error) {
+	var escrow domain.Escrow
+	query := fmt.Sprintf("SELECT %s FROM %s e %s WHERE e.id = $1", escrowColumns, escrowsTable, escrowJoins)
+	if err := r.db.Get(&escrow, query, escrowId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Escrow{}, domain.ErrEscrowNotFound
+		}
+		return domain.Escrow{}, err
+	}
+	return escrow, nil
+}
+
+// GetExpiredEscrows возвращает удержания, срок которых истек к моменту now.
+func (r *EscrowPostgres) GetExpiredEscrows(now time.Time) ([]domain.Escrow, error) {
+	escrows := []domain.Escrow{}
+	query := fmt.Sprintf(`SELECT %s FROM %s e %s
+	WHERE e.status = $1 AND e.expires_at <= $2 ORDER BY e.expires_at, e.id`, escrowColumns, escrowsTable, escrowJoins)
+	if err := r.db.Select(&escrows, query, domain.EscrowHeld, now); err != nil {
+		return nil, err
+	}
+	return escrows, nil
+}
+
+// SettleEscrow завершает удержание действием action: release зачисляет монеты получателю партией,
+// как обычный перевод, и записывает перевод, return возвращает их отправителю с прежним сроком действия.
+// В обоих случаях записи о потраченных на удержание частях партий удаляются в той же транзакции.
+// resolvedBy пуст при завершении по сроку. Перед зачислением проверяются дневной лимит
+// и максимальный баланс получателя.
+func (r *EscrowPostgres) SettleEscrow(escrowId int, action string, resolvedBy *int, limits domain.TransferLimits) (domain.Escrow, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.Escrow{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	var senderId, recipientId, amount int
+	var message string
+	lockQuery := fmt.Sprintf("SELECT sender_id, recipient_id, amount, message FROM %s WHERE id = $1 AND status = $2 FOR UPDATE", escrowsTable)
+	if err = tr.QueryRowx(lockQuery, escrowId, domain.EscrowHeld).Scan(&senderId, &recipientId, &amount, &message); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Escrow{}, domain.ErrEscrowResolved
+		}
+		return domain.Escrow{}, err
+	}
+	now := time.Now()
+	status := domain.EscrowReturned
+	var transactionId *int
+	if action == domain.EscrowActionRelease {
+		status = domain.EscrowReleased
+		var balance int
+		balanceQuery := fmt.Sprintf("SELECT coins FROM %s WHERE id = $1 FOR UPDATE", userListTable)
+		if err = tr.QueryRowx(balanceQuery, recipientId).Scan(&balance); err != nil {
+			return domain.Escrow{}, err
+		}
+		if limits.MaxBalance > 0 && balance+amount > limits.MaxBalance {
+			return domain.Escrow{}, domain.ErrBalanceCapExceeded
+		}
+		if err = checkDailyLimit(tr, "destination", recipientId, amount, now, limits.DailyInbound); err != nil {
+			return domain.Escrow{}, err
+		}
+		var id int
+		transactionQuery := fmt.Sprintf(`INSERT INTO %s (source, destination, amount, transaction_time, kind, message)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`, transactionsTable)
+		if err = tr.QueryRowx(transactionQuery, senderId, recipientId, amount, now, domain.TransactionEscrow, message).Scan(&id); err != nil {
+			return domain.Escrow{}, err
+		}
+		transactionId = &id
+		err = transferSpentLots(tr, recipientId, amount, spentOnEscrow, escrowId, transactionId)
+	} else {
+		err = restoreCoins(tr, senderId, amount, spentOnEscrow, escrowId, nil)
+	}
+	if err != nil {
+		return domain.Escrow{}, err
+	}
+	var escrow domain.Escrow
+	query := fmt.Sprintf(`WITH updated AS (
+		UPDATE %s SET status = $1, transaction_id = $2, resolved_at = $3, resolved_by = $4 WHERE id = $5 RETURNING *
+	)
+	SELECT %s FROM updated e %s`, escrowsTable, escrowColumns, escrowJoins)
+	if err = tr.Get(&escrow, query, status, transactionId, now, resolvedBy, escrowId); err != nil {
+		return domain.Escrow{}, err
+	}
+	logger.Log.Debug().Int("id", escrowId).Str("status", status).Msg("Успешно завершен перевод с удержанием")
+	return escrow, tr.Commit()
+}
+
+func escrowParticipantId(tr *sqlx.Tx, username string) (int, error) {
+	var id int
+	query := fmt.Sprintf("SELECT id FROM %s WHERE username = $1 AND %s", userListTable, activeUserFilter)
+	if err := tr.QueryRowx(query, username).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrUserNotFound
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *EscrowPostgres) DB() *sqlx.DB {
+	return r.db
+}
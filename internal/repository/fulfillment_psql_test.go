@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFulfillmentPostgres_UpdatePurchaseStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewFulfillmentPostgres(sqlx.NewDb(db, "postgres"))
+
+	purchaseDate := time.Date(2025, 2, 5, 12, 0, 0, 0, time.UTC)
+	packedAt := purchaseDate.Add(time.Hour)
+	columns := []string{"id", "user_id", "item_name", "price", "quantity", "purchase_date", "status", "packed_at"}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		mock    func()
+		want    domain.Purchase
+		wantErr error
+	}{
+		{
+			name: "OK",
+			from: domain.PurchasePending,
+			to:   domain.PurchasePacked,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET status = (.+), packed_at = (.+) WHERE id = (.+) AND status = (.+)", purchaseTable)).
+					WithArgs(domain.PurchasePacked, sqlmock.AnyArg(), 3, domain.PurchasePending).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(3, 1, "cup", 20, 1, purchaseDate, domain.PurchasePacked, packedAt))
+			},
+			want: domain.Purchase{
+				Id: 3, UserId: 1, ItemName: "cup", Price: 20, Quantity: 1, PurchaseDate: purchaseDate,
+				Status: domain.PurchasePacked, PackedAt: &packedAt,
+			},
+		},
+		{
+			name: "Статус уже изменен",
+			from: domain.PurchasePacked,
+			to:   domain.PurchaseShipped,
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET status = (.+), shipped_at = (.+)", purchaseTable)).
+					WithArgs(domain.PurchaseShipped, sqlmock.AnyArg(), 3, domain.PurchasePacked).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			wantErr: domain.ErrInvalidStatusTransition,
+		},
+		{
+			name:    "Статус без колонки времени",
+			from:    domain.PurchasePending,
+			to:      domain.PurchaseCancelled,
+			mock:    func() {},
+			wantErr: domain.ErrInvalidStatusTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.UpdatePurchaseStatus(3, tt.from, tt.to)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type FulfillmentPostgres struct {
+	db *sqlx.DB
+}
+
+func NewFulfillmentPostgres(db *sqlx.DB) *FulfillmentPostgres {
+	return &FulfillmentPostgres{
+		db: db,
+	}
+}
+
+// statusTimestampColumns сопоставляет статус покупки с колонкой времени перехода в него.
+var statusTimestampColumns = map[string]string{
+	domain.PurchasePacked:  "packed_at",
+	domain.PurchaseShipped: "shipped_at",
+}
+
+func (r *FulfillmentPostgres) GetPurchasesByStatus(status string) ([]domain.Purchase, error) {
+	purchases := []domain.Purchase{}
+	query := fmt.Sprintf(`SELECT %s
+	FROM %s p
+	%s
+	WHERE p.status = $1
+	ORDER BY p.purchase_date, p.id`, purchaseColumns, purchaseTable, purchaseJoins)
+	if err := r.db.Select(&purchases, query, status); err != nil {
+		return nil, err
+	}
+	return purchases, nil
+}
+
+// UpdatePurchaseStatus переводит покупку из статуса from в статус to. Условие на текущий
+// статус защищает от параллельного изменения: если покупка уже сменила статус, возвращается ErrInvalidStatusTransition.
+func (r *FulfillmentPostgres) UpdatePurchaseStatus(purchaseId int, from, to string) (domain.Purchase, error) {
+	column, ok := statusTimestampColumns[to]
+	if !ok {
+		return domain.Purchase{}, domain.ErrInvalidStatusTransition
+	}
+	var purchase domain.Purchase
+	query := fmt.Sprintf(`WITH p AS (
+		UPDATE %s SET status = $1, %s = $2
+		WHERE id = $3 AND status = $4 AND refunded_at IS NULL
+		RETURNING *
+	)
+	SELECT %s FROM p %s`, purchaseTable, column, purchaseColumns, purchaseJoins)
+	if err := r.db.Get(&purchase, query, to, time.Now(), purchaseId, from); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Purchase{}, domain.ErrInvalidStatusTransition
+		}
+		return domain.Purchase{}, err
+	}
+	logger.Log.Debug().Int("id", purchaseId).Str("status", to).Msg("Успешно изменен статус покупки")
+	return purchase, nil
+}
+
+func (r *FulfillmentPostgres) DB() *sqlx.DB {
+	return r.db
+}
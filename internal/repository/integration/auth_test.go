@@ -85,7 +85,7 @@ func (suite *AuthRepoTestSuite) TestCreateUser() {
 	//	"name", 1000, "password123")
 	//assert.NoError(t, err)
 
-	createUser, err := suite.repository.CreateUser(input)
+	createUser, err := suite.repository.CreateUser(input, nil)
 	if err != nil {
 		t.Fatalf("Failed to create user: %s", err)
 	}
@@ -99,6 +99,14 @@ func (suite *AuthRepoTestSuite) TestCreateUser() {
 	assert.Equal(t, input.UserName, inputCheck.UserName)
 	assert.Equal(t, input.Password, inputCheck.Password)
 
+	var coins, bonuses int
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = $1", createUser).Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, coins)
+	err = suite.repository.DB().QueryRow(`SELECT COUNT(*) FROM transactions t JOIN userlist u ON u.id = t.source
+	WHERE t.destination = $1 AND t.kind = $2 AND u.role = $3`, createUser, domain.TransactionSignupBonus, domain.RoleTreasury).Scan(&bonuses)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bonuses)
 }
 
 func (suite *AuthRepoTestSuite) TestGetUser() {
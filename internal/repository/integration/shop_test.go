@@ -2,13 +2,18 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/repository"
+	"github.com/bllooop/coinshop/internal/usecase"
 	logger "github.com/bllooop/coinshop/pkg/logging"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
@@ -67,7 +72,7 @@ func (suite *ShopRepoTestSuite) SetupSuite() {
 
 }
 func (suite *ShopRepoTestSuite) SetupTest() {
-	_, err := suite.repository.DB().Exec("TRUNCATE TABLE userlist, transactions, purchases, shop RESTART IDENTITY CASCADE")
+	_, err := suite.repository.DB().Exec("TRUNCATE TABLE userlist, transactions, purchases, orders, refunds, promotions, shop RESTART IDENTITY CASCADE")
 	assert.NoError(suite.T(), err)
 }
 func (suite *ShopRepoTestSuite) TearDownSuite() {
@@ -89,9 +94,11 @@ func (suite *ShopRepoTestSuite) TestSendingCoin() {
 		DestinationUsername: "name",
 		Destination:         IntPointer(1),
 		Amount:              10,
+		Message:             "спасибо за помощь",
+		Category:            domain.TransferThanks,
 		Timestamp:           func() *time.Time { t := time.Now(); return &t }(),
 	}
-	sendCoin, err := suite.repository.SendCoin(input)
+	sendCoin, err := suite.repository.SendCoin(input, domain.TransferLimits{})
 	if err != nil {
 		t.Fatalf("Failed to send coin: %s", err)
 	}
@@ -113,6 +120,518 @@ func (suite *ShopRepoTestSuite) TestSendingCoin() {
 	assert.NoError(t, err)
 	assert.Equal(t, 990, recipientCoins)
 
+	incoming, err := suite.repository.GetTransactions(1, domain.TransactionFilter{
+		Limit:     10,
+		Direction: domain.DirectionIn,
+		Category:  domain.TransferThanks,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, incoming, 1)
+	assert.Equal(t, "спасибо за помощь", incoming[0].Message)
+	assert.Equal(t, "name2", *incoming[0].SourceUsername)
+	outgoing, err := suite.repository.GetTransactions(1, domain.TransactionFilter{Limit: 10, Direction: domain.DirectionOut})
+	assert.NoError(t, err)
+	assert.Empty(t, outgoing)
+}
+
+func (suite *ShopRepoTestSuite) TestSendingCoinLimits() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"name", 1000, "password123", "name2", 1000, "password123")
+	assert.NoError(t, err)
+	limits := domain.TransferLimits{DailyOutbound: 100, DailyInbound: 150, Cooldown: time.Minute}
+	now := time.Now()
+	send := func(source int, destination string, amount int, at time.Time) error {
+		_, err := suite.repository.SendCoin(domain.Transactions{
+			Source:              IntPointer(source),
+			DestinationUsername: destination,
+			Amount:              amount,
+			Timestamp:           &at,
+		}, limits)
+		return err
+	}
+
+	assert.NoError(t, send(1, "name2", 60, now.Add(-2*time.Minute)))
+	assert.ErrorIs(t, send(1, "name2", 10, now.Add(-90*time.Second)), domain.ErrTransferCooldown)
+	assert.ErrorIs(t, send(1, "name2", 50, now), domain.ErrDailySendLimit)
+	assert.NoError(t, send(1, "name2", 40, now))
+	assert.ErrorIs(t, send(1, "name", 10, now), domain.ErrSelfTransfer)
+	assert.ErrorIs(t, send(1, "ghost", 10, now), domain.ErrUserNotFound)
+
+	var coins int
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = 1").Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 900, coins)
+}
+
+func (suite *ShopRepoTestSuite) TestRunningScheduledTransfers() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"lead", 120, "password123", "intern", 0, "password123")
+	assert.NoError(t, err)
+	repos := repository.NewRepository(suite.db)
+	schedules := usecase.NewScheduleUsecase(repos, usecase.NewShopUsecase(repos, domain.TransferLimits{}))
+
+	now := time.Now().UTC()
+	weekly, err := repos.CreateSchedule(domain.ScheduledTransfer{
+		UserId: 1, DestinationUsername: "intern", Amount: 50, Category: domain.TransferGift,
+		Interval: "168h0m0s", NextRunAt: now.Add(-time.Minute), CreatedAt: now,
+	})
+	assert.NoError(t, err)
+	tooLarge, err := repos.CreateSchedule(domain.ScheduledTransfer{
+		UserId: 1, DestinationUsername: "intern", Amount: 500, Cron: "@daily", NextRunAt: now.Add(-time.Minute), CreatedAt: now,
+	})
+	assert.NoError(t, err)
+
+	executed, err := schedules.RunDueSchedules(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, executed)
+	// повторный проход не выполняет уже перенесенные расписания
+	executed, err = schedules.RunDueSchedules(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, executed)
+
+	runs, err := repos.GetScheduleRuns(weekly.Id)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1)
+	assert.NotNil(t, runs[0].TransactionId)
+	failed, err := repos.GetScheduleRuns(tooLarge.Id)
+	assert.NoError(t, err)
+	assert.Len(t, failed, 1)
+	assert.Nil(t, failed[0].TransactionId)
+	assert.Equal(t, domain.ErrInsufficientCoins.Error(), failed[0].Error)
+
+	updated, err := repos.GetSchedule(1, weekly.Id)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, now.Add(-time.Minute).Add(168*time.Hour), updated.NextRunAt, time.Second)
+	var coins int
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE username = 'intern'").Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, coins)
+}
+
+func (suite *ShopRepoTestSuite) TestSendingCoinBatch() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6), ($7, $8, $9)",
+		"lead", 100, "password123", "anna", 0, "password123", "boris", 0, "password123")
+	assert.NoError(t, err)
+	shop := usecase.NewShopUsecase(repository.NewRepository(suite.db), domain.TransferLimits{})
+	transfers := []domain.Transactions{
+		{DestinationUsername: "anna", Amount: 40},
+		{DestinationUsername: "ghost", Amount: 10},
+		{DestinationUsername: "boris", Amount: 40},
+	}
+	coinsOf := func(username string) int {
+		var coins int
+		err := suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE username = $1", username).Scan(&coins)
+		assert.NoError(t, err)
+		return coins
+	}
+
+	atomic, err := shop.SendCoinBatch(1, domain.BatchTransferInput{Transfers: transfers})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, atomic.Succeeded)
+	assert.Equal(t, 3, atomic.Failed)
+	assert.Equal(t, domain.ErrUserNotFound.Error(), atomic.Results[1].Error)
+	assert.Equal(t, domain.ErrBatchAborted.Error(), atomic.Results[0].Error)
+	assert.Equal(t, 100, coinsOf("lead"))
+	assert.Equal(t, 0, coinsOf("anna"))
+
+	bestEffort, err := shop.SendCoinBatch(1, domain.BatchTransferInput{Mode: domain.BatchBestEffort, Transfers: transfers})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, bestEffort.Succeeded)
+	assert.Equal(t, 1, bestEffort.Failed)
+	assert.NotNil(t, bestEffort.Results[2].TransactionId)
+	assert.Equal(t, 20, coinsOf("lead"))
+	assert.Equal(t, 40, coinsOf("boris"))
+}
+
+func (suite *ShopRepoTestSuite) TestTreasuryAdjustments() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec(`INSERT INTO userlist (username, coins, password, role, last_login_at)
+	VALUES ($1, $2, $3, 'admin', now()), ($4, $5, $6, 'user', now()), ($7, $8, $9, 'user', NULL)`,
+		"admin", 0, "password123", "name", 100, "password123", "idle", 100, "password123")
+	assert.NoError(t, err)
+	treasury := repository.NewTreasuryPostgres(suite.db)
+
+	_, err = treasury.GrantCoins(1, domain.CoinAdjustmentInput{Username: "name", Amount: 200, Reason: "бонус"}, nil)
+	assert.NoError(t, err)
+	_, err = treasury.ClawbackCoins(1, domain.CoinAdjustmentInput{Username: "name", Amount: 50, Reason: "ошибка"})
+	assert.NoError(t, err)
+	_, err = treasury.ClawbackCoins(1, domain.CoinAdjustmentInput{Username: "name", Amount: 1000, Reason: "ошибка"})
+	assert.ErrorIs(t, err, domain.ErrInsufficientCoins)
+	_, err = treasury.GrantCoins(1, domain.CoinAdjustmentInput{Username: domain.TreasuryUsername, Amount: 10, Reason: "ошибка"}, nil)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	activeSince := time.Now().Add(-time.Hour)
+	paid, err := treasury.PayAllowance("2025-03", 30, activeSince, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, paid)
+	paid, err = treasury.PayAllowance("2025-03", 30, activeSince, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, paid)
+
+	var coins int
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE username = 'name'").Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 280, coins)
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE username = 'idle'").Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, coins)
+}
+
+func (suite *ShopRepoTestSuite) TestExpiringCoins() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"name", 0, "password123", "name2", 0, "password123")
+	assert.NoError(t, err)
+	treasury := repository.NewTreasuryPostgres(suite.db)
+	now := time.Now()
+	soon, later := now.Add(time.Hour), now.Add(48*time.Hour)
+
+	// первой партией расходуется старая, поэтому сгорает остаток второй
+	_, err = treasury.GrantCoins(1, domain.CoinAdjustmentInput{Username: "name", Amount: 100, Reason: "бонус"}, &later)
+	assert.NoError(t, err)
+	_, err = treasury.GrantCoins(1, domain.CoinAdjustmentInput{Username: "name", Amount: 50, Reason: "бонус"}, &soon)
+	assert.NoError(t, err)
+	_, err = suite.repository.SendCoin(domain.Transactions{
+		Source: IntPointer(1), DestinationUsername: "name2", Amount: 120, Timestamp: &now,
+	}, domain.TransferLimits{})
+	assert.NoError(t, err)
+	_, err = suite.repository.SendCoin(domain.Transactions{
+		Source: IntPointer(2), DestinationUsername: "name", Amount: 10, Timestamp: &now,
+	}, domain.TransferLimits{MaxBalance: 35})
+	assert.ErrorIs(t, err, domain.ErrBalanceCapExceeded)
+
+	expired, err := treasury.ExpireCoins(now.Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	var coins int
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = 1").Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, coins)
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = 2").Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 120, coins)
+	var burned int
+	err = suite.repository.DB().QueryRow("SELECT amount FROM transactions WHERE kind = $1", domain.TransactionExpiry).Scan(&burned)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, burned)
+}
+
+func (suite *ShopRepoTestSuite) TestPaymentRequests() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"name", 100, "password123", "name2", 100, "password123")
+	assert.NoError(t, err)
+	requests := repository.NewPaymentRequestPostgres(suite.db)
+	now := time.Now()
+
+	created, err := requests.CreatePaymentRequest(domain.PaymentRequest{
+		RequesterId: 1, PayerUsername: "name2", Amount: 40, CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "name", created.RequesterUsername)
+	_, err = requests.CreatePaymentRequest(domain.PaymentRequest{
+		RequesterId: 1, PayerUsername: "name", Amount: 40, CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	})
+	assert.ErrorIs(t, err, domain.ErrSelfTransfer)
+	stale, err := requests.CreatePaymentRequest(domain.PaymentRequest{
+		RequesterId: 2, PayerUsername: "name", Amount: 10, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+
+	incoming, err := requests.GetPaymentRequests(2, domain.PaymentRequestFilter{Direction: domain.DirectionIn})
+	assert.NoError(t, err)
+	assert.Len(t, incoming, 1)
+	assert.Equal(t, created.Id, incoming[0].Id)
+
+	declined, err := requests.UpdatePaymentRequestStatus(created.Id, domain.PaymentRequestPending, domain.PaymentRequestDeclined, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentRequestDeclined, declined.Status)
+	assert.NotNil(t, declined.ResolvedAt)
+	_, err = requests.UpdatePaymentRequestStatus(created.Id, domain.PaymentRequestPending, domain.PaymentRequestAccepted, nil)
+	assert.ErrorIs(t, err, domain.ErrPaymentRequestResolved)
+
+	payable, err := requests.CreatePaymentRequest(domain.PaymentRequest{
+		RequesterId: 1, PayerUsername: "name2", Amount: 40, CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	_, err = requests.AcceptPaymentRequest(payable.Id, 2, now, domain.TransferLimits{MaxBalance: 120})
+	assert.ErrorIs(t, err, domain.ErrBalanceCapExceeded)
+	accepted, err := requests.AcceptPaymentRequest(payable.Id, 2, now, domain.TransferLimits{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentRequestAccepted, accepted.Status)
+	assert.NotNil(t, accepted.TransactionId)
+	_, err = requests.AcceptPaymentRequest(payable.Id, 2, now, domain.TransferLimits{})
+	assert.ErrorIs(t, err, domain.ErrPaymentRequestResolved)
+
+	expired, err := requests.ExpirePaymentRequests(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	got, err := requests.GetPaymentRequest(stale.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PaymentRequestExpired, got.Status)
+}
+
+func (suite *ShopRepoTestSuite) TestEscrows() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"name", 100, "password123", "name2", 100, "password123")
+	assert.NoError(t, err)
+	escrows := repository.NewEscrowPostgres(suite.db)
+	now := time.Now()
+	// монеты отправителя выпущены казной и сгорают, удержание запоминает потраченные части партии
+	_, err = suite.repository.DB().Exec("INSERT INTO coin_lots (user_id, amount, remaining, expires_at) VALUES (1, 100, 100, $1)",
+		now.Add(30*24*time.Hour))
+	assert.NoError(t, err)
+
+	_, err = escrows.CreateEscrow(domain.Escrow{SenderId: 1, RecipientUsername: "name2", Amount: 500,
+		OnTimeout: domain.EscrowActionReturn, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, domain.TransferLimits{})
+	assert.ErrorIs(t, err, domain.ErrInsufficientCoins)
+	held, err := escrows.CreateEscrow(domain.Escrow{SenderId: 1, RecipientUsername: "name2", Amount: 60,
+		OnTimeout: domain.EscrowActionReturn, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, domain.TransferLimits{})
+	assert.NoError(t, err)
+	expiring, err := escrows.CreateEscrow(domain.Escrow{SenderId: 1, RecipientUsername: "name2", Amount: 30,
+		OnTimeout: domain.EscrowActionRelease, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}, domain.TransferLimits{})
+	assert.NoError(t, err)
+
+	summary, err := suite.repository.GetUserSummary(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, summary.Coins)
+	assert.Equal(t, 90, summary.HeldCoins)
+	assert.Len(t, summary.PendingEscrows, 2)
+
+	recipientId, senderId := 2, 1
+	returned, err := escrows.SettleEscrow(held.Id, domain.EscrowActionReturn, &recipientId, domain.TransferLimits{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EscrowReturned, returned.Status)
+	assert.Nil(t, returned.TransactionId)
+	_, err = escrows.SettleEscrow(held.Id, domain.EscrowActionRelease, &senderId, domain.TransferLimits{})
+	assert.ErrorIs(t, err, domain.ErrEscrowResolved)
+
+	expired, err := escrows.GetExpiredEscrows(now)
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	released, err := escrows.SettleEscrow(expiring.Id, expired[0].OnTimeout, nil, domain.TransferLimits{})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EscrowReleased, released.Status)
+	assert.NotNil(t, released.TransactionId)
+
+	var senderCoins, recipientCoins int
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = 1").Scan(&senderCoins)
+	assert.NoError(t, err)
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = 2").Scan(&recipientCoins)
+	assert.NoError(t, err)
+	assert.Equal(t, 70, senderCoins)
+	assert.Equal(t, 130, recipientCoins)
+
+	// после выпуска записи о потраченных частях не остаются, получатель получает бессрочную партию перевода
+	var spent int
+	err = suite.repository.DB().QueryRow("SELECT COUNT(*) FROM spent_coin_lots WHERE escrow_id IS NOT NULL").Scan(&spent)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, spent)
+	var lotAmount int
+	var lotExpiresAt *time.Time
+	err = suite.repository.DB().QueryRow("SELECT amount, expires_at FROM coin_lots WHERE user_id = 2 AND transaction_id = $1",
+		*released.TransactionId).Scan(&lotAmount, &lotExpiresAt)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, lotAmount)
+	assert.Nil(t, lotExpiresAt)
+}
+
+func (suite *ShopRepoTestSuite) TestEscrowTransferLimits() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"name", 200, "password123", "name2", 100, "password123")
+	assert.NoError(t, err)
+	escrows := repository.NewEscrowPostgres(suite.db)
+	now := time.Now()
+
+	_, err = suite.repository.SendCoin(domain.Transactions{
+		Source: IntPointer(1), DestinationUsername: "name2", Amount: 30, Timestamp: &now,
+	}, domain.TransferLimits{})
+	assert.NoError(t, err)
+	escrow := domain.Escrow{SenderId: 1, RecipientUsername: "name2", Amount: 40,
+		OnTimeout: domain.EscrowActionReturn, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	_, err = escrows.CreateEscrow(escrow, domain.TransferLimits{DailyOutbound: 50})
+	assert.ErrorIs(t, err, domain.ErrDailySendLimit)
+	_, err = escrows.CreateEscrow(escrow, domain.TransferLimits{Cooldown: time.Hour})
+	assert.ErrorIs(t, err, domain.ErrTransferCooldown)
+	held, err := escrows.CreateEscrow(escrow, domain.TransferLimits{DailyOutbound: 70})
+	assert.NoError(t, err)
+	_, err = suite.repository.SendCoin(domain.Transactions{
+		Source: IntPointer(1), DestinationUsername: "name2", Amount: 10, Timestamp: &now,
+	}, domain.TransferLimits{DailyOutbound: 70})
+	assert.ErrorIs(t, err, domain.ErrDailySendLimit)
+
+	senderId := 1
+	_, err = escrows.SettleEscrow(held.Id, domain.EscrowActionRelease, &senderId, domain.TransferLimits{MaxBalance: 150})
+	assert.ErrorIs(t, err, domain.ErrBalanceCapExceeded)
+	_, err = escrows.SettleEscrow(held.Id, domain.EscrowActionRelease, &senderId, domain.TransferLimits{DailyInbound: 50})
+	assert.ErrorIs(t, err, domain.ErrDailyReceiveLimit)
+	released, err := escrows.SettleEscrow(held.Id, domain.EscrowActionRelease, &senderId, domain.TransferLimits{DailyInbound: 70})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EscrowReleased, released.Status)
+
+	var recipientCoins int
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = 2").Scan(&recipientCoins)
+	assert.NoError(t, err)
+	assert.Equal(t, 170, recipientCoins)
+}
+
+func (suite *ShopRepoTestSuite) TestDeleteUser() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"name", 100, "password123", "name2", 100, "password123")
+	assert.NoError(t, err)
+	auth := repository.NewAuthPostgres(suite.db)
+	now := time.Now()
+	_, err = suite.repository.SendCoin(domain.Transactions{
+		Source: IntPointer(1), DestinationUsername: "name2", Amount: 40, Timestamp: &now,
+	}, domain.TransferLimits{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, auth.DeleteUser(2))
+	var username, role string
+	var coins int
+	err = suite.repository.DB().QueryRow("SELECT username, role, coins FROM userlist WHERE id = 2").Scan(&username, &role, &coins)
+	assert.NoError(t, err)
+	assert.Equal(t, "deleted_2", username)
+	assert.Equal(t, domain.RoleDeleted, role)
+	assert.Equal(t, 0, coins)
+
+	// перевод остается в истории отправителя с анонимным получателем
+	summary, err := suite.repository.GetUserSummary(1)
+	assert.NoError(t, err)
+	assert.Len(t, summary.TransactionsSummary.SentCoins, 1)
+	assert.Equal(t, "deleted_2", summary.TransactionsSummary.SentCoins[0].DestinationUsername)
+
+	_, err = auth.GetTokenState(2)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = suite.repository.SendCoin(domain.Transactions{
+		Source: IntPointer(1), DestinationUsername: "deleted_2", Amount: 10, Timestamp: &now,
+	}, domain.TransferLimits{})
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	assert.ErrorIs(t, auth.DeleteUser(2), domain.ErrUserNotFound)
+}
+
+func (suite *ShopRepoTestSuite) TestBanningUser() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"admin", 100, "password123", "name", 100, "password123")
+	assert.NoError(t, err)
+	auth := repository.NewAuthPostgres(suite.db)
+	users := repository.NewUserPostgres(suite.db)
+
+	user, err := users.BanUser(2, 1, "спам", time.Now())
+	assert.NoError(t, err)
+	assert.NotNil(t, user.BannedAt)
+	state, err := auth.GetTokenState(2)
+	assert.NoError(t, err)
+	assert.True(t, state.Banned)
+	found, err := users.SearchUsers(domain.UserFilter{Status: domain.UserStatusBanned, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "name", found[0].UserName)
+
+	_, err = users.UnbanUser(2)
+	assert.NoError(t, err)
+	assert.NoError(t, users.ResetPassword(2, "hash"))
+	state, err = auth.GetTokenState(2)
+	assert.NoError(t, err)
+	assert.False(t, state.Banned)
+	assert.True(t, state.PasswordResetRequired)
+	assert.Equal(t, 1, state.Version)
+
+	// смена пароля снимает требование сброса
+	_, err = auth.UpdatePassword(2, "newhash")
+	assert.NoError(t, err)
+	state, err = auth.GetTokenState(2)
+	assert.NoError(t, err)
+	assert.False(t, state.PasswordResetRequired)
+}
+
+func (suite *ShopRepoTestSuite) TestAuditLog() {
+	t := suite.T()
+	audit := repository.NewAuditPostgres(suite.db)
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	first, err := audit.AppendAuditEntry(domain.AuditEntry{
+		Action: domain.AuditLoginUnlock, TargetType: domain.AuditTargetLogin, TargetId: "1", CreatedAt: createdAt,
+	})
+	assert.NoError(t, err)
+	// запись вне цепочки не меняет prev_hash следующей записи цепочки
+	login, err := audit.InsertUnchainedAuditEntry(domain.AuditEntry{
+		Action: domain.AuditLogin, TargetType: domain.AuditTargetUser, TargetId: "1", CreatedAt: createdAt,
+	})
+	assert.NoError(t, err)
+	second, err := audit.AppendAuditEntry(domain.AuditEntry{
+		Action: domain.AuditUserBan, ActorId: IntPointer(1), TargetType: domain.AuditTargetUser, TargetId: "2",
+		Before: []byte(`{"banned_at": null}`), After: []byte(`{"ban_reason":"спам"}`), CreatedAt: createdAt,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, first.Hash, second.PrevHash)
+
+	// прочитанные записи дают те же хеши, что и при добавлении
+	chain, err := audit.GetAuditChain(0, 10)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, len(chain), 2)
+	for _, entry := range chain {
+		assert.NotEqual(t, login.Id, entry.Id)
+		assert.Equal(t, entry.Hash, entry.ComputeHash())
+	}
+	logins, err := audit.GetAuditLog(domain.AuditFilter{Action: domain.AuditLogin, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, login.Id, logins[0].Id)
+	assert.Empty(t, logins[0].Hash)
+	entries, err := audit.GetAuditLog(domain.AuditFilter{ActorId: IntPointer(1), Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, second.Id, entries[0].Id)
+
+	_, err = suite.repository.DB().Exec("UPDATE audit_log SET target_id = '3' WHERE id = $1", second.Id)
+	assert.Error(t, err)
+	_, err = suite.repository.DB().Exec("DELETE FROM audit_log WHERE id = $1", first.Id)
+	assert.Error(t, err)
+}
+
+func (suite *ShopRepoTestSuite) TestApiKeys() {
+	t := suite.T()
+	apiKeys := repository.NewApiKeyPostgres(suite.db)
+	account, err := apiKeys.CreateServiceAccount(1, domain.ServiceAccountInput{Name: "hr"})
+	assert.NoError(t, err)
+	assert.Equal(t, "svc_hr", account.UserName)
+	var role string
+	assert.NoError(t, suite.db.QueryRowx("SELECT role FROM userlist WHERE id = $1", account.UserId).Scan(&role))
+	assert.Equal(t, domain.RoleService, role)
+	_, err = apiKeys.CreateServiceAccount(1, domain.ServiceAccountInput{Name: "hr"})
+	assert.ErrorIs(t, err, domain.ErrServiceAccountExists)
+	// под пользователем сервисного аккаунта нельзя войти по паролю
+	_, err = repository.NewAuthPostgres(suite.db).SignUser("svc_hr")
+	assert.Error(t, err)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Microsecond)
+	key, err := apiKeys.CreateApiKey(1, account.Id, "0123456789ab", strings.Repeat("a", 64),
+		[]string{domain.ScopeCoinsSend, domain.ScopeInfoRead}, expiresAt)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{domain.ScopeCoinsSend, domain.ScopeInfoRead}, key.Scopes)
+
+	cred, err := apiKeys.GetApiKeyCredential("0123456789ab")
+	assert.NoError(t, err)
+	assert.Equal(t, account.UserId, cred.UserId)
+	assert.Equal(t, strings.Repeat("a", 64), cred.KeyHash)
+	assert.NoError(t, apiKeys.TouchApiKey(key.Id, time.Now()))
+
+	revoked, err := apiKeys.RevokeApiKey(key.Id, time.Now())
+	assert.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = apiKeys.RevokeApiKey(key.Id, time.Now())
+	assert.ErrorIs(t, err, domain.ErrApiKeyRevoked)
+
+	keys, err := apiKeys.GetApiKeys(account.Id)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt)
 }
 
 func (suite *ShopRepoTestSuite) TestBuyingItem() {
@@ -125,7 +644,7 @@ func (suite *ShopRepoTestSuite) TestBuyingItem() {
 	assert.NoError(t, err)
 	inputUserid := 1
 	inputName := "cup"
-	boughtItem, err := suite.repository.BuyItem(inputUserid, inputName)
+	boughtItem, err := suite.repository.BuyItem(inputUserid, inputName, domain.BuyOptions{})
 	if err != nil {
 		t.Fatalf("Failed to buy item: %s", err)
 	}
@@ -146,6 +665,106 @@ func (suite *ShopRepoTestSuite) TestBuyingItem() {
 	assert.Equal(t, 980, buyerCoins)
 }
 
+func (suite *ShopRepoTestSuite) TestBuyingItemConcurrently() {
+	t := suite.T()
+	const buyers = 20
+	const stock = 5
+	for i := 0; i < buyers; i++ {
+		_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
+			fmt.Sprintf("name%d", i), 1000, "password123")
+		assert.NoError(t, err)
+	}
+	_, err := suite.repository.DB().Exec("INSERT INTO shop (name, price, stock) VALUES ($1, $2, $3)", "cup", 20, stock)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var succeeded, outOfStock atomic.Int32
+	for i := 1; i <= buyers; i++ {
+		wg.Add(1)
+		go func(userId int) {
+			defer wg.Done()
+			_, err := suite.repository.BuyItem(userId, "cup", domain.BuyOptions{})
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, domain.ErrOutOfStock):
+				outOfStock.Add(1)
+			default:
+				t.Errorf("unexpected error: %s", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(stock), succeeded.Load())
+	assert.Equal(t, int32(buyers-stock), outOfStock.Load())
+	var left, purchases int
+	err = suite.repository.DB().QueryRow("SELECT stock FROM shop WHERE name = 'cup'").Scan(&left)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, left)
+	err = suite.repository.DB().QueryRow("SELECT COUNT(*) FROM purchases").Scan(&purchases)
+	assert.NoError(t, err)
+	assert.Equal(t, stock, purchases)
+}
+
+func (suite *ShopRepoTestSuite) TestBuyingItemPerUserLimit() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
+		"name", 2000, "password123")
+	assert.NoError(t, err)
+	_, err = suite.repository.DB().Exec("INSERT INTO shop (name, price, stock, per_user_limit) VALUES ($1, $2, $3, $4)",
+		"pink-hoody", 500, 10, 1)
+	assert.NoError(t, err)
+
+	_, err = suite.repository.BuyItem(1, "pink-hoody", domain.BuyOptions{})
+	assert.NoError(t, err)
+	_, err = suite.repository.BuyItem(1, "pink-hoody", domain.BuyOptions{})
+	assert.ErrorIs(t, err, domain.ErrPurchaseLimit)
+}
+
+func (suite *ShopRepoTestSuite) TestCreatingOrder() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
+		"name", 1000, "password123")
+	assert.NoError(t, err)
+	_, err = suite.repository.DB().Exec("INSERT INTO shop (name, price, stock) VALUES ($1, $2, $3), ($4, $5, $6)",
+		"cup", 20, 10, "pen", 10, 1)
+	assert.NoError(t, err)
+
+	orderId, err := suite.repository.CreateOrder(1, []domain.OrderLine{
+		{ItemName: "cup", Quantity: 3},
+		{ItemName: "pen", Quantity: 1},
+	}, "")
+	assert.NoError(t, err)
+
+	var total, lines, quantity int
+	err = suite.repository.DB().QueryRow("SELECT total FROM orders WHERE id = $1", orderId).Scan(&total)
+	assert.NoError(t, err)
+	assert.Equal(t, 70, total)
+	err = suite.repository.DB().QueryRow("SELECT COUNT(*), SUM(quantity) FROM purchases WHERE order_id = $1", orderId).Scan(&lines, &quantity)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lines)
+	assert.Equal(t, 4, quantity)
+	var coins int
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = 1").Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 930, coins)
+
+	// заказ, в котором одна позиция закончилась, не должен списать ничего
+	_, err = suite.repository.CreateOrder(1, []domain.OrderLine{
+		{ItemName: "cup", Quantity: 1},
+		{ItemName: "pen", Quantity: 1},
+	}, "")
+	assert.ErrorIs(t, err, domain.ErrOutOfStock)
+	var cupStock int
+	err = suite.repository.DB().QueryRow("SELECT stock FROM shop WHERE name = 'cup'").Scan(&cupStock)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, cupStock)
+	err = suite.repository.DB().QueryRow("SELECT coins FROM userlist WHERE id = 1").Scan(&coins)
+	assert.NoError(t, err)
+	assert.Equal(t, 930, coins)
+}
+
 func (suite *ShopRepoTestSuite) TestGetInfo() {
 	t := suite.T()
 	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING",
@@ -171,6 +790,180 @@ func (suite *ShopRepoTestSuite) TestGetInfo() {
 	assert.NotNil(t, getSummary)
 
 }
+func (suite *ShopRepoTestSuite) TestGetPurchases() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
+		"name", 1000, "password123")
+	assert.NoError(t, err)
+	_, err = suite.repository.DB().Exec("INSERT INTO shop (name, price) VALUES ($1, $2)", "cup", 20)
+	assert.NoError(t, err)
+	base := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err = suite.repository.DB().Exec("INSERT INTO purchases (user_id, item_id, price, purchase_date) VALUES ($1, $2, $3, $4)",
+			1, 1, 20+i, base.AddDate(0, 0, i))
+		assert.NoError(t, err)
+	}
+
+	firstPage, err := suite.repository.GetPurchases(1, domain.PurchaseFilter{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, firstPage, 2)
+	assert.Equal(t, 22, firstPage[0].Price)
+	assert.Equal(t, 21, firstPage[1].Price)
+
+	last := firstPage[1]
+	secondPage, err := suite.repository.GetPurchases(1, domain.PurchaseFilter{
+		Limit:  2,
+		Cursor: &domain.Cursor{Time: last.PurchaseDate, Id: last.Id},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, secondPage, 1)
+	assert.Equal(t, 20, secondPage[0].Price)
+
+	to := base.AddDate(0, 0, 1)
+	filtered, err := suite.repository.GetPurchases(1, domain.PurchaseFilter{Limit: 10, From: &base, To: &to})
+	assert.NoError(t, err)
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, base, filtered[0].PurchaseDate)
+}
+func (suite *ShopRepoTestSuite) TestRefundingPurchase() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
+		"name", 1000, "password123")
+	assert.NoError(t, err)
+	_, err = suite.repository.DB().Exec("INSERT INTO shop (name, price, stock) VALUES ($1, $2, $3)", "cup", 20, 5)
+	assert.NoError(t, err)
+	_, err = suite.repository.CreateOrder(1, []domain.OrderLine{{ItemName: "cup", Quantity: 2}}, "")
+	assert.NoError(t, err)
+
+	refunds := repository.NewRefundPostgres(suite.db)
+	refundId, err := refunds.CreateRefundRequest(domain.Refund{PurchaseId: 1, UserId: 1, Amount: 40})
+	assert.NoError(t, err)
+	_, err = refunds.CreateRefundRequest(domain.Refund{PurchaseId: 1, UserId: 1, Amount: 40})
+	assert.ErrorIs(t, err, domain.ErrRefundExists)
+
+	refund, err := refunds.ApproveRefund(refundId, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RefundApproved, refund.Status)
+	assert.NotNil(t, refund.TransactionId)
+
+	var coins, stock int
+	assert.NoError(t, suite.db.QueryRow("SELECT coins FROM userlist WHERE id = 1").Scan(&coins))
+	assert.NoError(t, suite.db.QueryRow("SELECT stock FROM shop WHERE id = 1").Scan(&stock))
+	assert.Equal(t, 1000, coins)
+	assert.Equal(t, 5, stock)
+
+	summary, err := suite.repository.GetUserSummary(1)
+	assert.NoError(t, err)
+	assert.Empty(t, summary.PurchasedItems)
+	assert.Len(t, summary.TransactionsSummary.ReceivedCoins, 1)
+	assert.Equal(t, domain.TransactionRefund, summary.TransactionsSummary.ReceivedCoins[0].Kind)
+
+	_, err = refunds.ForceRefund(1, 1, "")
+	assert.ErrorIs(t, err, domain.ErrAlreadyRefunded)
+}
+func (suite *ShopRepoTestSuite) TestUpdatingPurchaseStatus() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
+		"name", 1000, "password123")
+	assert.NoError(t, err)
+	_, err = suite.repository.DB().Exec("INSERT INTO shop (name, price) VALUES ($1, $2)", "cup", 20)
+	assert.NoError(t, err)
+	_, err = suite.repository.BuyItem(1, "cup", domain.BuyOptions{})
+	assert.NoError(t, err)
+
+	fulfillment := repository.NewFulfillmentPostgres(suite.db)
+	pending, err := fulfillment.GetPurchasesByStatus(domain.PurchasePending)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	packed, err := fulfillment.UpdatePurchaseStatus(1, domain.PurchasePending, domain.PurchasePacked)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PurchasePacked, packed.Status)
+	assert.NotNil(t, packed.PackedAt)
+	assert.Equal(t, "cup", packed.ItemName)
+
+	_, err = fulfillment.UpdatePurchaseStatus(1, domain.PurchasePending, domain.PurchasePacked)
+	assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition)
+
+	history, err := suite.repository.GetPurchases(1, domain.PurchaseFilter{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, domain.PurchasePacked, history[0].Status)
+}
+func (suite *ShopRepoTestSuite) TestBuyingItemVariant() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
+		"name", 1000, "password123")
+	assert.NoError(t, err)
+	_, err = suite.repository.DB().Exec("INSERT INTO shop (name, price) VALUES ($1, $2)", "hoody", 300)
+	assert.NoError(t, err)
+	inventory := repository.NewInventoryPostgres(suite.db)
+	_, err = inventory.CreateVariant("hoody", domain.VariantInput{
+		VariantSelector: domain.VariantSelector{Size: "M"},
+		PriceDelta:      20,
+		Stock:           IntPointer(1),
+	})
+	assert.NoError(t, err)
+
+	_, err = suite.repository.BuyItem(1, "hoody", domain.BuyOptions{})
+	assert.ErrorIs(t, err, domain.ErrVariantRequired)
+	_, err = suite.repository.BuyItem(1, "hoody", domain.BuyOptions{VariantSelector: domain.VariantSelector{Size: "M"}})
+	assert.NoError(t, err)
+	_, err = suite.repository.BuyItem(1, "hoody", domain.BuyOptions{VariantSelector: domain.VariantSelector{Size: "M"}})
+	assert.ErrorIs(t, err, domain.ErrOutOfStock)
+
+	summary, err := suite.repository.GetUserSummary(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 680, summary.Coins)
+	assert.Equal(t, []domain.PurchasedItem{{ItemName: "hoody", Size: "M", Quantity: 1}}, summary.PurchasedItems)
+}
+func (suite *ShopRepoTestSuite) TestBuyingWithPromotions() {
+	t := suite.T()
+	_, err := suite.repository.DB().Exec("INSERT INTO userlist (username, coins, password) VALUES ($1, $2, $3)",
+		"name", 1000, "password123")
+	assert.NoError(t, err)
+	_, err = suite.repository.DB().Exec("INSERT INTO shop (name, price, category) VALUES ($1, $2, $3)", "hoody", 300, "clothes")
+	assert.NoError(t, err)
+	now := time.Now()
+	promotions := repository.NewPromotionPostgres(suite.db)
+	_, err = promotions.CreatePromotion(domain.PromotionInput{
+		Name: "Неделя одежды", Kind: domain.DiscountPercent, Value: 10, Category: "clothes",
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	_, err = promotions.CreatePromotion(domain.PromotionInput{
+		Name: "Подарок", Kind: domain.DiscountFixed, Value: 100, Code: "GIFT", UsageLimit: IntPointer(1),
+		StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+
+	_, err = suite.repository.BuyItem(1, "hoody", domain.BuyOptions{})
+	assert.NoError(t, err)
+	_, err = suite.repository.BuyItem(1, "hoody", domain.BuyOptions{PromoCode: "GIFT"})
+	assert.NoError(t, err)
+	_, err = suite.repository.BuyItem(1, "hoody", domain.BuyOptions{PromoCode: "GIFT"})
+	assert.ErrorIs(t, err, domain.ErrPromoExhausted)
+
+	history, err := suite.repository.GetPurchases(1, domain.PurchaseFilter{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, 200, history[0].Price)
+	assert.Equal(t, 270, history[1].Price)
+	assert.Equal(t, 300, history[1].BasePrice)
+
+	var coins int
+	assert.NoError(t, suite.db.QueryRow("SELECT coins FROM userlist WHERE id = 1").Scan(&coins))
+	assert.Equal(t, 530, coins)
+
+	// возврат покупки по промокоду возвращает его использование
+	_, err = repository.NewRefundPostgres(suite.db).ForceRefund(history[0].Id, 1, "")
+	assert.NoError(t, err)
+	var usedCount int
+	assert.NoError(t, suite.db.QueryRow("SELECT used_count FROM promotions WHERE code = 'GIFT'").Scan(&usedCount))
+	assert.Equal(t, 0, usedCount)
+	_, err = suite.repository.BuyItem(1, "hoody", domain.BuyOptions{PromoCode: "GIFT"})
+	assert.NoError(t, err)
+}
 func TestCustomerRepoTestSuite(t *testing.T) {
 	suite.Run(t, new(ShopRepoTestSuite))
 }
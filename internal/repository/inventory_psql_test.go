@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventoryPostgres_Restock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+	r := NewInventoryPostgres(sqlxDB)
+
+	type args struct {
+		name     string
+		quantity int
+	}
+	tests := []struct {
+		name    string
+		mock    func()
+		input   args
+		want    domain.ItemStockChange
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, name, price, stock, per_user_limit FROM %s WHERE name = \\$1 FOR UPDATE", shopTable)).
+					WithArgs("cup").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "per_user_limit"}).
+						AddRow(2, "cup", 20, 10, nil))
+				mock.ExpectQuery(fmt.Sprintf(`UPDATE %s SET stock = COALESCE\(stock, 0\) \+ \$1 WHERE id = \$2`, shopTable)).
+					WithArgs(5, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "per_user_limit"}).
+						AddRow(2, "cup", 20, 15, nil))
+				mock.ExpectCommit()
+			},
+			input: args{name: "cup", quantity: 5},
+			want: domain.ItemStockChange{
+				Item:   domain.Merch{Id: 2, Name: "cup", Price: 20, Stock: IntPointer(15)},
+				Before: domain.Merch{Id: 2, Name: "cup", Price: 20, Stock: IntPointer(10)},
+			},
+		},
+		{
+			name: "Товар не найден",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE name = (.+) FOR UPDATE", shopTable)).
+					WithArgs("mug").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			input:   args{name: "mug", quantity: 5},
+			wantErr: domain.ErrItemNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.Restock(tt.input.name, tt.input.quantity)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInventoryPostgres_UpdateItemStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+	r := NewInventoryPostgres(sqlxDB)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   domain.ItemStockInput
+		want    domain.ItemStockChange
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE name = (.+) FOR UPDATE", shopTable)).
+					WithArgs("pink-hoody").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "per_user_limit"}).
+						AddRow(10, "pink-hoody", 500, 8, nil))
+				mock.ExpectQuery(fmt.Sprintf(`UPDATE %s SET stock = \$1, per_user_limit = \$2 WHERE id = \$3`, shopTable)).
+					WithArgs(3, 1, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "per_user_limit"}).
+						AddRow(10, "pink-hoody", 500, 3, 1))
+				mock.ExpectCommit()
+			},
+			input: domain.ItemStockInput{Stock: IntPointer(3), PerUserLimit: IntPointer(1)},
+			want: domain.ItemStockChange{
+				Item:   domain.Merch{Id: 10, Name: "pink-hoody", Price: 500, Stock: IntPointer(3), PerUserLimit: IntPointer(1)},
+				Before: domain.Merch{Id: 10, Name: "pink-hoody", Price: 500, Stock: IntPointer(8)},
+			},
+		},
+		{
+			name: "Снятие ограничений",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE name = (.+) FOR UPDATE", shopTable)).
+					WithArgs("pink-hoody").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "per_user_limit"}).
+						AddRow(10, "pink-hoody", 500, 3, 1))
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET stock (.+)", shopTable)).
+					WithArgs(nil, nil, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "stock", "per_user_limit"}).
+						AddRow(10, "pink-hoody", 500, nil, nil))
+				mock.ExpectCommit()
+			},
+			input: domain.ItemStockInput{},
+			want: domain.ItemStockChange{
+				Item:   domain.Merch{Id: 10, Name: "pink-hoody", Price: 500},
+				Before: domain.Merch{Id: 10, Name: "pink-hoody", Price: 500, Stock: IntPointer(3), PerUserLimit: IntPointer(1)},
+			},
+		},
+		{
+			name: "Товар не найден",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE name = (.+) FOR UPDATE", shopTable)).
+					WithArgs("pink-hoody").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			input:   domain.ItemStockInput{},
+			wantErr: domain.ErrItemNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.UpdateItemStock("pink-hoody", tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInventoryPostgres_CreateVariant(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewInventoryPostgres(sqlx.NewDb(db, "postgres"))
+	variantColumns := []string{"id", "item_id", "size", "colour", "price_delta", "stock"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   domain.VariantInput
+		want    domain.ItemVariant
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, price FROM %s WHERE name = (.+)", shopTable)).
+					WithArgs("hoody").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(6, 300))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+) ON CONFLICT (.+) DO NOTHING", variantsTable)).
+					WithArgs(6, "XL", "", 50, 10).
+					WillReturnRows(sqlmock.NewRows(variantColumns).AddRow(1, 6, "XL", "", 50, 10))
+			},
+			input: domain.VariantInput{VariantSelector: domain.VariantSelector{Size: "XL"}, PriceDelta: 50, Stock: IntPointer(10)},
+			want:  domain.ItemVariant{Id: 1, ItemId: 6, Size: "XL", PriceDelta: 50, Stock: IntPointer(10)},
+		},
+		{
+			name: "Вариант уже существует",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, price FROM %s (.+)", shopTable)).
+					WithArgs("hoody").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(6, 300))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", variantsTable)).
+					WithArgs(6, "XL", "", 0, nil).
+					WillReturnRows(sqlmock.NewRows(variantColumns))
+			},
+			input:   domain.VariantInput{VariantSelector: domain.VariantSelector{Size: "XL"}},
+			wantErr: domain.ErrVariantExists,
+		},
+		{
+			name: "Отрицательная итоговая цена",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, price FROM %s (.+)", shopTable)).
+					WithArgs("hoody").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(6, 300))
+			},
+			input:   domain.VariantInput{VariantSelector: domain.VariantSelector{Size: "XS"}, PriceDelta: -301},
+			wantErr: domain.ErrInvalidPrice,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CreateVariant("hoody", tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInventoryPostgres_UpdateVariantStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewInventoryPostgres(sqlx.NewDb(db, "postgres"))
+	variantColumns := []string{"id", "item_id", "size", "colour", "price_delta", "stock"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf("SELECT v.id, (.+) FROM %s v JOIN %s s (.+) FOR UPDATE OF v", variantsTable, shopTable)).
+		WithArgs("hoody", 3).
+		WillReturnRows(sqlmock.NewRows(variantColumns).AddRow(3, 1, "L", "black", 50, 4))
+	mock.ExpectQuery(fmt.Sprintf(`UPDATE %s SET stock = \$1 WHERE id = \$2`, variantsTable)).
+		WithArgs(7, 3).
+		WillReturnRows(sqlmock.NewRows(variantColumns).AddRow(3, 1, "L", "black", 50, 7))
+	mock.ExpectCommit()
+	got, err := r.UpdateVariantStock("hoody", 3, IntPointer(7))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.VariantStockChange{
+		Variant: domain.ItemVariant{Id: 3, ItemId: 1, Size: "L", Colour: "black", PriceDelta: 50, Stock: IntPointer(7)},
+		Before:  domain.ItemVariant{Id: 3, ItemId: 1, Size: "L", Colour: "black", PriceDelta: 50, Stock: IntPointer(4)},
+	}, got)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf("SELECT v.id, (.+) FROM %s v JOIN %s s (.+)", variantsTable, shopTable)).
+		WithArgs("hoody", 4).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	_, err = r.UpdateVariantStock("hoody", 4, nil)
+	assert.ErrorIs(t, err, domain.ErrVariantNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type InventoryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewInventoryPostgres(db *sqlx.DB) *InventoryPostgres {
+	return &InventoryPostgres{
+		db: db,
+	}
+}
+
+func (r *InventoryPostgres) GetItems() ([]domain.Merch, error) {
+	var items []domain.Merch
+	query := fmt.Sprintf("SELECT id, name, price, stock, per_user_limit FROM %s ORDER BY id", shopTable)
+	if err := r.db.Select(&items, query); err != nil {
+		return nil, err
+	}
+	var variants []domain.ItemVariant
+	variantsQuery := fmt.Sprintf("SELECT id, item_id, size, colour, price_delta, stock FROM %s ORDER BY item_id, id", variantsTable)
+	if err := r.db.Select(&variants, variantsQuery); err != nil {
+		return nil, err
+	}
+	byItem := make(map[int][]domain.ItemVariant, len(items))
+	for _, variant := range variants {
+		byItem[variant.ItemId] = append(byItem[variant.ItemId], variant)
+	}
+	for i := range items {
+		items[i].Variants = byItem[items[i].Id]
+	}
+	return items, nil
+}
+
+// lockItem блокирует товар до конца транзакции и возвращает его состояние до изменения.
+func lockItem(tr *sqlx.Tx, name string) (domain.Merch, error) {
+	var item domain.Merch
+	query := fmt.Sprintf("SELECT id, name, price, stock, per_user_limit FROM %s WHERE name = $1 FOR UPDATE", shopTable)
+	if err := tr.Get(&item, query, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Merch{}, domain.ErrItemNotFound
+		}
+		return domain.Merch{}, err
+	}
+	return item, nil
+}
+
+// Restock пополняет запас товара и возвращает товар до и после изменения, прочитанный в той же транзакции.
+func (r *InventoryPostgres) Restock(name string, quantity int) (domain.ItemStockChange, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.ItemStockChange{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	change := domain.ItemStockChange{}
+	if change.Before, err = lockItem(tr, name); err != nil {
+		return domain.ItemStockChange{}, err
+	}
+	query := fmt.Sprintf(`UPDATE %s SET stock = COALESCE(stock, 0) + $1 WHERE id = $2
+	RETURNING id, name, price, stock, per_user_limit`, shopTable)
+	if err = tr.Get(&change.Item, query, quantity, change.Before.Id); err != nil {
+		return domain.ItemStockChange{}, err
+	}
+	if err = tr.Commit(); err != nil {
+		return domain.ItemStockChange{}, err
+	}
+	logger.Log.Debug().Str("item", name).Int("quantity", quantity).Msg("Успешно пополнен запас товара")
+	return change, nil
+}
+
+// UpdateItemStock задает остаток и лимит товара и возвращает товар до и после изменения.
+func (r *InventoryPostgres) UpdateItemStock(name string, input domain.ItemStockInput) (domain.ItemStockChange, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.ItemStockChange{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	change := domain.ItemStockChange{}
+	if change.Before, err = lockItem(tr, name); err != nil {
+		return domain.ItemStockChange{}, err
+	}
+	query := fmt.Sprintf(`UPDATE %s SET stock = $1, per_user_limit = $2 WHERE id = $3
+	RETURNING id, name, price, stock, per_user_limit`, shopTable)
+	if err = tr.Get(&change.Item, query, input.Stock, input.PerUserLimit, change.Before.Id); err != nil {
+		return domain.ItemStockChange{}, err
+	}
+	if err = tr.Commit(); err != nil {
+		return domain.ItemStockChange{}, err
+	}
+	logger.Log.Debug().Str("item", name).Msg("Успешно обновлены остаток и лимит товара")
+	return change, nil
+}
+
+// CreateVariant добавляет товару вариант; итоговая цена варианта не может быть отрицательной.
+func (r *InventoryPostgres) CreateVariant(name string, input domain.VariantInput) (domain.ItemVariant, error) {
+	var item domain.Merch
+	itemQuery := fmt.Sprintf("SELECT id, price FROM %s WHERE name = $1", shopTable)
+	if err := r.db.Get(&item, itemQuery, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ItemVariant{}, domain.ErrItemNotFound
+		}
+		return domain.ItemVariant{}, err
+	}
+	if item.Price+input.PriceDelta < 0 {
+		return domain.ItemVariant{}, domain.ErrInvalidPrice
+	}
+	var variant domain.ItemVariant
+	query := fmt.Sprintf(`INSERT INTO %s (item_id, size, colour, price_delta, stock) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (item_id, size, colour) DO NOTHING
+	RETURNING id, item_id, size, colour, price_delta, stock`, variantsTable)
+	if err := r.db.Get(&variant, query, item.Id, input.Size, input.Colour, input.PriceDelta, input.Stock); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ItemVariant{}, domain.ErrVariantExists
+		}
+		return domain.ItemVariant{}, err
+	}
+	logger.Log.Debug().Str("item", name).Int("id", variant.Id).Msg("Успешно создан вариант товара")
+	return variant, nil
+}
+
+// UpdateVariantStock задает остаток варианта и возвращает вариант до и после изменения.
+func (r *InventoryPostgres) UpdateVariantStock(name string, variantId int, stock *int) (domain.VariantStockChange, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.VariantStockChange{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	change := domain.VariantStockChange{}
+	lockQuery := fmt.Sprintf(`SELECT v.id, v.item_id, v.size, v.colour, v.price_delta, v.stock FROM %s v
+	JOIN %s s ON v.item_id = s.id WHERE s.name = $1 AND v.id = $2 FOR UPDATE OF v`, variantsTable, shopTable)
+	if err = tr.Get(&change.Before, lockQuery, name, variantId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.VariantStockChange{}, domain.ErrVariantNotFound
+		}
+		return domain.VariantStockChange{}, err
+	}
+	query := fmt.Sprintf(`UPDATE %s SET stock = $1 WHERE id = $2
+	RETURNING id, item_id, size, colour, price_delta, stock`, variantsTable)
+	if err = tr.Get(&change.Variant, query, stock, variantId); err != nil {
+		return domain.VariantStockChange{}, err
+	}
+	if err = tr.Commit(); err != nil {
+		return domain.VariantStockChange{}, err
+	}
+	logger.Log.Debug().Str("item", name).Int("id", variantId).Msg("Успешно обновлен запас варианта товара")
+	return change, nil
+}
+
+func (r *InventoryPostgres) DB() *sqlx.DB {
+	return r.db
+}
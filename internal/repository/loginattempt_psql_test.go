@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptPostgres_GetLoginAttempts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewLoginAttemptPostgres(sqlx.NewDb(db, "postgres"))
+
+	lastFailure := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	lockedUntil := lastFailure.Add(15 * time.Minute)
+	mock.ExpectQuery(fmt.Sprintf("SELECT scope, value, failures, last_failure_at, locked_until FROM %s", loginAttemptsTable)).
+		WithArgs(domain.LoginScopeUsername, "anna", domain.LoginScopeIP, "10.0.0.1").
+		WillReturnRows(sqlmock.NewRows([]string{"scope", "value", "failures", "last_failure_at", "locked_until"}).
+			AddRow(domain.LoginScopeUsername, "anna", 0, lastFailure, lockedUntil).
+			AddRow(domain.LoginScopeIP, "10.0.0.1", 3, lastFailure, nil))
+
+	got, err := r.GetLoginAttempts("anna", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.LoginAttempt{
+		{Scope: domain.LoginScopeUsername, Value: "anna", LastFailureAt: lastFailure, LockedUntil: &lockedUntil},
+		{Scope: domain.LoginScopeIP, Value: "10.0.0.1", Failures: 3, LastFailureAt: lastFailure},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptPostgres_RecordLoginFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewLoginAttemptPostgres(sqlx.NewDb(db, "postgres"))
+
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	resetBefore := now.Add(-15 * time.Minute)
+	mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s AS la (.+)", loginAttemptsTable)).
+		WithArgs(domain.LoginScopeUsername, "anna", now, resetBefore).
+		WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(4))
+
+	got, err := r.RecordLoginFailure(domain.LoginScopeUsername, "anna", now, resetBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptPostgres_ResetLoginAttempts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewLoginAttemptPostgres(sqlx.NewDb(db, "postgres"))
+
+	mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE scope = (.+)", loginAttemptsTable)).
+		WithArgs(domain.LoginScopeIP, "10.0.0.1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	got, err := r.ResetLoginAttempts(domain.LoginScopeIP, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
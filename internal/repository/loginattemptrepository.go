@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginAttemptPostgres struct {
+	db *sqlx.DB
+}
+
+func NewLoginAttemptPostgres(db *sqlx.DB) *LoginAttemptPostgres {
+	return &LoginAttemptPostgres{
+		db: db,
+	}
+}
+
+// GetLoginAttempts возвращает учет неудачных попыток входа для имени пользователя и IP-адреса.
+func (r *LoginAttemptPostgres) GetLoginAttempts(username, ip string) ([]domain.LoginAttempt, error) {
+	attempts := []domain.LoginAttempt{}
+	query := fmt.Sprintf(`SELECT scope, value, failures, last_failure_at, locked_until FROM %s
+	WHERE (scope = $1 AND value = $2) OR (scope = $3 AND value = $4)`, loginAttemptsTable)
+	if err := r.db.Select(&attempts, query, domain.LoginScopeUsername, username, domain.LoginScopeIP, ip); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// RecordLoginFailure увеличивает счетчик неудачных попыток и возвращает его новое значение.
+// Если предыдущая неудача была раньше resetBefore, счет начинается заново.
+func (r *LoginAttemptPostgres) RecordLoginFailure(scope, value string, now, resetBefore time.Time) (int, error) {
+	var failures int
+	query := fmt.Sprintf(`INSERT INTO %s AS la (scope, value, failures, last_failure_at) VALUES ($1, $2, 1, $3)
+	ON CONFLICT (scope, value) DO UPDATE SET
+		failures = CASE WHEN la.last_failure_at < $4 THEN 1 ELSE la.failures + 1 END,
+		last_failure_at = $3
+	RETURNING failures`, loginAttemptsTable)
+	if err := r.db.QueryRowx(query, scope, value, now, resetBefore).Scan(&failures); err != nil {
+		return 0, err
+	}
+	return failures, nil
+}
+
+// LockLogin блокирует вход до until и обнуляет счетчик, чтобы после блокировки попытки считались заново.
+func (r *LoginAttemptPostgres) LockLogin(scope, value string, until time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET failures = 0, locked_until = $1 WHERE scope = $2 AND value = $3", loginAttemptsTable)
+	if _, err := r.db.Exec(query, until, scope, value); err != nil {
+		return err
+	}
+	logger.Log.Debug().Str("scope", scope).Str("value", value).Msg("Вход заблокирован")
+	return nil
+}
+
+// ResetLoginAttempts удаляет учет попыток входа вместе с блокировкой и возвращает число удаленных записей.
+func (r *LoginAttemptPostgres) ResetLoginAttempts(scope, value string) (int, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE scope = $1 AND value = $2", loginAttemptsTable)
+	result, err := r.db.Exec(query, scope, value)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// PurgeLoginAttempts удаляет записи без действующей блокировки, последняя неудача в которых была раньше before.
+func (r *LoginAttemptPostgres) PurgeLoginAttempts(before time.Time) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %s
+	WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1)`, loginAttemptsTable)
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *LoginAttemptPostgres) DB() *sqlx.DB {
+	return r.db
+}
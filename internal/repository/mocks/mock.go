@@ -0,0 +1,1739 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=repository.go -destination=mocks/mock.go
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/bllooop/coinshop/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuthorization is a mock of Authorization interface.
+type MockAuthorization struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorizationMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthorizationMockRecorder is the mock recorder for MockAuthorization.
+type MockAuthorizationMockRecorder struct {
+	mock *MockAuthorization
+}
+
+// NewMockAuthorization creates a new mock instance.
+func NewMockAuthorization(ctrl *gomock.Controller) *MockAuthorization {
+	mock := &MockAuthorization{ctrl: ctrl}
+	mock.recorder = &MockAuthorizationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorization) EXPECT() *MockAuthorizationMockRecorder {
+	return m.recorder
+}
+
+// CreateUser mocks base method.
+func (m *MockAuthorization) CreateUser(user domain.User, bonusExpiresAt *time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", user, bonusExpiresAt)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockAuthorizationMockRecorder) CreateUser(user, bonusExpiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user, bonusExpiresAt)
+}
+
+// DeleteUser mocks base method.
+func (m *MockAuthorization) DeleteUser(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockAuthorizationMockRecorder) DeleteUser(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockAuthorization)(nil).DeleteUser), userId)
+}
+
+// GetTokenState mocks base method.
+func (m *MockAuthorization) GetTokenState(userId int) (domain.TokenState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenState", userId)
+	ret0, _ := ret[0].(domain.TokenState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenState indicates an expected call of GetTokenState.
+func (mr *MockAuthorizationMockRecorder) GetTokenState(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenState", reflect.TypeOf((*MockAuthorization)(nil).GetTokenState), userId)
+}
+
+// GetUser mocks base method.
+func (m *MockAuthorization) GetUser(userId int) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", userId)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockAuthorizationMockRecorder) GetUser(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAuthorization)(nil).GetUser), userId)
+}
+
+// GetUserRole mocks base method.
+func (m *MockAuthorization) GetUserRole(userId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRole", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRole indicates an expected call of GetUserRole.
+func (mr *MockAuthorizationMockRecorder) GetUserRole(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRole", reflect.TypeOf((*MockAuthorization)(nil).GetUserRole), userId)
+}
+
+// RehashPassword mocks base method.
+func (m *MockAuthorization) RehashPassword(userId int, oldHash, newHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RehashPassword", userId, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RehashPassword indicates an expected call of RehashPassword.
+func (mr *MockAuthorizationMockRecorder) RehashPassword(userId, oldHash, newHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashPassword", reflect.TypeOf((*MockAuthorization)(nil).RehashPassword), userId, oldHash, newHash)
+}
+
+// SignUser mocks base method.
+func (m *MockAuthorization) SignUser(username string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignUser", username)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignUser indicates an expected call of SignUser.
+func (mr *MockAuthorizationMockRecorder) SignUser(username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUser", reflect.TypeOf((*MockAuthorization)(nil).SignUser), username)
+}
+
+// UpdateLastLogin mocks base method.
+func (m *MockAuthorization) UpdateLastLogin(userId int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastLogin", userId, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastLogin indicates an expected call of UpdateLastLogin.
+func (mr *MockAuthorizationMockRecorder) UpdateLastLogin(userId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastLogin", reflect.TypeOf((*MockAuthorization)(nil).UpdateLastLogin), userId, at)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockAuthorization) UpdatePassword(userId int, passwordHash string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", userId, passwordHash)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockAuthorizationMockRecorder) UpdatePassword(userId, passwordHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockAuthorization)(nil).UpdatePassword), userId, passwordHash)
+}
+
+// UpdateUsername mocks base method.
+func (m *MockAuthorization) UpdateUsername(userId int, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUsername", userId, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUsername indicates an expected call of UpdateUsername.
+func (mr *MockAuthorizationMockRecorder) UpdateUsername(userId, username any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUsername", reflect.TypeOf((*MockAuthorization)(nil).UpdateUsername), userId, username)
+}
+
+// MockShop is a mock of Shop interface.
+type MockShop struct {
+	ctrl     *gomock.Controller
+	recorder *MockShopMockRecorder
+	isgomock struct{}
+}
+
+// MockShopMockRecorder is the mock recorder for MockShop.
+type MockShopMockRecorder struct {
+	mock *MockShop
+}
+
+// NewMockShop creates a new mock instance.
+func NewMockShop(ctrl *gomock.Controller) *MockShop {
+	mock := &MockShop{ctrl: ctrl}
+	mock.recorder = &MockShopMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShop) EXPECT() *MockShopMockRecorder {
+	return m.recorder
+}
+
+// BuyItem mocks base method.
+func (m *MockShop) BuyItem(userid int, name string, options domain.BuyOptions) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuyItem", userid, name, options)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuyItem indicates an expected call of BuyItem.
+func (mr *MockShopMockRecorder) BuyItem(userid, name, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockShop)(nil).BuyItem), userid, name, options)
+}
+
+// CreateOrder mocks base method.
+func (m *MockShop) CreateOrder(userid int, lines []domain.OrderLine, promoCode string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", userid, lines, promoCode)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrder indicates an expected call of CreateOrder.
+func (mr *MockShopMockRecorder) CreateOrder(userid, lines, promoCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockShop)(nil).CreateOrder), userid, lines, promoCode)
+}
+
+// GetPurchases mocks base method.
+func (m *MockShop) GetPurchases(userID int, filter domain.PurchaseFilter) ([]domain.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchases", userID, filter)
+	ret0, _ := ret[0].([]domain.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchases indicates an expected call of GetPurchases.
+func (mr *MockShopMockRecorder) GetPurchases(userID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchases", reflect.TypeOf((*MockShop)(nil).GetPurchases), userID, filter)
+}
+
+// GetTransactions mocks base method.
+func (m *MockShop) GetTransactions(userID int, filter domain.TransactionFilter) ([]domain.Transactions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactions", userID, filter)
+	ret0, _ := ret[0].([]domain.Transactions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactions indicates an expected call of GetTransactions.
+func (mr *MockShopMockRecorder) GetTransactions(userID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactions", reflect.TypeOf((*MockShop)(nil).GetTransactions), userID, filter)
+}
+
+// GetUserSummary mocks base method.
+func (m *MockShop) GetUserSummary(userID int) (*domain.UserSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSummary", userID)
+	ret0, _ := ret[0].(*domain.UserSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSummary indicates an expected call of GetUserSummary.
+func (mr *MockShopMockRecorder) GetUserSummary(userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSummary", reflect.TypeOf((*MockShop)(nil).GetUserSummary), userID)
+}
+
+// SendCoin mocks base method.
+func (m *MockShop) SendCoin(input domain.Transactions, limits domain.TransferLimits) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendCoin", input, limits)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendCoin indicates an expected call of SendCoin.
+func (mr *MockShopMockRecorder) SendCoin(input, limits any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCoin", reflect.TypeOf((*MockShop)(nil).SendCoin), input, limits)
+}
+
+// SendCoinBatch mocks base method.
+func (m *MockShop) SendCoinBatch(source int, transfers []domain.Transactions, limits domain.TransferLimits) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendCoinBatch", source, transfers, limits)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendCoinBatch indicates an expected call of SendCoinBatch.
+func (mr *MockShopMockRecorder) SendCoinBatch(source, transfers, limits any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCoinBatch", reflect.TypeOf((*MockShop)(nil).SendCoinBatch), source, transfers, limits)
+}
+
+// MockInventory is a mock of Inventory interface.
+type MockInventory struct {
+	ctrl     *gomock.Controller
+	recorder *MockInventoryMockRecorder
+	isgomock struct{}
+}
+
+// MockInventoryMockRecorder is the mock recorder for MockInventory.
+type MockInventoryMockRecorder struct {
+	mock *MockInventory
+}
+
+// NewMockInventory creates a new mock instance.
+func NewMockInventory(ctrl *gomock.Controller) *MockInventory {
+	mock := &MockInventory{ctrl: ctrl}
+	mock.recorder = &MockInventoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInventory) EXPECT() *MockInventoryMockRecorder {
+	return m.recorder
+}
+
+// CreateVariant mocks base method.
+func (m *MockInventory) CreateVariant(name string, input domain.VariantInput) (domain.ItemVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVariant", name, input)
+	ret0, _ := ret[0].(domain.ItemVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVariant indicates an expected call of CreateVariant.
+func (mr *MockInventoryMockRecorder) CreateVariant(name, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVariant", reflect.TypeOf((*MockInventory)(nil).CreateVariant), name, input)
+}
+
+// GetItems mocks base method.
+func (m *MockInventory) GetItems() ([]domain.Merch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItems")
+	ret0, _ := ret[0].([]domain.Merch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItems indicates an expected call of GetItems.
+func (mr *MockInventoryMockRecorder) GetItems() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItems", reflect.TypeOf((*MockInventory)(nil).GetItems))
+}
+
+// Restock mocks base method.
+func (m *MockInventory) Restock(name string, quantity int) (domain.ItemStockChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restock", name, quantity)
+	ret0, _ := ret[0].(domain.ItemStockChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restock indicates an expected call of Restock.
+func (mr *MockInventoryMockRecorder) Restock(name, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restock", reflect.TypeOf((*MockInventory)(nil).Restock), name, quantity)
+}
+
+// UpdateItemStock mocks base method.
+func (m *MockInventory) UpdateItemStock(name string, input domain.ItemStockInput) (domain.ItemStockChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateItemStock", name, input)
+	ret0, _ := ret[0].(domain.ItemStockChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateItemStock indicates an expected call of UpdateItemStock.
+func (mr *MockInventoryMockRecorder) UpdateItemStock(name, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItemStock", reflect.TypeOf((*MockInventory)(nil).UpdateItemStock), name, input)
+}
+
+// UpdateVariantStock mocks base method.
+func (m *MockInventory) UpdateVariantStock(name string, variantId int, stock *int) (domain.VariantStockChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVariantStock", name, variantId, stock)
+	ret0, _ := ret[0].(domain.VariantStockChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVariantStock indicates an expected call of UpdateVariantStock.
+func (mr *MockInventoryMockRecorder) UpdateVariantStock(name, variantId, stock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVariantStock", reflect.TypeOf((*MockInventory)(nil).UpdateVariantStock), name, variantId, stock)
+}
+
+// MockRefunds is a mock of Refunds interface.
+type MockRefunds struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefundsMockRecorder
+	isgomock struct{}
+}
+
+// MockRefundsMockRecorder is the mock recorder for MockRefunds.
+type MockRefundsMockRecorder struct {
+	mock *MockRefunds
+}
+
+// NewMockRefunds creates a new mock instance.
+func NewMockRefunds(ctrl *gomock.Controller) *MockRefunds {
+	mock := &MockRefunds{ctrl: ctrl}
+	mock.recorder = &MockRefundsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefunds) EXPECT() *MockRefundsMockRecorder {
+	return m.recorder
+}
+
+// ApproveRefund mocks base method.
+func (m *MockRefunds) ApproveRefund(refundId, adminId int) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveRefund", refundId, adminId)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveRefund indicates an expected call of ApproveRefund.
+func (mr *MockRefundsMockRecorder) ApproveRefund(refundId, adminId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveRefund", reflect.TypeOf((*MockRefunds)(nil).ApproveRefund), refundId, adminId)
+}
+
+// CreateRefundRequest mocks base method.
+func (m *MockRefunds) CreateRefundRequest(refund domain.Refund) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefundRequest", refund)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRefundRequest indicates an expected call of CreateRefundRequest.
+func (mr *MockRefundsMockRecorder) CreateRefundRequest(refund any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefundRequest", reflect.TypeOf((*MockRefunds)(nil).CreateRefundRequest), refund)
+}
+
+// ForceRefund mocks base method.
+func (m *MockRefunds) ForceRefund(purchaseId, adminId int, reason string) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceRefund", purchaseId, adminId, reason)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceRefund indicates an expected call of ForceRefund.
+func (mr *MockRefundsMockRecorder) ForceRefund(purchaseId, adminId, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceRefund", reflect.TypeOf((*MockRefunds)(nil).ForceRefund), purchaseId, adminId, reason)
+}
+
+// GetPurchase mocks base method.
+func (m *MockRefunds) GetPurchase(purchaseId int) (domain.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchase", purchaseId)
+	ret0, _ := ret[0].(domain.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchase indicates an expected call of GetPurchase.
+func (mr *MockRefundsMockRecorder) GetPurchase(purchaseId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchase", reflect.TypeOf((*MockRefunds)(nil).GetPurchase), purchaseId)
+}
+
+// GetRefunds mocks base method.
+func (m *MockRefunds) GetRefunds(status string) ([]domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefunds", status)
+	ret0, _ := ret[0].([]domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefunds indicates an expected call of GetRefunds.
+func (mr *MockRefundsMockRecorder) GetRefunds(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefunds", reflect.TypeOf((*MockRefunds)(nil).GetRefunds), status)
+}
+
+// RejectRefund mocks base method.
+func (m *MockRefunds) RejectRefund(refundId, adminId int) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectRefund", refundId, adminId)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectRefund indicates an expected call of RejectRefund.
+func (mr *MockRefundsMockRecorder) RejectRefund(refundId, adminId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectRefund", reflect.TypeOf((*MockRefunds)(nil).RejectRefund), refundId, adminId)
+}
+
+// MockFulfillment is a mock of Fulfillment interface.
+type MockFulfillment struct {
+	ctrl     *gomock.Controller
+	recorder *MockFulfillmentMockRecorder
+	isgomock struct{}
+}
+
+// MockFulfillmentMockRecorder is the mock recorder for MockFulfillment.
+type MockFulfillmentMockRecorder struct {
+	mock *MockFulfillment
+}
+
+// NewMockFulfillment creates a new mock instance.
+func NewMockFulfillment(ctrl *gomock.Controller) *MockFulfillment {
+	mock := &MockFulfillment{ctrl: ctrl}
+	mock.recorder = &MockFulfillmentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFulfillment) EXPECT() *MockFulfillmentMockRecorder {
+	return m.recorder
+}
+
+// GetPurchasesByStatus mocks base method.
+func (m *MockFulfillment) GetPurchasesByStatus(status string) ([]domain.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchasesByStatus", status)
+	ret0, _ := ret[0].([]domain.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchasesByStatus indicates an expected call of GetPurchasesByStatus.
+func (mr *MockFulfillmentMockRecorder) GetPurchasesByStatus(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchasesByStatus", reflect.TypeOf((*MockFulfillment)(nil).GetPurchasesByStatus), status)
+}
+
+// UpdatePurchaseStatus mocks base method.
+func (m *MockFulfillment) UpdatePurchaseStatus(purchaseId int, from, to string) (domain.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePurchaseStatus", purchaseId, from, to)
+	ret0, _ := ret[0].(domain.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePurchaseStatus indicates an expected call of UpdatePurchaseStatus.
+func (mr *MockFulfillmentMockRecorder) UpdatePurchaseStatus(purchaseId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePurchaseStatus", reflect.TypeOf((*MockFulfillment)(nil).UpdatePurchaseStatus), purchaseId, from, to)
+}
+
+// MockPromotions is a mock of Promotions interface.
+type MockPromotions struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromotionsMockRecorder
+	isgomock struct{}
+}
+
+// MockPromotionsMockRecorder is the mock recorder for MockPromotions.
+type MockPromotionsMockRecorder struct {
+	mock *MockPromotions
+}
+
+// NewMockPromotions creates a new mock instance.
+func NewMockPromotions(ctrl *gomock.Controller) *MockPromotions {
+	mock := &MockPromotions{ctrl: ctrl}
+	mock.recorder = &MockPromotionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromotions) EXPECT() *MockPromotionsMockRecorder {
+	return m.recorder
+}
+
+// CreatePromotion mocks base method.
+func (m *MockPromotions) CreatePromotion(input domain.PromotionInput) (domain.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePromotion", input)
+	ret0, _ := ret[0].(domain.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePromotion indicates an expected call of CreatePromotion.
+func (mr *MockPromotionsMockRecorder) CreatePromotion(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePromotion", reflect.TypeOf((*MockPromotions)(nil).CreatePromotion), input)
+}
+
+// EndPromotion mocks base method.
+func (m *MockPromotions) EndPromotion(id int) (domain.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndPromotion", id)
+	ret0, _ := ret[0].(domain.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndPromotion indicates an expected call of EndPromotion.
+func (mr *MockPromotionsMockRecorder) EndPromotion(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndPromotion", reflect.TypeOf((*MockPromotions)(nil).EndPromotion), id)
+}
+
+// GetPromotions mocks base method.
+func (m *MockPromotions) GetPromotions() ([]domain.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotions")
+	ret0, _ := ret[0].([]domain.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotions indicates an expected call of GetPromotions.
+func (mr *MockPromotionsMockRecorder) GetPromotions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotions", reflect.TypeOf((*MockPromotions)(nil).GetPromotions))
+}
+
+// MockSchedules is a mock of Schedules interface.
+type MockSchedules struct {
+	ctrl     *gomock.Controller
+	recorder *MockSchedulesMockRecorder
+	isgomock struct{}
+}
+
+// MockSchedulesMockRecorder is the mock recorder for MockSchedules.
+type MockSchedulesMockRecorder struct {
+	mock *MockSchedules
+}
+
+// NewMockSchedules creates a new mock instance.
+func NewMockSchedules(ctrl *gomock.Controller) *MockSchedules {
+	mock := &MockSchedules{ctrl: ctrl}
+	mock.recorder = &MockSchedulesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSchedules) EXPECT() *MockSchedulesMockRecorder {
+	return m.recorder
+}
+
+// CancelSchedule mocks base method.
+func (m *MockSchedules) CancelSchedule(userId, scheduleId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSchedule", userId, scheduleId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelSchedule indicates an expected call of CancelSchedule.
+func (mr *MockSchedulesMockRecorder) CancelSchedule(userId, scheduleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSchedule", reflect.TypeOf((*MockSchedules)(nil).CancelSchedule), userId, scheduleId)
+}
+
+// ClaimSchedule mocks base method.
+func (m *MockSchedules) ClaimSchedule(scheduleId int, dueAt, nextRunAt, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimSchedule", scheduleId, dueAt, nextRunAt, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimSchedule indicates an expected call of ClaimSchedule.
+func (mr *MockSchedulesMockRecorder) ClaimSchedule(scheduleId, dueAt, nextRunAt, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimSchedule", reflect.TypeOf((*MockSchedules)(nil).ClaimSchedule), scheduleId, dueAt, nextRunAt, now)
+}
+
+// CreateSchedule mocks base method.
+func (m *MockSchedules) CreateSchedule(schedule domain.ScheduledTransfer) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", schedule)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule.
+func (mr *MockSchedulesMockRecorder) CreateSchedule(schedule any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockSchedules)(nil).CreateSchedule), schedule)
+}
+
+// CreateScheduleRun mocks base method.
+func (m *MockSchedules) CreateScheduleRun(run domain.ScheduleRun) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduleRun", run)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduleRun indicates an expected call of CreateScheduleRun.
+func (mr *MockSchedulesMockRecorder) CreateScheduleRun(run any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduleRun", reflect.TypeOf((*MockSchedules)(nil).CreateScheduleRun), run)
+}
+
+// GetDueSchedules mocks base method.
+func (m *MockSchedules) GetDueSchedules(now time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueSchedules", now, limit)
+	ret0, _ := ret[0].([]domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueSchedules indicates an expected call of GetDueSchedules.
+func (mr *MockSchedulesMockRecorder) GetDueSchedules(now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueSchedules", reflect.TypeOf((*MockSchedules)(nil).GetDueSchedules), now, limit)
+}
+
+// GetSchedule mocks base method.
+func (m *MockSchedules) GetSchedule(userId, scheduleId int) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedule", userId, scheduleId)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedule indicates an expected call of GetSchedule.
+func (mr *MockSchedulesMockRecorder) GetSchedule(userId, scheduleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedule", reflect.TypeOf((*MockSchedules)(nil).GetSchedule), userId, scheduleId)
+}
+
+// GetScheduleRuns mocks base method.
+func (m *MockSchedules) GetScheduleRuns(scheduleId int) ([]domain.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduleRuns", scheduleId)
+	ret0, _ := ret[0].([]domain.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduleRuns indicates an expected call of GetScheduleRuns.
+func (mr *MockSchedulesMockRecorder) GetScheduleRuns(scheduleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduleRuns", reflect.TypeOf((*MockSchedules)(nil).GetScheduleRuns), scheduleId)
+}
+
+// GetSchedules mocks base method.
+func (m *MockSchedules) GetSchedules(userId int) ([]domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules", userId)
+	ret0, _ := ret[0].([]domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockSchedulesMockRecorder) GetSchedules(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockSchedules)(nil).GetSchedules), userId)
+}
+
+// MockTreasury is a mock of Treasury interface.
+type MockTreasury struct {
+	ctrl     *gomock.Controller
+	recorder *MockTreasuryMockRecorder
+	isgomock struct{}
+}
+
+// MockTreasuryMockRecorder is the mock recorder for MockTreasury.
+type MockTreasuryMockRecorder struct {
+	mock *MockTreasury
+}
+
+// NewMockTreasury creates a new mock instance.
+func NewMockTreasury(ctrl *gomock.Controller) *MockTreasury {
+	mock := &MockTreasury{ctrl: ctrl}
+	mock.recorder = &MockTreasuryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTreasury) EXPECT() *MockTreasuryMockRecorder {
+	return m.recorder
+}
+
+// ClawbackCoins mocks base method.
+func (m *MockTreasury) ClawbackCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClawbackCoins", adminId, input)
+	ret0, _ := ret[0].(domain.CoinAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClawbackCoins indicates an expected call of ClawbackCoins.
+func (mr *MockTreasuryMockRecorder) ClawbackCoins(adminId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClawbackCoins", reflect.TypeOf((*MockTreasury)(nil).ClawbackCoins), adminId, input)
+}
+
+// ExpireCoins mocks base method.
+func (m *MockTreasury) ExpireCoins(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireCoins", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireCoins indicates an expected call of ExpireCoins.
+func (mr *MockTreasuryMockRecorder) ExpireCoins(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireCoins", reflect.TypeOf((*MockTreasury)(nil).ExpireCoins), now)
+}
+
+// GrantCoins mocks base method.
+func (m *MockTreasury) GrantCoins(adminId int, input domain.CoinAdjustmentInput, expiresAt *time.Time) (domain.CoinAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantCoins", adminId, input, expiresAt)
+	ret0, _ := ret[0].(domain.CoinAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantCoins indicates an expected call of GrantCoins.
+func (mr *MockTreasuryMockRecorder) GrantCoins(adminId, input, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantCoins", reflect.TypeOf((*MockTreasury)(nil).GrantCoins), adminId, input, expiresAt)
+}
+
+// PayAllowance mocks base method.
+func (m *MockTreasury) PayAllowance(period string, amount int, activeSince time.Time, expiresAt *time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PayAllowance", period, amount, activeSince, expiresAt)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PayAllowance indicates an expected call of PayAllowance.
+func (mr *MockTreasuryMockRecorder) PayAllowance(period, amount, activeSince, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PayAllowance", reflect.TypeOf((*MockTreasury)(nil).PayAllowance), period, amount, activeSince, expiresAt)
+}
+
+// MockPaymentRequests is a mock of PaymentRequests interface.
+type MockPaymentRequests struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentRequestsMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentRequestsMockRecorder is the mock recorder for MockPaymentRequests.
+type MockPaymentRequestsMockRecorder struct {
+	mock *MockPaymentRequests
+}
+
+// NewMockPaymentRequests creates a new mock instance.
+func NewMockPaymentRequests(ctrl *gomock.Controller) *MockPaymentRequests {
+	mock := &MockPaymentRequests{ctrl: ctrl}
+	mock.recorder = &MockPaymentRequestsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentRequests) EXPECT() *MockPaymentRequestsMockRecorder {
+	return m.recorder
+}
+
+// AcceptPaymentRequest mocks base method.
+func (m *MockPaymentRequests) AcceptPaymentRequest(requestId, payerId int, timestamp time.Time, limits domain.TransferLimits) (domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptPaymentRequest", requestId, payerId, timestamp, limits)
+	ret0, _ := ret[0].(domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptPaymentRequest indicates an expected call of AcceptPaymentRequest.
+func (mr *MockPaymentRequestsMockRecorder) AcceptPaymentRequest(requestId, payerId, timestamp, limits any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptPaymentRequest", reflect.TypeOf((*MockPaymentRequests)(nil).AcceptPaymentRequest), requestId, payerId, timestamp, limits)
+}
+
+// CreatePaymentRequest mocks base method.
+func (m *MockPaymentRequests) CreatePaymentRequest(request domain.PaymentRequest) (domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentRequest", request)
+	ret0, _ := ret[0].(domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentRequest indicates an expected call of CreatePaymentRequest.
+func (mr *MockPaymentRequestsMockRecorder) CreatePaymentRequest(request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentRequest", reflect.TypeOf((*MockPaymentRequests)(nil).CreatePaymentRequest), request)
+}
+
+// ExpirePaymentRequests mocks base method.
+func (m *MockPaymentRequests) ExpirePaymentRequests(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpirePaymentRequests", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpirePaymentRequests indicates an expected call of ExpirePaymentRequests.
+func (mr *MockPaymentRequestsMockRecorder) ExpirePaymentRequests(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpirePaymentRequests", reflect.TypeOf((*MockPaymentRequests)(nil).ExpirePaymentRequests), now)
+}
+
+// GetPaymentRequest mocks base method.
+func (m *MockPaymentRequests) GetPaymentRequest(requestId int) (domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentRequest", requestId)
+	ret0, _ := ret[0].(domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentRequest indicates an expected call of GetPaymentRequest.
+func (mr *MockPaymentRequestsMockRecorder) GetPaymentRequest(requestId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentRequest", reflect.TypeOf((*MockPaymentRequests)(nil).GetPaymentRequest), requestId)
+}
+
+// GetPaymentRequests mocks base method.
+func (m *MockPaymentRequests) GetPaymentRequests(userId int, filter domain.PaymentRequestFilter) ([]domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentRequests", userId, filter)
+	ret0, _ := ret[0].([]domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentRequests indicates an expected call of GetPaymentRequests.
+func (mr *MockPaymentRequestsMockRecorder) GetPaymentRequests(userId, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentRequests", reflect.TypeOf((*MockPaymentRequests)(nil).GetPaymentRequests), userId, filter)
+}
+
+// UpdatePaymentRequestStatus mocks base method.
+func (m *MockPaymentRequests) UpdatePaymentRequestStatus(requestId int, from, to string, transactionId *int) (domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePaymentRequestStatus", requestId, from, to, transactionId)
+	ret0, _ := ret[0].(domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePaymentRequestStatus indicates an expected call of UpdatePaymentRequestStatus.
+func (mr *MockPaymentRequestsMockRecorder) UpdatePaymentRequestStatus(requestId, from, to, transactionId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePaymentRequestStatus", reflect.TypeOf((*MockPaymentRequests)(nil).UpdatePaymentRequestStatus), requestId, from, to, transactionId)
+}
+
+// MockEscrows is a mock of Escrows interface.
+type MockEscrows struct {
+	ctrl     *gomock.Controller
+	recorder *MockEscrowsMockRecorder
+	isgomock struct{}
+}
+
+// MockEscrowsMockRecorder is the mock recorder for MockEscrows.
+type MockEscrowsMockRecorder struct {
+	mock *MockEscrows
+}
+
+// NewMockEscrows creates a new mock instance.
+func NewMockEscrows(ctrl *gomock.Controller) *MockEscrows {
+	mock := &MockEscrows{ctrl: ctrl}
+	mock.recorder = &MockEscrowsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEscrows) EXPECT() *MockEscrowsMockRecorder {
+	return m.recorder
+}
+
+// CreateEscrow mocks base method.
+func (m *MockEscrows) CreateEscrow(escrow domain.Escrow, limits domain.TransferLimits) (domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEscrow", escrow, limits)
+	ret0, _ := ret[0].(domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEscrow indicates an expected call of CreateEscrow.
+func (mr *MockEscrowsMockRecorder) CreateEscrow(escrow, limits any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEscrow", reflect.TypeOf((*MockEscrows)(nil).CreateEscrow), escrow, limits)
+}
+
+// GetEscrow mocks base method.
+func (m *MockEscrows) GetEscrow(escrowId int) (domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEscrow", escrowId)
+	ret0, _ := ret[0].(domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEscrow indicates an expected call of GetEscrow.
+func (mr *MockEscrowsMockRecorder) GetEscrow(escrowId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEscrow", reflect.TypeOf((*MockEscrows)(nil).GetEscrow), escrowId)
+}
+
+// GetEscrows mocks base method.
+func (m *MockEscrows) GetEscrows(userId int, status string) ([]domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEscrows", userId, status)
+	ret0, _ := ret[0].([]domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEscrows indicates an expected call of GetEscrows.
+func (mr *MockEscrowsMockRecorder) GetEscrows(userId, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEscrows", reflect.TypeOf((*MockEscrows)(nil).GetEscrows), userId, status)
+}
+
+// GetExpiredEscrows mocks base method.
+func (m *MockEscrows) GetExpiredEscrows(now time.Time) ([]domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredEscrows", now)
+	ret0, _ := ret[0].([]domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredEscrows indicates an expected call of GetExpiredEscrows.
+func (mr *MockEscrowsMockRecorder) GetExpiredEscrows(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredEscrows", reflect.TypeOf((*MockEscrows)(nil).GetExpiredEscrows), now)
+}
+
+// SettleEscrow mocks base method.
+func (m *MockEscrows) SettleEscrow(escrowId int, action string, resolvedBy *int, limits domain.TransferLimits) (domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SettleEscrow", escrowId, action, resolvedBy, limits)
+	ret0, _ := ret[0].(domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SettleEscrow indicates an expected call of SettleEscrow.
+func (mr *MockEscrowsMockRecorder) SettleEscrow(escrowId, action, resolvedBy, limits any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SettleEscrow", reflect.TypeOf((*MockEscrows)(nil).SettleEscrow), escrowId, action, resolvedBy, limits)
+}
+
+// MockLoginAttempts is a mock of LoginAttempts interface.
+type MockLoginAttempts struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptsMockRecorder
+	isgomock struct{}
+}
+
+// MockLoginAttemptsMockRecorder is the mock recorder for MockLoginAttempts.
+type MockLoginAttemptsMockRecorder struct {
+	mock *MockLoginAttempts
+}
+
+// NewMockLoginAttempts creates a new mock instance.
+func NewMockLoginAttempts(ctrl *gomock.Controller) *MockLoginAttempts {
+	mock := &MockLoginAttempts{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttempts) EXPECT() *MockLoginAttemptsMockRecorder {
+	return m.recorder
+}
+
+// GetLoginAttempts mocks base method.
+func (m *MockLoginAttempts) GetLoginAttempts(username, ip string) ([]domain.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginAttempts", username, ip)
+	ret0, _ := ret[0].([]domain.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginAttempts indicates an expected call of GetLoginAttempts.
+func (mr *MockLoginAttemptsMockRecorder) GetLoginAttempts(username, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginAttempts", reflect.TypeOf((*MockLoginAttempts)(nil).GetLoginAttempts), username, ip)
+}
+
+// LockLogin mocks base method.
+func (m *MockLoginAttempts) LockLogin(scope, value string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", scope, value, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockLoginAttemptsMockRecorder) LockLogin(scope, value, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockLoginAttempts)(nil).LockLogin), scope, value, until)
+}
+
+// PurgeLoginAttempts mocks base method.
+func (m *MockLoginAttempts) PurgeLoginAttempts(before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeLoginAttempts", before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeLoginAttempts indicates an expected call of PurgeLoginAttempts.
+func (mr *MockLoginAttemptsMockRecorder) PurgeLoginAttempts(before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeLoginAttempts", reflect.TypeOf((*MockLoginAttempts)(nil).PurgeLoginAttempts), before)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockLoginAttempts) RecordLoginFailure(scope, value string, now, resetBefore time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", scope, value, now, resetBefore)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockLoginAttemptsMockRecorder) RecordLoginFailure(scope, value, now, resetBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockLoginAttempts)(nil).RecordLoginFailure), scope, value, now, resetBefore)
+}
+
+// ResetLoginAttempts mocks base method.
+func (m *MockLoginAttempts) ResetLoginAttempts(scope, value string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginAttempts", scope, value)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetLoginAttempts indicates an expected call of ResetLoginAttempts.
+func (mr *MockLoginAttemptsMockRecorder) ResetLoginAttempts(scope, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginAttempts", reflect.TypeOf((*MockLoginAttempts)(nil).ResetLoginAttempts), scope, value)
+}
+
+// MockUsers is a mock of Users interface.
+type MockUsers struct {
+	ctrl     *gomock.Controller
+	recorder *MockUsersMockRecorder
+	isgomock struct{}
+}
+
+// MockUsersMockRecorder is the mock recorder for MockUsers.
+type MockUsersMockRecorder struct {
+	mock *MockUsers
+}
+
+// NewMockUsers creates a new mock instance.
+func NewMockUsers(ctrl *gomock.Controller) *MockUsers {
+	mock := &MockUsers{ctrl: ctrl}
+	mock.recorder = &MockUsersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUsers) EXPECT() *MockUsersMockRecorder {
+	return m.recorder
+}
+
+// BanUser mocks base method.
+func (m *MockUsers) BanUser(userId, adminId int, reason string, at time.Time) (domain.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanUser", userId, adminId, reason, at)
+	ret0, _ := ret[0].(domain.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BanUser indicates an expected call of BanUser.
+func (mr *MockUsersMockRecorder) BanUser(userId, adminId, reason, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockUsers)(nil).BanUser), userId, adminId, reason, at)
+}
+
+// GetUserInfo mocks base method.
+func (m *MockUsers) GetUserInfo(userId int) (domain.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserInfo", userId)
+	ret0, _ := ret[0].(domain.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserInfo indicates an expected call of GetUserInfo.
+func (mr *MockUsersMockRecorder) GetUserInfo(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInfo", reflect.TypeOf((*MockUsers)(nil).GetUserInfo), userId)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUsers) ResetPassword(userId int, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", userId, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUsersMockRecorder) ResetPassword(userId, passwordHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUsers)(nil).ResetPassword), userId, passwordHash)
+}
+
+// SearchUsers mocks base method.
+func (m *MockUsers) SearchUsers(filter domain.UserFilter) ([]domain.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", filter)
+	ret0, _ := ret[0].([]domain.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockUsersMockRecorder) SearchUsers(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockUsers)(nil).SearchUsers), filter)
+}
+
+// UnbanUser mocks base method.
+func (m *MockUsers) UnbanUser(userId int) (domain.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanUser", userId)
+	ret0, _ := ret[0].(domain.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnbanUser indicates an expected call of UnbanUser.
+func (mr *MockUsersMockRecorder) UnbanUser(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanUser", reflect.TypeOf((*MockUsers)(nil).UnbanUser), userId)
+}
+
+// MockAudit is a mock of Audit interface.
+type MockAudit struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditMockRecorder is the mock recorder for MockAudit.
+type MockAuditMockRecorder struct {
+	mock *MockAudit
+}
+
+// NewMockAudit creates a new mock instance.
+func NewMockAudit(ctrl *gomock.Controller) *MockAudit {
+	mock := &MockAudit{ctrl: ctrl}
+	mock.recorder = &MockAuditMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAudit) EXPECT() *MockAuditMockRecorder {
+	return m.recorder
+}
+
+// AppendAuditEntry mocks base method.
+func (m *MockAudit) AppendAuditEntry(entry domain.AuditEntry) (domain.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendAuditEntry", entry)
+	ret0, _ := ret[0].(domain.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendAuditEntry indicates an expected call of AppendAuditEntry.
+func (mr *MockAuditMockRecorder) AppendAuditEntry(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendAuditEntry", reflect.TypeOf((*MockAudit)(nil).AppendAuditEntry), entry)
+}
+
+// GetAuditChain mocks base method.
+func (m *MockAudit) GetAuditChain(afterId int64, limit int) ([]domain.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditChain", afterId, limit)
+	ret0, _ := ret[0].([]domain.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditChain indicates an expected call of GetAuditChain.
+func (mr *MockAuditMockRecorder) GetAuditChain(afterId, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditChain", reflect.TypeOf((*MockAudit)(nil).GetAuditChain), afterId, limit)
+}
+
+// GetAuditLog mocks base method.
+func (m *MockAudit) GetAuditLog(filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLog", filter)
+	ret0, _ := ret[0].([]domain.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLog indicates an expected call of GetAuditLog.
+func (mr *MockAuditMockRecorder) GetAuditLog(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLog", reflect.TypeOf((*MockAudit)(nil).GetAuditLog), filter)
+}
+
+// InsertUnchainedAuditEntry mocks base method.
+func (m *MockAudit) InsertUnchainedAuditEntry(entry domain.AuditEntry) (domain.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertUnchainedAuditEntry", entry)
+	ret0, _ := ret[0].(domain.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertUnchainedAuditEntry indicates an expected call of InsertUnchainedAuditEntry.
+func (mr *MockAuditMockRecorder) InsertUnchainedAuditEntry(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUnchainedAuditEntry", reflect.TypeOf((*MockAudit)(nil).InsertUnchainedAuditEntry), entry)
+}
+
+// MockTwoFactor is a mock of TwoFactor interface.
+type MockTwoFactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorMockRecorder
+	isgomock struct{}
+}
+
+// MockTwoFactorMockRecorder is the mock recorder for MockTwoFactor.
+type MockTwoFactorMockRecorder struct {
+	mock *MockTwoFactor
+}
+
+// NewMockTwoFactor creates a new mock instance.
+func NewMockTwoFactor(ctrl *gomock.Controller) *MockTwoFactor {
+	mock := &MockTwoFactor{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactor) EXPECT() *MockTwoFactorMockRecorder {
+	return m.recorder
+}
+
+// DisableTwoFactor mocks base method.
+func (m *MockTwoFactor) DisableTwoFactor(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockTwoFactorMockRecorder) DisableTwoFactor(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockTwoFactor)(nil).DisableTwoFactor), userId)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockTwoFactor) EnableTwoFactor(userId int, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", userId, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockTwoFactorMockRecorder) EnableTwoFactor(userId, step, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockTwoFactor)(nil).EnableTwoFactor), userId, step, codeHashes)
+}
+
+// GetTwoFactor mocks base method.
+func (m *MockTwoFactor) GetTwoFactor(userId int) (domain.TwoFactorState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactor", userId)
+	ret0, _ := ret[0].(domain.TwoFactorState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactor indicates an expected call of GetTwoFactor.
+func (mr *MockTwoFactorMockRecorder) GetTwoFactor(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactor", reflect.TypeOf((*MockTwoFactor)(nil).GetTwoFactor), userId)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockTwoFactor) ReplaceRecoveryCodes(userId int, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", userId, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockTwoFactorMockRecorder) ReplaceRecoveryCodes(userId, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockTwoFactor)(nil).ReplaceRecoveryCodes), userId, codeHashes)
+}
+
+// SetTwoFactorSecret mocks base method.
+func (m *MockTwoFactor) SetTwoFactorSecret(userId int, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTwoFactorSecret", userId, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTwoFactorSecret indicates an expected call of SetTwoFactorSecret.
+func (mr *MockTwoFactorMockRecorder) SetTwoFactorSecret(userId, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTwoFactorSecret", reflect.TypeOf((*MockTwoFactor)(nil).SetTwoFactorSecret), userId, secret)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockTwoFactor) UseRecoveryCode(userId int, codeHash string, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", userId, codeHash, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockTwoFactorMockRecorder) UseRecoveryCode(userId, codeHash, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockTwoFactor)(nil).UseRecoveryCode), userId, codeHash, at)
+}
+
+// UseTotpStep mocks base method.
+func (m *MockTwoFactor) UseTotpStep(userId int, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTotpStep", userId, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTotpStep indicates an expected call of UseTotpStep.
+func (mr *MockTwoFactorMockRecorder) UseTotpStep(userId, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTotpStep", reflect.TypeOf((*MockTwoFactor)(nil).UseTotpStep), userId, step)
+}
+
+// MockApiKeys is a mock of ApiKeys interface.
+type MockApiKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeysMockRecorder
+	isgomock struct{}
+}
+
+// MockApiKeysMockRecorder is the mock recorder for MockApiKeys.
+type MockApiKeysMockRecorder struct {
+	mock *MockApiKeys
+}
+
+// NewMockApiKeys creates a new mock instance.
+func NewMockApiKeys(ctrl *gomock.Controller) *MockApiKeys {
+	mock := &MockApiKeys{ctrl: ctrl}
+	mock.recorder = &MockApiKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeys) EXPECT() *MockApiKeysMockRecorder {
+	return m.recorder
+}
+
+// CreateApiKey mocks base method.
+func (m *MockApiKeys) CreateApiKey(adminId, accountId int, prefix, keyHash string, scopes []string, expiresAt time.Time) (domain.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", adminId, accountId, prefix, keyHash, scopes, expiresAt)
+	ret0, _ := ret[0].(domain.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockApiKeysMockRecorder) CreateApiKey(adminId, accountId, prefix, keyHash, scopes, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockApiKeys)(nil).CreateApiKey), adminId, accountId, prefix, keyHash, scopes, expiresAt)
+}
+
+// CreateServiceAccount mocks base method.
+func (m *MockApiKeys) CreateServiceAccount(adminId int, input domain.ServiceAccountInput) (domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", adminId, input)
+	ret0, _ := ret[0].(domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount.
+func (mr *MockApiKeysMockRecorder) CreateServiceAccount(adminId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockApiKeys)(nil).CreateServiceAccount), adminId, input)
+}
+
+// GetApiKeyCredential mocks base method.
+func (m *MockApiKeys) GetApiKeyCredential(prefix string) (domain.ApiKeyCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeyCredential", prefix)
+	ret0, _ := ret[0].(domain.ApiKeyCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeyCredential indicates an expected call of GetApiKeyCredential.
+func (mr *MockApiKeysMockRecorder) GetApiKeyCredential(prefix any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeyCredential", reflect.TypeOf((*MockApiKeys)(nil).GetApiKeyCredential), prefix)
+}
+
+// GetApiKeys mocks base method.
+func (m *MockApiKeys) GetApiKeys(accountId int) ([]domain.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeys", accountId)
+	ret0, _ := ret[0].([]domain.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeys indicates an expected call of GetApiKeys.
+func (mr *MockApiKeysMockRecorder) GetApiKeys(accountId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeys", reflect.TypeOf((*MockApiKeys)(nil).GetApiKeys), accountId)
+}
+
+// GetServiceAccount mocks base method.
+func (m *MockApiKeys) GetServiceAccount(id int) (domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccount", id)
+	ret0, _ := ret[0].(domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccount indicates an expected call of GetServiceAccount.
+func (mr *MockApiKeysMockRecorder) GetServiceAccount(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccount", reflect.TypeOf((*MockApiKeys)(nil).GetServiceAccount), id)
+}
+
+// GetServiceAccounts mocks base method.
+func (m *MockApiKeys) GetServiceAccounts() ([]domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccounts")
+	ret0, _ := ret[0].([]domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccounts indicates an expected call of GetServiceAccounts.
+func (mr *MockApiKeysMockRecorder) GetServiceAccounts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccounts", reflect.TypeOf((*MockApiKeys)(nil).GetServiceAccounts))
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockApiKeys) RevokeApiKey(keyId int, at time.Time) (domain.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKey", keyId, at)
+	ret0, _ := ret[0].(domain.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockApiKeysMockRecorder) RevokeApiKey(keyId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockApiKeys)(nil).RevokeApiKey), keyId, at)
+}
+
+// TouchApiKey mocks base method.
+func (m *MockApiKeys) TouchApiKey(keyId int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchApiKey", keyId, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchApiKey indicates an expected call of TouchApiKey.
+func (mr *MockApiKeysMockRecorder) TouchApiKey(keyId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchApiKey", reflect.TypeOf((*MockApiKeys)(nil).TouchApiKey), keyId, at)
+}
+
+// MockOidc is a mock of Oidc interface.
+type MockOidc struct {
+	ctrl     *gomock.Controller
+	recorder *MockOidcMockRecorder
+	isgomock struct{}
+}
+
+// MockOidcMockRecorder is the mock recorder for MockOidc.
+type MockOidcMockRecorder struct {
+	mock *MockOidc
+}
+
+// NewMockOidc creates a new mock instance.
+func NewMockOidc(ctrl *gomock.Controller) *MockOidc {
+	mock := &MockOidc{ctrl: ctrl}
+	mock.recorder = &MockOidcMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOidc) EXPECT() *MockOidcMockRecorder {
+	return m.recorder
+}
+
+// ConsumeOidcState mocks base method.
+func (m *MockOidc) ConsumeOidcState(state string, now time.Time) (domain.OidcState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeOidcState", state, now)
+	ret0, _ := ret[0].(domain.OidcState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeOidcState indicates an expected call of ConsumeOidcState.
+func (mr *MockOidcMockRecorder) ConsumeOidcState(state, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeOidcState", reflect.TypeOf((*MockOidc)(nil).ConsumeOidcState), state, now)
+}
+
+// CreateOidcState mocks base method.
+func (m *MockOidc) CreateOidcState(state domain.OidcState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOidcState", state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOidcState indicates an expected call of CreateOidcState.
+func (mr *MockOidcMockRecorder) CreateOidcState(state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOidcState", reflect.TypeOf((*MockOidc)(nil).CreateOidcState), state)
+}
+
+// CreateOidcUser mocks base method.
+func (m *MockOidc) CreateOidcUser(user domain.User, issuer, subject string, bonusExpiresAt *time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOidcUser", user, issuer, subject, bonusExpiresAt)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOidcUser indicates an expected call of CreateOidcUser.
+func (mr *MockOidcMockRecorder) CreateOidcUser(user, issuer, subject, bonusExpiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOidcUser", reflect.TypeOf((*MockOidc)(nil).CreateOidcUser), user, issuer, subject, bonusExpiresAt)
+}
+
+// GetUserByOidcSubject mocks base method.
+func (m *MockOidc) GetUserByOidcSubject(issuer, subject string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByOidcSubject", issuer, subject)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByOidcSubject indicates an expected call of GetUserByOidcSubject.
+func (mr *MockOidcMockRecorder) GetUserByOidcSubject(issuer, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByOidcSubject", reflect.TypeOf((*MockOidc)(nil).GetUserByOidcSubject), issuer, subject)
+}
+
+// PurgeOidcStates mocks base method.
+func (m *MockOidc) PurgeOidcStates(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeOidcStates", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeOidcStates indicates an expected call of PurgeOidcStates.
+func (mr *MockOidcMockRecorder) PurgeOidcStates(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeOidcStates", reflect.TypeOf((*MockOidc)(nil).PurgeOidcStates), now)
+}
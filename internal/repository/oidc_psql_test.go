@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOidcPostgres_ConsumeOidcState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewOidcPostgres(sqlx.NewDb(db, "postgres"))
+
+	now := time.Date(2025, 3, 21, 12, 0, 0, 0, time.UTC)
+	query := fmt.Sprintf("DELETE FROM %s WHERE state = \\$1 RETURNING (.+)", oidcStatesTable)
+	columns := []string{"state", "nonce", "code_verifier", "expires_at"}
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs("abc").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("abc", "nonce", "verifier", now.Add(5*time.Minute)))
+
+		got, err := r.ConsumeOidcState("abc", now)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OidcState{State: "abc", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(5 * time.Minute)}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("Срок истек", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs("abc").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("abc", "nonce", "verifier", now.Add(-time.Second)))
+
+		_, err := r.ConsumeOidcState("abc", now)
+		assert.ErrorIs(t, err, domain.ErrInvalidOidcState)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("Уже использован", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs("abc").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := r.ConsumeOidcState("abc", now)
+		assert.ErrorIs(t, err, domain.ErrInvalidOidcState)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestOidcPostgres_GetUserByOidcSubject(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewOidcPostgres(sqlx.NewDb(db, "postgres"))
+
+	query := fmt.Sprintf("SELECT (.+) FROM %s WHERE oidc_issuer = \\$1 AND oidc_subject = \\$2", userListTable)
+	mock.ExpectQuery(query).WithArgs("https://idp.example.com", "abc").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password", "banned", "password_reset_required", "totp_enabled"}).
+			AddRow(4, "ivan", "", false, false, true))
+
+	got, err := r.GetUserByOidcSubject("https://idp.example.com", "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.User{Id: 4, UserName: "ivan", TwoFactorEnabled: true}, got)
+
+	mock.ExpectQuery(query).WithArgs("https://idp.example.com", "other").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password", "banned", "password_reset_required", "totp_enabled"}))
+	_, err = r.GetUserByOidcSubject("https://idp.example.com", "other")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOidcPostgres_CreateOidcUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewOidcPostgres(sqlx.NewDb(db, "postgres"))
+
+	query := fmt.Sprintf("INSERT INTO %s (.+) WHERE NOT EXISTS (.+) ON CONFLICT DO NOTHING RETURNING id", userListTable)
+	user := domain.User{UserName: "ivan", Coins: IntPointer(1000)}
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).WithArgs("ivan", "https://idp.example.com", "abc").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE role", userListTable)).
+			WithArgs(domain.RoleTreasury).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(99))
+		mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s", transactionsTable)).
+			WithArgs(99, 4, 1000, sqlmock.AnyArg(), domain.TransactionSignupBonus, sqlmock.AnyArg(), nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		expectCreditCoins(mock, 4, 1000)
+		mock.ExpectCommit()
+
+		id, err := r.CreateOidcUser(user, "https://idp.example.com", "abc", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("Имя занято", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).WithArgs("ivan", "https://idp.example.com", "abc").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		_, err := r.CreateOidcUser(user, "https://idp.example.com", "abc", nil)
+		assert.ErrorIs(t, err, domain.ErrUsernameTaken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
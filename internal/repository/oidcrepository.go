@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type OidcPostgres struct {
+	db *sqlx.DB
+}
+
+func NewOidcPostgres(db *sqlx.DB) *OidcPostgres {
+	return &OidcPostgres{
+		db: db,
+	}
+}
+
+func (r *OidcPostgres) CreateOidcState(state domain.OidcState) error {
+	query := fmt.Sprintf(`INSERT INTO %s (state, nonce, code_verifier, expires_at) VALUES ($1, $2, $3, $4)`, oidcStatesTable)
+	_, err := r.db.Exec(query, state.State, state.Nonce, state.CodeVerifier, state.ExpiresAt)
+	return err
+}
+
+// ConsumeOidcState удаляет state при чтении, поэтому адрес возврата от провайдера нельзя использовать повторно.
+func (r *OidcPostgres) ConsumeOidcState(state string, now time.Time) (domain.OidcState, error) {
+	var result domain.OidcState
+	query := fmt.Sprintf(`DELETE FROM %s WHERE state = $1 RETURNING state, nonce, code_verifier, expires_at`, oidcStatesTable)
+	if err := r.db.Get(&result, query, state); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.OidcState{}, domain.ErrInvalidOidcState
+		}
+		return domain.OidcState{}, err
+	}
+	if !result.ExpiresAt.After(now) {
+		return domain.OidcState{}, domain.ErrInvalidOidcState
+	}
+	return result, nil
+}
+
+// PurgeOidcStates удаляет входы, которые не были завершены до истечения срока.
+func (r *OidcPostgres) PurgeOidcStates(now time.Time) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE expires_at <= $1`, oidcStatesTable)
+	result, err := r.db.Exec(query, now)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *OidcPostgres) GetUserByOidcSubject(issuer, subject string) (domain.User, error) {
+	var user domain.User
+	query := fmt.Sprintf(`SELECT id,username,password,banned_at IS NOT NULL,password_reset_required,totp_enabled
+	FROM %s WHERE oidc_issuer = $1 AND oidc_subject = $2 AND deleted_at IS NULL`, userListTable)
+	err := r.db.QueryRowx(query, issuer, subject).
+		Scan(&user.Id, &user.UserName, &user.Password, &user.Banned, &user.PasswordResetRequired, &user.TwoFactorEnabled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, domain.ErrUserNotFound
+		}
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// CreateOidcUser создает пользователя, связанного с пользователем провайдера, без пароля и начисляет
+// бонус за регистрацию. Если имя занято или пользователь провайдера уже связан с аккаунтом,
+// возвращает ErrUsernameTaken.
+func (r *OidcPostgres) CreateOidcUser(user domain.User, issuer, subject string, bonusExpiresAt *time.Time) (int, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (username, password, coins, oidc_issuer, oidc_subject)
+	SELECT $1, '', 0, $2, $3 WHERE NOT EXISTS (SELECT 1 FROM %s WHERE username = $1)
+	ON CONFLICT DO NOTHING RETURNING id`, userListTable, userListTable)
+	if err = tr.QueryRowx(query, user.UserName, issuer, subject).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrUsernameTaken
+		}
+		return 0, err
+	}
+	if err = grantSignupBonus(tr, id, user.Coins, bonusExpiresAt); err != nil {
+		return 0, err
+	}
+	logger.Log.Debug().Int("id", id).Str("issuer", issuer).Msg("Создан пользователь внешнего провайдера входа")
+	return id, tr.Commit()
+}
+
+func (r *OidcPostgres) DB() *sqlx.DB {
+	return r.db
+}
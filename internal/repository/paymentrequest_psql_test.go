@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var paymentRequestRowColumns = []string{"id", "requester_id", "requester_username", "payer_id", "payer_username",
+	"amount", "message", "status", "transaction_id", "created_at", "expires_at", "resolved_at"}
+
+func TestPaymentRequestPostgres_CreatePaymentRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewPaymentRequestPostgres(sqlx.NewDb(db, "postgres"))
+
+	createdAt := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+	input := domain.PaymentRequest{RequesterId: 1, PayerUsername: "boris", Amount: 40, Message: "за обед",
+		CreatedAt: createdAt, ExpiresAt: expiresAt}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    domain.PaymentRequest
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("boris").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectQuery(fmt.Sprintf("WITH created AS \\(\\s+INSERT INTO %s", paymentRequestsTable)).
+					WithArgs(1, 2, 40, "за обед", domain.PaymentRequestPending, createdAt, expiresAt).
+					WillReturnRows(sqlmock.NewRows(paymentRequestRowColumns).
+						AddRow(5, 1, "anna", 2, "boris", 40, "за обед", domain.PaymentRequestPending, nil, createdAt, expiresAt, nil))
+			},
+			want: domain.PaymentRequest{Id: 5, RequesterId: 1, RequesterUsername: "anna", PayerId: 2, PayerUsername: "boris",
+				Amount: 40, Message: "за обед", Status: domain.PaymentRequestPending, CreatedAt: createdAt, ExpiresAt: expiresAt},
+		},
+		{
+			name: "Плательщик не найден",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("boris").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: domain.ErrUserNotFound,
+		},
+		{
+			name: "Запрос самому себе",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("boris").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			wantErr: domain.ErrSelfTransfer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CreatePaymentRequest(input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPaymentRequestPostgres_GetPaymentRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewPaymentRequestPostgres(sqlx.NewDb(db, "postgres"))
+
+	createdAt := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+
+	mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s pr", paymentRequestsTable)).
+		WithArgs(2, domain.PaymentRequestPending).
+		WillReturnRows(sqlmock.NewRows(paymentRequestRowColumns).
+			AddRow(5, 1, "anna", 2, "boris", 40, "", domain.PaymentRequestPending, nil, createdAt, expiresAt, nil))
+
+	got, err := r.GetPaymentRequests(2, domain.PaymentRequestFilter{Direction: domain.DirectionIn, Status: domain.PaymentRequestPending})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.PaymentRequest{{Id: 5, RequesterId: 1, RequesterUsername: "anna", PayerId: 2, PayerUsername: "boris",
+		Amount: 40, Status: domain.PaymentRequestPending, CreatedAt: createdAt, ExpiresAt: expiresAt}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPaymentRequestPostgres_UpdatePaymentRequestStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewPaymentRequestPostgres(sqlx.NewDb(db, "postgres"))
+
+	createdAt := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+	resolvedAt := createdAt.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		from    string
+		to      string
+		want    domain.PaymentRequest
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("WITH updated AS \\(\\s+UPDATE %s", paymentRequestsTable)).
+					WithArgs(domain.PaymentRequestDeclined, sqlmock.AnyArg(), nil, 5, domain.PaymentRequestPending).
+					WillReturnRows(sqlmock.NewRows(paymentRequestRowColumns).
+						AddRow(5, 1, "anna", 2, "boris", 40, "", domain.PaymentRequestDeclined, nil, createdAt, expiresAt, resolvedAt))
+			},
+			from: domain.PaymentRequestPending,
+			to:   domain.PaymentRequestDeclined,
+			want: domain.PaymentRequest{Id: 5, RequesterId: 1, RequesterUsername: "anna", PayerId: 2, PayerUsername: "boris",
+				Amount: 40, Status: domain.PaymentRequestDeclined, CreatedAt: createdAt, ExpiresAt: expiresAt, ResolvedAt: &resolvedAt},
+		},
+		{
+			name: "Запрос уже обработан",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("WITH updated AS \\(\\s+UPDATE %s", paymentRequestsTable)).
+					WithArgs(domain.PaymentRequestCancelled, sqlmock.AnyArg(), nil, 5, domain.PaymentRequestPending).
+					WillReturnRows(sqlmock.NewRows(paymentRequestRowColumns))
+			},
+			from:    domain.PaymentRequestPending,
+			to:      domain.PaymentRequestCancelled,
+			wantErr: domain.ErrPaymentRequestResolved,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.UpdatePaymentRequestStatus(5, tt.from, tt.to, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPaymentRequestPostgres_AcceptPaymentRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewPaymentRequestPostgres(sqlx.NewDb(db, "postgres"))
+
+	createdAt := time.Date(2025, 3, 12, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+	now := createdAt.Add(time.Hour)
+	transactionId := 9
+	expectLock := func(expiresAt time.Time) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf("SELECT ru.username, pr.amount, pr.message, pr.expires_at FROM %s pr (.+) FOR UPDATE OF pr", paymentRequestsTable)).
+			WithArgs(5, 2, domain.PaymentRequestPending).
+			WillReturnRows(sqlmock.NewRows([]string{"username", "amount", "message", "expires_at"}).AddRow("anna", 40, "обед", expiresAt))
+	}
+	expectParties := func(payerCoins int) {
+		mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+)", userListTable)).
+			WithArgs("anna").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(fmt.Sprintf("SELECT id, coins FROM %s WHERE id IN (.+) ORDER BY id FOR UPDATE", userListTable)).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, 0).AddRow(2, payerCoins))
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    domain.PaymentRequest
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				expectLock(expiresAt)
+				expectParties(100)
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+					WithArgs(2, 1, 40, sqlmock.AnyArg(), "обед", "").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(transactionId))
+				expectDebitCoins(mock, 2, 40)
+				expectCreditCoins(mock, 1, 40)
+				mock.ExpectQuery(fmt.Sprintf("WITH updated AS \\(\\s+UPDATE %s", paymentRequestsTable)).
+					WithArgs(domain.PaymentRequestAccepted, sqlmock.AnyArg(), &transactionId, 5, domain.PaymentRequestPending).
+					WillReturnRows(sqlmock.NewRows(paymentRequestRowColumns).
+						AddRow(5, 1, "anna", 2, "boris", 40, "обед", domain.PaymentRequestAccepted, transactionId, createdAt, expiresAt, now))
+				mock.ExpectCommit()
+			},
+			want: domain.PaymentRequest{Id: 5, RequesterId: 1, RequesterUsername: "anna", PayerId: 2, PayerUsername: "boris",
+				Amount: 40, Message: "обед", Status: domain.PaymentRequestAccepted, TransactionId: &transactionId,
+				CreatedAt: createdAt, ExpiresAt: expiresAt, ResolvedAt: &now},
+		},
+		{
+			name: "Запрос уже обработан",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT ru.username, pr.amount, pr.message, pr.expires_at FROM %s pr (.+) FOR UPDATE OF pr", paymentRequestsTable)).
+					WithArgs(5, 2, domain.PaymentRequestPending).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrPaymentRequestResolved,
+		},
+		{
+			name: "Запрос истек",
+			mock: func() {
+				expectLock(createdAt)
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrPaymentRequestExpired,
+		},
+		{
+			name: "Недостаточно монет",
+			mock: func() {
+				expectLock(expiresAt)
+				expectParties(10)
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrInsufficientCoins,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.AcceptPaymentRequest(5, 2, now, domain.TransferLimits{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPaymentRequestPostgres_ExpirePaymentRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewPaymentRequestPostgres(sqlx.NewDb(db, "postgres"))
+
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status = (.+)", paymentRequestsTable)).
+		WithArgs(domain.PaymentRequestExpired, now, domain.PaymentRequestPending).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	expired, err := r.ExpirePaymentRequests(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, expired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type PaymentRequestPostgres struct {
+	db   *sqlx.DB
+	shop *ShopPostgres
+}
+
+func NewPaymentRequestPostgres(db *sqlx.DB) *PaymentRequestPostgres {
+	return &PaymentRequestPostgres{
+		db:   db,
+		shop: NewShopPostgres(db),
+	}
+}
+
+// paymentRequestColumns перечисляет поля запроса для запросов с псевдонимами
+// pr (payment_requests), ru (запросивший) и pu (плательщик) из userlist.
+const paymentRequestColumns = `pr.id, pr.requester_id, ru.username AS requester_username, pr.payer_id,
+	pu.username AS payer_username, pr.amount, pr.message, pr.status, pr.transaction_id, pr.created_at, pr.expires_at, pr.resolved_at`
+
+func (r *PaymentRequestPostgres) CreatePaymentRequest(request domain.PaymentRequest) (domain.PaymentRequest, error) {
+	payerQuery := fmt.Sprintf("SELECT id FROM %s WHERE username = $1 AND %s", userListTable, activeUserFilter)
+	if err := r.db.QueryRowx(payerQuery, request.PayerUsername).Scan(&request.PayerId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PaymentRequest{}, domain.ErrUserNotFound
+		}
+		return domain.PaymentRequest{}, err
+	}
+	if request.PayerId == request.RequesterId {
+		return domain.PaymentRequest{}, domain.ErrSelfTransfer
+	}
+	query := fmt.Sprintf(`WITH created AS (
+		INSERT INTO %s (requester_id, payer_id, amount, message, status, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *
+	)
+	SELECT %s FROM created pr JOIN %s ru ON pr.requester_id = ru.id JOIN %s pu ON pr.payer_id = pu.id`,
+		paymentRequestsTable, paymentRequestColumns, userListTable, userListTable)
+	var created domain.PaymentRequest
+	err := r.db.Get(&created, query, request.RequesterId, request.PayerId, request.Amount, request.Message,
+		domain.PaymentRequestPending, request.CreatedAt, request.ExpiresAt)
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	logger.Log.Debug().Int("id", created.Id).Msg("Успешно создан запрос на перевод")
+	return created, nil
+}
+
+func (r *PaymentRequestPostgres) GetPaymentRequests(userId int, filter domain.PaymentRequestFilter) ([]domain.PaymentRequest, error) {
+	args := []interface{}{userId}
+	var conditions []string
+	switch filter.Direction {
+	case domain.DirectionIn:
+		conditions = append(conditions, "pr.payer_id = $1")
+	case domain.DirectionOut:
+		conditions = append(conditions, "pr.requester_id = $1")
+	default:
+		conditions = append(conditions, "(pr.payer_id = $1 OR pr.requester_id = $1)")
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("pr.status = $%d", len(args)))
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s pr
+	JOIN %s ru ON pr.requester_id = ru.id
+	JOIN %s pu ON pr.payer_id = pu.id
+	WHERE %s
+	ORDER BY pr.created_at DESC, pr.id DESC`, paymentRequestColumns, paymentRequestsTable, userListTable, userListTable,
+		strings.Join(conditions, " AND "))
+
+	requests := []domain.PaymentRequest{}
+	if err := r.db.Select(&requests, query, args...); err != nil {
+		return nil, err
+	}
+	logger.Log.Debug().Int("count", len(requests)).Msg("Успешно получены запросы на перевод")
+	return requests, nil
+}
+
+func (r *PaymentRequestPostgres) GetPaymentRequest(requestId int) (domain.PaymentRequest, error) {
+	var request domain.PaymentRequest
+	query := fmt.Sprintf(`SELECT %s FROM %s pr
+	JOIN %s ru ON pr.requester_id = ru.id
+	JOIN %s pu ON pr.payer_id = pu.id
+	WHERE pr.id = $1`, paymentRequestColumns, paymentRequestsTable, userListTable, userListTable)
+	if err := r.db.Get(&request, query, requestId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PaymentRequest{}, domain.ErrPaymentRequestNotFound
+		}
+		return domain.PaymentRequest{}, err
+	}
+	return request, nil
+}
+
+// UpdatePaymentRequestStatus переводит запрос из статуса from в статус to, только если
+// запрос все еще в статусе from, иначе возвращает domain.ErrPaymentRequestResolved.
+// transactionId, если задан, привязывает к запросу выполненный перевод.
+func (r *PaymentRequestPostgres) UpdatePaymentRequestStatus(requestId int, from, to string, transactionId *int) (domain.PaymentRequest, error) {
+	return updatePaymentRequestStatus(r.db, requestId, from, to, transactionId)
+}
+
+// AcceptPaymentRequest блокирует ожидающий запрос плательщика payerId, переводит монеты запросившему
+// с проверкой баланса и лимитов и помечает запрос принятым в одной транзакции:
+// при ошибке перевода запрос остается в ожидании, а повторное принятие не выполнит второй перевод.
+func (r *PaymentRequestPostgres) AcceptPaymentRequest(requestId, payerId int, timestamp time.Time, limits domain.TransferLimits) (domain.PaymentRequest, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	var requesterUsername, message string
+	var amount int
+	var expiresAt time.Time
+	lockQuery := fmt.Sprintf(`SELECT ru.username, pr.amount, pr.message, pr.expires_at FROM %s pr
+	JOIN %s ru ON pr.requester_id = ru.id
+	WHERE pr.id = $1 AND pr.payer_id = $2 AND pr.status = $3 FOR UPDATE OF pr`, paymentRequestsTable, userListTable)
+	err = tr.QueryRowx(lockQuery, requestId, payerId, domain.PaymentRequestPending).Scan(&requesterUsername, &amount, &message, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PaymentRequest{}, domain.ErrPaymentRequestResolved
+		}
+		return domain.PaymentRequest{}, err
+	}
+	if !expiresAt.After(timestamp) {
+		return domain.PaymentRequest{}, domain.ErrPaymentRequestExpired
+	}
+	requesterId, err := r.shop.getDestinationUserId(tr, requesterUsername)
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	balances, err := r.shop.lockTransferParties(tr, payerId, []int{requesterId})
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	transactionId, err := r.shop.executeTransfer(tr, domain.Transactions{
+		Source:      &payerId,
+		Destination: &requesterId,
+		Amount:      amount,
+		Message:     message,
+		Timestamp:   &timestamp,
+	}, balances, limits)
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	request, err := updatePaymentRequestStatus(tr, requestId, domain.PaymentRequestPending, domain.PaymentRequestAccepted, &transactionId)
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	return request, tr.Commit()
+}
+
+func updatePaymentRequestStatus(q sqlx.Queryer, requestId int, from, to string, transactionId *int) (domain.PaymentRequest, error) {
+	var resolvedAt *time.Time
+	if to != domain.PaymentRequestPending {
+		now := time.Now()
+		resolvedAt = &now
+	}
+	query := fmt.Sprintf(`WITH updated AS (
+		UPDATE %s SET status = $1, resolved_at = $2, transaction_id = COALESCE($3, transaction_id)
+		WHERE id = $4 AND status = $5 RETURNING *
+	)
+	SELECT %s FROM updated pr JOIN %s ru ON pr.requester_id = ru.id JOIN %s pu ON pr.payer_id = pu.id`,
+		paymentRequestsTable, paymentRequestColumns, userListTable, userListTable)
+	var request domain.PaymentRequest
+	if err := sqlx.Get(q, &request, query, to, resolvedAt, transactionId, requestId, from); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PaymentRequest{}, domain.ErrPaymentRequestResolved
+		}
+		return domain.PaymentRequest{}, err
+	}
+	logger.Log.Debug().Int("id", requestId).Str("status", to).Msg("Успешно изменен статус запроса на перевод")
+	return request, nil
+}
+
+// ExpirePaymentRequests помечает просроченными ожидающие запросы со сроком действия до now.
+func (r *PaymentRequestPostgres) ExpirePaymentRequests(now time.Time) (int, error) {
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, resolved_at = $2
+	WHERE status = $3 AND expires_at <= $2`, paymentRequestsTable)
+	result, err := r.db.Exec(query, domain.PaymentRequestExpired, now, domain.PaymentRequestPending)
+	if err != nil {
+		return 0, err
+	}
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(expired), nil
+}
+
+func (r *PaymentRequestPostgres) DB() *sqlx.DB {
+	return r.db
+}
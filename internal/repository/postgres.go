@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/bllooop/coinshop/internal/domain"
 	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 )
@@ -18,12 +21,42 @@ type Config struct {
 }
 
 const (
-	userListTable     = "userlist"
-	shopTable         = "shop"
-	transactionsTable = "transactions"
-	purchaseTable     = "purchases"
+	userListTable        = "userlist"
+	shopTable            = "shop"
+	transactionsTable    = "transactions"
+	purchaseTable        = "purchases"
+	ordersTable          = "orders"
+	refundsTable         = "refunds"
+	variantsTable        = "item_variants"
+	promotionsTable      = "promotions"
+	schedulesTable       = "scheduled_transfers"
+	scheduleRunsTable    = "scheduled_transfer_runs"
+	allowanceTable       = "allowance_payouts"
+	coinLotsTable        = "coin_lots"
+	spentCoinLotsTable   = "spent_coin_lots"
+	paymentRequestsTable = "payment_requests"
+	escrowsTable         = "escrows"
+	loginAttemptsTable   = "login_attempts"
+	rateLimitTable       = "rate_limit_buckets"
+	auditLogTable        = "audit_log"
+	recoveryCodesTable   = "recovery_codes"
+	serviceAccountsTable = "service_accounts"
+	apiKeysTable         = "api_keys"
+	oidcStatesTable      = "oidc_states"
 )
 
+// activeUserFilter исключает из поиска пользователей по имени служебный счет казны и удаленные аккаунты.
+const activeUserFilter = "role NOT IN ('" + domain.RoleTreasury + "', '" + domain.RoleDeleted + "')"
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении ограничения уникальности.
+const uniqueViolation = "23505"
+
+// isUniqueViolation сообщает, что запрос нарушил ограничение уникальности.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
 func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
 	logger.Log.Info().Msg("Подключение к базе данных")
 	constring := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s", cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.DBname, cfg.SSLMode)
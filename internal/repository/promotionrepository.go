@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type PromotionPostgres struct {
+	db *sqlx.DB
+}
+
+func NewPromotionPostgres(db *sqlx.DB) *PromotionPostgres {
+	return &PromotionPostgres{
+		db: db,
+	}
+}
+
+const promotionListColumns = `pr.id, pr.name, pr.kind, pr.value, pr.item_id, s.name AS item_name, pr.category, pr.code,
+	pr.usage_limit, pr.used_count, pr.starts_at, pr.ends_at`
+
+func (r *PromotionPostgres) CreatePromotion(input domain.PromotionInput) (domain.Promotion, error) {
+	var itemId *int
+	if input.Item != "" {
+		var id int
+		itemQuery := fmt.Sprintf("SELECT id FROM %s WHERE name = $1", shopTable)
+		if err := r.db.QueryRowx(itemQuery, input.Item).Scan(&id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.Promotion{}, domain.ErrItemNotFound
+			}
+			return domain.Promotion{}, err
+		}
+		itemId = &id
+	}
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (name, kind, value, item_id, category, code, usage_limit, starts_at, ends_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (code) DO NOTHING RETURNING id`, promotionsTable)
+	err := r.db.QueryRowx(query, input.Name, input.Kind, input.Value, itemId, nullString(input.Category),
+		nullString(input.Code), input.UsageLimit, input.StartsAt, input.EndsAt).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Promotion{}, domain.ErrPromotionExists
+		}
+		return domain.Promotion{}, err
+	}
+	logger.Log.Debug().Int("id", id).Msg("Успешно создана акция")
+	return r.getPromotion(id)
+}
+
+func (r *PromotionPostgres) GetPromotions() ([]domain.Promotion, error) {
+	promotions := []domain.Promotion{}
+	query := fmt.Sprintf(`SELECT %s FROM %s pr LEFT JOIN %s s ON pr.item_id = s.id
+	ORDER BY pr.starts_at DESC, pr.id DESC`, promotionListColumns, promotionsTable, shopTable)
+	if err := r.db.Select(&promotions, query); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// EndPromotion завершает акцию досрочно, история покупок по ней сохраняется.
+func (r *PromotionPostgres) EndPromotion(id int) (domain.Promotion, error) {
+	now := time.Now()
+	query := fmt.Sprintf(`UPDATE %s SET ends_at = $1, starts_at = LEAST(starts_at, $1 - interval '1 microsecond')
+	WHERE id = $2 AND ends_at > $1`, promotionsTable)
+	res, err := r.db.Exec(query, now, id)
+	if err != nil {
+		return domain.Promotion{}, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return domain.Promotion{}, err
+	}
+	promotion, err := r.getPromotion(id)
+	if err != nil {
+		return domain.Promotion{}, err
+	}
+	if affected > 0 {
+		logger.Log.Debug().Int("id", id).Msg("Акция завершена")
+	}
+	return promotion, nil
+}
+
+func (r *PromotionPostgres) getPromotion(id int) (domain.Promotion, error) {
+	var promotion domain.Promotion
+	query := fmt.Sprintf(`SELECT %s FROM %s pr LEFT JOIN %s s ON pr.item_id = s.id
+	WHERE pr.id = $1`, promotionListColumns, promotionsTable, shopTable)
+	if err := r.db.Get(&promotion, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Promotion{}, domain.ErrPromotionNotFound
+		}
+		return domain.Promotion{}, err
+	}
+	return promotion, nil
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func (r *PromotionPostgres) DB() *sqlx.DB {
+	return r.db
+}
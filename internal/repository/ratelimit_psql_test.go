@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/pkg/ratelimit"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitPostgres_Take(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewRateLimitPostgres(sqlx.NewDb(db, "postgres"))
+
+	now := time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC)
+	limit := ratelimit.Limit{Rate: 2, Burst: 10}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		allowed bool
+		want    ratelimit.Result
+	}{
+		{name: "Токен взят", tokens: 4, allowed: true, want: ratelimit.Result{Allowed: true, Remaining: 4}},
+		{name: "Корзина пуста", tokens: 0.5, allowed: false, want: ratelimit.Result{RetryAfter: 250 * time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s AS b (.+)", rateLimitTable)).
+				WithArgs("api:user:1", 10, now, 2.0).
+				WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(tt.tokens, tt.allowed))
+
+			got, err := r.Take("api:user:1", limit, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
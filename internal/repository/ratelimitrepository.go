@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/pkg/ratelimit"
+	"github.com/jmoiron/sqlx"
+)
+
+// RateLimitPostgres хранит корзины ограничителя частоты запросов в общей базе,
+// чтобы лимиты действовали на все узлы сервиса.
+type RateLimitPostgres struct {
+	db *sqlx.DB
+}
+
+func NewRateLimitPostgres(db *sqlx.DB) *RateLimitPostgres {
+	return &RateLimitPostgres{
+		db: db,
+	}
+}
+
+// rateLimitRefill - число токенов в корзине b после пополнения на момент $3 при емкости $2 и скорости $4 в секунду.
+const rateLimitRefill = `LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($3::timestamp - b.updated_at))::float8, 0) * $4::float8)`
+
+// Take пополняет корзину и берет токен одним запросом, поэтому параллельные запросы с разных
+// узлов не могут взять один и тот же токен. Поле allowed хранит результат последней попытки.
+func (r *RateLimitPostgres) Take(key string, limit ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	var tokens float64
+	var allowed bool
+	query := fmt.Sprintf(`INSERT INTO %s AS b (key, tokens, allowed, updated_at) VALUES ($1, $2::float8 - 1, true, $3)
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = %s - CASE WHEN %s >= 1 THEN 1 ELSE 0 END,
+		allowed = %s >= 1,
+		updated_at = GREATEST(b.updated_at, $3::timestamp)
+	RETURNING tokens, allowed`, rateLimitTable, rateLimitRefill, rateLimitRefill, rateLimitRefill)
+	if err := r.db.QueryRowx(query, key, limit.Burst, now, limit.Rate).Scan(&tokens, &allowed); err != nil {
+		return ratelimit.Result{}, err
+	}
+	if allowed {
+		return ratelimit.NewResult(tokens+1, limit), nil
+	}
+	return ratelimit.NewResult(tokens, limit), nil
+}
+
+func (r *RateLimitPostgres) Purge(before time.Time) (int, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE updated_at < $1", rateLimitTable)
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *RateLimitPostgres) DB() *sqlx.DB {
+	return r.db
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var refundRowColumns = []string{"id", "purchase_id", "user_id", "amount", "status", "reason",
+	"transaction_id", "resolved_by", "created_at", "resolved_at"}
+
+var refundMarkColumns = []string{"item_id", "quantity", "variant_id", "order_id", "promotion_id"}
+
+func TestRefundPostgres_CreateRefundRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewRefundPostgres(sqlx.NewDb(db, "postgres"))
+
+	input := domain.Refund{PurchaseId: 3, UserId: 1, Amount: 40, Reason: "не подошел размер"}
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+) WHERE NOT EXISTS", refundsTable)).
+					WithArgs(3, 1, 40, domain.RefundRequested, "не подошел размер", sqlmock.AnyArg(), domain.RefundApproved).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			want: 1,
+		},
+		{
+			name: "Заявка уже существует",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", refundsTable)).
+					WithArgs(3, 1, 40, domain.RefundRequested, "не подошел размер", sqlmock.AnyArg(), domain.RefundApproved).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: domain.ErrRefundExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CreateRefundRequest(input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRefundPostgres_ApproveRefund(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewRefundPostgres(sqlx.NewDb(db, "postgres"))
+	createdAt := time.Date(2025, 2, 5, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    domain.Refund
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE id = (.+) FOR UPDATE", refundsTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(refundRowColumns).
+						AddRow(1, 3, 1, 40, domain.RefundRequested, "", nil, nil, createdAt, nil))
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET refunded_at (.+) RETURNING item_id, quantity, variant_id", purchaseTable)).
+					WithArgs(sqlmock.AnyArg(), 3, domain.PurchaseCancelled, domain.PurchasePending, domain.PurchasePacked).
+					WillReturnRows(sqlmock.NewRows(refundMarkColumns).AddRow(2, 2, nil, 4, nil))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET stock = stock \\+ (.+)", shopTable)).
+					WithArgs(2, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+					WithArgs(1, 40, sqlmock.AnyArg(), domain.TransactionRefund, 3).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				expectRestoreCoins(mock, spentOnPurchase, 3)
+				expectCreditCoins(mock, 1, 40)
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET status (.+)", refundsTable)).
+					WithArgs(domain.RefundApproved, 7, 5, sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows(refundRowColumns).
+						AddRow(1, 3, 1, 40, domain.RefundApproved, "", 7, 5, createdAt, createdAt))
+				mock.ExpectCommit()
+			},
+			want: domain.Refund{
+				Id: 1, PurchaseId: 3, UserId: 1, Amount: 40, Status: domain.RefundApproved,
+				TransactionId: IntPointer(7), ResolvedBy: IntPointer(5), CreatedAt: createdAt, ResolvedAt: &createdAt,
+			},
+		},
+		{
+			// покупка по промокоду возвращает его использование в той же транзакции
+			name: "Покупка по промокоду",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE id = (.+) FOR UPDATE", refundsTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(refundRowColumns).
+						AddRow(1, 3, 1, 40, domain.RefundRequested, "", nil, nil, createdAt, nil))
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET refunded_at (.+) RETURNING item_id, quantity, variant_id, order_id, promotion_id", purchaseTable)).
+					WithArgs(sqlmock.AnyArg(), 3, domain.PurchaseCancelled, domain.PurchasePending, domain.PurchasePacked).
+					WillReturnRows(sqlmock.NewRows(refundMarkColumns).AddRow(2, 2, nil, 4, 6))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET stock = stock \\+ (.+)", shopTable)).
+					WithArgs(2, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(fmt.Sprintf("SELECT id FROM %s WHERE id = \\$1 FOR UPDATE", ordersTable)).
+					WithArgs(4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET used_count = used_count - 1 (.+) AND NOT EXISTS (.+)", promotionsTable)).
+					WithArgs(6, 4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+					WithArgs(1, 40, sqlmock.AnyArg(), domain.TransactionRefund, 3).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				expectRestoreCoins(mock, spentOnPurchase, 3)
+				expectCreditCoins(mock, 1, 40)
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET status (.+)", refundsTable)).
+					WithArgs(domain.RefundApproved, 7, 5, sqlmock.AnyArg(), 1).
+					WillReturnRows(sqlmock.NewRows(refundRowColumns).
+						AddRow(1, 3, 1, 40, domain.RefundApproved, "", 7, 5, createdAt, createdAt))
+				mock.ExpectCommit()
+			},
+			want: domain.Refund{
+				Id: 1, PurchaseId: 3, UserId: 1, Amount: 40, Status: domain.RefundApproved,
+				TransactionId: IntPointer(7), ResolvedBy: IntPointer(5), CreatedAt: createdAt, ResolvedAt: &createdAt,
+			},
+		},
+		{
+			name: "Покупка уже отправлена",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE id = (.+) FOR UPDATE", refundsTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(refundRowColumns).
+						AddRow(1, 3, 1, 40, domain.RefundRequested, "", nil, nil, createdAt, nil))
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET refunded_at (.+) AND status IN (.+)", purchaseTable)).
+					WithArgs(sqlmock.AnyArg(), 3, domain.PurchaseCancelled, domain.PurchasePending, domain.PurchasePacked).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(fmt.Sprintf("SELECT refunded_at IS NOT NULL FROM %s", purchaseTable)).
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows([]string{"refunded"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrPurchaseShipped,
+		},
+		{
+			name: "Заявка уже рассмотрена",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE id = (.+) FOR UPDATE", refundsTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(refundRowColumns).
+						AddRow(1, 3, 1, 40, domain.RefundRejected, "", nil, 5, createdAt, createdAt))
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrRefundResolved,
+		},
+		{
+			name: "Заявка не найдена",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE id = (.+) FOR UPDATE", refundsTable)).
+					WithArgs(1).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrRefundNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.ApproveRefund(1, 5)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRefundPostgres_RejectRefund(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewRefundPostgres(sqlx.NewDb(db, "postgres"))
+
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "Заявка уже рассмотрена",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET status (.+)", refundsTable)).
+					WithArgs(domain.RefundRejected, 5, sqlmock.AnyArg(), 1, domain.RefundRequested).
+					WillReturnRows(sqlmock.NewRows(refundRowColumns))
+				mock.ExpectQuery(fmt.Sprintf("SELECT status FROM %s", refundsTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(domain.RefundApproved))
+			},
+			wantErr: domain.ErrRefundResolved,
+		},
+		{
+			name: "Заявка не найдена",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET status (.+)", refundsTable)).
+					WithArgs(domain.RefundRejected, 5, sqlmock.AnyArg(), 1, domain.RefundRequested).
+					WillReturnRows(sqlmock.NewRows(refundRowColumns))
+				mock.ExpectQuery(fmt.Sprintf("SELECT status FROM %s", refundsTable)).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"status"}))
+			},
+			wantErr: domain.ErrRefundNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			_, err := r.RejectRefund(1, 5)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
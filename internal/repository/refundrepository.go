@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type RefundPostgres struct {
+	db *sqlx.DB
+}
+
+func NewRefundPostgres(db *sqlx.DB) *RefundPostgres {
+	return &RefundPostgres{
+		db: db,
+	}
+}
+
+const refundColumns = "id, purchase_id, user_id, amount, status, reason, transaction_id, resolved_by, created_at, resolved_at"
+
+func (r *RefundPostgres) GetPurchase(purchaseId int) (domain.Purchase, error) {
+	var purchase domain.Purchase
+	query := fmt.Sprintf(`SELECT %s
+	FROM %s p
+	%s
+	WHERE p.id = $1`, purchaseColumns, purchaseTable, purchaseJoins)
+	if err := r.db.Get(&purchase, query, purchaseId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Purchase{}, domain.ErrPurchaseNotFound
+		}
+		return domain.Purchase{}, err
+	}
+	return purchase, nil
+}
+
+// CreateRefundRequest создает заявку, только если по покупке нет открытой или одобренной заявки.
+func (r *RefundPostgres) CreateRefundRequest(refund domain.Refund) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (purchase_id, user_id, amount, status, reason, created_at)
+	SELECT $1, $2, $3, $4, $5, $6
+	WHERE NOT EXISTS (SELECT 1 FROM %s WHERE purchase_id = $1 AND status IN ($4, $7))
+	RETURNING id`, refundsTable, refundsTable)
+	row := r.db.QueryRowx(query, refund.PurchaseId, refund.UserId, refund.Amount, domain.RefundRequested,
+		refund.Reason, time.Now(), domain.RefundApproved)
+	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrRefundExists
+		}
+		return 0, err
+	}
+	logger.Log.Debug().Int("id", id).Msg("Успешно создана заявка на возврат")
+	return id, nil
+}
+
+func (r *RefundPostgres) GetRefunds(status string) ([]domain.Refund, error) {
+	refunds := []domain.Refund{}
+	query := fmt.Sprintf("SELECT %s FROM %s", refundColumns, refundsTable)
+	args := []interface{}{}
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at, id"
+	if err := r.db.Select(&refunds, query, args...); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *RefundPostgres) ApproveRefund(refundId, adminId int) (domain.Refund, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	refund, err := r.lockRefund(tr, refundId)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	if refund.Status != domain.RefundRequested {
+		return domain.Refund{}, domain.ErrRefundResolved
+	}
+	if refund, err = r.executeRefund(tr, refund, adminId); err != nil {
+		return domain.Refund{}, err
+	}
+	return refund, tr.Commit()
+}
+
+func (r *RefundPostgres) RejectRefund(refundId, adminId int) (domain.Refund, error) {
+	var refund domain.Refund
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, resolved_by = $2, resolved_at = $3
+	WHERE id = $4 AND status = $5
+	RETURNING %s`, refundsTable, refundColumns)
+	err := r.db.Get(&refund, query, domain.RefundRejected, adminId, time.Now(), refundId, domain.RefundRequested)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Refund{}, r.refundMissingReason(refundId)
+		}
+		return domain.Refund{}, err
+	}
+	logger.Log.Debug().Int("id", refundId).Msg("Заявка на возврат отклонена")
+	return refund, nil
+}
+
+// ForceRefund возвращает покупку без проверки срока: открытая заявка одобряется,
+// а при ее отсутствии создается сразу одобренная.
+func (r *RefundPostgres) ForceRefund(purchaseId, adminId int, reason string) (domain.Refund, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	var amount, userId int
+	purchaseQuery := fmt.Sprintf("SELECT user_id, price * quantity FROM %s WHERE id = $1 FOR UPDATE", purchaseTable)
+	if err = tr.QueryRowx(purchaseQuery, purchaseId).Scan(&userId, &amount); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Refund{}, domain.ErrPurchaseNotFound
+		}
+		return domain.Refund{}, err
+	}
+
+	var refund domain.Refund
+	openQuery := fmt.Sprintf("SELECT %s FROM %s WHERE purchase_id = $1 AND status IN ($2, $3) FOR UPDATE", refundColumns, refundsTable)
+	err = tr.Get(&refund, openQuery, purchaseId, domain.RefundRequested, domain.RefundApproved)
+	switch {
+	case err == nil && refund.Status == domain.RefundApproved:
+		return domain.Refund{}, domain.ErrAlreadyRefunded
+	case errors.Is(err, sql.ErrNoRows):
+		createQuery := fmt.Sprintf(`INSERT INTO %s (purchase_id, user_id, amount, status, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING %s`, refundsTable, refundColumns)
+		if err = tr.Get(&refund, createQuery, purchaseId, userId, amount, domain.RefundRequested, reason, time.Now()); err != nil {
+			return domain.Refund{}, err
+		}
+	case err != nil:
+		return domain.Refund{}, err
+	}
+	if refund, err = r.executeRefund(tr, refund, adminId); err != nil {
+		return domain.Refund{}, err
+	}
+	return refund, tr.Commit()
+}
+
+func (r *RefundPostgres) lockRefund(tr *sqlx.Tx, refundId int) (domain.Refund, error) {
+	var refund domain.Refund
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 FOR UPDATE", refundColumns, refundsTable)
+	if err := tr.Get(&refund, query, refundId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Refund{}, domain.ErrRefundNotFound
+		}
+		return domain.Refund{}, err
+	}
+	return refund, nil
+}
+
+// executeRefund возвращает монеты и запас товара, записывает транзакцию возврата,
+// связанную с покупкой, помечает покупку как возвращенную и отмененную, а заявку как одобренную.
+// Вернуть можно только покупку, которая еще не отправлена: условие на статус в UPDATE
+// защищает от одновременной отправки покупки администратором.
+func (r *RefundPostgres) executeRefund(tr *sqlx.Tx, refund domain.Refund, adminId int) (domain.Refund, error) {
+	now := time.Now()
+	var itemId, quantity int
+	var variantId, orderId, promotionId *int
+	markQuery := fmt.Sprintf(`UPDATE %s SET refunded_at = $1, status = $3, cancelled_at = $1
+	WHERE id = $2 AND refunded_at IS NULL AND status IN ($4, $5)
+	RETURNING item_id, quantity, variant_id, order_id, promotion_id`, purchaseTable)
+	err := tr.QueryRowx(markQuery, now, refund.PurchaseId, domain.PurchaseCancelled, domain.PurchasePending, domain.PurchasePacked).
+		Scan(&itemId, &quantity, &variantId, &orderId, &promotionId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Refund{}, r.unrefundableReason(tr, refund.PurchaseId)
+		}
+		return domain.Refund{}, err
+	}
+	restockQuery := fmt.Sprintf("UPDATE %s SET stock = stock + $1 WHERE id = $2", shopTable)
+	if _, err := tr.Exec(restockQuery, quantity, itemId); err != nil {
+		return domain.Refund{}, err
+	}
+	if variantId != nil {
+		restockVariantQuery := fmt.Sprintf("UPDATE %s SET stock = stock + $1 WHERE id = $2", variantsTable)
+		if _, err := tr.Exec(restockVariantQuery, quantity, *variantId); err != nil {
+			return domain.Refund{}, err
+		}
+	}
+	if promotionId != nil {
+		if err = releasePromoCode(tr, *promotionId, orderId); err != nil {
+			return domain.Refund{}, err
+		}
+	}
+	var transactionId int
+	transactionQuery := fmt.Sprintf(`INSERT INTO %s (source, destination, amount, transaction_time, kind, purchase_id)
+	VALUES (NULL, $1, $2, $3, $4, $5) RETURNING id`, transactionsTable)
+	err = tr.QueryRowx(transactionQuery, refund.UserId, refund.Amount, now, domain.TransactionRefund, refund.PurchaseId).Scan(&transactionId)
+	if err != nil {
+		return domain.Refund{}, err
+	}
+	if err = restoreCoins(tr, refund.UserId, refund.Amount, spentOnPurchase, refund.PurchaseId, &transactionId); err != nil {
+		return domain.Refund{}, err
+	}
+	var resolved domain.Refund
+	resolveQuery := fmt.Sprintf(`UPDATE %s SET status = $1, transaction_id = $2, resolved_by = $3, resolved_at = $4
+	WHERE id = $5 RETURNING %s`, refundsTable, refundColumns)
+	if err = tr.Get(&resolved, resolveQuery, domain.RefundApproved, transactionId, adminId, now, refund.Id); err != nil {
+		return domain.Refund{}, err
+	}
+	logger.Log.Debug().Int("id", refund.Id).Int("transaction", transactionId).Msg("Успешно выполнен возврат покупки")
+	return resolved, nil
+}
+
+// releasePromoCode возвращает использование промокода, когда возвращена последняя покупка заказа с этой акцией:
+// промокод учитывается один раз на заказ. Заказ блокируется, чтобы из одновременных возвратов разных позиций
+// использование вернул ровно один. Акции без кода использования не считают, для них запрос ничего не меняет.
+func releasePromoCode(tr *sqlx.Tx, promotionId int, orderId *int) error {
+	if orderId != nil {
+		lockQuery := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR UPDATE", ordersTable)
+		if _, err := tr.Exec(lockQuery, *orderId); err != nil {
+			return err
+		}
+	}
+	query := fmt.Sprintf(`UPDATE %s SET used_count = used_count - 1
+	WHERE id = $1 AND code IS NOT NULL AND used_count > 0
+	AND NOT EXISTS (SELECT 1 FROM %s WHERE order_id = $2 AND promotion_id = $1 AND refunded_at IS NULL)`,
+		promotionsTable, purchaseTable)
+	_, err := tr.Exec(query, promotionId, orderId)
+	return err
+}
+
+// unrefundableReason объясняет, почему покупку не удалось пометить возвращенной.
+func (r *RefundPostgres) unrefundableReason(tr *sqlx.Tx, purchaseId int) error {
+	var refunded bool
+	query := fmt.Sprintf("SELECT refunded_at IS NOT NULL FROM %s WHERE id = $1", purchaseTable)
+	if err := tr.QueryRowx(query, purchaseId).Scan(&refunded); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrPurchaseNotFound
+		}
+		return err
+	}
+	if refunded {
+		return domain.ErrAlreadyRefunded
+	}
+	return domain.ErrPurchaseShipped
+}
+
+func (r *RefundPostgres) refundMissingReason(refundId int) error {
+	var status string
+	query := fmt.Sprintf("SELECT status FROM %s WHERE id = $1", refundsTable)
+	if err := r.db.QueryRowx(query, refundId).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrRefundNotFound
+		}
+		return err
+	}
+	return domain.ErrRefundResolved
+}
+
+func (r *RefundPostgres) DB() *sqlx.DB {
+	return r.db
+}
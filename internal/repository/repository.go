@@ -1,28 +1,186 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/jmoiron/sqlx"
 )
 
+//go:generate mockgen -source=repository.go -destination=mocks/mock.go
 type Authorization interface {
-	CreateUser(user domain.User) (int, error)
+	CreateUser(user domain.User, bonusExpiresAt *time.Time) (int, error)
 	SignUser(username string) (domain.User, error)
+	GetUserRole(userId int) (string, error)
+	UpdateLastLogin(userId int, at time.Time) error
+	GetUser(userId int) (domain.User, error)
+	GetTokenState(userId int) (domain.TokenState, error)
+	UpdatePassword(userId int, passwordHash string) (int, error)
+	RehashPassword(userId int, oldHash, newHash string) error
+	UpdateUsername(userId int, username string) error
+	DeleteUser(userId int) error
 }
 type Shop interface {
-	BuyItem(userid int, name string) (int, error)
-	SendCoin(input domain.Transactions) (int, error)
+	BuyItem(userid int, name string, options domain.BuyOptions) (int, error)
+	CreateOrder(userid int, lines []domain.OrderLine, promoCode string) (int, error)
+	SendCoin(input domain.Transactions, limits domain.TransferLimits) (int, error)
+	SendCoinBatch(source int, transfers []domain.Transactions, limits domain.TransferLimits) ([]int, error)
 	GetUserSummary(userID int) (*domain.UserSummary, error)
+	GetPurchases(userID int, filter domain.PurchaseFilter) ([]domain.Purchase, error)
+	GetTransactions(userID int, filter domain.TransactionFilter) ([]domain.Transactions, error)
+}
+
+type Inventory interface {
+	GetItems() ([]domain.Merch, error)
+	Restock(name string, quantity int) (domain.ItemStockChange, error)
+	UpdateItemStock(name string, input domain.ItemStockInput) (domain.ItemStockChange, error)
+	CreateVariant(name string, input domain.VariantInput) (domain.ItemVariant, error)
+	UpdateVariantStock(name string, variantId int, stock *int) (domain.VariantStockChange, error)
+}
+
+type Refunds interface {
+	GetPurchase(purchaseId int) (domain.Purchase, error)
+	CreateRefundRequest(refund domain.Refund) (int, error)
+	GetRefunds(status string) ([]domain.Refund, error)
+	ApproveRefund(refundId, adminId int) (domain.Refund, error)
+	RejectRefund(refundId, adminId int) (domain.Refund, error)
+	ForceRefund(purchaseId, adminId int, reason string) (domain.Refund, error)
+}
+
+type Fulfillment interface {
+	GetPurchasesByStatus(status string) ([]domain.Purchase, error)
+	UpdatePurchaseStatus(purchaseId int, from, to string) (domain.Purchase, error)
+}
+
+type Promotions interface {
+	CreatePromotion(input domain.PromotionInput) (domain.Promotion, error)
+	GetPromotions() ([]domain.Promotion, error)
+	EndPromotion(id int) (domain.Promotion, error)
+}
+
+type Schedules interface {
+	CreateSchedule(schedule domain.ScheduledTransfer) (domain.ScheduledTransfer, error)
+	GetSchedules(userId int) ([]domain.ScheduledTransfer, error)
+	GetSchedule(userId, scheduleId int) (domain.ScheduledTransfer, error)
+	CancelSchedule(userId, scheduleId int) error
+	GetScheduleRuns(scheduleId int) ([]domain.ScheduleRun, error)
+	GetDueSchedules(now time.Time, limit int) ([]domain.ScheduledTransfer, error)
+	ClaimSchedule(scheduleId int, dueAt, nextRunAt, now time.Time) (bool, error)
+	CreateScheduleRun(run domain.ScheduleRun) (int, error)
+}
+
+type Treasury interface {
+	GrantCoins(adminId int, input domain.CoinAdjustmentInput, expiresAt *time.Time) (domain.CoinAdjustment, error)
+	ClawbackCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error)
+	PayAllowance(period string, amount int, activeSince time.Time, expiresAt *time.Time) (int, error)
+	ExpireCoins(now time.Time) (int, error)
+}
+
+type PaymentRequests interface {
+	CreatePaymentRequest(request domain.PaymentRequest) (domain.PaymentRequest, error)
+	GetPaymentRequests(userId int, filter domain.PaymentRequestFilter) ([]domain.PaymentRequest, error)
+	GetPaymentRequest(requestId int) (domain.PaymentRequest, error)
+	UpdatePaymentRequestStatus(requestId int, from, to string, transactionId *int) (domain.PaymentRequest, error)
+	AcceptPaymentRequest(requestId, payerId int, timestamp time.Time, limits domain.TransferLimits) (domain.PaymentRequest, error)
+	ExpirePaymentRequests(now time.Time) (int, error)
+}
+
+type Escrows interface {
+	CreateEscrow(escrow domain.Escrow, limits domain.TransferLimits) (domain.Escrow, error)
+	GetEscrows(userId int, status string) ([]domain.Escrow, error)
+	GetEscrow(escrowId int) (domain.Escrow, error)
+	GetExpiredEscrows(now time.Time) ([]domain.Escrow, error)
+	SettleEscrow(escrowId int, action string, resolvedBy *int, limits domain.TransferLimits) (domain.Escrow, error)
+}
+
+type LoginAttempts interface {
+	GetLoginAttempts(username, ip string) ([]domain.LoginAttempt, error)
+	RecordLoginFailure(scope, value string, now, resetBefore time.Time) (int, error)
+	LockLogin(scope, value string, until time.Time) error
+	ResetLoginAttempts(scope, value string) (int, error)
+	PurgeLoginAttempts(before time.Time) (int, error)
+}
+
+type Users interface {
+	SearchUsers(filter domain.UserFilter) ([]domain.UserInfo, error)
+	GetUserInfo(userId int) (domain.UserInfo, error)
+	BanUser(userId, adminId int, reason string, at time.Time) (domain.UserInfo, error)
+	UnbanUser(userId int) (domain.UserInfo, error)
+	ResetPassword(userId int, passwordHash string) error
+}
+
+type Audit interface {
+	AppendAuditEntry(entry domain.AuditEntry) (domain.AuditEntry, error)
+	InsertUnchainedAuditEntry(entry domain.AuditEntry) (domain.AuditEntry, error)
+	GetAuditLog(filter domain.AuditFilter) ([]domain.AuditEntry, error)
+	GetAuditChain(afterId int64, limit int) ([]domain.AuditEntry, error)
+}
+
+type TwoFactor interface {
+	GetTwoFactor(userId int) (domain.TwoFactorState, error)
+	SetTwoFactorSecret(userId int, secret string) error
+	EnableTwoFactor(userId int, step int64, codeHashes []string) error
+	DisableTwoFactor(userId int) error
+	ReplaceRecoveryCodes(userId int, codeHashes []string) error
+	UseTotpStep(userId int, step int64) (bool, error)
+	UseRecoveryCode(userId int, codeHash string, at time.Time) (bool, error)
+}
+
+type ApiKeys interface {
+	CreateServiceAccount(adminId int, input domain.ServiceAccountInput) (domain.ServiceAccount, error)
+	GetServiceAccount(id int) (domain.ServiceAccount, error)
+	GetServiceAccounts() ([]domain.ServiceAccount, error)
+	CreateApiKey(adminId, accountId int, prefix, keyHash string, scopes []string, expiresAt time.Time) (domain.ApiKey, error)
+	GetApiKeys(accountId int) ([]domain.ApiKey, error)
+	RevokeApiKey(keyId int, at time.Time) (domain.ApiKey, error)
+	GetApiKeyCredential(prefix string) (domain.ApiKeyCredential, error)
+	TouchApiKey(keyId int, at time.Time) error
+}
+
+type Oidc interface {
+	CreateOidcState(state domain.OidcState) error
+	ConsumeOidcState(state string, now time.Time) (domain.OidcState, error)
+	PurgeOidcStates(now time.Time) (int, error)
+	GetUserByOidcSubject(issuer, subject string) (domain.User, error)
+	CreateOidcUser(user domain.User, issuer, subject string, bonusExpiresAt *time.Time) (int, error)
 }
 
 type Repository struct {
 	Authorization
 	Shop
+	Inventory
+	Refunds
+	Fulfillment
+	Promotions
+	Schedules
+	Treasury
+	PaymentRequests
+	Escrows
+	LoginAttempts
+	Users
+	Audit
+	TwoFactor
+	ApiKeys
+	Oidc
 }
 
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{
-		Authorization: NewAuthPostgres(db),
-		Shop:          NewShopPostgres(db),
+		Authorization:   NewAuthPostgres(db),
+		Shop:            NewShopPostgres(db),
+		Inventory:       NewInventoryPostgres(db),
+		Refunds:         NewRefundPostgres(db),
+		Fulfillment:     NewFulfillmentPostgres(db),
+		Promotions:      NewPromotionPostgres(db),
+		Schedules:       NewSchedulePostgres(db),
+		Treasury:        NewTreasuryPostgres(db),
+		PaymentRequests: NewPaymentRequestPostgres(db),
+		Escrows:         NewEscrowPostgres(db),
+		LoginAttempts:   NewLoginAttemptPostgres(db),
+		Users:           NewUserPostgres(db),
+		Audit:           NewAuditPostgres(db),
+		TwoFactor:       NewTwoFactorPostgres(db),
+		ApiKeys:         NewApiKeyPostgres(db),
+		Oidc:            NewOidcPostgres(db),
 	}
 }
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulePostgres_CreateSchedule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewSchedulePostgres(sqlx.NewDb(db, "postgres"))
+
+	nextRunAt := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)
+	input := domain.ScheduledTransfer{
+		UserId: 1, DestinationUsername: "intern", Amount: 50, Category: domain.TransferGift,
+		Cron: "0 9 * * 1", NextRunAt: nextRunAt, CreatedAt: createdAt,
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    domain.ScheduledTransfer
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("intern").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", schedulesTable)).
+					WithArgs(1, 2, 50, "", domain.TransferGift, "0 9 * * 1", "", nextRunAt, domain.ScheduleActive, createdAt).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+			},
+			want: domain.ScheduledTransfer{
+				Id: 3, UserId: 1, DestinationId: 2, DestinationUsername: "intern", Amount: 50, Category: domain.TransferGift,
+				Cron: "0 9 * * 1", NextRunAt: nextRunAt, Status: domain.ScheduleActive, CreatedAt: createdAt,
+			},
+		},
+		{
+			name: "Получатель не найден",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("intern").
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: domain.ErrUserNotFound,
+		},
+		{
+			name: "Перевод самому себе",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE username = (.+)", userListTable)).
+					WithArgs("intern").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			wantErr: domain.ErrSelfTransfer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.CreateSchedule(input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSchedulePostgres_CancelSchedule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewSchedulePostgres(sqlx.NewDb(db, "postgres"))
+
+	scheduleRowColumns := []string{"id", "user_id", "destination_id", "destination_username", "amount", "cron", "status"}
+	tests := []struct {
+		name    string
+		mock    func()
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status (.+)", schedulesTable)).
+					WithArgs(domain.ScheduleCancelled, sqlmock.AnyArg(), 3, 1, domain.ScheduleActive).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Уже отменено",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status (.+)", schedulesTable)).
+					WithArgs(domain.ScheduleCancelled, sqlmock.AnyArg(), 3, 1, domain.ScheduleActive).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s st (.+) WHERE st.id = (.+)", schedulesTable)).
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows(scheduleRowColumns).
+						AddRow(3, 1, 2, "intern", 50, "0 9 * * 1", domain.ScheduleCancelled))
+			},
+			wantErr: domain.ErrScheduleCancelled,
+		},
+		{
+			name: "Не найдено",
+			mock: func() {
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET status (.+)", schedulesTable)).
+					WithArgs(domain.ScheduleCancelled, sqlmock.AnyArg(), 3, 1, domain.ScheduleActive).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s st (.+) WHERE st.id = (.+)", schedulesTable)).
+					WithArgs(3, 1).
+					WillReturnRows(sqlmock.NewRows(scheduleRowColumns))
+			},
+			wantErr: domain.ErrScheduleNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			err := r.CancelSchedule(1, 3)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSchedulePostgres_ClaimSchedule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewSchedulePostgres(sqlx.NewDb(db, "postgres"))
+
+	dueAt := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	nextRunAt := dueAt.AddDate(0, 0, 7)
+	now := dueAt.Add(30 * time.Second)
+
+	tests := []struct {
+		name     string
+		affected int64
+		want     bool
+	}{
+		{name: "Запуск получен", affected: 1, want: true},
+		{name: "Запуск уже выполнен другим экземпляром", affected: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET next_run_at (.+)", schedulesTable)).
+				WithArgs(nextRunAt, now, 3, dueAt, domain.ScheduleActive).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			got, err := r.ClaimSchedule(3, dueAt, nextRunAt, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
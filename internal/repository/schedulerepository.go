@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type SchedulePostgres struct {
+	db *sqlx.DB
+}
+
+func NewSchedulePostgres(db *sqlx.DB) *SchedulePostgres {
+	return &SchedulePostgres{
+		db: db,
+	}
+}
+
+// scheduleColumns перечисляет поля расписания для запросов с псевдонимами
+// st (scheduled_transfers) и d (получатель из userlist).
+const scheduleColumns = `st.id, st.user_id, st.destination_id, d.username AS destination_username, st.amount,
+	st.message, st.category, st.cron, st.repeat_every, st.next_run_at, st.last_run_at, st.status, st.created_at, st.cancelled_at`
+
+func (r *SchedulePostgres) CreateSchedule(schedule domain.ScheduledTransfer) (domain.ScheduledTransfer, error) {
+	destQuery := fmt.Sprintf("SELECT id FROM %s WHERE username = $1 AND %s", userListTable, activeUserFilter)
+	if err := r.db.QueryRowx(destQuery, schedule.DestinationUsername).Scan(&schedule.DestinationId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ScheduledTransfer{}, domain.ErrUserNotFound
+		}
+		return domain.ScheduledTransfer{}, err
+	}
+	if schedule.DestinationId == schedule.UserId {
+		return domain.ScheduledTransfer{}, domain.ErrSelfTransfer
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, destination_id, amount, message, category, cron, repeat_every, next_run_at, status, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`, schedulesTable)
+	err := r.db.QueryRowx(query, schedule.UserId, schedule.DestinationId, schedule.Amount, schedule.Message, schedule.Category,
+		schedule.Cron, schedule.Interval, schedule.NextRunAt, domain.ScheduleActive, schedule.CreatedAt).Scan(&schedule.Id)
+	if err != nil {
+		return domain.ScheduledTransfer{}, err
+	}
+	schedule.Status = domain.ScheduleActive
+	logger.Log.Debug().Int("id", schedule.Id).Msg("Успешно создано расписание перевода")
+	return schedule, nil
+}
+
+func (r *SchedulePostgres) GetSchedules(userId int) ([]domain.ScheduledTransfer, error) {
+	schedules := []domain.ScheduledTransfer{}
+	query := fmt.Sprintf(`SELECT %s FROM %s st JOIN %s d ON st.destination_id = d.id
+	WHERE st.user_id = $1 ORDER BY st.created_at DESC, st.id DESC`, scheduleColumns, schedulesTable, userListTable)
+	if err := r.db.Select(&schedules, query, userId); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *SchedulePostgres) GetSchedule(userId, scheduleId int) (domain.ScheduledTransfer, error) {
+	var schedule domain.ScheduledTransfer
+	query := fmt.Sprintf(`SELECT %s FROM %s st JOIN %s d ON st.destination_id = d.id
+	WHERE st.id = $1 AND st.user_id = $2`, scheduleColumns, schedulesTable, userListTable)
+	if err := r.db.Get(&schedule, query, scheduleId, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ScheduledTransfer{}, domain.ErrScheduleNotFound
+		}
+		return domain.ScheduledTransfer{}, err
+	}
+	return schedule, nil
+}
+
+func (r *SchedulePostgres) CancelSchedule(userId, scheduleId int) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, cancelled_at = $2
+	WHERE id = $3 AND user_id = $4 AND status = $5`, schedulesTable)
+	result, err := r.db.Exec(query, domain.ScheduleCancelled, time.Now(), scheduleId, userId, domain.ScheduleActive)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		if _, err = r.GetSchedule(userId, scheduleId); err != nil {
+			return err
+		}
+		return domain.ErrScheduleCancelled
+	}
+	logger.Log.Debug().Int("id", scheduleId).Msg("Расписание перевода отменено")
+	return nil
+}
+
+func (r *SchedulePostgres) GetScheduleRuns(scheduleId int) ([]domain.ScheduleRun, error) {
+	runs := []domain.ScheduleRun{}
+	query := fmt.Sprintf(`SELECT id, schedule_id, run_at, transaction_id, error FROM %s
+	WHERE schedule_id = $1 ORDER BY run_at DESC, id DESC`, scheduleRunsTable)
+	if err := r.db.Select(&runs, query, scheduleId); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *SchedulePostgres) GetDueSchedules(now time.Time, limit int) ([]domain.ScheduledTransfer, error) {
+	schedules := []domain.ScheduledTransfer{}
+	query := fmt.Sprintf(`SELECT %s FROM %s st JOIN %s d ON st.destination_id = d.id
+	WHERE st.status = $1 AND st.next_run_at <= $2
+	ORDER BY st.next_run_at, st.id LIMIT $3`, scheduleColumns, schedulesTable, userListTable)
+	if err := r.db.Select(&schedules, query, domain.ScheduleActive, now, limit); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ClaimSchedule переносит следующий запуск, только если его еще не перенес другой экземпляр
+// сервера, и сообщает, досталось ли выполнение вызывающему.
+func (r *SchedulePostgres) ClaimSchedule(scheduleId int, dueAt, nextRunAt, now time.Time) (bool, error) {
+	query := fmt.Sprintf(`UPDATE %s SET next_run_at = $1, last_run_at = $2
+	WHERE id = $3 AND next_run_at = $4 AND status = $5`, schedulesTable)
+	result, err := r.db.Exec(query, nextRunAt, now, scheduleId, dueAt, domain.ScheduleActive)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *SchedulePostgres) CreateScheduleRun(run domain.ScheduleRun) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (schedule_id, run_at, transaction_id, error)
+	VALUES ($1, $2, $3, $4) RETURNING id`, scheduleRunsTable)
+	if err := r.db.QueryRowx(query, run.ScheduleId, run.RunAt, run.TransactionId, run.Error).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *SchedulePostgres) DB() *sqlx.DB {
+	return r.db
+}
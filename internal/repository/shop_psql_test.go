@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"testing"
@@ -12,6 +13,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var promotionRowColumns = []string{"id", "name", "kind", "value", "item_id", "category", "code",
+	"usage_limit", "used_count", "starts_at", "ends_at"}
+
 func TestShopPostgres_BuyItem(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -23,33 +27,42 @@ func TestShopPostgres_BuyItem(t *testing.T) {
 		userid int
 		name   string
 	}
+	itemColumns := []string{"id", "name", "price", "per_user_limit", "has_variants"}
 	tests := []struct {
 		name    string
 		mock    func()
 		input   args
 		want    int
-		wantErr bool
+		wantErr error
 	}{
 		{
 			name: "OK",
 			mock: func() {
 				mock.ExpectBegin()
 
-				mock.ExpectQuery("SELECT id, price FROM shop WHERE name = (.+)").
-					WithArgs("cup").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(1, 10))
-
-				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+)").
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(100))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("cup").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(1, "cup", 10, nil, false))
+
+				mock.ExpectExec("UPDATE shop SET stock = stock - (.+) WHERE (.+)").
+					WithArgs(1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectQuery("INSERT INTO orders").
+					WithArgs(1, 10, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 				mock.ExpectQuery("INSERT INTO purchases").
-					WithArgs(1, 1, 10, sqlmock.AnyArg()).
+					WithArgs(1, 1, 10, sqlmock.AnyArg(), 1, 1, nil, 10, nil).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
-				mock.ExpectExec("UPDATE userlist SET coins = (.+) WHERE id = (.+)").
-					WithArgs(90, 1).
-					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectDebitCoins(mock, 1, 10)
 
 				mock.ExpectCommit()
 			},
@@ -57,21 +70,22 @@ func TestShopPostgres_BuyItem(t *testing.T) {
 				userid: 1,
 				name:   "cup",
 			},
-			want:    1,
-			wantErr: false,
+			want: 1,
 		},
 		{
 			name: "Недостаточно средств",
 			mock: func() {
 				mock.ExpectBegin()
 
-				mock.ExpectQuery("SELECT id, price FROM shop WHERE name = (.+)").
-					WithArgs("cup").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(1, 10))
-
 				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+)").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(5))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("cup").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(1, "cup", 10, nil, false))
 
 				mock.ExpectRollback()
 			},
@@ -80,16 +94,22 @@ func TestShopPostgres_BuyItem(t *testing.T) {
 				name:   "cup",
 			},
 			want:    0,
-			wantErr: true,
+			wantErr: domain.ErrNotEnoughCoins,
 		},
 		{
 			name: "Предмет не найден",
 			mock: func() {
 				mock.ExpectBegin()
 
-				mock.ExpectQuery("SELECT id, price FROM shop WHERE name = (.+)").
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+)").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(100))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
 					WithArgs("cup").
-					WillReturnError(errors.New("item not found"))
+					WillReturnError(sql.ErrNoRows)
 
 				mock.ExpectRollback()
 			},
@@ -98,7 +118,63 @@ func TestShopPostgres_BuyItem(t *testing.T) {
 				name:   "cup",
 			},
 			want:    0,
-			wantErr: true,
+			wantErr: domain.ErrItemNotFound,
+		},
+		{
+			name: "Товар закончился",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+)").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(100))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("cup").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(1, "cup", 10, nil, false))
+
+				mock.ExpectExec("UPDATE shop SET stock = stock - (.+) WHERE (.+)").
+					WithArgs(1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				mock.ExpectRollback()
+			},
+			input: args{
+				userid: 1,
+				name:   "cup",
+			},
+			want:    0,
+			wantErr: domain.ErrOutOfStock,
+		},
+		{
+			name: "Превышен лимит на пользователя",
+			mock: func() {
+				mock.ExpectBegin()
+
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+)").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("pink-hoody").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(10, "pink-hoody", 500, 1, false))
+
+				mock.ExpectQuery("SELECT COALESCE(.+) FROM purchases WHERE user_id = (.+) AND item_id = (.+)").
+					WithArgs(1, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(1))
+
+				mock.ExpectRollback()
+			},
+			input: args{
+				userid: 1,
+				name:   "pink-hoody",
+			},
+			want:    0,
+			wantErr: domain.ErrPurchaseLimit,
 		},
 	}
 
@@ -108,10 +184,10 @@ func TestShopPostgres_BuyItem(t *testing.T) {
 
 			shop := NewShopPostgres(sqlxDB)
 
-			got, err := shop.BuyItem(tt.input.userid, tt.input.name)
+			got, err := shop.BuyItem(tt.input.userid, tt.input.name, domain.BuyOptions{})
 
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.want, got)
@@ -122,109 +198,715 @@ func TestShopPostgres_BuyItem(t *testing.T) {
 	}
 }
 
-func TestShopPostgres_sendCoin(t *testing.T) {
+func TestShopPostgres_CreateOrder(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
 	sqlxDB := sqlx.NewDb(db, "postgres")
+	itemColumns := []string{"id", "name", "price", "per_user_limit", "has_variants"}
+	promoItemColumns := []string{"id", "name", "price", "category", "per_user_limit", "has_variants"}
+	startsAt := time.Now().Add(-time.Hour)
+	endsAt := time.Now().Add(time.Hour)
 
 	tests := []struct {
-		name    string
-		mock    func()
-		input   domain.Transactions
-		want    int
-		wantErr bool
+		name      string
+		mock      func()
+		input     []domain.OrderLine
+		promoCode string
+		want      int
+		wantErr   error
 	}{
-
 		{
 			name: "OK",
 			mock: func() {
 				mock.ExpectBegin()
-
-				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s (.+)", userListTable)).
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
 					WithArgs(1).
 					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
-				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+)", userListTable)).
-					WithArgs("name").
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
-
-				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET (.+)", userListTable)).
-					WithArgs(10, 2).
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("cup").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(2, "cup", 20, nil, false))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("pink-hoody").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(10, "pink-hoody", 500, 1, false))
+				mock.ExpectExec("UPDATE shop SET stock (.+)").
+					WithArgs(2, 3).
 					WillReturnResult(sqlmock.NewResult(0, 1))
-
-				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET (.+)", userListTable)).
+				mock.ExpectQuery("SELECT COALESCE(.+) FROM purchases (.+)").
+					WithArgs(1, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+				mock.ExpectExec("UPDATE shop SET stock (.+)").
 					WithArgs(10, 1).
 					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO orders").
+					WithArgs(1, 560, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				mock.ExpectQuery("INSERT INTO purchases").
+					WithArgs(1, 2, 20, sqlmock.AnyArg(), 4, 3, nil, 20, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO purchases").
+					WithArgs(1, 10, 500, sqlmock.AnyArg(), 4, 1, nil, 500, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				expectDebitCoins(mock, 1, 560)
+				mock.ExpectCommit()
+			},
+			input: []domain.OrderLine{
+				{ItemName: "cup", Quantity: 3},
+				{ItemName: "pink-hoody", Quantity: 1},
+			},
+			want: 4,
+		},
+		{
+			name: "Сумма заказа выше баланса",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(100))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("cup").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(2, "cup", 20, nil, false))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("socks").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(8, "socks", 10, nil, false))
+				mock.ExpectRollback()
+			},
+			input: []domain.OrderLine{
+				{ItemName: "cup", Quantity: 5},
+				{ItemName: "socks", Quantity: 1},
+			},
+			wantErr: domain.ErrNotEnoughCoins,
+		},
+		{
+			name: "Одна из позиций закончилась",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("cup").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(2, "cup", 20, nil, false))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("socks").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(8, "socks", 10, nil, false))
+				mock.ExpectExec("UPDATE shop SET stock (.+)").
+					WithArgs(2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE shop SET stock (.+)").
+					WithArgs(8, 2).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			input: []domain.OrderLine{
+				{ItemName: "cup", Quantity: 1},
+				{ItemName: "socks", Quantity: 2},
+			},
+			wantErr: domain.ErrOutOfStock,
+		},
+		{
+			name: "Вариант с надбавкой к цене",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("hoody").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(6, "hoody", 300, nil, true))
+				mock.ExpectQuery("SELECT (.+) FROM item_variants WHERE item_id = (.+) AND size = (.+) AND colour = (.+)").
+					WithArgs(6, "XL", "black").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "item_id", "size", "colour", "price_delta", "stock"}).
+						AddRow(3, 6, "XL", "black", 50, 4))
+				mock.ExpectExec("UPDATE shop SET stock (.+)").
+					WithArgs(6, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE item_variants SET stock (.+)").
+					WithArgs(3, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO orders").
+					WithArgs(1, 700, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				mock.ExpectQuery("INSERT INTO purchases").
+					WithArgs(1, 6, 350, sqlmock.AnyArg(), 5, 2, 3, 350, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectDebitCoins(mock, 1, 700)
+				mock.ExpectCommit()
+			},
+			input: []domain.OrderLine{
+				{ItemName: "hoody", VariantSelector: domain.VariantSelector{Size: "XL", Colour: "black"}, Quantity: 2},
+			},
+			want: 5,
+		},
+		{
+			name: "Вариант не выбран",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("hoody").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(6, "hoody", 300, nil, true))
+				mock.ExpectRollback()
+			},
+			input: []domain.OrderLine{
+				{ItemName: "hoody", Quantity: 1},
+			},
+			wantErr: domain.ErrVariantRequired,
+		},
+		{
+			name: "Вариант не найден",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("hoody").
+					WillReturnRows(sqlmock.NewRows(itemColumns).AddRow(6, "hoody", 300, nil, true))
+				mock.ExpectQuery("SELECT (.+) FROM item_variants (.+)").
+					WithArgs(6, "XXS", "").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			input: []domain.OrderLine{
+				{ItemName: "hoody", VariantSelector: domain.VariantSelector{Size: "XXS"}, Quantity: 1},
+			},
+			wantErr: domain.ErrVariantNotFound,
+		},
+		{
+			name: "Акция на категорию и промокод",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns).
+						AddRow(1, "Неделя одежды", domain.DiscountPercent, 10, nil, "clothes", nil, nil, 0, startsAt, endsAt))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code = (.+) FOR UPDATE").
+					WithArgs("CUP5").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns).
+						AddRow(2, "Кружки", domain.DiscountFixed, 5, 2, nil, "CUP5", 10, 3, startsAt, endsAt))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("cup").
+					WillReturnRows(sqlmock.NewRows(promoItemColumns).AddRow(2, "cup", 20, "accessories", nil, false))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("t-shirt").
+					WillReturnRows(sqlmock.NewRows(promoItemColumns).AddRow(1, "t-shirt", 80, "clothes", nil, false))
+				mock.ExpectExec("UPDATE shop SET stock (.+)").
+					WithArgs(2, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE shop SET stock (.+)").
+					WithArgs(1, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE promotions SET used_count = used_count \\+ 1").
+					WithArgs(2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("INSERT INTO orders").
+					WithArgs(1, 102, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+				mock.ExpectQuery("INSERT INTO purchases").
+					WithArgs(1, 2, 15, sqlmock.AnyArg(), 6, 2, nil, 20, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO purchases").
+					WithArgs(1, 1, 72, sqlmock.AnyArg(), 6, 1, nil, 80, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				expectDebitCoins(mock, 1, 102)
+				mock.ExpectCommit()
+			},
+			input: []domain.OrderLine{
+				{ItemName: "cup", Quantity: 2},
+				{ItemName: "t-shirt", Quantity: 1},
+			},
+			promoCode: "CUP5",
+			want:      6,
+		},
+		{
+			name: "Промокод исчерпан",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code = (.+) FOR UPDATE").
+					WithArgs("CUP5").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns).
+						AddRow(2, "Кружки", domain.DiscountFixed, 5, 2, nil, "CUP5", 10, 10, startsAt, endsAt))
+				mock.ExpectRollback()
+			},
+			input:     []domain.OrderLine{{ItemName: "cup", Quantity: 1}},
+			promoCode: "CUP5",
+			wantErr:   domain.ErrPromoExhausted,
+		},
+		{
+			name: "Промокод не подходит к заказу",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT coins FROM userlist WHERE id = (.+) FOR UPDATE").
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code IS NULL (.+)").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns))
+				mock.ExpectQuery("SELECT (.+) FROM promotions WHERE code = (.+) FOR UPDATE").
+					WithArgs("CUP5").
+					WillReturnRows(sqlmock.NewRows(promotionRowColumns).
+						AddRow(2, "Кружки", domain.DiscountFixed, 5, 2, nil, "CUP5", nil, 0, startsAt, endsAt))
+				mock.ExpectQuery("SELECT (.+) FROM shop s WHERE s.name = (.+)").
+					WithArgs("pen").
+					WillReturnRows(sqlmock.NewRows(promoItemColumns).AddRow(4, "pen", 10, "accessories", nil, false))
+				mock.ExpectRollback()
+			},
+			input:     []domain.OrderLine{{ItemName: "pen", Quantity: 1}},
+			promoCode: "CUP5",
+			wantErr:   domain.ErrPromoNotApplicable,
+		},
+	}
 
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			shop := NewShopPostgres(sqlxDB)
+
+			got, err := shop.CreateOrder(1, tt.input, tt.promoCode)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShopPostgres_sendCoin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+
+	now := time.Now()
+	limits := domain.TransferLimits{DailyOutbound: 100, DailyInbound: 200, Cooldown: time.Minute}
+	expectLock := func(sourceCoins int) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+)", userListTable)).
+			WithArgs("name").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery(fmt.Sprintf("SELECT id, coins FROM %s WHERE id IN (.+) ORDER BY id FOR UPDATE", userListTable)).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, sourceCoins).AddRow(2, 0))
+	}
+	expectSums := func(sent, received int) {
+		mock.ExpectQuery(fmt.Sprintf("SELECT \\(SELECT COALESCE(.+) FROM %s WHERE source = (.+) FROM %s WHERE sender_id = (.+)", transactionsTable, escrowsTable)).
+			WithArgs(1, domain.TransactionTransfer, sqlmock.AnyArg(), domain.EscrowReturned).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(sent))
+		if received < 0 {
+			return
+		}
+		mock.ExpectQuery(fmt.Sprintf("SELECT COALESCE(.+) FROM %s WHERE destination = (.+)", transactionsTable)).
+			WithArgs(2, domain.TransactionTransfer, sqlmock.AnyArg(), domain.TransactionEscrow).
+			WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(received))
+	}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   domain.Transactions
+		limits  domain.TransferLimits
+		want    int
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				expectLock(1000)
 				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
-					WithArgs(1, 2, 10, sqlmock.AnyArg()).
+					WithArgs(1, 2, 10, sqlmock.AnyArg(), "спасибо за помощь", domain.TransferThanks).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				expectDebitCoins(mock, 1, 10)
+				expectCreditCoins(mock, 2, 10)
 				mock.ExpectCommit()
 			},
 			input: domain.Transactions{
 				Source:              IntPointer(1),
 				DestinationUsername: "name",
-				Destination:         IntPointer(2),
 				Amount:              10,
-				Timestamp:           func() *time.Time { t := time.Now(); return &t }(),
+				Message:             "спасибо за помощь",
+				Category:            domain.TransferThanks,
+				Timestamp:           &now,
 			},
-			want:    1,
-			wantErr: false,
+			want: 1,
+		},
+		{
+			name: "OK с лимитами",
+			mock: func() {
+				expectLock(1000)
+				expectSums(90, 190)
+				mock.ExpectQuery(fmt.Sprintf("SELECT GREATEST(.+) FROM %s (.+) FROM %s", transactionsTable, escrowsTable)).
+					WithArgs(1, 2, domain.TransactionTransfer).
+					WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(now.Add(-time.Hour)))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+					WithArgs(1, 2, 10, sqlmock.AnyArg(), "", "").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+				expectDebitCoins(mock, 1, 10)
+				expectCreditCoins(mock, 2, 10)
+				mock.ExpectCommit()
+			},
+			input:  domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			limits: limits,
+			want:   2,
 		},
 		{
 			name: "Недостаточно средств",
 			mock: func() {
-				mock.ExpectBegin()
-
-				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s WHERE id = (.+)", userListTable)).
-					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(10))
+				expectLock(5)
+				mock.ExpectRollback()
 			},
-			input: domain.Transactions{
-				Source:              IntPointer(1),
-				DestinationUsername: "name",
-				Destination:         IntPointer(2),
-				Amount:              10,
-				Timestamp:           func() *time.Time { t := time.Now(); return &t }(),
+			input:   domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			wantErr: domain.ErrInsufficientCoins,
+		},
+		{
+			name: "Баланс получателя превысит максимум",
+			mock: func() {
+				expectLock(1000)
+				mock.ExpectRollback()
 			},
-			want:    0,
-			wantErr: true,
+			input:   domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			limits:  domain.TransferLimits{MaxBalance: 5},
+			wantErr: domain.ErrBalanceCapExceeded,
 		},
 		{
-			name: "Ошибка транзакции",
+			name: "Получатель не найден",
 			mock: func() {
 				mock.ExpectBegin()
-
-				mock.ExpectQuery(fmt.Sprintf("SELECT coins FROM %s (.+)", userListTable)).
-					WithArgs(1).
-					WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(100))
 				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+)", userListTable)).
 					WithArgs("name").
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
-				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET (.+)", userListTable)).
-					WithArgs(10, 2).
-					WillReturnResult(sqlmock.NewResult(0, 1))
-
-				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET (.+)", userListTable)).
-					WithArgs(10, 1).
-					WillReturnResult(sqlmock.NewResult(0, 1))
-
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			input:   domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			wantErr: domain.ErrUserNotFound,
+		},
+		{
+			name: "Перевод самому себе",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+)", userListTable)).
+					WithArgs("name").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			input:   domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			wantErr: domain.ErrSelfTransfer,
+		},
+		{
+			name: "Превышен дневной лимит отправки",
+			mock: func() {
+				expectLock(1000)
+				expectSums(95, -1)
+				mock.ExpectRollback()
+			},
+			input:   domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			limits:  limits,
+			wantErr: domain.ErrDailySendLimit,
+		},
+		{
+			name: "Превышен дневной лимит получателя",
+			mock: func() {
+				expectLock(1000)
+				expectSums(0, 195)
+				mock.ExpectRollback()
+			},
+			input:   domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			limits:  limits,
+			wantErr: domain.ErrDailyReceiveLimit,
+		},
+		{
+			name: "Слишком частые переводы",
+			mock: func() {
+				expectLock(1000)
+				expectSums(0, 0)
+				mock.ExpectQuery(fmt.Sprintf("SELECT GREATEST(.+) FROM %s (.+) FROM %s", transactionsTable, escrowsTable)).
+					WithArgs(1, 2, domain.TransactionTransfer).
+					WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(now.Add(-10 * time.Second)))
+				mock.ExpectRollback()
+			},
+			input:   domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			limits:  limits,
+			wantErr: domain.ErrTransferCooldown,
+		},
+		{
+			name: "Ошибка транзакции",
+			mock: func() {
+				expectLock(100)
 				mock.ExpectQuery(fmt.Sprintf(`INSERT INTO %s (.+)`, transactionsTable)).
-					WithArgs(1, 2, 10, sqlmock.AnyArg()).
+					WithArgs(1, 2, 10, sqlmock.AnyArg(), "", "").
 					WillReturnError(errors.New("insert failed"))
+				mock.ExpectRollback()
+			},
+			input:   domain.Transactions{Source: IntPointer(1), DestinationUsername: "name", Amount: 10, Timestamp: &now},
+			wantErr: errors.New("insert failed"),
+		},
+	}
 
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			shop := NewShopPostgres(sqlxDB)
+
+			got, err := shop.SendCoin(tt.input, tt.limits)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShopPostgres_SendCoinBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+
+	now := time.Now()
+	transfers := []domain.Transactions{
+		{DestinationUsername: "anna", Amount: 30, Timestamp: &now},
+		{DestinationUsername: "boris", Amount: 50, Timestamp: &now},
+	}
+	expectLock := func(sourceCoins int) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+)", userListTable)).
+			WithArgs("anna").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE (.+)", userListTable)).
+			WithArgs("boris").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery(fmt.Sprintf("SELECT id, coins FROM %s WHERE id IN (.+) ORDER BY id FOR UPDATE", userListTable)).
+			WithArgs(1, 3, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, sourceCoins).AddRow(2, 0).AddRow(3, 0))
+	}
+	expectTransfer := func(destId, amount, id int) {
+		mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+			WithArgs(1, destId, amount, sqlmock.AnyArg(), "", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+		expectDebitCoins(mock, 1, amount)
+		expectCreditCoins(mock, destId, amount)
+	}
+
+	tests := []struct {
+		name      string
+		mock      func()
+		want      []int
+		wantErr   error
+		wantIndex int
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				expectLock(100)
+				expectTransfer(3, 30, 7)
+				expectTransfer(2, 50, 8)
+				mock.ExpectCommit()
+			},
+			want: []int{7, 8},
+		},
+		{
+			name: "Не хватает монет на второй перевод",
+			mock: func() {
+				expectLock(60)
+				expectTransfer(3, 30, 7)
 				mock.ExpectRollback()
 			},
-			input: domain.Transactions{
-				Source:              IntPointer(1),
-				Destination:         IntPointer(2),
-				DestinationUsername: "name",
-				Amount:              10,
-				Timestamp:           func() *time.Time { t := time.Now(); return &t }(),
+			wantErr:   domain.ErrInsufficientCoins,
+			wantIndex: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			shop := NewShopPostgres(sqlxDB)
+
+			got, err := shop.SendCoinBatch(1, transfers, domain.TransferLimits{})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				var itemErr *domain.BatchItemError
+				if assert.ErrorAs(t, err, &itemErr) {
+					assert.Equal(t, tt.wantIndex, itemErr.Index)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShopPostgres_GetPurchases(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+
+	from := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	purchaseDate := time.Date(2025, 2, 5, 12, 0, 0, 0, time.UTC)
+	cursor := &domain.Cursor{Time: purchaseDate, Id: 3}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   domain.PurchaseFilter
+		want    []domain.Purchase
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf(`SELECT (.+) FROM %s p JOIN %s s (.+) WHERE p.user_id = \$1 ORDER BY (.+) LIMIT \$2`, purchaseTable, shopTable)).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "item_name", "price", "quantity", "purchase_date"}).
+						AddRow(3, 2, "cup", 20, 1, purchaseDate))
 			},
-			want:    0,
+			input: domain.PurchaseFilter{Limit: 2},
+			want: []domain.Purchase{
+				{Id: 3, OrderId: IntPointer(2), ItemName: "cup", Price: 20, Quantity: 1, PurchaseDate: purchaseDate},
+			},
+		},
+		{
+			name: "Фильтр по дате и курсор",
+			mock: func() {
+				mock.ExpectQuery(`WHERE p.user_id = \$1 AND p.purchase_date >= \$2 AND \(p.purchase_date, p.id\) < \(\$3, \$4\) ORDER BY (.+) LIMIT \$5`).
+					WithArgs(1, from, purchaseDate, 3, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "item_name", "price", "quantity", "purchase_date"}))
+			},
+			input: domain.PurchaseFilter{Limit: 2, From: &from, Cursor: cursor},
+			want:  nil,
+		},
+		{
+			name: "Ошибка запроса",
+			mock: func() {
+				mock.ExpectQuery("SELECT (.+)").
+					WithArgs(1, 2).
+					WillReturnError(errors.New("query failed"))
+			},
+			input:   domain.PurchaseFilter{Limit: 2},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			shop := NewShopPostgres(sqlxDB)
+
+			got, err := shop.GetPurchases(1, tt.input)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShopPostgres_GetTransactions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+
+	transferTime := time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)
+	cursor := &domain.Cursor{Time: transferTime, Id: 4}
+	sourceUsername := "user"
+	columns := []string{"id", "source", "source_username", "destination", "destination_username", "amount", "kind", "message", "category", "timestamp"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		input   domain.TransactionFilter
+		want    []domain.Transactions
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf(`SELECT (.+) FROM %s t (.+) WHERE \(t.source = \$1 OR t.destination = \$1\) ORDER BY (.+) LIMIT \$2`, transactionsTable)).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(4, 1, "user", 2, "friend", 10, domain.TransactionTransfer, "спасибо", domain.TransferThanks, transferTime))
+			},
+			input: domain.TransactionFilter{Limit: 2},
+			want: []domain.Transactions{
+				{
+					Id: 4, Source: IntPointer(1), SourceUsername: &sourceUsername, Destination: IntPointer(2),
+					DestinationUsername: "friend", Amount: 10, Kind: domain.TransactionTransfer, Message: "спасибо",
+					Category: domain.TransferThanks, Timestamp: &transferTime,
+				},
+			},
+		},
+		{
+			name: "Входящие с категорией и курсором",
+			mock: func() {
+				mock.ExpectQuery(`WHERE t.destination = \$1 AND t.category = \$2 AND \(t.transaction_time, t.id\) < \(\$3, \$4\) ORDER BY (.+) LIMIT \$5`).
+					WithArgs(1, domain.TransferGift, transferTime, 4, 2).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			input: domain.TransactionFilter{Limit: 2, Direction: domain.DirectionIn, Category: domain.TransferGift, Cursor: cursor},
+			want:  nil,
+		},
+		{
+			name: "Исходящие",
+			mock: func() {
+				mock.ExpectQuery(`WHERE t.source = \$1 ORDER BY (.+) LIMIT \$2`).
+					WithArgs(1, 2).
+					WillReturnError(errors.New("query failed"))
+			},
+			input:   domain.TransactionFilter{Limit: 2, Direction: domain.DirectionOut},
 			wantErr: true,
 		},
 	}
@@ -235,7 +917,7 @@ func TestShopPostgres_sendCoin(t *testing.T) {
 
 			shop := NewShopPostgres(sqlxDB)
 
-			got, err := shop.SendCoin(tt.input)
+			got, err := shop.GetTransactions(1, tt.input)
 
 			if tt.wantErr {
 				assert.Error(t, err)
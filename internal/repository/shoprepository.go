@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bllooop/coinshop/internal/domain"
@@ -21,85 +22,384 @@ func NewShopPostgres(db *sqlx.DB) *ShopPostgres {
 	}
 }
 
-func (r *ShopPostgres) BuyItem(userid int, name string) (int, error) {
-	tr, err := r.db.Beginx()
+func (r *ShopPostgres) BuyItem(userid int, name string, options domain.BuyOptions) (int, error) {
+	line := domain.OrderLine{ItemName: name, VariantSelector: options.VariantSelector, Quantity: 1}
+	return r.CreateOrder(userid, []domain.OrderLine{line}, options.PromoCode)
+}
+
+// shopItem дополняет товар признаком наличия вариантов, при которых покупка без выбора варианта запрещена.
+type shopItem struct {
+	domain.Merch
+	HasVariants bool `db:"has_variants"`
+}
+
+type orderItem struct {
+	item      shopItem
+	variant   *domain.ItemVariant
+	basePrice int
+	price     int
+	promotion *domain.Promotion
+	quantity  int
+}
+
+// CreateOrder оформляет заказ одной транзакцией: проверяет баланс, лимиты и запас
+// по всем позициям, применяет акции и промокод, списывает запас и монеты
+// и записывает заголовок заказа с позициями. Позиции должны быть уникальны по товару и варианту.
+func (r *ShopPostgres) CreateOrder(userid int, lines []domain.OrderLine, promoCode string) (int, error) {
+	tr, err := r.beginTransaction()
 	if err != nil {
 		return 0, err
 	}
 	defer tr.Rollback() // nolint:errcheck
 
-	var id, itemID, price, amount int
-	getIdQuery := fmt.Sprintf("SELECT id, price FROM %s WHERE name = $1", shopTable)
-	row := tr.QueryRowx(getIdQuery, name)
-	if err = row.Scan(&itemID, &price); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return 0, errors.New("товар не найден")
-		}
-
+	// блокировка строки пользователя сериализует его параллельные покупки
+	amount, err := r.lockUserCoins(tr, userid)
+	if err != nil {
 		return 0, err
 	}
-	getCoinLeft := fmt.Sprintf("SELECT coins FROM %s WHERE id = $1", userListTable)
-	row = tr.QueryRowx(getCoinLeft, userid)
-	if err = row.Scan(&amount); err != nil {
+	now := time.Now()
+	promotions, err := r.getActivePromotions(tr, now)
+	if err != nil {
 		return 0, err
 	}
-	if amount-price < 0 {
-		return 0, errors.New("цена товара выше количества текущих монет")
+	var codePromotion *domain.Promotion
+	if promoCode != "" {
+		if codePromotion, err = r.lockPromoCode(tr, promoCode, now); err != nil {
+			return 0, err
+		}
+		// промокод идет первым, чтобы при равной скидке применялся именно он
+		promotions = append([]domain.Promotion{*codePromotion}, promotions...)
+	}
+	codeApplied := false
+	items := make([]orderItem, 0, len(lines))
+	itemQuantities := make(map[int]int, len(lines))
+	total := 0
+	for _, line := range lines {
+		item, err := r.getShopItem(tr, line.ItemName)
+		if err != nil {
+			return 0, err
+		}
+		variant, err := r.getItemVariant(tr, item, line.VariantSelector)
+		if err != nil {
+			return 0, err
+		}
+		basePrice := item.Price
+		if variant != nil {
+			basePrice += variant.PriceDelta
+		}
+		price, promotion := bestPrice(item.Merch, basePrice, promotions)
+		if promotion != nil && promotion.Code != nil {
+			codeApplied = true
+		}
+		total += price * line.Quantity
+		itemQuantities[item.Id] += line.Quantity
+		items = append(items, orderItem{item: item, variant: variant, basePrice: basePrice, price: price,
+			promotion: promotion, quantity: line.Quantity})
+	}
+	if codePromotion != nil && !codeApplied {
+		return 0, domain.ErrPromoNotApplicable
+	}
+	if amount-total < 0 {
+		return 0, domain.ErrNotEnoughCoins
+	}
+	// лимит на пользователя считается по товару целиком, независимо от варианта
+	checked := make(map[int]bool, len(items))
+	for _, line := range items {
+		if line.item.PerUserLimit != nil && !checked[line.item.Id] {
+			if err = r.checkPurchaseLimit(tr, userid, line.item.Merch, itemQuantities[line.item.Id]); err != nil {
+				return 0, err
+			}
+			checked[line.item.Id] = true
+		}
+		if err = r.reserveStock(tr, line.item.Merch, line.quantity); err != nil {
+			return 0, err
+		}
+		if line.variant != nil {
+			if err = r.reserveVariantStock(tr, line.item.Merch, *line.variant, line.quantity); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if codePromotion != nil {
+		usePromoQuery := fmt.Sprintf("UPDATE %s SET used_count = used_count + 1 WHERE id = $1", promotionsTable)
+		if _, err = tr.Exec(usePromoQuery, codePromotion.Id); err != nil {
+			return 0, err
+		}
+	}
+
+	var orderId int
+	createOrderQuery := fmt.Sprintf("INSERT INTO %s (user_id, total, created_at) VALUES ($1,$2,$3) RETURNING id", ordersTable)
+	if err = tr.QueryRowx(createOrderQuery, userid, total, now).Scan(&orderId); err != nil {
+		return 0, err
 	}
-	createListQuery := fmt.Sprintf("INSERT INTO %s (user_id, item_id, price, purchase_date) VALUES ($1,$2,$3,$4) RETURNING id", purchaseTable)
-	row = tr.QueryRowx(createListQuery, userid, itemID, price, time.Now())
-	if err = row.Scan(&id); err != nil {
-		rollbackErr := tr.Rollback()
-		if rollbackErr != nil {
-			logger.Log.Error().Err(rollbackErr).Msg("Error during rollback")
+	createLineQuery := fmt.Sprintf(`INSERT INTO %s (user_id, item_id, price, purchase_date, order_id, quantity, variant_id, base_price, promotion_id)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING id`, purchaseTable)
+	purchaseIds := make([]int, len(items))
+	lineAmounts := make([]int, len(items))
+	for i, line := range items {
+		var variantId, promotionId *int
+		if line.variant != nil {
+			variantId = &line.variant.Id
+		}
+		if line.promotion != nil {
+			promotionId = &line.promotion.Id
+		}
+		lineAmounts[i] = line.price * line.quantity
+		if err = tr.QueryRowx(createLineQuery, userid, line.item.Id, line.price, now, orderId, line.quantity,
+			variantId, line.basePrice, promotionId).Scan(&purchaseIds[i]); err != nil {
+			return 0, err
 		}
-		return id, err
 	}
-	changeAmountQuery := fmt.Sprintf("UPDATE %s SET coins = $1 WHERE id = $2", userListTable)
-	_, err = tr.Exec(changeAmountQuery, amount-price, userid)
+	spent, err := debitCoins(tr, userid, total)
 	if err != nil {
-		rollbackErr := tr.Rollback()
-		if rollbackErr != nil {
-			logger.Log.Error().Err(rollbackErr).Msg("Error during rollback")
+		return 0, err
+	}
+	for i, lots := range splitSpentLots(spent, lineAmounts) {
+		if err = recordSpentLots(tr, spentOnPurchase, purchaseIds[i], lots); err != nil {
+			return 0, err
 		}
+	}
+	logger.Log.Debug().Int("id", orderId).Int("total", total).Msg("Успешно оформлен заказ")
+	return orderId, tr.Commit()
+}
+
+func (r *ShopPostgres) lockUserCoins(tr *sqlx.Tx, userId int) (int, error) {
+	var amount int
+	getCoinLeft := fmt.Sprintf("SELECT coins FROM %s WHERE id = $1 FOR UPDATE", userListTable)
+	if err := tr.QueryRowx(getCoinLeft, userId).Scan(&amount); err != nil {
 		return 0, err
 	}
-	logger.Log.Debug().Int("id", id).Msg("Успешно совершена покупка товара")
-	return id, tr.Commit()
+	return amount, nil
+}
+
+func (r *ShopPostgres) getShopItem(tr *sqlx.Tx, name string) (shopItem, error) {
+	var item shopItem
+	getIdQuery := fmt.Sprintf(`SELECT s.id, s.name, s.price, s.category, s.per_user_limit,
+	EXISTS (SELECT 1 FROM %s v WHERE v.item_id = s.id) AS has_variants
+	FROM %s s WHERE s.name = $1`, variantsTable, shopTable)
+	if err := tr.Get(&item, getIdQuery, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return shopItem{}, fmt.Errorf("%w: %s", domain.ErrItemNotFound, name)
+		}
+		return shopItem{}, err
+	}
+	return item, nil
+}
+
+// getItemVariant возвращает выбранный вариант товара или nil, если товар продается без вариантов.
+func (r *ShopPostgres) getItemVariant(tr *sqlx.Tx, item shopItem, selector domain.VariantSelector) (*domain.ItemVariant, error) {
+	if selector.IsEmpty() {
+		if item.HasVariants {
+			return nil, fmt.Errorf("%w: %s", domain.ErrVariantRequired, item.Name)
+		}
+		return nil, nil
+	}
+	var variant domain.ItemVariant
+	query := fmt.Sprintf(`SELECT id, item_id, size, colour, price_delta, stock FROM %s
+	WHERE item_id = $1 AND size = $2 AND colour = $3`, variantsTable)
+	if err := tr.Get(&variant, query, item.Id, selector.Size, selector.Colour); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrVariantNotFound, variantName(item.Name, selector))
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func variantName(item string, selector domain.VariantSelector) string {
+	parts := []string{}
+	for _, part := range []string{selector.Size, selector.Colour} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return fmt.Sprintf("%s (%s)", item, strings.Join(parts, ", "))
+}
+
+const promotionColumns = "id, name, kind, value, item_id, category, code, usage_limit, used_count, starts_at, ends_at"
+
+func (r *ShopPostgres) getActivePromotions(tr *sqlx.Tx, now time.Time) ([]domain.Promotion, error) {
+	var promotions []domain.Promotion
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE code IS NULL AND starts_at <= $1 AND ends_at > $1", promotionColumns, promotionsTable)
+	if err := tr.Select(&promotions, query, now); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// lockPromoCode блокирует промокод до конца транзакции, чтобы параллельные заказы
+// не превысили лимит использований.
+func (r *ShopPostgres) lockPromoCode(tr *sqlx.Tx, code string, now time.Time) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE code = $1 FOR UPDATE", promotionColumns, promotionsTable)
+	if err := tr.Get(&promotion, query, code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrPromoNotFound
+		}
+		return nil, err
+	}
+	if !promotion.IsActive(now) {
+		return nil, domain.ErrPromoExpired
+	}
+	if promotion.UsageLimit != nil && promotion.UsedCount >= *promotion.UsageLimit {
+		return nil, domain.ErrPromoExhausted
+	}
+	return &promotion, nil
+}
+
+// bestPrice выбирает среди подходящих акций ту, что дает наименьшую цену единицы товара.
+func bestPrice(item domain.Merch, price int, promotions []domain.Promotion) (int, *domain.Promotion) {
+	best := price
+	var applied *domain.Promotion
+	for i := range promotions {
+		if !promotions[i].Matches(item) {
+			continue
+		}
+		if discounted := promotions[i].Apply(price); discounted < best {
+			best = discounted
+			applied = &promotions[i]
+		}
+	}
+	return best, applied
+}
+
+func (r *ShopPostgres) checkPurchaseLimit(tr *sqlx.Tx, userId int, item domain.Merch, quantity int) error {
+	var bought int
+	countQuery := fmt.Sprintf("SELECT COALESCE(SUM(quantity), 0) FROM %s WHERE user_id = $1 AND item_id = $2 AND refunded_at IS NULL", purchaseTable)
+	if err := tr.QueryRowx(countQuery, userId, item.Id).Scan(&bought); err != nil {
+		return err
+	}
+	if bought+quantity > *item.PerUserLimit {
+		return fmt.Errorf("%w: %s", domain.ErrPurchaseLimit, item.Name)
+	}
+	return nil
+}
+
+// reserveStock атомарно списывает запас товара: условие в WHERE не дает
+// остатку уйти в минус при параллельных покупках, NULL означает неограниченный запас.
+func (r *ShopPostgres) reserveStock(tr *sqlx.Tx, item domain.Merch, quantity int) error {
+	reserveQuery := fmt.Sprintf("UPDATE %s SET stock = stock - $2 WHERE id = $1 AND (stock IS NULL OR stock >= $2)", shopTable)
+	res, err := tr.Exec(reserveQuery, item.Id, quantity)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrOutOfStock, item.Name)
+	}
+	return nil
 }
 
-func (r *ShopPostgres) SendCoin(input domain.Transactions) (int, error) {
+func (r *ShopPostgres) reserveVariantStock(tr *sqlx.Tx, item domain.Merch, variant domain.ItemVariant, quantity int) error {
+	reserveQuery := fmt.Sprintf("UPDATE %s SET stock = stock - $2 WHERE id = $1 AND (stock IS NULL OR stock >= $2)", variantsTable)
+	res, err := tr.Exec(reserveQuery, variant.Id, quantity)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrOutOfStock, variantName(item.Name, variant.Selector()))
+	}
+	return nil
+}
+
+func (r *ShopPostgres) SendCoin(input domain.Transactions, limits domain.TransferLimits) (int, error) {
 	tr, err := r.beginTransaction()
 	if err != nil {
 		return 0, err
 	}
 	defer tr.Rollback() // nolint:errcheck
 
-	amount, err := r.getSourceCoinAmount(tr, *input.Source)
+	destId, err := r.getDestinationUserId(tr, input.DestinationUsername)
 	if err != nil {
 		return 0, err
 	}
-
-	if amount-input.Amount < 0 {
-		return 0, errors.New("количество отправки выше количества текущих монет")
+	if destId == *input.Source {
+		return 0, domain.ErrSelfTransfer
 	}
-
-	destId, err := r.getDestinationUserId(tr, input.DestinationUsername)
+	balances, err := r.lockTransferParties(tr, *input.Source, []int{destId})
 	if err != nil {
 		return 0, err
 	}
 	input.Destination = &destId
-	if err = r.transferCoins(tr, input.Amount, destId, *input.Source); err != nil {
+	id, err := r.executeTransfer(tr, input, balances, limits)
+	if err != nil {
 		return 0, err
 	}
 
+	logger.Log.Debug().Int("id", id).Msg("Успешно совершена отправка момент")
+	return id, tr.Commit()
+}
+
+// SendCoinBatch выполняет все переводы в одной транзакции: при ошибке любого из них
+// не выполняется ни один, а ошибка указывает номер перевода через domain.BatchItemError.
+func (r *ShopPostgres) SendCoinBatch(source int, transfers []domain.Transactions, limits domain.TransferLimits) ([]int, error) {
+	tr, err := r.beginTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	destIds := make([]int, len(transfers))
+	for i, input := range transfers {
+		if destIds[i], err = r.getDestinationUserId(tr, input.DestinationUsername); err != nil {
+			return nil, &domain.BatchItemError{Index: i, Err: err}
+		}
+		if destIds[i] == source {
+			return nil, &domain.BatchItemError{Index: i, Err: domain.ErrSelfTransfer}
+		}
+	}
+	balances, err := r.lockTransferParties(tr, source, destIds)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(transfers))
+	for i, input := range transfers {
+		input.Source = &source
+		input.Destination = &destIds[i]
+		if ids[i], err = r.executeTransfer(tr, input, balances, limits); err != nil {
+			return nil, &domain.BatchItemError{Index: i, Err: err}
+		}
+	}
+
+	logger.Log.Debug().Int("count", len(ids)).Msg("Успешно совершена пакетная отправка монет")
+	return ids, tr.Commit()
+}
+
+// executeTransfer проверяет баланс и лимиты и проводит перевод, строки участников уже заблокированы.
+// balances содержит балансы участников и обновляется после перевода.
+func (r *ShopPostgres) executeTransfer(tr *sqlx.Tx, input domain.Transactions, balances map[int]int, limits domain.TransferLimits) (int, error) {
+	source, destination := *input.Source, *input.Destination
+	if balances[source]-input.Amount < 0 {
+		return 0, domain.ErrInsufficientCoins
+	}
+	if limits.MaxBalance > 0 && balances[destination]+input.Amount > limits.MaxBalance {
+		return 0, domain.ErrBalanceCapExceeded
+	}
+	if err := r.checkTransferLimits(tr, input, limits); err != nil {
+		return 0, err
+	}
 	id, err := r.createTransaction(tr, input)
 	if err != nil {
 		return 0, err
 	}
-
-	logger.Log.Debug().Int("id", id).Msg("Успешно совершена отправка момент")
-	return id, tr.Commit()
+	if _, err = debitCoins(tr, source, input.Amount); err != nil {
+		return 0, err
+	}
+	// переведенные монеты не сгорают: срок действия есть только у монет, выпущенных казной
+	if err = creditCoins(tr, destination, input.Amount, &id, nil); err != nil {
+		return 0, err
+	}
+	balances[source] -= input.Amount
+	balances[destination] += input.Amount
+	return id, nil
 }
 
 func (r *ShopPostgres) beginTransaction() (*sqlx.Tx, error) {
@@ -110,41 +410,128 @@ func (r *ShopPostgres) beginTransaction() (*sqlx.Tx, error) {
 	return tr, nil
 }
 
-func (r *ShopPostgres) getSourceCoinAmount(tr *sqlx.Tx, userId int) (int, error) {
-	var amount int
-	getCoinLeft := fmt.Sprintf("SELECT coins FROM %s WHERE id = $1", userListTable)
-	row := tr.QueryRowx(getCoinLeft, userId)
-	if err := row.Scan(&amount); err != nil {
-		return 0, err
+// lockTransferParties блокирует строки отправителя и получателей в порядке id, чтобы встречные
+// переводы не взаимоблокировались, и возвращает балансы по id пользователя.
+func (r *ShopPostgres) lockTransferParties(tr *sqlx.Tx, sourceId int, destIds []int) (map[int]int, error) {
+	args := []interface{}{sourceId}
+	placeholders := []string{"$1"}
+	seen := map[int]bool{sourceId: true}
+	for _, id := range destIds {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
 	}
-	return amount, nil
+	query := fmt.Sprintf("SELECT id, coins FROM %s WHERE id IN (%s) ORDER BY id FOR UPDATE",
+		userListTable, strings.Join(placeholders, ", "))
+	rows, err := tr.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	balances := make(map[int]int, len(args))
+	for rows.Next() {
+		var id, coins int
+		if err = rows.Scan(&id, &coins); err != nil {
+			return nil, err
+		}
+		balances[id] = coins
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := balances[sourceId]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	return balances, nil
 }
 
 func (r *ShopPostgres) getDestinationUserId(tr *sqlx.Tx, username string) (int, error) {
 	var destId int
-	getDestId := fmt.Sprintf("SELECT id FROM %s WHERE username = $1", userListTable)
+	getDestId := fmt.Sprintf("SELECT id FROM %s WHERE username = $1 AND %s", userListTable, activeUserFilter)
 	row := tr.QueryRowx(getDestId, username)
 	if err := row.Scan(&destId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, domain.ErrUserNotFound
+		}
 		return 0, err
 	}
 	return destId, nil
 }
 
-func (r *ShopPostgres) transferCoins(tr *sqlx.Tx, amount, destId, sourceId int) error {
-	sendMoneyQuery := fmt.Sprintf("UPDATE %s SET coins = coins + $1 WHERE id = $2", userListTable)
-	_, err := tr.Exec(sendMoneyQuery, amount, destId)
+// checkTransferLimits проверяет дневные лимиты отправителя и получателя и паузу между
+// переводами одному получателю. Строки обоих пользователей к этому моменту заблокированы.
+func (r *ShopPostgres) checkTransferLimits(tr *sqlx.Tx, input domain.Transactions, limits domain.TransferLimits) error {
+	if err := checkDailyLimit(tr, "source", *input.Source, input.Amount, *input.Timestamp, limits.DailyOutbound); err != nil {
+		return err
+	}
+	if err := checkDailyLimit(tr, "destination", *input.Destination, input.Amount, *input.Timestamp, limits.DailyInbound); err != nil {
+		return err
+	}
+	return checkTransferCooldown(tr, *input.Source, *input.Destination, *input.Timestamp, limits.Cooldown)
+}
+
+// checkDailyLimit проверяет, что сумма переводов пользователя с указанной стороны (source или destination)
+// за сутки до at вместе с amount не превышает limit. Нулевой limit не ограничивает переводы.
+func checkDailyLimit(tr *sqlx.Tx, side string, userId, amount int, at time.Time, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+	total, err := sumTransfers(tr, side, userId, at.Add(-24*time.Hour))
 	if err != nil {
 		return err
 	}
+	if total+amount <= limit {
+		return nil
+	}
+	if side == "source" {
+		return domain.ErrDailySendLimit
+	}
+	return domain.ErrDailyReceiveLimit
+}
+
+// checkTransferCooldown проверяет паузу между переводами и удержаниями от source к destination.
+func checkTransferCooldown(tr *sqlx.Tx, source, destination int, at time.Time, cooldown time.Duration) error {
+	if cooldown <= 0 {
+		return nil
+	}
+	var last *time.Time
+	query := fmt.Sprintf(`SELECT GREATEST(
+		(SELECT MAX(transaction_time) FROM %s WHERE source = $1 AND destination = $2 AND kind = $3),
+		(SELECT MAX(created_at) FROM %s WHERE sender_id = $1 AND recipient_id = $2))`, transactionsTable, escrowsTable)
+	if err := tr.QueryRowx(query, source, destination, domain.TransactionTransfer).Scan(&last); err != nil {
+		return err
+	}
+	if last != nil && at.Sub(*last) < cooldown {
+		return domain.ErrTransferCooldown
+	}
+	return nil
+}
 
-	changeAmountQuery := fmt.Sprintf("UPDATE %s SET coins = coins - $1 WHERE id = $2", userListTable)
-	_, err = tr.Exec(changeAmountQuery, amount, sourceId)
-	return err
+// sumTransfers суммирует переводы пользователя с указанной стороны (source или destination) начиная с since.
+// Удержания учитываются у отправителя при создании, если не были возвращены, а у получателя - при зачислении.
+func sumTransfers(tr *sqlx.Tx, side string, userId int, since time.Time) (int, error) {
+	var total int
+	if side == "source" {
+		query := fmt.Sprintf(`SELECT (SELECT COALESCE(SUM(amount), 0) FROM %s
+		WHERE source = $1 AND kind = $2 AND transaction_time > $3)
+	+ (SELECT COALESCE(SUM(amount), 0) FROM %s WHERE sender_id = $1 AND status <> $4 AND created_at > $3)`,
+			transactionsTable, escrowsTable)
+		err := tr.QueryRowx(query, userId, domain.TransactionTransfer, since, domain.EscrowReturned).Scan(&total)
+		return total, err
+	}
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(amount), 0) FROM %s
+	WHERE destination = $1 AND kind IN ($2, $4) AND transaction_time > $3`, transactionsTable)
+	err := tr.QueryRowx(query, userId, domain.TransactionTransfer, since, domain.TransactionEscrow).Scan(&total)
+	return total, err
 }
 
 func (r *ShopPostgres) createTransaction(tr *sqlx.Tx, input domain.Transactions) (int, error) {
-	createListQuery := fmt.Sprintf("INSERT INTO %s (source, destination, amount, transaction_time) VALUES ($1,$2,$3,$4) RETURNING id", transactionsTable)
-	row := tr.QueryRowx(createListQuery, input.Source, input.Destination, input.Amount, input.Timestamp)
+	createListQuery := fmt.Sprintf(`INSERT INTO %s (source, destination, amount, transaction_time, message, category)
+	VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`, transactionsTable)
+	row := tr.QueryRowx(createListQuery, input.Source, input.Destination, input.Amount, input.Timestamp, input.Message, input.Category)
 	var id int
 	if err := row.Scan(&id); err != nil {
 		return 0, err
@@ -161,11 +548,12 @@ func (s *ShopPostgres) GetUserSummary(userID int) (*domain.UserSummary, error) {
 
 	var purchases []domain.PurchasedItem
 	err = s.db.Select(&purchases, `
-    SELECT s.name AS item_name, COUNT(*) AS quantity
+    SELECT s.name AS item_name, COALESCE(v.size, '') AS size, COALESCE(v.colour, '') AS colour, SUM(p.quantity) AS quantity
               FROM purchases p
               JOIN shop s ON p.item_id = s.id
-              WHERE p.user_id = $1
-              GROUP BY s.name;
+              LEFT JOIN item_variants v ON p.variant_id = v.id
+              WHERE p.user_id = $1 AND p.refunded_at IS NULL
+              GROUP BY s.name, v.size, v.colour;
     `, userID)
 	if err != nil {
 		return nil, err
@@ -173,9 +561,9 @@ func (s *ShopPostgres) GetUserSummary(userID int) (*domain.UserSummary, error) {
 
 	var receivedCoins []domain.Transactions
 	err = s.db.Select(&receivedCoins, `
-    SELECT t.source, u.username AS source_username, t.amount
+    SELECT t.source, u.username AS source_username, t.amount, t.kind, t.purchase_id, t.message, t.category
     FROM transactions t
-    JOIN userlist u ON t.source = u.id
+    LEFT JOIN userlist u ON t.source = u.id
     WHERE t.destination = $1;
     `, userID)
 	if err != nil {
@@ -184,7 +572,7 @@ func (s *ShopPostgres) GetUserSummary(userID int) (*domain.UserSummary, error) {
 
 	var sentCoins []domain.Transactions
 	err = s.db.Select(&sentCoins, `
-    SELECT t.destination,d.username AS destination_username, t.amount
+    SELECT t.destination,d.username AS destination_username, t.amount, t.kind, t.message, t.category
     FROM transactions t
     JOIN userlist d ON t.destination = d.id
     WHERE t.source = $1;
@@ -193,9 +581,25 @@ func (s *ShopPostgres) GetUserSummary(userID int) (*domain.UserSummary, error) {
 		return nil, err
 	}
 
+	var escrows []domain.Escrow
+	escrowsQuery := fmt.Sprintf(`SELECT %s FROM %s e %s
+	WHERE e.status = $1 AND (e.sender_id = $2 OR e.recipient_id = $2)
+	ORDER BY e.created_at, e.id`, escrowColumns, escrowsTable, escrowJoins)
+	if err = s.db.Select(&escrows, escrowsQuery, domain.EscrowHeld, userID); err != nil {
+		return nil, err
+	}
+	heldCoins := 0
+	for _, escrow := range escrows {
+		if escrow.SenderId == userID {
+			heldCoins += escrow.Amount
+		}
+	}
+
 	userSummary := &domain.UserSummary{
 		UserName:       user.UserName,
 		Coins:          *user.Coins,
+		HeldCoins:      heldCoins,
+		PendingEscrows: escrows,
 		PurchasedItems: purchases,
 		TransactionsSummary: domain.TransactionsSummary{
 			ReceivedCoins: receivedCoins,
@@ -206,6 +610,83 @@ func (s *ShopPostgres) GetUserSummary(userID int) (*domain.UserSummary, error) {
 	return userSummary, nil
 }
 
+// purchaseColumns перечисляет поля покупки для запросов с псевдонимами p (purchases),
+// s (shop) и v (item_variants), присоединенных через purchaseJoins.
+const purchaseColumns = `p.id, p.user_id, p.order_id, s.name AS item_name, COALESCE(v.size, '') AS size,
+	COALESCE(v.colour, '') AS colour, p.price, COALESCE(p.base_price, p.price) AS base_price, p.promotion_id, p.quantity, p.purchase_date,
+	p.refunded_at, p.status, p.packed_at, p.shipped_at, p.cancelled_at`
+
+const purchaseJoins = "JOIN shop s ON p.item_id = s.id LEFT JOIN item_variants v ON p.variant_id = v.id"
+
+func (r *ShopPostgres) GetPurchases(userID int, filter domain.PurchaseFilter) ([]domain.Purchase, error) {
+	conditions := []string{"p.user_id = $1"}
+	args := []interface{}{userID}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("p.purchase_date >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("p.purchase_date < $%d", len(args)))
+	}
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.Time, filter.Cursor.Id)
+		conditions = append(conditions, fmt.Sprintf("(p.purchase_date, p.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(`SELECT %s
+	FROM %s p
+	%s
+	WHERE %s
+	ORDER BY p.purchase_date DESC, p.id DESC
+	LIMIT $%d`, purchaseColumns, purchaseTable, purchaseJoins, strings.Join(conditions, " AND "), len(args))
+
+	var purchases []domain.Purchase
+	if err := r.db.Select(&purchases, query, args...); err != nil {
+		return nil, err
+	}
+	logger.Log.Debug().Int("count", len(purchases)).Msg("Успешно получена история покупок")
+	return purchases, nil
+}
+
+func (r *ShopPostgres) GetTransactions(userID int, filter domain.TransactionFilter) ([]domain.Transactions, error) {
+	args := []interface{}{userID}
+	var conditions []string
+	switch filter.Direction {
+	case domain.DirectionIn:
+		conditions = append(conditions, "t.destination = $1")
+	case domain.DirectionOut:
+		conditions = append(conditions, "t.source = $1")
+	default:
+		conditions = append(conditions, "(t.source = $1 OR t.destination = $1)")
+	}
+	if filter.Category != "" {
+		args = append(args, filter.Category)
+		conditions = append(conditions, fmt.Sprintf("t.category = $%d", len(args)))
+	}
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.Time, filter.Cursor.Id)
+		conditions = append(conditions, fmt.Sprintf("(t.transaction_time, t.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(`SELECT t.id, t.source, su.username AS source_username, t.destination,
+	COALESCE(du.username, '') AS destination_username, t.amount, t.kind, t.purchase_id, t.message, t.category,
+	t.transaction_time AS timestamp
+	FROM %s t
+	LEFT JOIN %s su ON t.source = su.id
+	LEFT JOIN %s du ON t.destination = du.id
+	WHERE %s
+	ORDER BY t.transaction_time DESC, t.id DESC
+	LIMIT $%d`, transactionsTable, userListTable, userListTable, strings.Join(conditions, " AND "), len(args))
+
+	var transactions []domain.Transactions
+	if err := r.db.Select(&transactions, query, args...); err != nil {
+		return nil, err
+	}
+	logger.Log.Debug().Int("count", len(transactions)).Msg("Успешно получена история переводов")
+	return transactions, nil
+}
+
 func (r *ShopPostgres) DB() *sqlx.DB {
 	return r.db
 }
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreasuryPostgres_GrantCoins(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewTreasuryPostgres(sqlx.NewDb(db, "postgres"))
+
+	input := domain.CoinAdjustmentInput{Username: "intern", Amount: 200, Reason: "победа в хакатоне"}
+	expiresAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    domain.CoinAdjustment
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, coins FROM %s WHERE username = (.+) FOR UPDATE", userListTable)).
+					WithArgs("intern").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(2, 50))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE role", userListTable)).
+					WithArgs(domain.RoleTreasury).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(99))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+					WithArgs(99, 2, 200, sqlmock.AnyArg(), domain.TransactionGrant, "победа в хакатоне", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectExec(fmt.Sprintf("UPDATE %s SET coins = coins \\+ (.+)", userListTable)).
+					WithArgs(200, 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(fmt.Sprintf("INSERT INTO %s (.+)", coinLotsTable)).
+					WithArgs(2, 200, 7, expiresAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want: domain.CoinAdjustment{TransactionId: 7, BalanceBefore: 50, BalanceAfter: 250},
+		},
+		{
+			name: "Пользователь не найден",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, coins FROM %s WHERE username = (.+) FOR UPDATE", userListTable)).
+					WithArgs("intern").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.GrantCoins(1, input, &expiresAt)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTreasuryPostgres_ClawbackCoins(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewTreasuryPostgres(sqlx.NewDb(db, "postgres"))
+
+	input := domain.CoinAdjustmentInput{Username: "intern", Amount: 100, Reason: "ошибочное начисление"}
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    domain.CoinAdjustment
+		wantErr error
+	}{
+		{
+			name: "OK",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, coins FROM %s WHERE username = (.+) FOR UPDATE", userListTable)).
+					WithArgs("intern").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(2, 150))
+				mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE role", userListTable)).
+					WithArgs(domain.RoleTreasury).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(99))
+				mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+					WithArgs(2, 99, 100, sqlmock.AnyArg(), domain.TransactionClawback, "ошибочное начисление", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+				expectDebitCoins(mock, 2, 100)
+				mock.ExpectCommit()
+			},
+			want: domain.CoinAdjustment{TransactionId: 8, BalanceBefore: 150, BalanceAfter: 50},
+		},
+		{
+			name: "Недостаточно монет",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(fmt.Sprintf("SELECT id, coins FROM %s WHERE username = (.+) FOR UPDATE", userListTable)).
+					WithArgs("intern").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(2, 30))
+				mock.ExpectRollback()
+			},
+			wantErr: domain.ErrInsufficientCoins,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+
+			got, err := r.ClawbackCoins(1, input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTreasuryPostgres_PayAllowance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewTreasuryPostgres(sqlx.NewDb(db, "postgres"))
+
+	activeSince := time.Date(2025, 2, 8, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE role", userListTable)).
+		WithArgs(domain.RoleTreasury).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(99))
+	mock.ExpectQuery(fmt.Sprintf("SELECT u.id FROM %s u (.+)", userListTable)).
+		WithArgs(domain.RoleUser, domain.RoleAdmin, activeSince, "2025-03").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(3))
+	// первому пользователю начисление уже выплатил параллельный запуск
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s (.+)", allowanceTable)).
+		WithArgs(2, "2025-03", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s (.+)", allowanceTable)).
+		WithArgs(3, "2025-03", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+		WithArgs(99, 3, 100, sqlmock.AnyArg(), domain.TransactionAllowance, sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	expectCreditCoins(mock, 3, 100)
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET transaction_id (.+)", allowanceTable)).
+		WithArgs(11, 3, "2025-03").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	paid, err := r.PayAllowance("2025-03", 100, activeSince, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, paid)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTreasuryPostgres_ExpireCoins(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewTreasuryPostgres(sqlx.NewDb(db, "postgres"))
+
+	now := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(fmt.Sprintf("SELECT DISTINCT user_id FROM %s (.+)", coinLotsTable)).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(2).AddRow(3))
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf("SELECT id FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(fmt.Sprintf("WITH expired AS (.+) FROM %s WHERE user_id = (.+)", coinLotsTable)).
+		WithArgs(2, now).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(150))
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET coins = coins - (.+)", userListTable)).
+		WithArgs(150, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(fmt.Sprintf("SELECT id FROM %s WHERE role", userListTable)).
+		WithArgs(domain.RoleTreasury).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(99))
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s (.+)", transactionsTable)).
+		WithArgs(2, 99, 150, now, domain.TransactionExpiry, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectCommit()
+	// партии второго пользователя успели потратить до блокировки
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf("SELECT id FROM %s WHERE id = (.+) FOR UPDATE", userListTable)).
+		WithArgs(3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(fmt.Sprintf("WITH expired AS (.+) FROM %s WHERE user_id = (.+)", coinLotsTable)).
+		WithArgs(3, now).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+	mock.ExpectRollback()
+
+	expired, err := r.ExpireCoins(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTreasuryUserId(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+
+	selectQuery := fmt.Sprintf("SELECT id FROM %s WHERE role = \\$1", userListTable)
+	createQuery := fmt.Sprintf("INSERT INTO %s (.+) ON CONFLICT \\(username\\) DO NOTHING RETURNING id", userListTable)
+
+	tests := []struct {
+		name    string
+		mock    func()
+		want    int
+		wantErr error
+	}{
+		{
+			name: "Счет создан миграцией",
+			mock: func() {
+				mock.ExpectQuery(selectQuery).WithArgs(domain.RoleTreasury).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			},
+			want: 1,
+		},
+		{
+			name: "Счет создан параллельным запросом",
+			mock: func() {
+				mock.ExpectQuery(selectQuery).WithArgs(domain.RoleTreasury).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(createQuery).WithArgs(domain.TreasuryUsername, domain.RoleTreasury).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(selectQuery).WithArgs(domain.RoleTreasury).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+			},
+			want: 4,
+		},
+		{
+			name: "Имя занято пользователем",
+			mock: func() {
+				mock.ExpectQuery(selectQuery).WithArgs(domain.RoleTreasury).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(createQuery).WithArgs(domain.TreasuryUsername, domain.RoleTreasury).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(selectQuery).WithArgs(domain.RoleTreasury).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: domain.ErrTreasuryUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			tt.mock()
+			tr, err := sqlxDB.Beginx()
+			assert.NoError(t, err)
+
+			got, err := treasuryUserId(tr)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			mock.ExpectRollback()
+			assert.NoError(t, tr.Rollback())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type TreasuryPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTreasuryPostgres(db *sqlx.DB) *TreasuryPostgres {
+	return &TreasuryPostgres{
+		db: db,
+	}
+}
+
+func (r *TreasuryPostgres) GrantCoins(adminId int, input domain.CoinAdjustmentInput, expiresAt *time.Time) (domain.CoinAdjustment, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	userId, coins, err := lockUserByName(tr, input.Username)
+	if err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	treasuryId, err := treasuryUserId(tr)
+	if err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	id, err := issueCoins(tr, treasuryId, userId, input.Amount, domain.TransactionGrant, input.Reason, &adminId, expiresAt)
+	if err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	logger.Log.Debug().Int("id", id).Msg("Успешно начислены монеты из казны")
+	return domain.CoinAdjustment{TransactionId: id, BalanceBefore: coins, BalanceAfter: coins + input.Amount}, tr.Commit()
+}
+
+func (r *TreasuryPostgres) ClawbackCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	userId, coins, err := lockUserByName(tr, input.Username)
+	if err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	if coins < input.Amount {
+		return domain.CoinAdjustment{}, domain.ErrInsufficientCoins
+	}
+	treasuryId, err := treasuryUserId(tr)
+	if err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (source, destination, amount, transaction_time, kind, message, issued_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`, transactionsTable)
+	err = tr.QueryRowx(query, userId, treasuryId, input.Amount, time.Now(), domain.TransactionClawback, input.Reason, adminId).Scan(&id)
+	if err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	if _, err = debitCoins(tr, userId, input.Amount); err != nil {
+		return domain.CoinAdjustment{}, err
+	}
+	logger.Log.Debug().Int("id", id).Msg("Успешно списаны монеты в казну")
+	return domain.CoinAdjustment{TransactionId: id, BalanceBefore: coins, BalanceAfter: coins - input.Amount}, tr.Commit()
+}
+
+// PayAllowance начисляет amount каждому пользователю, входившему в систему начиная с activeSince
+// и еще не получившему начисление за period. Повторный вызов за тот же период ничего не начисляет.
+func (r *TreasuryPostgres) PayAllowance(period string, amount int, activeSince time.Time, expiresAt *time.Time) (int, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	treasuryId, err := treasuryUserId(tr)
+	if err != nil {
+		return 0, err
+	}
+	var userIds []int
+	usersQuery := fmt.Sprintf(`SELECT u.id FROM %s u
+	WHERE u.role IN ($1, $2) AND u.last_login_at >= $3
+	AND NOT EXISTS (SELECT 1 FROM %s p WHERE p.user_id = u.id AND p.period = $4)
+	ORDER BY u.id`, userListTable, allowanceTable)
+	if err = tr.Select(&userIds, usersQuery, domain.RoleUser, domain.RoleAdmin, activeSince, period); err != nil {
+		return 0, err
+	}
+	// запись о выплате вставляется первой: первичный ключ не даст параллельному запуску начислить повторно
+	claimQuery := fmt.Sprintf(`INSERT INTO %s (user_id, period, paid_at) VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING`, allowanceTable)
+	linkQuery := fmt.Sprintf("UPDATE %s SET transaction_id = $1 WHERE user_id = $2 AND period = $3", allowanceTable)
+	paid := 0
+	for _, userId := range userIds {
+		result, err := tr.Exec(claimQuery, userId, period, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if affected == 0 {
+			continue
+		}
+		id, err := issueCoins(tr, treasuryId, userId, amount, domain.TransactionAllowance, "ежемесячное начисление за "+period, nil, expiresAt)
+		if err != nil {
+			return 0, err
+		}
+		if _, err = tr.Exec(linkQuery, id, userId, period); err != nil {
+			return 0, err
+		}
+		paid++
+	}
+	logger.Log.Debug().Int("count", paid).Str("period", period).Msg("Успешно выплачено ежемесячное начисление")
+	return paid, tr.Commit()
+}
+
+// ExpireCoins сжигает просроченные партии монет и записывает транзакции сгорания на счет казны.
+// Каждый пользователь обрабатывается отдельной транзакцией, возвращается число пользователей, у которых сгорели монеты.
+func (r *TreasuryPostgres) ExpireCoins(now time.Time) (int, error) {
+	var userIds []int
+	query := fmt.Sprintf(`SELECT DISTINCT user_id FROM %s
+	WHERE remaining > 0 AND expires_at <= $1 ORDER BY user_id`, coinLotsTable)
+	if err := r.db.Select(&userIds, query, now); err != nil {
+		return 0, err
+	}
+	expired := 0
+	for _, userId := range userIds {
+		amount, err := r.expireUserCoins(userId, now)
+		if err != nil {
+			return expired, err
+		}
+		if amount > 0 {
+			expired++
+		}
+	}
+	logger.Log.Debug().Int("count", expired).Msg("Успешно сожжены просроченные монеты")
+	return expired, nil
+}
+
+func (r *TreasuryPostgres) expireUserCoins(userId int, now time.Time) (int, error) {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	lockQuery := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR UPDATE", userListTable)
+	if _, err = tr.Exec(lockQuery, userId); err != nil {
+		return 0, err
+	}
+	amount, err := expireCoins(tr, userId, now)
+	if err != nil || amount == 0 {
+		return 0, err
+	}
+	treasuryId, err := treasuryUserId(tr)
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (source, destination, amount, transaction_time, kind, message)
+	VALUES ($1, $2, $3, $4, $5, $6)`, transactionsTable)
+	if _, err = tr.Exec(query, userId, treasuryId, amount, now, domain.TransactionExpiry, "сгорание неиспользованных монет"); err != nil {
+		return 0, err
+	}
+	return amount, tr.Commit()
+}
+
+func lockUserByName(tr *sqlx.Tx, username string) (int, int, error) {
+	var id, coins int
+	query := fmt.Sprintf("SELECT id, coins FROM %s WHERE username = $1 AND %s FOR UPDATE", userListTable, activeUserFilter)
+	if err := tr.QueryRowx(query, username).Scan(&id, &coins); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, domain.ErrUserNotFound
+		}
+		return 0, 0, err
+	}
+	return id, coins, nil
+}
+
+// treasuryUserId возвращает id счета казны. Счет заводит миграция; если его нет, он создается.
+// Если имя уже занято, счет перечитывается по роли: его мог только что создать параллельный запрос.
+func treasuryUserId(tr *sqlx.Tx) (int, error) {
+	var id int
+	query := fmt.Sprintf("SELECT id FROM %s WHERE role = $1", userListTable)
+	err := tr.QueryRowx(query, domain.RoleTreasury).Scan(&id)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	createQuery := fmt.Sprintf(`INSERT INTO %s (username, password, coins, role) VALUES ($1, '', 0, $2)
+	ON CONFLICT (username) DO NOTHING RETURNING id`, userListTable)
+	err = tr.QueryRowx(createQuery, domain.TreasuryUsername, domain.RoleTreasury).Scan(&id)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+	if err = tr.QueryRowx(query, domain.RoleTreasury).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Log.Error().Str("username", domain.TreasuryUsername).
+				Msg("Имя служебного счета казны занято обычным пользователем, счет не создан")
+			return 0, domain.ErrTreasuryUnavailable
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+// issueCoins зачисляет пользователю монеты из казны партией со сроком действия expiresAt
+// и записывает транзакцию. Баланс казны не уменьшается: выпущенные монеты учитываются по ее транзакциям.
+func issueCoins(tr *sqlx.Tx, treasuryId, userId, amount int, kind, message string, issuedBy *int, expiresAt *time.Time) (int, error) {
+	var id int
+	query := fmt.Sprintf(`INSERT INTO %s (source, destination, amount, transaction_time, kind, message, issued_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`, transactionsTable)
+	if err := tr.QueryRowx(query, treasuryId, userId, amount, time.Now(), kind, message, issuedBy).Scan(&id); err != nil {
+		return 0, err
+	}
+	if err := creditCoins(tr, userId, amount, &id, expiresAt); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *TreasuryPostgres) DB() *sqlx.DB {
+	return r.db
+}
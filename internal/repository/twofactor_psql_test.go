@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactorPostgres_SetTwoFactorSecret(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewTwoFactorPostgres(sqlx.NewDb(db, "postgres"))
+
+	query := fmt.Sprintf("UPDATE %s SET totp_secret = \\$1, totp_last_step = NULL WHERE id = \\$2 AND totp_enabled = false", userListTable)
+	mock.ExpectExec(query).WithArgs("SECRET", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.SetTwoFactorSecret(1, "SECRET"))
+
+	// включенный секрет не заменяется
+	mock.ExpectExec(query).WithArgs("SECRET", 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, r.SetTwoFactorSecret(2, "SECRET"), domain.ErrTwoFactorEnabled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorPostgres_EnableTwoFactor(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewTwoFactorPostgres(sqlx.NewDb(db, "postgres"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_enabled = true, totp_last_step = \\$1", userListTable)).
+		WithArgs(int64(100), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf("DELETE FROM %s WHERE user_id = \\$1", recoveryCodesTable)).
+		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(fmt.Sprintf("INSERT INTO %s \\(user_id, code_hash\\) VALUES \\(\\$1, \\$2\\), \\(\\$1, \\$3\\)", recoveryCodesTable)).
+		WithArgs(1, "hash1", "hash2").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	assert.NoError(t, r.EnableTwoFactor(1, 100, []string{"hash1", "hash2"}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorPostgres_UseTotpStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewTwoFactorPostgres(sqlx.NewDb(db, "postgres"))
+
+	tests := []struct {
+		name     string
+		affected int64
+		want     bool
+	}{
+		{name: "Новый интервал", affected: 1, want: true},
+		{name: "Повторный код", affected: 0, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectExec(fmt.Sprintf("UPDATE %s SET totp_last_step = \\$1 WHERE id = \\$2 AND \\(totp_last_step IS NULL OR totp_last_step < \\$1\\)", userListTable)).
+				WithArgs(int64(100), 1).WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			got, err := r.UseTotpStep(1, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type TwoFactorPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTwoFactorPostgres(db *sqlx.DB) *TwoFactorPostgres {
+	return &TwoFactorPostgres{
+		db: db,
+	}
+}
+
+func (r *TwoFactorPostgres) GetTwoFactor(userId int) (domain.TwoFactorState, error) {
+	var state domain.TwoFactorState
+	query := fmt.Sprintf(`SELECT username, role, totp_secret, totp_enabled FROM %s WHERE id = $1 AND %s`,
+		userListTable, activeUserFilter)
+	if err := r.db.Get(&state, query, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.TwoFactorState{}, domain.ErrUserNotFound
+		}
+		return domain.TwoFactorState{}, err
+	}
+	return state, nil
+}
+
+// SetTwoFactorSecret сохраняет новый секрет, ожидающий подтверждения. Повторная настройка
+// заменяет неподтвержденный секрет, но не включенный.
+func (r *TwoFactorPostgres) SetTwoFactorSecret(userId int, secret string) error {
+	query := fmt.Sprintf(`UPDATE %s SET totp_secret = $1, totp_last_step = NULL
+	WHERE id = $2 AND totp_enabled = false AND %s`, userListTable, activeUserFilter)
+	res, err := r.db.Exec(query, secret, userId)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+// EnableTwoFactor включает двухфакторную аутентификацию, запоминает интервал подтверждающего кода
+// и заменяет коды восстановления.
+func (r *TwoFactorPostgres) EnableTwoFactor(userId int, step int64, codeHashes []string) error {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	query := fmt.Sprintf(`UPDATE %s SET totp_enabled = true, totp_last_step = $1
+	WHERE id = $2 AND totp_enabled = false AND totp_secret IS NOT NULL`, userListTable)
+	res, err := tr.Exec(query, step, userId)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrTwoFactorEnabled
+	}
+	if err = replaceRecoveryCodes(tr, userId, codeHashes); err != nil {
+		return err
+	}
+	logger.Log.Debug().Int("user_id", userId).Msg("Двухфакторная аутентификация включена")
+	return tr.Commit()
+}
+
+func (r *TwoFactorPostgres) DisableTwoFactor(userId int) error {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	query := fmt.Sprintf(`UPDATE %s SET totp_enabled = false, totp_secret = NULL, totp_last_step = NULL
+	WHERE id = $1`, userListTable)
+	if _, err = tr.Exec(query, userId); err != nil {
+		return err
+	}
+	if err = replaceRecoveryCodes(tr, userId, nil); err != nil {
+		return err
+	}
+	logger.Log.Debug().Int("user_id", userId).Msg("Двухфакторная аутентификация отключена")
+	return tr.Commit()
+}
+
+// ReplaceRecoveryCodes удаляет прежние коды восстановления, в том числе неиспользованные, и сохраняет новые.
+func (r *TwoFactorPostgres) ReplaceRecoveryCodes(userId int, codeHashes []string) error {
+	tr, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tr.Rollback() // nolint:errcheck
+
+	if err = replaceRecoveryCodes(tr, userId, codeHashes); err != nil {
+		return err
+	}
+	return tr.Commit()
+}
+
+func replaceRecoveryCodes(tr *sqlx.Tx, userId int, codeHashes []string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", recoveryCodesTable)
+	if _, err := tr.Exec(query, userId); err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+	args := []interface{}{userId}
+	values := make([]string, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		args = append(args, hash)
+		values = append(values, fmt.Sprintf("($1, $%d)", len(args)))
+	}
+	query = fmt.Sprintf("INSERT INTO %s (user_id, code_hash) VALUES %s", recoveryCodesTable, strings.Join(values, ", "))
+	_, err := tr.Exec(query, args...)
+	return err
+}
+
+// UseTotpStep отмечает интервал кода как использованный. Возвращает false, если код этого
+// или более позднего интервала уже принимался.
+func (r *TwoFactorPostgres) UseTotpStep(userId int, step int64) (bool, error) {
+	query := fmt.Sprintf(`UPDATE %s SET totp_last_step = $1
+	WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)`, userListTable)
+	res, err := r.db.Exec(query, step, userId)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UseRecoveryCode погашает код восстановления. Возвращает false, если кода нет или он уже использован.
+func (r *TwoFactorPostgres) UseRecoveryCode(userId int, codeHash string, at time.Time) (bool, error) {
+	query := fmt.Sprintf(`UPDATE %s SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`,
+		recoveryCodesTable)
+	res, err := r.db.Exec(query, at, userId, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
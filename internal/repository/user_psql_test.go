@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var userInfoRows = []string{"id", "username", "role", "coins", "last_login_at", "banned_at", "ban_reason", "password_reset_required", "deleted_at"}
+
+func TestUserPostgres_SearchUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewUserPostgres(sqlx.NewDb(db, "postgres"))
+
+	bannedAt := time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(fmt.Sprintf("SELECT (.+) FROM %s WHERE role <> 'treasury' AND username ILIKE \\$1 AND deleted_at IS NULL AND banned_at IS NOT NULL ORDER BY id LIMIT \\$2 OFFSET \\$3", userListTable)).
+		WithArgs("%ann%", 20, 40).
+		WillReturnRows(sqlmock.NewRows(userInfoRows).
+			AddRow(3, "anna", domain.RoleUser, 150, nil, bannedAt, "спам", false, nil))
+
+	got, err := r.SearchUsers(domain.UserFilter{Query: "ann", Status: domain.UserStatusBanned, Limit: 20, Offset: 40})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.UserInfo{
+		{Id: 3, UserName: "anna", Role: domain.RoleUser, Coins: 150, BannedAt: &bannedAt, BanReason: "спам"},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserPostgres_BanUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewUserPostgres(sqlx.NewDb(db, "postgres"))
+
+	at := time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		mock    func()
+		want    domain.UserInfo
+		wantErr error
+	}{
+		{
+			name: "Ok",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET banned_at = COALESCE(.+) RETURNING (.+)", userListTable)).
+					WithArgs(at, 1, "спам", 3).
+					WillReturnRows(sqlmock.NewRows(userInfoRows).
+						AddRow(3, "anna", domain.RoleUser, 150, nil, at, "спам", false, nil))
+			},
+			want: domain.UserInfo{Id: 3, UserName: "anna", Role: domain.RoleUser, Coins: 150, BannedAt: &at, BanReason: "спам"},
+		},
+		{
+			name: "Пользователь не найден",
+			mock: func() {
+				mock.ExpectQuery(fmt.Sprintf("UPDATE %s SET banned_at = COALESCE(.+) RETURNING (.+)", userListTable)).
+					WithArgs(at, 1, "спам", 3).
+					WillReturnRows(sqlmock.NewRows(userInfoRows))
+			},
+			wantErr: domain.ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mock()
+			got, err := r.BanUser(3, 1, "спам", at)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserPostgres_ResetPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	r := NewUserPostgres(sqlx.NewDb(db, "postgres"))
+
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET password = \\$1, password_reset_required = true, token_version = token_version \\+ 1", userListTable)).
+		WithArgs("hash", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(fmt.Sprintf("UPDATE %s SET password = \\$1, password_reset_required = true, token_version = token_version \\+ 1", userListTable)).
+		WithArgs("hash", 99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, r.ResetPassword(3, "hash"))
+	assert.ErrorIs(t, r.ResetPassword(99, "hash"), domain.ErrUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
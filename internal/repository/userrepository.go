@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/jmoiron/sqlx"
+)
+
+type UserPostgres struct {
+	db *sqlx.DB
+}
+
+func NewUserPostgres(db *sqlx.DB) *UserPostgres {
+	return &UserPostgres{
+		db: db,
+	}
+}
+
+const userInfoColumns = `id, username, role, coins, last_login_at, banned_at, ban_reason, password_reset_required, deleted_at`
+
+// SearchUsers возвращает пользователей по фильтру, упорядоченных по id. Служебный счет казны не возвращается.
+func (r *UserPostgres) SearchUsers(filter domain.UserFilter) ([]domain.UserInfo, error) {
+	conditions := []string{fmt.Sprintf("role <> '%s'", domain.RoleTreasury)}
+	args := []interface{}{}
+	if filter.Query != "" {
+		args = append(args, "%"+filter.Query+"%")
+		conditions = append(conditions, fmt.Sprintf("username ILIKE $%d", len(args)))
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+	}
+	switch filter.Status {
+	case domain.UserStatusActive:
+		conditions = append(conditions, "deleted_at IS NULL AND banned_at IS NULL")
+	case domain.UserStatusBanned:
+		conditions = append(conditions, "deleted_at IS NULL AND banned_at IS NOT NULL")
+	case domain.UserStatusDeleted:
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY id LIMIT $%d OFFSET $%d`,
+		userInfoColumns, userListTable, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	users := []domain.UserInfo{}
+	if err := r.db.Select(&users, query, args...); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *UserPostgres) GetUserInfo(userId int) (domain.UserInfo, error) {
+	var user domain.UserInfo
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1 AND role <> '%s'`, userInfoColumns, userListTable, domain.RoleTreasury)
+	if err := r.db.Get(&user, query, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.UserInfo{}, domain.ErrUserNotFound
+		}
+		return domain.UserInfo{}, err
+	}
+	return user, nil
+}
+
+// BanUser блокирует пользователя. Повторная блокировка обновляет причину, но сохраняет исходное время.
+func (r *UserPostgres) BanUser(userId, adminId int, reason string, at time.Time) (domain.UserInfo, error) {
+	var user domain.UserInfo
+	query := fmt.Sprintf(`UPDATE %s SET banned_at = COALESCE(banned_at, $1), banned_by = $2, ban_reason = $3
+	WHERE id = $4 AND %s RETURNING %s`, userListTable, activeUserFilter, userInfoColumns)
+	if err := r.db.Get(&user, query, at, adminId, reason, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.UserInfo{}, domain.ErrUserNotFound
+		}
+		return domain.UserInfo{}, err
+	}
+	logger.Log.Debug().Int("user_id", userId).Int("admin_id", adminId).Msg("Пользователь заблокирован")
+	return user, nil
+}
+
+func (r *UserPostgres) UnbanUser(userId int) (domain.UserInfo, error) {
+	var user domain.UserInfo
+	query := fmt.Sprintf(`UPDATE %s SET banned_at = NULL, banned_by = NULL, ban_reason = ''
+	WHERE id = $1 AND %s RETURNING %s`, userListTable, activeUserFilter, userInfoColumns)
+	if err := r.db.Get(&user, query, userId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.UserInfo{}, domain.ErrUserNotFound
+		}
+		return domain.UserInfo{}, err
+	}
+	logger.Log.Debug().Int("user_id", userId).Msg("Пользователь разблокирован")
+	return user, nil
+}
+
+// ResetPassword устанавливает временный пароль, требует его смены при следующем входе и отзывает выданные токены.
+func (r *UserPostgres) ResetPassword(userId int, passwordHash string) error {
+	query := fmt.Sprintf(`UPDATE %s SET password = $1, password_reset_required = true, token_version = token_version + 1
+	WHERE id = $2 AND %s`, userListTable, activeUserFilter)
+	res, err := r.db.Exec(query, passwordHash, userId)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrUserNotFound
+	}
+	logger.Log.Debug().Int("user_id", userId).Msg("Пароль пользователя сброшен")
+	return nil
+}
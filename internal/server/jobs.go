@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	logger "github.com/bllooop/coinshop/pkg/logging"
+)
+
+// jobRunner запускает фоновые задачи сервера и дожидается их завершения при остановке.
+type jobRunner struct {
+	wg sync.WaitGroup
+}
+
+// runPeriodic вызывает job с заданным интервалом до отмены ctx. Нулевой интервал отключает задачу.
+func (j *jobRunner) runPeriodic(ctx context.Context, name string, interval time.Duration, job func(now time.Time) error) {
+	if interval <= 0 {
+		logger.Log.Info().Str("job", name).Msg("Фоновая задача отключена")
+		return
+	}
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		logger.Log.Info().Str("job", name).Dur("interval", interval).Msg("Фоновая задача запущена")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := job(now); err != nil {
+					logger.Log.Error().Err(err).Str("job", name).Msg("Ошибка фоновой задачи")
+				}
+			}
+		}
+	}()
+}
+
+func (j *jobRunner) wait() {
+	j.wg.Wait()
+}
@@ -9,9 +9,13 @@ import (
 	"time"
 
 	handlers "github.com/bllooop/coinshop/internal/delivery/api"
+	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/repository"
 	"github.com/bllooop/coinshop/internal/usecase"
 	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/bllooop/coinshop/pkg/oidc"
+	"github.com/bllooop/coinshop/pkg/passhash"
+	"github.com/bllooop/coinshop/pkg/ratelimit"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -61,15 +65,143 @@ func Run() {
 	}
 	logger.Log.Debug().Msg("Инициализация слоя репозитория")
 	repos := repository.NewRepository(dbpool)
+	hasher, err := passhash.New(passhash.Config{
+		Algorithm:  viper.GetString("password.algorithm"),
+		BcryptCost: viper.GetInt("password.bcrypt_cost"),
+		Argon2: passhash.Argon2Params{
+			Memory:      viper.GetUint32("password.argon2.memory"),
+			Iterations:  viper.GetUint32("password.argon2.iterations"),
+			Parallelism: uint8(viper.GetUint("password.argon2.parallelism")),
+			SaltLength:  viper.GetUint32("password.argon2.salt_length"),
+			KeyLength:   viper.GetUint32("password.argon2.key_length"),
+		},
+	})
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("")
+		logger.Log.Fatal().Msg("Некорректные настройки хеширования паролей")
+	}
 	logger.Log.Debug().Msg("Инициализация usecase слоя")
-	usecases := usecase.NewUsecase(repos)
+	usecases := usecase.NewUsecase(repos, hasher, usecase.Config{
+		Refund: usecase.RefundConfig{
+			Window: viper.GetDuration("refund.window"),
+		},
+		Transfer: domain.TransferLimits{
+			MaxAmount:     viper.GetInt("transfer.max_amount"),
+			DailyOutbound: viper.GetInt("transfer.daily_outbound"),
+			DailyInbound:  viper.GetInt("transfer.daily_inbound"),
+			Cooldown:      viper.GetDuration("transfer.cooldown"),
+			MaxBalance:    viper.GetInt("transfer.max_balance"),
+		},
+		Allowance: usecase.AllowanceConfig{
+			Amount:       viper.GetInt("allowance.amount"),
+			ActiveWindow: viper.GetDuration("allowance.active_window"),
+		},
+		Login: usecase.LoginConfig{
+			MaxFailures:      viper.GetInt("login.max_failures"),
+			MaxFailuresPerIP: viper.GetInt("login.max_failures_per_ip"),
+			Delay:            viper.GetDuration("login.delay"),
+			Lockout:          viper.GetDuration("login.lockout"),
+		},
+		TwoFactor: usecase.TwoFactorConfig{
+			Issuer:        viper.GetString("two_factor.issuer"),
+			RequiredRoles: viper.GetStringSlice("two_factor.required_roles"),
+		},
+		ApiKeys: usecase.ApiKeyConfig{
+			DefaultTTL: viper.GetDuration("api_keys.default_ttl"),
+			MaxTTL:     viper.GetDuration("api_keys.max_ttl"),
+		},
+		Oidc: usecase.OidcConfig{
+			Provider: oidc.Config{
+				Issuer:       viper.GetString("oidc.issuer"),
+				ClientID:     viper.GetString("oidc.client_id"),
+				ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+				RedirectURL:  viper.GetString("oidc.redirect_url"),
+				Scopes:       viper.GetStringSlice("oidc.scopes"),
+			},
+			StateTTL: viper.GetDuration("oidc.state_ttl"),
+		},
+		SignupBonus:       viper.GetInt("coins.signup_bonus"),
+		CoinExpiry:        time.Duration(viper.GetInt("coins.expiry_days")) * 24 * time.Hour,
+		PaymentRequestTTL: viper.GetDuration("payment_requests.ttl"),
+	})
 	logger.Log.Debug().Msg("Инициализация обработчиков API")
 	handler := handlers.NewHandler(usecases)
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if viper.GetString("rate_limit.store") == "postgres" {
+		rateLimitStore = repository.NewRateLimitPostgres(dbpool)
+	}
+	rateLimits := handlers.RateLimits{
+		Store: rateLimitStore,
+		Auth:  rateLimit("rate_limit.auth"),
+		User:  rateLimit("rate_limit.user"),
+		Admin: rateLimit("rate_limit.admin"),
+	}
+	router, err := handler.InitRoutes(rateLimits, viper.GetStringSlice("trusted_proxies"))
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("Некорректный список доверенных прокси")
+	}
 	srv := new(Server)
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs := new(jobRunner)
+	jobs.runPeriodic(jobsCtx, "scheduled_transfers", viper.GetDuration("scheduler.interval"), func(now time.Time) error {
+		executed, err := usecases.Schedules.RunDueSchedules(now)
+		if executed > 0 {
+			logger.Log.Info().Int("count", executed).Msg("Выполнены запланированные переводы")
+		}
+		return err
+	})
+	jobs.runPeriodic(jobsCtx, "monthly_allowance", viper.GetDuration("allowance.check_interval"), func(now time.Time) error {
+		paid, err := usecases.Treasury.PayAllowance(now)
+		if paid > 0 {
+			logger.Log.Info().Int("count", paid).Msg("Выплачено ежемесячное начисление")
+		}
+		return err
+	})
+	jobs.runPeriodic(jobsCtx, "coin_expiry", viper.GetDuration("coins.expiry_check_interval"), func(now time.Time) error {
+		expired, err := usecases.Treasury.ExpireCoins(now)
+		if expired > 0 {
+			logger.Log.Info().Int("count", expired).Msg("Сожжены просроченные монеты")
+		}
+		return err
+	})
+	jobs.runPeriodic(jobsCtx, "payment_request_expiry", viper.GetDuration("payment_requests.expiry_check_interval"), func(now time.Time) error {
+		expired, err := usecases.PaymentRequests.ExpirePaymentRequests(now)
+		if expired > 0 {
+			logger.Log.Info().Int("count", expired).Msg("Истекли запросы на перевод")
+		}
+		return err
+	})
+	jobs.runPeriodic(jobsCtx, "escrow_timeout", viper.GetDuration("escrow.check_interval"), func(now time.Time) error {
+		settled, err := usecases.Escrows.SettleExpiredEscrows(now)
+		if settled > 0 {
+			logger.Log.Info().Int("count", settled).Msg("Завершены переводы с удержанием по сроку")
+		}
+		return err
+	})
+	jobs.runPeriodic(jobsCtx, "login_attempts_cleanup", viper.GetDuration("login.cleanup_interval"), func(now time.Time) error {
+		purged, err := usecases.Authorization.PurgeLoginAttempts(now)
+		if purged > 0 {
+			logger.Log.Debug().Int("count", purged).Msg("Удален устаревший учет попыток входа")
+		}
+		return err
+	})
+	jobs.runPeriodic(jobsCtx, "oidc_states_cleanup", viper.GetDuration("oidc.cleanup_interval"), func(now time.Time) error {
+		purged, err := usecases.Oidc.PurgeOidcStates(now)
+		if purged > 0 {
+			logger.Log.Debug().Int("count", purged).Msg("Удалены незавершенные входы через внешнего провайдера")
+		}
+		return err
+	})
+	rateLimitIdle := viper.GetDuration("rate_limit.cleanup_interval")
+	jobs.runPeriodic(jobsCtx, "rate_limit_cleanup", rateLimitIdle, func(now time.Time) error {
+		_, err := rateLimitStore.Purge(now.Add(-rateLimitIdle))
+		return err
+	})
+
 	go func() {
 		logger.Log.Info().Msg("Запуск сервера...")
-		if err := srv.RunServer(viper.GetString("port"), handler.InitRoutes()); err != nil && err == http.ErrServerClosed {
+		if err := srv.RunServer(viper.GetString("port"), router); err != nil && err == http.ErrServerClosed {
 			logger.Log.Info().Msg("Сервер был закрыт аккуратно")
 		} else {
 			logger.Log.Error().Err(err).Msg("")
@@ -82,6 +214,8 @@ func Run() {
 	logger.Log.Debug().Msg("Прослушивание сигналов завершения работы ОС")
 	<-quit
 	logger.Log.Info().Msg("Сервер отключается")
+	stopJobs()
+	jobs.wait()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	defer dbpool.Close()
@@ -97,3 +231,11 @@ func initConfig() error {
 	viper.SetConfigName("config")
 	return viper.ReadInConfig()
 }
+
+// rateLimit читает лимит группы маршрутов из ключей rate и burst раздела конфига.
+func rateLimit(key string) ratelimit.Limit {
+	return ratelimit.Limit{
+		Rate:  viper.GetFloat64(key + ".rate"),
+		Burst: viper.GetInt(key + ".burst"),
+	}
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+)
+
+const (
+	// apiKeyPrefixBytes дает открытую часть ключа из 12 шестнадцатеричных символов.
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+type ApiKeyUsecase struct {
+	repo repository.ApiKeys
+	cfg  ApiKeyConfig
+}
+
+func NewApiKeyUsecase(repo *repository.Repository, cfg ApiKeyConfig) *ApiKeyUsecase {
+	return &ApiKeyUsecase{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+func (s *ApiKeyUsecase) CreateServiceAccount(adminId int, input domain.ServiceAccountInput) (domain.ServiceAccount, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	return s.repo.CreateServiceAccount(adminId, input)
+}
+
+func (s *ApiKeyUsecase) GetServiceAccounts() ([]domain.ServiceAccount, error) {
+	return s.repo.GetServiceAccounts()
+}
+
+// CreateApiKey выпускает ключ вида cs_<префикс>_<секрет>. Секрет возвращается один раз,
+// в базе хранится только хеш всего ключа.
+func (s *ApiKeyUsecase) CreateApiKey(adminId, accountId int, input domain.ApiKeyInput) (domain.CreatedApiKey, error) {
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return domain.CreatedApiKey{}, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.DefaultTTL)
+	if input.ExpiresAt != nil {
+		expiresAt = *input.ExpiresAt
+	}
+	if !expiresAt.After(now) || (s.cfg.MaxTTL > 0 && expiresAt.After(now.Add(s.cfg.MaxTTL))) {
+		return domain.CreatedApiKey{}, domain.ErrInvalidApiKeyExpiry
+	}
+
+	prefix := make([]byte, apiKeyPrefixBytes)
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err = rand.Read(prefix); err != nil {
+		return domain.CreatedApiKey{}, err
+	}
+	if _, err = rand.Read(secret); err != nil {
+		return domain.CreatedApiKey{}, err
+	}
+	prefixHex := hex.EncodeToString(prefix)
+	key := domain.ApiKeyPrefix + "_" + prefixHex + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey, err := s.repo.CreateApiKey(adminId, accountId, prefixHex, hashApiKey(key), scopes, expiresAt.UTC())
+	if err != nil {
+		return domain.CreatedApiKey{}, err
+	}
+	return domain.CreatedApiKey{Key: key, ApiKey: apiKey}, nil
+}
+
+func (s *ApiKeyUsecase) GetApiKeys(accountId int) ([]domain.ApiKey, error) {
+	return s.repo.GetApiKeys(accountId)
+}
+
+func (s *ApiKeyUsecase) RevokeApiKey(keyId int) (domain.ApiKey, error) {
+	return s.repo.RevokeApiKey(keyId, time.Now())
+}
+
+// AuthenticateApiKey проверяет ключ и возвращает пользователя сервисного аккаунта, от имени которого
+// выполняется запрос, и разрешения ключа. Отозванный, просроченный и неизвестный ключи неразличимы для клиента.
+func (s *ApiKeyUsecase) AuthenticateApiKey(key string) (domain.ApiKeyIdentity, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != domain.ApiKeyPrefix || len(parts[1]) != 2*apiKeyPrefixBytes {
+		return domain.ApiKeyIdentity{}, domain.ErrInvalidApiKey
+	}
+	cred, err := s.repo.GetApiKeyCredential(parts[1])
+	if err != nil {
+		return domain.ApiKeyIdentity{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(cred.KeyHash), []byte(hashApiKey(key))) != 1 {
+		return domain.ApiKeyIdentity{}, domain.ErrInvalidApiKey
+	}
+	now := time.Now()
+	if cred.RevokedAt != nil || !now.Before(cred.ExpiresAt) || cred.UserDeleted {
+		return domain.ApiKeyIdentity{}, domain.ErrInvalidApiKey
+	}
+	if cred.UserBanned {
+		return domain.ApiKeyIdentity{}, domain.ErrUserBanned
+	}
+	if err = s.repo.TouchApiKey(cred.Id, now); err != nil {
+		logger.Log.Error().Err(err).Int("api_key_id", cred.Id).Msg("Не удалось отметить использование ключа API")
+	}
+	return domain.ApiKeyIdentity{KeyId: cred.Id, ServiceAccountId: cred.ServiceAccountId, UserId: cred.UserId,
+		Scopes: cred.Scopes}, nil
+}
+
+// normalizeScopes проверяет, что все разрешения известны, и убирает повторы.
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, domain.ErrUnknownScope
+	}
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(domain.ApiKeyScopes, scope) {
+			return nil, domain.ErrUnknownScope
+		}
+		result = append(result, scope)
+	}
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+// hashApiKey хеширует ключ целиком. Ключ случайный и длинный, поэтому достаточно SHA-256.
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+	// auditVerifyBatch - сколько записей читается за раз при проверке цепочки.
+	auditVerifyBatch = 1000
+)
+
+type AuditUsecase struct {
+	repo repository.Audit
+}
+
+func NewAuditUsecase(repo *repository.Repository) *AuditUsecase {
+	return &AuditUsecase{
+		repo: repo,
+	}
+}
+
+// Record добавляет событие в журнал аудита: в цепочку хешей или, для частых событий, вне ее.
+func (s *AuditUsecase) Record(event domain.AuditEvent) error {
+	entry := domain.AuditEntry{
+		Action:     event.Action,
+		ActorId:    event.ActorId,
+		TargetType: event.TargetType,
+		TargetId:   event.TargetId,
+		IP:         event.IP,
+		RequestId:  event.RequestId,
+		// Postgres хранит время с точностью до микросекунд, иначе хеш прочитанной записи не совпадет
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	var err error
+	if entry.Before, err = auditJSON(event.Before); err != nil {
+		return err
+	}
+	if entry.After, err = auditJSON(event.After); err != nil {
+		return err
+	}
+	if !domain.AuditChained(entry.Action) {
+		_, err = s.repo.InsertUnchainedAuditEntry(entry)
+		return err
+	}
+	_, err = s.repo.AppendAuditEntry(entry)
+	return err
+}
+
+func auditJSON(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+func (s *AuditUsecase) GetAuditLog(filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	filter.Limit = min(filter.Limit, maxAuditLimit)
+	filter.Offset = max(filter.Offset, 0)
+	return s.repo.GetAuditLog(filter)
+}
+
+// VerifyAuditLog проходит журнал с первой записи и проверяет, что каждая запись ссылается на хеш
+// предыдущей и ее собственный хеш совпадает с содержимым.
+func (s *AuditUsecase) VerifyAuditLog() (domain.AuditVerification, error) {
+	result := domain.AuditVerification{Valid: true}
+	prevHash := domain.AuditGenesisHash
+	var afterId int64
+	for {
+		entries, err := s.repo.GetAuditChain(afterId, auditVerifyBatch)
+		if err != nil {
+			return domain.AuditVerification{}, err
+		}
+		for _, entry := range entries {
+			if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+				result.Valid = false
+				result.BrokenAt = &entry.Id
+				return result, nil
+			}
+			prevHash = entry.Hash
+			afterId = entry.Id
+			result.Checked++
+		}
+		if len(entries) < auditVerifyBatch {
+			return result, nil
+		}
+	}
+}
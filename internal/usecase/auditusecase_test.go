@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuditUsecase_Record(t *testing.T) {
+	testTable := []struct {
+		action  string
+		chained bool
+	}{
+		{action: domain.AuditUserBan, chained: true},
+		{action: domain.AuditLoginUnlock, chained: true},
+		{action: domain.AuditLogin, chained: false},
+		{action: domain.AuditLoginFailed, chained: false},
+		{action: domain.AuditLoginLocked, chained: false},
+		{action: domain.AuditApiKeyUse, chained: false},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.action, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockAudit(c)
+			if test.chained {
+				repo.EXPECT().AppendAuditEntry(gomock.Any()).Return(domain.AuditEntry{}, nil)
+			} else {
+				repo.EXPECT().InsertUnchainedAuditEntry(gomock.Any()).Return(domain.AuditEntry{}, nil)
+			}
+			s := &AuditUsecase{repo: repo}
+
+			assert.NoError(t, s.Record(domain.AuditEvent{Action: test.action, TargetType: domain.AuditTargetUser, TargetId: "2"}))
+		})
+	}
+}
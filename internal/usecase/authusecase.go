@@ -2,65 +2,275 @@ package usecase
 
 import (
 	"errors"
+	"expvar"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/repository"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/bllooop/coinshop/pkg/passhash"
 	"github.com/golang-jwt/jwt"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// loginLockouts считает блокировки входа по областям username и ip, доступен через expvar.
+var loginLockouts = expvar.NewMap("login_lockouts")
+
 type AuthUsecase struct {
-	repo repository.Authorization
+	repo        repository.Authorization
+	attempts    repository.LoginAttempts
+	hasher      passhash.Hasher
+	signupBonus int
+	coinExpiry  time.Duration
+	login       LoginConfig
+	// dummyHash - хеш фиксированного пароля, с которым сверяется пароль несуществующего пользователя.
+	dummyHash     string
+	dummyHashOnce sync.Once
 }
 
-func NewAuthUsecase(repo *repository.Repository) *AuthUsecase {
+func NewAuthUsecase(repo *repository.Repository, hasher passhash.Hasher, signupBonus int, coinExpiry time.Duration, login LoginConfig) *AuthUsecase {
 	return &AuthUsecase{
-		repo: repo,
+		repo:        repo,
+		attempts:    repo,
+		hasher:      hasher,
+		signupBonus: signupBonus,
+		coinExpiry:  coinExpiry,
+		login:       login,
 	}
 }
 
 const (
-	salt       = "hjqrhjqw124617ajfhajs"
 	signingKey = "qrkjk#4#%35FSFJlja#4353KSFjH"
 	tokenTTL   = 12 * time.Hour
+	// twoFactorTokenTTL - время на ввод кода второго фактора после проверки пароля.
+	twoFactorTokenTTL = 5 * time.Minute
+	// twoFactorPurpose помечает промежуточный токен, который годится только для второго шага входа.
+	twoFactorPurpose = "2fa"
+	// reauthWindow - сколько после входа пользователь без пароля может подтверждать опасные действия токеном.
+	reauthWindow = 5 * time.Minute
 )
 
+// tokenClaims хранит версию токенов пользователя на момент выдачи: смена пароля или удаление
+// аккаунта увеличивает версию, и выданные ранее токены перестают приниматься.
 type tokenClaims struct {
 	jwt.StandardClaims
-	UserId int `json:"user_id"`
+	UserId       int    `json:"user_id"`
+	TokenVersion int    `json:"token_version"`
+	Purpose      string `json:"purpose,omitempty"`
 }
 
 func (s *AuthUsecase) CreateUser(user domain.User) (int, error) {
+	if err := validateUsername(user.UserName); err != nil {
+		return 0, err
+	}
 	var err error
-	user.Password, err = HashPassword(user.Password)
+	user.Password, err = s.hasher.Hash(user.Password)
 	if err != nil {
 		return 0, err
 	}
-	return s.repo.CreateUser(user)
+	bonus := s.signupBonus
+	user.Coins = &bonus
+	return s.repo.CreateUser(user, coinExpiryDate(time.Now(), s.coinExpiry))
 }
-func (s *AuthUsecase) SignUser(username, password string) (domain.User, error) {
+
+// SignUser проверяет пароль, если вход для имени пользователя и IP-адреса не ограничен.
+// Ограничение проверяется до поиска пользователя, чтобы не тратить хеширование на перебор.
+// Неизвестное имя считается неудачной попыткой так же, как неверный пароль: пароль сверяется
+// с фиксированным хешем, чтобы ни ответ, ни время ответа не выдавали, есть ли такой пользователь.
+// Хеш, созданный прежним алгоритмом или с прежними параметрами, после успешной проверки пароля пересчитывается.
+func (s *AuthUsecase) SignUser(username, password, clientIP string) (domain.User, error) {
+	now := time.Now()
+	if err := s.checkLoginThrottle(username, clientIP, now); err != nil {
+		return domain.User{}, err
+	}
 	user, err := s.repo.SignUser(username)
-	if err != nil {
+	found := err == nil
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
 		return domain.User{}, err
 	}
-	if !verifyPassword(user.Password, password) {
-		return domain.User{}, errors.New("неккоретные данные")
+	passwordHash := user.Password
+	if !found {
+		passwordHash = s.fixedHash()
+	}
+	if !s.hasher.Verify(passwordHash, password) || !found {
+		locked, err := s.recordLoginFailure(username, clientIP, now)
+		if err != nil {
+			return domain.User{}, err
+		}
+		if locked {
+			return domain.User{}, &domain.LoginThrottledError{Until: now.Add(s.login.Lockout), Locked: true, Started: true}
+		}
+		return domain.User{}, domain.ErrInvalidCredentials
+	}
+	s.rehashPassword(user, password)
+	if user.Banned {
+		return domain.User{}, domain.ErrUserBanned
+	}
+	// со вторым фактором вход завершается только после проверки кода, до этого счетчик неудач не сбрасывается
+	if user.TwoFactorEnabled {
+		return user, nil
+	}
+	if err := s.completeLogin(user.Id, username, now); err != nil {
+		return domain.User{}, err
 	}
 	return user, nil
 }
+
+// fixedHash один раз вычисляет хеш фиксированного пароля текущим алгоритмом и с текущими параметрами,
+// чтобы его проверка занимала столько же времени, сколько проверка пароля настоящего пользователя.
+func (s *AuthUsecase) fixedHash() string {
+	s.dummyHashOnce.Do(func() {
+		hash, err := s.hasher.Hash("coinshop-dummy-password")
+		if err != nil {
+			logger.Log.Error().Err(err).Msg("Не удалось вычислить фиксированный хеш пароля")
+			return
+		}
+		s.dummyHash = hash
+	})
+	return s.dummyHash
+}
+
+// rehashPassword заменяет устаревший хеш пароля. Ошибка не мешает входу, хеш будет
+// пересчитан при следующем входе.
+func (s *AuthUsecase) rehashPassword(user domain.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+	hash, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.repo.RehashPassword(user.Id, user.Password, hash)
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).Int("user_id", user.Id).Msg("Не удалось обновить хеш пароля")
+		return
+	}
+	logger.Log.Info().Int("user_id", user.Id).Msg("Хеш пароля обновлен")
+}
+
+func (s *AuthUsecase) completeLogin(userId int, username string, now time.Time) error {
+	if _, err := s.attempts.ResetLoginAttempts(domain.LoginScopeUsername, username); err != nil {
+		return err
+	}
+	return s.repo.UpdateLastLogin(userId, now)
+}
+
+// UnlockLogin снимает блокировку и сбрасывает неудачные попытки входа для имени пользователя и IP-адреса.
+// Возвращает число сброшенных записей.
+func (s *AuthUsecase) UnlockLogin(input domain.LoginUnlockInput) (int, error) {
+	unlocked := 0
+	for scope, value := range map[string]string{domain.LoginScopeUsername: input.Username, domain.LoginScopeIP: input.IP} {
+		if value == "" {
+			continue
+		}
+		count, err := s.attempts.ResetLoginAttempts(scope, value)
+		if err != nil {
+			return unlocked, err
+		}
+		unlocked += count
+	}
+	return unlocked, nil
+}
+
+// PurgeLoginAttempts удаляет устаревший учет попыток входа, у которого истекли и окно подсчета, и блокировка.
+func (s *AuthUsecase) PurgeLoginAttempts(now time.Time) (int, error) {
+	return s.attempts.PurgeLoginAttempts(now.Add(-s.login.Lockout))
+}
+
+// checkLoginThrottle возвращает LoginThrottledError с самым поздним временем, после которого вход
+// снова доступен: при действующей блокировке или если не прошла задержка после последней неудачи.
+func (s *AuthUsecase) checkLoginThrottle(username, clientIP string, now time.Time) error {
+	attempts, err := s.attempts.GetLoginAttempts(username, clientIP)
+	if err != nil {
+		return err
+	}
+	var throttled *domain.LoginThrottledError
+	for _, attempt := range attempts {
+		var until time.Time
+		locked := attempt.LockedUntil != nil && attempt.LockedUntil.After(now)
+		switch {
+		case locked:
+			until = *attempt.LockedUntil
+		case attempt.Failures > 0 && attempt.LastFailureAt.After(now.Add(-s.login.Lockout)):
+			until = attempt.LastFailureAt.Add(s.loginDelay(attempt.Failures))
+		}
+		if until.After(now) && (throttled == nil || until.After(throttled.Until)) {
+			throttled = &domain.LoginThrottledError{Until: until, Locked: locked}
+		}
+	}
+	if throttled != nil {
+		return throttled
+	}
+	return nil
+}
+
+// recordLoginFailure учитывает неудачу по имени пользователя и по IP-адресу и блокирует вход
+// на срок Lockout, когда число неудач достигает порога. Возвращает true, если на этой попытке вход заблокирован.
+func (s *AuthUsecase) recordLoginFailure(username, clientIP string, now time.Time) (bool, error) {
+	locked := false
+	limits := []struct {
+		scope, value string
+		max          int
+	}{
+		{domain.LoginScopeUsername, username, s.login.MaxFailures},
+		{domain.LoginScopeIP, clientIP, s.login.MaxFailuresPerIP},
+	}
+	for _, limit := range limits {
+		if limit.value == "" {
+			continue
+		}
+		failures, err := s.attempts.RecordLoginFailure(limit.scope, limit.value, now, now.Add(-s.login.Lockout))
+		if err != nil {
+			return false, err
+		}
+		if limit.max <= 0 || failures < limit.max {
+			continue
+		}
+		if err = s.attempts.LockLogin(limit.scope, limit.value, now.Add(s.login.Lockout)); err != nil {
+			return false, err
+		}
+		locked = true
+		loginLockouts.Add(limit.scope, 1)
+		logger.Log.Warn().Str("scope", limit.scope).Str("value", limit.value).Int("failures", failures).
+			Msg("Вход заблокирован после неудачных попыток")
+	}
+	return locked, nil
+}
+
+// loginDelay удваивает задержку после каждой неудачной попытки, но не дольше срока блокировки.
+func (s *AuthUsecase) loginDelay(failures int) time.Duration {
+	delay := s.login.Delay
+	for i := 1; i < failures && delay < s.login.Lockout; i++ {
+		delay *= 2
+	}
+	return min(delay, s.login.Lockout)
+}
 func (s *AuthUsecase) GenerateToken(userId int) (string, error) {
+	state, err := s.repo.GetTokenState(userId)
+	if err != nil {
+		return "", err
+	}
+	return generateToken(userId, state.Version)
+}
+
+func generateToken(userId, version int) (string, error) {
+	return signToken(userId, version, "", tokenTTL)
+}
+
+func signToken(userId, version int, purpose string, ttl time.Duration) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
+			ExpiresAt: time.Now().Add(ttl).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 		userId,
+		version,
+		purpose,
 	})
 	return token.SignedString([]byte(signingKey))
 }
 
-func (s *AuthUsecase) ParseToken(accessToken string) (int, error) {
+func parseToken(accessToken string) (*tokenClaims, error) {
 	token, err := jwt.ParseWithClaims(accessToken, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("некорретный signing method")
@@ -69,23 +279,103 @@ func (s *AuthUsecase) ParseToken(accessToken string) (int, error) {
 		return []byte(signingKey), nil
 	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(*tokenClaims)
 	if !ok {
-		return 0, errors.New("token claims не типа *tokenClaims")
+		return nil, errors.New("token claims не типа *tokenClaims")
 	}
+	return claims, nil
+}
 
-	return claims.UserId, nil
+func (s *AuthUsecase) ParseToken(accessToken string) (domain.Session, error) {
+	claims, err := parseToken(accessToken)
+	if err != nil {
+		return domain.Session{}, err
+	}
+	// промежуточный токен входа с двухфакторной аутентификацией не дает доступа к API
+	if claims.Purpose != "" {
+		return domain.Session{}, errors.New("токен не предназначен для доступа к API")
+	}
+	state, err := s.repo.GetTokenState(claims.UserId)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.Session{}, domain.ErrTokenRevoked
+		}
+		return domain.Session{}, err
+	}
+	if claims.TokenVersion != state.Version {
+		return domain.Session{}, domain.ErrTokenRevoked
+	}
+	if state.Banned {
+		return domain.Session{}, domain.ErrUserBanned
+	}
+	session := domain.Session{UserId: claims.UserId, IssuedAt: time.Unix(claims.IssuedAt, 0)}
+	// id возвращается вместе с ошибкой: со сброшенным паролем доступна только его смена
+	if state.PasswordResetRequired {
+		return session, domain.ErrPasswordResetRequired
+	}
+
+	return session, nil
+}
+
+// ChangePassword меняет пароль после проверки текущего и отзывает выданные токены.
+// Возвращает новый токен, чтобы пользователь остался авторизован.
+func (s *AuthUsecase) ChangePassword(userId int, input domain.PasswordChangeInput) (string, error) {
+	user, err := s.repo.GetUser(userId)
+	if err != nil {
+		return "", err
+	}
+	if !s.hasher.Verify(user.Password, input.OldPassword) {
+		return "", domain.ErrWrongPassword
+	}
+	hash, err := s.hasher.Hash(input.NewPassword)
+	if err != nil {
+		return "", err
+	}
+	version, err := s.repo.UpdatePassword(userId, hash)
+	if err != nil {
+		return "", err
+	}
+	return generateToken(userId, version)
+}
+
+func (s *AuthUsecase) ChangeUsername(userId int, input domain.UsernameChangeInput) error {
+	username := strings.TrimSpace(input.UserName)
+	if err := validateUsername(username); err != nil {
+		return err
+	}
+	return s.repo.UpdateUsername(userId, username)
 }
 
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+// DeleteAccount удаляет аккаунт после подтверждения паролем. Пользователь без пароля, входящий через
+// внешнего провайдера, подтверждает удаление свежим входом: токен должен быть выдан не раньше reauthWindow назад.
+// Пользователю с двухфакторной аутентификацией такой вход уже требовал кода.
+func (s *AuthUsecase) DeleteAccount(userId int, input domain.AccountDeleteInput) error {
+	user, err := s.repo.GetUser(userId)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" {
+		if time.Since(input.TokenIssuedAt) > reauthWindow {
+			return domain.ErrReauthRequired
+		}
+	} else if !s.hasher.Verify(user.Password, input.Password) {
+		return domain.ErrWrongPassword
+	}
+	return s.repo.DeleteUser(userId)
+}
+
+// validateUsername запрещает имена служебного счета казны, удаленных и сервисных аккаунтов.
+func validateUsername(username string) error {
+	if username == domain.TreasuryUsername || strings.HasPrefix(username, domain.DeletedUsernamePrefix) ||
+		strings.HasPrefix(username, domain.ServiceUsernamePrefix) {
+		return domain.ErrUsernameReserved
+	}
+	return nil
 }
 
-func verifyPassword(hashedPassword, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err == nil
+func (s *AuthUsecase) GetUserRole(userId int) (string, error) {
+	return s.repo.GetUserRole(userId)
 }
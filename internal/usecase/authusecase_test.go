@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	"github.com/bllooop/coinshop/pkg/passhash"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestHasher(t *testing.T) passhash.Hasher {
+	hasher, err := passhash.New(passhash.Config{Algorithm: passhash.AlgorithmBcrypt, BcryptCost: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hasher
+}
+
+func TestAuthUsecase_SignUser(t *testing.T) {
+	hasher := newTestHasher(t)
+	hash, err := hasher.Hash("password123")
+	assert.NoError(t, err)
+	login := LoginConfig{MaxFailures: 3, MaxFailuresPerIP: 10, Delay: time.Second, Lockout: 15 * time.Minute}
+
+	type mockBehavior func(r *mock_repository.MockAuthorization, a *mock_repository.MockLoginAttempts)
+
+	testTable := []struct {
+		name         string
+		password     string
+		mockBehavior mockBehavior
+		wantErr      error
+		wantLocked   bool
+	}{
+		{
+			name:     "OK",
+			password: "password123",
+			mockBehavior: func(r *mock_repository.MockAuthorization, a *mock_repository.MockLoginAttempts) {
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return(nil, nil)
+				r.EXPECT().SignUser("anna").Return(domain.User{Id: 1, UserName: "anna", Password: hash}, nil)
+				a.EXPECT().ResetLoginAttempts(domain.LoginScopeUsername, "anna").Return(1, nil)
+				r.EXPECT().UpdateLastLogin(1, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:     "Неверный пароль",
+			password: "wrong",
+			mockBehavior: func(r *mock_repository.MockAuthorization, a *mock_repository.MockLoginAttempts) {
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return(nil, nil)
+				r.EXPECT().SignUser("anna").Return(domain.User{Id: 1, UserName: "anna", Password: hash}, nil)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeUsername, "anna", gomock.Any(), gomock.Any()).Return(1, nil)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeIP, "10.0.0.1", gomock.Any(), gomock.Any()).Return(1, nil)
+			},
+			wantErr: domain.ErrInvalidCredentials,
+		},
+		{
+			// неизвестное имя учитывается как неудача, ответ не отличается от неверного пароля
+			name:     "Неизвестный пользователь",
+			password: "password123",
+			mockBehavior: func(r *mock_repository.MockAuthorization, a *mock_repository.MockLoginAttempts) {
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return(nil, nil)
+				r.EXPECT().SignUser("anna").Return(domain.User{}, domain.ErrUserNotFound)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeUsername, "anna", gomock.Any(), gomock.Any()).Return(1, nil)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeIP, "10.0.0.1", gomock.Any(), gomock.Any()).Return(1, nil)
+			},
+			wantErr: domain.ErrInvalidCredentials,
+		},
+		{
+			name:     "Неизвестный пользователь - блокировка",
+			password: "password123",
+			mockBehavior: func(r *mock_repository.MockAuthorization, a *mock_repository.MockLoginAttempts) {
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return(nil, nil)
+				r.EXPECT().SignUser("anna").Return(domain.User{}, domain.ErrUserNotFound)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeUsername, "anna", gomock.Any(), gomock.Any()).Return(3, nil)
+				a.EXPECT().LockLogin(domain.LoginScopeUsername, "anna", gomock.Any()).Return(nil)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeIP, "10.0.0.1", gomock.Any(), gomock.Any()).Return(3, nil)
+			},
+			wantErr:    domain.ErrLoginLocked,
+			wantLocked: true,
+		},
+		{
+			name:     "Ошибка базы данных",
+			password: "password123",
+			mockBehavior: func(r *mock_repository.MockAuthorization, a *mock_repository.MockLoginAttempts) {
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return(nil, nil)
+				r.EXPECT().SignUser("anna").Return(domain.User{}, errors.New("connection refused"))
+			},
+			wantErr: errors.New("connection refused"),
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockAuthorization(c)
+			attempts := mock_repository.NewMockLoginAttempts(c)
+			test.mockBehavior(repo, attempts)
+			s := &AuthUsecase{repo: repo, attempts: attempts, hasher: hasher, login: login}
+
+			user, err := s.SignUser("anna", test.password, "10.0.0.1")
+			switch {
+			case test.wantLocked:
+				var throttled *domain.LoginThrottledError
+				assert.ErrorAs(t, err, &throttled)
+				assert.True(t, throttled.Started)
+			case test.wantErr != nil:
+				assert.EqualError(t, err, test.wantErr.Error())
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, 1, user.Id)
+			}
+		})
+	}
+}
+
+func TestAuthUsecase_DeleteAccount(t *testing.T) {
+	hasher := newTestHasher(t)
+	hash, err := hasher.Hash("password123")
+	assert.NoError(t, err)
+
+	testTable := []struct {
+		name    string
+		user    domain.User
+		input   domain.AccountDeleteInput
+		deleted bool
+		wantErr error
+	}{
+		{
+			name:    "Пароль",
+			user:    domain.User{Id: 1, Password: hash},
+			input:   domain.AccountDeleteInput{Password: "password123"},
+			deleted: true,
+		},
+		{
+			name:    "Неверный пароль",
+			user:    domain.User{Id: 1, Password: hash},
+			input:   domain.AccountDeleteInput{Password: "wrong", TokenIssuedAt: time.Now()},
+			wantErr: domain.ErrWrongPassword,
+		},
+		{
+			// пользователь внешнего провайдера только что вошел заново
+			name:    "Без пароля - свежий вход",
+			user:    domain.User{Id: 1},
+			input:   domain.AccountDeleteInput{TokenIssuedAt: time.Now().Add(-time.Minute)},
+			deleted: true,
+		},
+		{
+			name:    "Без пароля - давний вход",
+			user:    domain.User{Id: 1},
+			input:   domain.AccountDeleteInput{TokenIssuedAt: time.Now().Add(-time.Hour)},
+			wantErr: domain.ErrReauthRequired,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockAuthorization(c)
+			repo.EXPECT().GetUser(1).Return(test.user, nil)
+			if test.deleted {
+				repo.EXPECT().DeleteUser(1).Return(nil)
+			}
+			s := &AuthUsecase{repo: repo, hasher: hasher}
+
+			err := s.DeleteAccount(1, test.input)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
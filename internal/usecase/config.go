@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/pkg/oidc"
+)
+
+type Config struct {
+	Refund    RefundConfig
+	Transfer  domain.TransferLimits
+	Allowance AllowanceConfig
+	Login     LoginConfig
+	TwoFactor TwoFactorConfig
+	ApiKeys   ApiKeyConfig
+	Oidc      OidcConfig
+	// SignupBonus - количество монет, которое казна начисляет новому пользователю.
+	SignupBonus int
+	// CoinExpiry - срок, через который сгорают непотраченные монеты, выданные казной; 0 - монеты не сгорают.
+	CoinExpiry time.Duration
+	// PaymentRequestTTL - срок, в течение которого запрос на перевод можно принять.
+	PaymentRequestTTL time.Duration
+}
+
+type RefundConfig struct {
+	// Window - срок с момента покупки, в течение которого пользователь может запросить возврат.
+	Window time.Duration
+}
+
+type AllowanceConfig struct {
+	// Amount - ежемесячное начисление активному пользователю, 0 отключает начисление.
+	Amount int
+	// ActiveWindow - пользователь считается активным, если входил в систему за этот период.
+	ActiveWindow time.Duration
+}
+
+type LoginConfig struct {
+	// MaxFailures - число неудачных попыток входа по имени пользователя до блокировки, 0 отключает блокировку.
+	MaxFailures int
+	// MaxFailuresPerIP - то же для IP-адреса клиента; порог выше, так как за одним адресом может быть много пользователей.
+	MaxFailuresPerIP int
+	// Delay - задержка после первой неудачной попытки, после каждой следующей она удваивается.
+	Delay time.Duration
+	// Lockout - срок блокировки и окно, в течение которого учитываются неудачные попытки.
+	Lockout time.Duration
+}
+
+type TwoFactorConfig struct {
+	// Issuer - название сервиса, под которым учетная запись отображается в приложении-аутентификаторе.
+	Issuer string
+	// RequiredRoles - роли, которым доступ к их маршрутам открывается только с включенной двухфакторной аутентификацией.
+	RequiredRoles []string
+}
+
+type ApiKeyConfig struct {
+	// DefaultTTL - срок действия ключа API, если при выпуске он не указан; при 0 срок обязателен.
+	DefaultTTL time.Duration
+	// MaxTTL - наибольший допустимый срок действия ключа, 0 снимает ограничение.
+	MaxTTL time.Duration
+}
+
+type OidcConfig struct {
+	// Provider - настройки клиента провайдера OpenID Connect, пустой Issuer отключает вход через провайдера.
+	Provider oidc.Config
+	// StateTTL - время, за которое пользователь должен вернуться от провайдера после начала входа.
+	StateTTL time.Duration
+}
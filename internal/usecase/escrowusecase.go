@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+)
+
+const (
+	minEscrowTimeout = time.Minute
+	maxEscrowTimeout = 90 * 24 * time.Hour
+)
+
+type EscrowUsecase struct {
+	repo   repository.Escrows
+	limits domain.TransferLimits
+}
+
+func NewEscrowUsecase(repo *repository.Repository, limits domain.TransferLimits) *EscrowUsecase {
+	return &EscrowUsecase{
+		repo:   repo,
+		limits: limits,
+	}
+}
+
+// CreateEscrow проверяет сумму и срок удержания и списывает монеты у отправителя.
+// Монеты остаются недоступны отправителю, пока удержание не будет завершено.
+func (s *EscrowUsecase) CreateEscrow(userId int, input domain.EscrowInput) (domain.Escrow, error) {
+	if input.Amount <= 0 {
+		return domain.Escrow{}, domain.ErrInvalidAmount
+	}
+	if s.limits.MaxAmount > 0 && input.Amount > s.limits.MaxAmount {
+		return domain.Escrow{}, domain.ErrTransferTooLarge
+	}
+	timeout, err := time.ParseDuration(input.Timeout)
+	if err != nil {
+		return domain.Escrow{}, fmt.Errorf("%w: некорректный срок удержания", domain.ErrInvalidEscrow)
+	}
+	if timeout < minEscrowTimeout || timeout > maxEscrowTimeout {
+		return domain.Escrow{}, fmt.Errorf("%w: срок удержания должен быть от %s до %s", domain.ErrInvalidEscrow, minEscrowTimeout, maxEscrowTimeout)
+	}
+	onTimeout := input.OnTimeout
+	if onTimeout == "" {
+		onTimeout = domain.EscrowActionReturn
+	}
+	escrow := domain.Escrow{
+		SenderId:          userId,
+		RecipientUsername: input.RecipientUsername,
+		Amount:            input.Amount,
+		Message:           sanitizeMessage(input.Message),
+		OnTimeout:         onTimeout,
+		CreatedAt:         time.Now(),
+	}
+	escrow.ExpiresAt = escrow.CreatedAt.Add(timeout)
+	if arbiter := strings.TrimSpace(input.ArbiterUsername); arbiter != "" {
+		escrow.ArbiterUsername = &arbiter
+	}
+	return s.repo.CreateEscrow(escrow, s.limits)
+}
+
+func (s *EscrowUsecase) GetEscrows(userId int, status string) ([]domain.Escrow, error) {
+	return s.repo.GetEscrows(userId, status)
+}
+
+// ReleaseEscrow передает монеты получателю. Выпустить их может отправитель или арбитр.
+func (s *EscrowUsecase) ReleaseEscrow(userId, escrowId int) (domain.Escrow, error) {
+	return s.settle(userId, escrowId, domain.EscrowActionRelease)
+}
+
+// ReturnEscrow возвращает монеты отправителю. Вернуть их может получатель или арбитр.
+func (s *EscrowUsecase) ReturnEscrow(userId, escrowId int) (domain.Escrow, error) {
+	return s.settle(userId, escrowId, domain.EscrowActionReturn)
+}
+
+// SettleExpiredEscrows завершает удержания с истекшим сроком действием, выбранным при создании,
+// и возвращает число завершенных. Удержания, завершенные участниками параллельно, пропускаются.
+// Если лимиты получателя не позволяют зачислить монеты, они возвращаются отправителю.
+func (s *EscrowUsecase) SettleExpiredEscrows(now time.Time) (int, error) {
+	escrows, err := s.repo.GetExpiredEscrows(now)
+	if err != nil {
+		return 0, err
+	}
+	settled := 0
+	for _, escrow := range escrows {
+		_, err := s.repo.SettleEscrow(escrow.Id, escrow.OnTimeout, nil, s.limits)
+		if errors.Is(err, domain.ErrBalanceCapExceeded) || errors.Is(err, domain.ErrDailyReceiveLimit) {
+			logger.Log.Warn().Err(err).Int("id", escrow.Id).Msg("Лимиты получателя не позволяют зачислить удержание, монеты возвращаются отправителю")
+			_, err = s.repo.SettleEscrow(escrow.Id, domain.EscrowActionReturn, nil, s.limits)
+		}
+		if err != nil {
+			if errors.Is(err, domain.ErrEscrowResolved) {
+				continue
+			}
+			logger.Log.Error().Err(err).Int("id", escrow.Id).Msg("Не удалось завершить перевод с удержанием по сроку")
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+// settle проверяет право пользователя на действие: отправитель может только выпустить монеты,
+// получатель - только вернуть, арбитр - и то и другое. Для посторонних удержание не найдено.
+func (s *EscrowUsecase) settle(userId, escrowId int, action string) (domain.Escrow, error) {
+	escrow, err := s.repo.GetEscrow(escrowId)
+	if err != nil {
+		return domain.Escrow{}, err
+	}
+	isArbiter := escrow.ArbiterId != nil && *escrow.ArbiterId == userId
+	if escrow.SenderId != userId && escrow.RecipientId != userId && !isArbiter {
+		return domain.Escrow{}, domain.ErrEscrowNotFound
+	}
+	if escrow.Status != domain.EscrowHeld {
+		return domain.Escrow{}, domain.ErrEscrowResolved
+	}
+	allowed := isArbiter ||
+		(action == domain.EscrowActionRelease && escrow.SenderId == userId) ||
+		(action == domain.EscrowActionReturn && escrow.RecipientId == userId)
+	if !allowed {
+		return domain.Escrow{}, domain.ErrEscrowActionForbidden
+	}
+	return s.repo.SettleEscrow(escrow.Id, action, &userId, s.limits)
+}
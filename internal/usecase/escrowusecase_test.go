@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEscrowUsecase_Settle(t *testing.T) {
+	limits := domain.TransferLimits{MaxAmount: 100}
+	arbiterId := 3
+	// отправитель 1, получатель 2, арбитр 3
+	held := domain.Escrow{Id: 7, SenderId: 1, RecipientId: 2, ArbiterId: &arbiterId, Amount: 30, Status: domain.EscrowHeld}
+	released := held
+	released.Status = domain.EscrowReleased
+
+	testTable := []struct {
+		name    string
+		escrow  domain.Escrow
+		userId  int
+		action  string
+		settled bool
+		wantErr error
+	}{
+		{
+			name:    "Отправитель выпускает",
+			escrow:  held,
+			userId:  1,
+			action:  domain.EscrowActionRelease,
+			settled: true,
+		},
+		{
+			name:    "Получатель возвращает",
+			escrow:  held,
+			userId:  2,
+			action:  domain.EscrowActionReturn,
+			settled: true,
+		},
+		{
+			name:    "Арбитр выпускает",
+			escrow:  held,
+			userId:  3,
+			action:  domain.EscrowActionRelease,
+			settled: true,
+		},
+		{
+			name:    "Арбитр возвращает",
+			escrow:  held,
+			userId:  3,
+			action:  domain.EscrowActionReturn,
+			settled: true,
+		},
+		{
+			name:    "Отправитель возвращает",
+			escrow:  held,
+			userId:  1,
+			action:  domain.EscrowActionReturn,
+			wantErr: domain.ErrEscrowActionForbidden,
+		},
+		{
+			name:    "Получатель выпускает",
+			escrow:  held,
+			userId:  2,
+			action:  domain.EscrowActionRelease,
+			wantErr: domain.ErrEscrowActionForbidden,
+		},
+		{
+			// для постороннего удержание не существует
+			name:    "Посторонний",
+			escrow:  held,
+			userId:  4,
+			action:  domain.EscrowActionRelease,
+			wantErr: domain.ErrEscrowNotFound,
+		},
+		{
+			name:    "Удержание уже завершено",
+			escrow:  released,
+			userId:  3,
+			action:  domain.EscrowActionReturn,
+			wantErr: domain.ErrEscrowResolved,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockEscrows(c)
+			repo.EXPECT().GetEscrow(7).Return(test.escrow, nil)
+			if test.settled {
+				repo.EXPECT().SettleEscrow(7, test.action, &test.userId, limits).Return(domain.Escrow{Id: 7}, nil)
+			}
+			s := &EscrowUsecase{repo: repo, limits: limits}
+
+			var err error
+			if test.action == domain.EscrowActionRelease {
+				_, err = s.ReleaseEscrow(test.userId, 7)
+			} else {
+				_, err = s.ReturnEscrow(test.userId, 7)
+			}
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEscrowUsecase_CreateEscrow(t *testing.T) {
+	limits := domain.TransferLimits{MaxAmount: 100}
+
+	testTable := []struct {
+		name    string
+		input   domain.EscrowInput
+		wantErr error
+	}{
+		{
+			name:  "OK",
+			input: domain.EscrowInput{RecipientUsername: "bob", Amount: 30, Timeout: "72h"},
+		},
+		{
+			name:    "Сумма выше лимита",
+			input:   domain.EscrowInput{RecipientUsername: "bob", Amount: 150, Timeout: "72h"},
+			wantErr: domain.ErrTransferTooLarge,
+		},
+		{
+			name:    "Слишком короткий срок",
+			input:   domain.EscrowInput{RecipientUsername: "bob", Amount: 30, Timeout: "10s"},
+			wantErr: domain.ErrInvalidEscrow,
+		},
+		{
+			name:    "Слишком долгий срок",
+			input:   domain.EscrowInput{RecipientUsername: "bob", Amount: 30, Timeout: "2400h"},
+			wantErr: domain.ErrInvalidEscrow,
+		},
+		{
+			name:    "Некорректный срок",
+			input:   domain.EscrowInput{RecipientUsername: "bob", Amount: 30, Timeout: "три дня"},
+			wantErr: domain.ErrInvalidEscrow,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockEscrows(c)
+			if test.wantErr == nil {
+				// по умолчанию по истечении срока монеты возвращаются отправителю
+				repo.EXPECT().CreateEscrow(gomock.Cond(func(e domain.Escrow) bool {
+					return e.SenderId == 1 && e.OnTimeout == domain.EscrowActionReturn && e.ExpiresAt.Sub(e.CreatedAt) == 72*time.Hour
+				}), limits).Return(domain.Escrow{Id: 7}, nil)
+			}
+			s := &EscrowUsecase{repo: repo, limits: limits}
+
+			_, err := s.CreateEscrow(1, test.input)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEscrowUsecase_SettleExpiredEscrows(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	now := time.Now()
+	repo := mock_repository.NewMockEscrows(c)
+	repo.EXPECT().GetExpiredEscrows(now).Return([]domain.Escrow{
+		{Id: 1, OnTimeout: domain.EscrowActionRelease},
+		{Id: 2, OnTimeout: domain.EscrowActionRelease},
+		{Id: 3, OnTimeout: domain.EscrowActionReturn},
+		{Id: 4, OnTimeout: domain.EscrowActionReturn},
+	}, nil)
+	repo.EXPECT().SettleEscrow(1, domain.EscrowActionRelease, nil, gomock.Any()).Return(domain.Escrow{Id: 1}, nil)
+	// получатель упирается в потолок баланса, монеты возвращаются отправителю
+	repo.EXPECT().SettleEscrow(2, domain.EscrowActionRelease, nil, gomock.Any()).Return(domain.Escrow{}, domain.ErrBalanceCapExceeded)
+	repo.EXPECT().SettleEscrow(2, domain.EscrowActionReturn, nil, gomock.Any()).Return(domain.Escrow{Id: 2}, nil)
+	// участник завершил удержание параллельно
+	repo.EXPECT().SettleEscrow(3, domain.EscrowActionReturn, nil, gomock.Any()).Return(domain.Escrow{}, domain.ErrEscrowResolved)
+	repo.EXPECT().SettleEscrow(4, domain.EscrowActionReturn, nil, gomock.Any()).Return(domain.Escrow{}, errors.New("connection refused"))
+	s := &EscrowUsecase{repo: repo}
+
+	settled, err := s.SettleExpiredEscrows(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, settled)
+}
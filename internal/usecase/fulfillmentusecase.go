@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+)
+
+// purchaseTransitions описывает допустимые переходы статуса покупки,
+// статусы shipped и cancelled конечные.
+var purchaseTransitions = map[string][]string{
+	domain.PurchasePending: {domain.PurchasePacked, domain.PurchaseCancelled},
+	domain.PurchasePacked:  {domain.PurchaseShipped, domain.PurchaseCancelled},
+}
+
+func canTransition(transitions map[string][]string, from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+type FulfillmentUsecase struct {
+	repo    repository.Fulfillment
+	refunds repository.Refunds
+}
+
+func NewFulfillmentUsecase(repo *repository.Repository) *FulfillmentUsecase {
+	return &FulfillmentUsecase{
+		repo:    repo,
+		refunds: repo,
+	}
+}
+
+func (s *FulfillmentUsecase) GetPurchasesByStatus(status string) ([]domain.Purchase, error) {
+	return s.repo.GetPurchasesByStatus(status)
+}
+
+// UpdatePurchaseStatus проверяет переход по графу статусов. Отмена выполняется
+// через принудительный возврат, чтобы пользователь получил монеты обратно.
+func (s *FulfillmentUsecase) UpdatePurchaseStatus(adminId, purchaseId int, input domain.PurchaseStatusInput) (domain.Purchase, error) {
+	purchase, err := s.refunds.GetPurchase(purchaseId)
+	if err != nil {
+		return domain.Purchase{}, err
+	}
+	if !canTransition(purchaseTransitions, purchase.Status, input.Status) {
+		return domain.Purchase{}, domain.ErrInvalidStatusTransition
+	}
+	if input.Status == domain.PurchaseCancelled {
+		if _, err = s.refunds.ForceRefund(purchaseId, adminId, input.Reason); err != nil {
+			return domain.Purchase{}, err
+		}
+		return s.refunds.GetPurchase(purchaseId)
+	}
+	return s.repo.UpdatePurchaseStatus(purchaseId, purchase.Status, input.Status)
+}
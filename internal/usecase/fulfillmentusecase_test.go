@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFulfillmentUsecase_UpdatePurchaseStatus(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockFulfillment, refunds *mock_repository.MockRefunds)
+
+	testTable := []struct {
+		name         string
+		from         string
+		to           string
+		mockBehavior mockBehavior
+		wantErr      error
+	}{
+		{
+			name: "Упаковка",
+			from: domain.PurchasePending,
+			to:   domain.PurchasePacked,
+			mockBehavior: func(r *mock_repository.MockFulfillment, refunds *mock_repository.MockRefunds) {
+				r.EXPECT().UpdatePurchaseStatus(5, domain.PurchasePending, domain.PurchasePacked).
+					Return(domain.Purchase{Id: 5, Status: domain.PurchasePacked}, nil)
+			},
+		},
+		{
+			name: "Отправка",
+			from: domain.PurchasePacked,
+			to:   domain.PurchaseShipped,
+			mockBehavior: func(r *mock_repository.MockFulfillment, refunds *mock_repository.MockRefunds) {
+				r.EXPECT().UpdatePurchaseStatus(5, domain.PurchasePacked, domain.PurchaseShipped).
+					Return(domain.Purchase{Id: 5, Status: domain.PurchaseShipped}, nil)
+			},
+		},
+		{
+			// отмена возвращает монеты через принудительный возврат, а не простой сменой статуса
+			name: "Отмена",
+			from: domain.PurchasePacked,
+			to:   domain.PurchaseCancelled,
+			mockBehavior: func(r *mock_repository.MockFulfillment, refunds *mock_repository.MockRefunds) {
+				refunds.EXPECT().ForceRefund(5, 1, "нет на складе").Return(domain.Refund{}, nil)
+				refunds.EXPECT().GetPurchase(5).Return(domain.Purchase{Id: 5, Status: domain.PurchaseCancelled}, nil)
+			},
+		},
+		{
+			name:    "Отправка без упаковки",
+			from:    domain.PurchasePending,
+			to:      domain.PurchaseShipped,
+			wantErr: domain.ErrInvalidStatusTransition,
+		},
+		{
+			name:    "Отмена отправленного",
+			from:    domain.PurchaseShipped,
+			to:      domain.PurchaseCancelled,
+			wantErr: domain.ErrInvalidStatusTransition,
+		},
+		{
+			name:    "Возврат из отмены",
+			from:    domain.PurchaseCancelled,
+			to:      domain.PurchasePending,
+			wantErr: domain.ErrInvalidStatusTransition,
+		},
+		{
+			name:    "Тот же статус",
+			from:    domain.PurchasePacked,
+			to:      domain.PurchasePacked,
+			wantErr: domain.ErrInvalidStatusTransition,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockFulfillment(c)
+			refunds := mock_repository.NewMockRefunds(c)
+			refunds.EXPECT().GetPurchase(5).Return(domain.Purchase{Id: 5, Status: test.from}, nil)
+			if test.mockBehavior != nil {
+				test.mockBehavior(repo, refunds)
+			}
+			s := &FulfillmentUsecase{repo: repo, refunds: refunds}
+
+			purchase, err := s.UpdatePurchaseStatus(1, 5, domain.PurchaseStatusInput{Status: test.to, Reason: "нет на складе"})
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.to, purchase.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+)
+
+type InventoryUsecase struct {
+	repo repository.Inventory
+}
+
+func NewInventoryUsecase(repo *repository.Repository) *InventoryUsecase {
+	return &InventoryUsecase{
+		repo: repo,
+	}
+}
+
+func (s *InventoryUsecase) GetItems() ([]domain.Merch, error) {
+	items, err := s.repo.GetItems()
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []domain.Merch{}
+	}
+	return items, nil
+}
+
+func (s *InventoryUsecase) Restock(name string, quantity int) (domain.ItemStockChange, error) {
+	return s.repo.Restock(name, quantity)
+}
+
+func (s *InventoryUsecase) UpdateItemStock(name string, input domain.ItemStockInput) (domain.ItemStockChange, error) {
+	return s.repo.UpdateItemStock(name, input)
+}
+
+func (s *InventoryUsecase) CreateVariant(name string, input domain.VariantInput) (domain.ItemVariant, error) {
+	if input.IsEmpty() {
+		return domain.ItemVariant{}, domain.ErrEmptyVariant
+	}
+	return s.repo.CreateVariant(name, input)
+}
+
+func (s *InventoryUsecase) UpdateVariantStock(name string, variantId int, input domain.VariantStockInput) (domain.VariantStockChange, error) {
+	return s.repo.UpdateVariantStock(name, variantId, input.Stock)
+}
@@ -11,6 +11,7 @@ package mock_usecase
 
 import (
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/bllooop/coinshop/internal/domain"
 	gomock "go.uber.org/mock/gomock"
@@ -40,6 +41,35 @@ func (m *MockAuthorization) EXPECT() *MockAuthorizationMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockAuthorization) ChangePassword(userId int, input domain.PasswordChangeInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", userId, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthorizationMockRecorder) ChangePassword(userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthorization)(nil).ChangePassword), userId, input)
+}
+
+// ChangeUsername mocks base method.
+func (m *MockAuthorization) ChangeUsername(userId int, input domain.UsernameChangeInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeUsername", userId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeUsername indicates an expected call of ChangeUsername.
+func (mr *MockAuthorizationMockRecorder) ChangeUsername(userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUsername", reflect.TypeOf((*MockAuthorization)(nil).ChangeUsername), userId, input)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuthorization) CreateUser(user domain.User) (int, error) {
 	m.ctrl.T.Helper()
@@ -55,6 +85,20 @@ func (mr *MockAuthorizationMockRecorder) CreateUser(user any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user)
 }
 
+// DeleteAccount mocks base method.
+func (m *MockAuthorization) DeleteAccount(userId int, input domain.AccountDeleteInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", userId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockAuthorizationMockRecorder) DeleteAccount(userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAuthorization)(nil).DeleteAccount), userId, input)
+}
+
 // GenerateToken mocks base method.
 func (m *MockAuthorization) GenerateToken(userId int) (string, error) {
 	m.ctrl.T.Helper()
@@ -70,11 +114,26 @@ func (mr *MockAuthorizationMockRecorder) GenerateToken(userId any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), userId)
 }
 
+// GetUserRole mocks base method.
+func (m *MockAuthorization) GetUserRole(userId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRole", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRole indicates an expected call of GetUserRole.
+func (mr *MockAuthorizationMockRecorder) GetUserRole(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRole", reflect.TypeOf((*MockAuthorization)(nil).GetUserRole), userId)
+}
+
 // ParseToken mocks base method.
-func (m *MockAuthorization) ParseToken(accessToken string) (int, error) {
+func (m *MockAuthorization) ParseToken(accessToken string) (domain.Session, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ParseToken", accessToken)
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(domain.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -85,19 +144,49 @@ func (mr *MockAuthorizationMockRecorder) ParseToken(accessToken any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockAuthorization)(nil).ParseToken), accessToken)
 }
 
+// PurgeLoginAttempts mocks base method.
+func (m *MockAuthorization) PurgeLoginAttempts(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeLoginAttempts", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeLoginAttempts indicates an expected call of PurgeLoginAttempts.
+func (mr *MockAuthorizationMockRecorder) PurgeLoginAttempts(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeLoginAttempts", reflect.TypeOf((*MockAuthorization)(nil).PurgeLoginAttempts), now)
+}
+
 // SignUser mocks base method.
-func (m *MockAuthorization) SignUser(username, password string) (domain.User, error) {
+func (m *MockAuthorization) SignUser(username, password, clientIP string) (domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignUser", username, password)
+	ret := m.ctrl.Call(m, "SignUser", username, password, clientIP)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SignUser indicates an expected call of SignUser.
-func (mr *MockAuthorizationMockRecorder) SignUser(username, password any) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) SignUser(username, password, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUser", reflect.TypeOf((*MockAuthorization)(nil).SignUser), username, password, clientIP)
+}
+
+// UnlockLogin mocks base method.
+func (m *MockAuthorization) UnlockLogin(input domain.LoginUnlockInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockLogin", input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockLogin indicates an expected call of UnlockLogin.
+func (mr *MockAuthorizationMockRecorder) UnlockLogin(input any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUser", reflect.TypeOf((*MockAuthorization)(nil).SignUser), username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockLogin", reflect.TypeOf((*MockAuthorization)(nil).UnlockLogin), input)
 }
 
 // MockShop is a mock of Shop interface.
@@ -125,18 +214,63 @@ func (m *MockShop) EXPECT() *MockShopMockRecorder {
 }
 
 // BuyItem mocks base method.
-func (m *MockShop) BuyItem(userid int, name string) (int, error) {
+func (m *MockShop) BuyItem(userid int, name string, options domain.BuyOptions) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BuyItem", userid, name)
+	ret := m.ctrl.Call(m, "BuyItem", userid, name, options)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // BuyItem indicates an expected call of BuyItem.
-func (mr *MockShopMockRecorder) BuyItem(userid, name any) *gomock.Call {
+func (mr *MockShopMockRecorder) BuyItem(userid, name, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockShop)(nil).BuyItem), userid, name, options)
+}
+
+// CreateOrder mocks base method.
+func (m *MockShop) CreateOrder(userid int, input domain.OrderInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", userid, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrder indicates an expected call of CreateOrder.
+func (mr *MockShopMockRecorder) CreateOrder(userid, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockShop)(nil).CreateOrder), userid, input)
+}
+
+// GetPurchases mocks base method.
+func (m *MockShop) GetPurchases(userID int, filter domain.PurchaseFilter) (*domain.PurchaseHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchases", userID, filter)
+	ret0, _ := ret[0].(*domain.PurchaseHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchases indicates an expected call of GetPurchases.
+func (mr *MockShopMockRecorder) GetPurchases(userID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchases", reflect.TypeOf((*MockShop)(nil).GetPurchases), userID, filter)
+}
+
+// GetTransactions mocks base method.
+func (m *MockShop) GetTransactions(userID int, filter domain.TransactionFilter) (*domain.TransactionHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransactions", userID, filter)
+	ret0, _ := ret[0].(*domain.TransactionHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransactions indicates an expected call of GetTransactions.
+func (mr *MockShopMockRecorder) GetTransactions(userID, filter any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockShop)(nil).BuyItem), userid, name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactions", reflect.TypeOf((*MockShop)(nil).GetTransactions), userID, filter)
 }
 
 // GetUserSummary mocks base method.
@@ -168,3 +302,1226 @@ func (mr *MockShopMockRecorder) SendCoin(userid, input any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCoin", reflect.TypeOf((*MockShop)(nil).SendCoin), userid, input)
 }
+
+// SendCoinBatch mocks base method.
+func (m *MockShop) SendCoinBatch(userid int, input domain.BatchTransferInput) (domain.BatchTransferResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendCoinBatch", userid, input)
+	ret0, _ := ret[0].(domain.BatchTransferResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendCoinBatch indicates an expected call of SendCoinBatch.
+func (mr *MockShopMockRecorder) SendCoinBatch(userid, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCoinBatch", reflect.TypeOf((*MockShop)(nil).SendCoinBatch), userid, input)
+}
+
+// MockInventory is a mock of Inventory interface.
+type MockInventory struct {
+	ctrl     *gomock.Controller
+	recorder *MockInventoryMockRecorder
+	isgomock struct{}
+}
+
+// MockInventoryMockRecorder is the mock recorder for MockInventory.
+type MockInventoryMockRecorder struct {
+	mock *MockInventory
+}
+
+// NewMockInventory creates a new mock instance.
+func NewMockInventory(ctrl *gomock.Controller) *MockInventory {
+	mock := &MockInventory{ctrl: ctrl}
+	mock.recorder = &MockInventoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInventory) EXPECT() *MockInventoryMockRecorder {
+	return m.recorder
+}
+
+// CreateVariant mocks base method.
+func (m *MockInventory) CreateVariant(name string, input domain.VariantInput) (domain.ItemVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateVariant", name, input)
+	ret0, _ := ret[0].(domain.ItemVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateVariant indicates an expected call of CreateVariant.
+func (mr *MockInventoryMockRecorder) CreateVariant(name, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateVariant", reflect.TypeOf((*MockInventory)(nil).CreateVariant), name, input)
+}
+
+// GetItems mocks base method.
+func (m *MockInventory) GetItems() ([]domain.Merch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItems")
+	ret0, _ := ret[0].([]domain.Merch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItems indicates an expected call of GetItems.
+func (mr *MockInventoryMockRecorder) GetItems() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItems", reflect.TypeOf((*MockInventory)(nil).GetItems))
+}
+
+// Restock mocks base method.
+func (m *MockInventory) Restock(name string, quantity int) (domain.ItemStockChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restock", name, quantity)
+	ret0, _ := ret[0].(domain.ItemStockChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restock indicates an expected call of Restock.
+func (mr *MockInventoryMockRecorder) Restock(name, quantity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restock", reflect.TypeOf((*MockInventory)(nil).Restock), name, quantity)
+}
+
+// UpdateItemStock mocks base method.
+func (m *MockInventory) UpdateItemStock(name string, input domain.ItemStockInput) (domain.ItemStockChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateItemStock", name, input)
+	ret0, _ := ret[0].(domain.ItemStockChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateItemStock indicates an expected call of UpdateItemStock.
+func (mr *MockInventoryMockRecorder) UpdateItemStock(name, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItemStock", reflect.TypeOf((*MockInventory)(nil).UpdateItemStock), name, input)
+}
+
+// UpdateVariantStock mocks base method.
+func (m *MockInventory) UpdateVariantStock(name string, variantId int, input domain.VariantStockInput) (domain.VariantStockChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVariantStock", name, variantId, input)
+	ret0, _ := ret[0].(domain.VariantStockChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateVariantStock indicates an expected call of UpdateVariantStock.
+func (mr *MockInventoryMockRecorder) UpdateVariantStock(name, variantId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVariantStock", reflect.TypeOf((*MockInventory)(nil).UpdateVariantStock), name, variantId, input)
+}
+
+// MockRefunds is a mock of Refunds interface.
+type MockRefunds struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefundsMockRecorder
+	isgomock struct{}
+}
+
+// MockRefundsMockRecorder is the mock recorder for MockRefunds.
+type MockRefundsMockRecorder struct {
+	mock *MockRefunds
+}
+
+// NewMockRefunds creates a new mock instance.
+func NewMockRefunds(ctrl *gomock.Controller) *MockRefunds {
+	mock := &MockRefunds{ctrl: ctrl}
+	mock.recorder = &MockRefundsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefunds) EXPECT() *MockRefundsMockRecorder {
+	return m.recorder
+}
+
+// ApproveRefund mocks base method.
+func (m *MockRefunds) ApproveRefund(adminId, refundId int) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveRefund", adminId, refundId)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveRefund indicates an expected call of ApproveRefund.
+func (mr *MockRefundsMockRecorder) ApproveRefund(adminId, refundId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveRefund", reflect.TypeOf((*MockRefunds)(nil).ApproveRefund), adminId, refundId)
+}
+
+// ForceRefund mocks base method.
+func (m *MockRefunds) ForceRefund(adminId, purchaseId int, reason string) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceRefund", adminId, purchaseId, reason)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceRefund indicates an expected call of ForceRefund.
+func (mr *MockRefundsMockRecorder) ForceRefund(adminId, purchaseId, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceRefund", reflect.TypeOf((*MockRefunds)(nil).ForceRefund), adminId, purchaseId, reason)
+}
+
+// GetRefunds mocks base method.
+func (m *MockRefunds) GetRefunds(status string) ([]domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefunds", status)
+	ret0, _ := ret[0].([]domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefunds indicates an expected call of GetRefunds.
+func (mr *MockRefundsMockRecorder) GetRefunds(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefunds", reflect.TypeOf((*MockRefunds)(nil).GetRefunds), status)
+}
+
+// RejectRefund mocks base method.
+func (m *MockRefunds) RejectRefund(adminId, refundId int) (domain.Refund, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectRefund", adminId, refundId)
+	ret0, _ := ret[0].(domain.Refund)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectRefund indicates an expected call of RejectRefund.
+func (mr *MockRefundsMockRecorder) RejectRefund(adminId, refundId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectRefund", reflect.TypeOf((*MockRefunds)(nil).RejectRefund), adminId, refundId)
+}
+
+// RequestRefund mocks base method.
+func (m *MockRefunds) RequestRefund(userId, purchaseId int, reason string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestRefund", userId, purchaseId, reason)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestRefund indicates an expected call of RequestRefund.
+func (mr *MockRefundsMockRecorder) RequestRefund(userId, purchaseId, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestRefund", reflect.TypeOf((*MockRefunds)(nil).RequestRefund), userId, purchaseId, reason)
+}
+
+// MockFulfillment is a mock of Fulfillment interface.
+type MockFulfillment struct {
+	ctrl     *gomock.Controller
+	recorder *MockFulfillmentMockRecorder
+	isgomock struct{}
+}
+
+// MockFulfillmentMockRecorder is the mock recorder for MockFulfillment.
+type MockFulfillmentMockRecorder struct {
+	mock *MockFulfillment
+}
+
+// NewMockFulfillment creates a new mock instance.
+func NewMockFulfillment(ctrl *gomock.Controller) *MockFulfillment {
+	mock := &MockFulfillment{ctrl: ctrl}
+	mock.recorder = &MockFulfillmentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFulfillment) EXPECT() *MockFulfillmentMockRecorder {
+	return m.recorder
+}
+
+// GetPurchasesByStatus mocks base method.
+func (m *MockFulfillment) GetPurchasesByStatus(status string) ([]domain.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPurchasesByStatus", status)
+	ret0, _ := ret[0].([]domain.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPurchasesByStatus indicates an expected call of GetPurchasesByStatus.
+func (mr *MockFulfillmentMockRecorder) GetPurchasesByStatus(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPurchasesByStatus", reflect.TypeOf((*MockFulfillment)(nil).GetPurchasesByStatus), status)
+}
+
+// UpdatePurchaseStatus mocks base method.
+func (m *MockFulfillment) UpdatePurchaseStatus(adminId, purchaseId int, input domain.PurchaseStatusInput) (domain.Purchase, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePurchaseStatus", adminId, purchaseId, input)
+	ret0, _ := ret[0].(domain.Purchase)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePurchaseStatus indicates an expected call of UpdatePurchaseStatus.
+func (mr *MockFulfillmentMockRecorder) UpdatePurchaseStatus(adminId, purchaseId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePurchaseStatus", reflect.TypeOf((*MockFulfillment)(nil).UpdatePurchaseStatus), adminId, purchaseId, input)
+}
+
+// MockPromotions is a mock of Promotions interface.
+type MockPromotions struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromotionsMockRecorder
+	isgomock struct{}
+}
+
+// MockPromotionsMockRecorder is the mock recorder for MockPromotions.
+type MockPromotionsMockRecorder struct {
+	mock *MockPromotions
+}
+
+// NewMockPromotions creates a new mock instance.
+func NewMockPromotions(ctrl *gomock.Controller) *MockPromotions {
+	mock := &MockPromotions{ctrl: ctrl}
+	mock.recorder = &MockPromotionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromotions) EXPECT() *MockPromotionsMockRecorder {
+	return m.recorder
+}
+
+// CreatePromotion mocks base method.
+func (m *MockPromotions) CreatePromotion(input domain.PromotionInput) (domain.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePromotion", input)
+	ret0, _ := ret[0].(domain.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePromotion indicates an expected call of CreatePromotion.
+func (mr *MockPromotionsMockRecorder) CreatePromotion(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePromotion", reflect.TypeOf((*MockPromotions)(nil).CreatePromotion), input)
+}
+
+// EndPromotion mocks base method.
+func (m *MockPromotions) EndPromotion(id int) (domain.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndPromotion", id)
+	ret0, _ := ret[0].(domain.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndPromotion indicates an expected call of EndPromotion.
+func (mr *MockPromotionsMockRecorder) EndPromotion(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndPromotion", reflect.TypeOf((*MockPromotions)(nil).EndPromotion), id)
+}
+
+// GetPromotions mocks base method.
+func (m *MockPromotions) GetPromotions() ([]domain.Promotion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromotions")
+	ret0, _ := ret[0].([]domain.Promotion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromotions indicates an expected call of GetPromotions.
+func (mr *MockPromotionsMockRecorder) GetPromotions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromotions", reflect.TypeOf((*MockPromotions)(nil).GetPromotions))
+}
+
+// MockSchedules is a mock of Schedules interface.
+type MockSchedules struct {
+	ctrl     *gomock.Controller
+	recorder *MockSchedulesMockRecorder
+	isgomock struct{}
+}
+
+// MockSchedulesMockRecorder is the mock recorder for MockSchedules.
+type MockSchedulesMockRecorder struct {
+	mock *MockSchedules
+}
+
+// NewMockSchedules creates a new mock instance.
+func NewMockSchedules(ctrl *gomock.Controller) *MockSchedules {
+	mock := &MockSchedules{ctrl: ctrl}
+	mock.recorder = &MockSchedulesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSchedules) EXPECT() *MockSchedulesMockRecorder {
+	return m.recorder
+}
+
+// CancelSchedule mocks base method.
+func (m *MockSchedules) CancelSchedule(userId, scheduleId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSchedule", userId, scheduleId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelSchedule indicates an expected call of CancelSchedule.
+func (mr *MockSchedulesMockRecorder) CancelSchedule(userId, scheduleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSchedule", reflect.TypeOf((*MockSchedules)(nil).CancelSchedule), userId, scheduleId)
+}
+
+// CreateSchedule mocks base method.
+func (m *MockSchedules) CreateSchedule(userId int, input domain.ScheduleInput) (domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", userId, input)
+	ret0, _ := ret[0].(domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule.
+func (mr *MockSchedulesMockRecorder) CreateSchedule(userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockSchedules)(nil).CreateSchedule), userId, input)
+}
+
+// GetScheduleRuns mocks base method.
+func (m *MockSchedules) GetScheduleRuns(userId, scheduleId int) ([]domain.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduleRuns", userId, scheduleId)
+	ret0, _ := ret[0].([]domain.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduleRuns indicates an expected call of GetScheduleRuns.
+func (mr *MockSchedulesMockRecorder) GetScheduleRuns(userId, scheduleId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduleRuns", reflect.TypeOf((*MockSchedules)(nil).GetScheduleRuns), userId, scheduleId)
+}
+
+// GetSchedules mocks base method.
+func (m *MockSchedules) GetSchedules(userId int) ([]domain.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules", userId)
+	ret0, _ := ret[0].([]domain.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockSchedulesMockRecorder) GetSchedules(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockSchedules)(nil).GetSchedules), userId)
+}
+
+// RunDueSchedules mocks base method.
+func (m *MockSchedules) RunDueSchedules(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDueSchedules", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunDueSchedules indicates an expected call of RunDueSchedules.
+func (mr *MockSchedulesMockRecorder) RunDueSchedules(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDueSchedules", reflect.TypeOf((*MockSchedules)(nil).RunDueSchedules), now)
+}
+
+// MockTreasury is a mock of Treasury interface.
+type MockTreasury struct {
+	ctrl     *gomock.Controller
+	recorder *MockTreasuryMockRecorder
+	isgomock struct{}
+}
+
+// MockTreasuryMockRecorder is the mock recorder for MockTreasury.
+type MockTreasuryMockRecorder struct {
+	mock *MockTreasury
+}
+
+// NewMockTreasury creates a new mock instance.
+func NewMockTreasury(ctrl *gomock.Controller) *MockTreasury {
+	mock := &MockTreasury{ctrl: ctrl}
+	mock.recorder = &MockTreasuryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTreasury) EXPECT() *MockTreasuryMockRecorder {
+	return m.recorder
+}
+
+// ClawbackCoins mocks base method.
+func (m *MockTreasury) ClawbackCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClawbackCoins", adminId, input)
+	ret0, _ := ret[0].(domain.CoinAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClawbackCoins indicates an expected call of ClawbackCoins.
+func (mr *MockTreasuryMockRecorder) ClawbackCoins(adminId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClawbackCoins", reflect.TypeOf((*MockTreasury)(nil).ClawbackCoins), adminId, input)
+}
+
+// ExpireCoins mocks base method.
+func (m *MockTreasury) ExpireCoins(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireCoins", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireCoins indicates an expected call of ExpireCoins.
+func (mr *MockTreasuryMockRecorder) ExpireCoins(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireCoins", reflect.TypeOf((*MockTreasury)(nil).ExpireCoins), now)
+}
+
+// GrantCoins mocks base method.
+func (m *MockTreasury) GrantCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantCoins", adminId, input)
+	ret0, _ := ret[0].(domain.CoinAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantCoins indicates an expected call of GrantCoins.
+func (mr *MockTreasuryMockRecorder) GrantCoins(adminId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantCoins", reflect.TypeOf((*MockTreasury)(nil).GrantCoins), adminId, input)
+}
+
+// PayAllowance mocks base method.
+func (m *MockTreasury) PayAllowance(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PayAllowance", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PayAllowance indicates an expected call of PayAllowance.
+func (mr *MockTreasuryMockRecorder) PayAllowance(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PayAllowance", reflect.TypeOf((*MockTreasury)(nil).PayAllowance), now)
+}
+
+// MockPaymentRequests is a mock of PaymentRequests interface.
+type MockPaymentRequests struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentRequestsMockRecorder
+	isgomock struct{}
+}
+
+// MockPaymentRequestsMockRecorder is the mock recorder for MockPaymentRequests.
+type MockPaymentRequestsMockRecorder struct {
+	mock *MockPaymentRequests
+}
+
+// NewMockPaymentRequests creates a new mock instance.
+func NewMockPaymentRequests(ctrl *gomock.Controller) *MockPaymentRequests {
+	mock := &MockPaymentRequests{ctrl: ctrl}
+	mock.recorder = &MockPaymentRequestsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentRequests) EXPECT() *MockPaymentRequestsMockRecorder {
+	return m.recorder
+}
+
+// AcceptPaymentRequest mocks base method.
+func (m *MockPaymentRequests) AcceptPaymentRequest(userId, requestId int) (domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptPaymentRequest", userId, requestId)
+	ret0, _ := ret[0].(domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptPaymentRequest indicates an expected call of AcceptPaymentRequest.
+func (mr *MockPaymentRequestsMockRecorder) AcceptPaymentRequest(userId, requestId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptPaymentRequest", reflect.TypeOf((*MockPaymentRequests)(nil).AcceptPaymentRequest), userId, requestId)
+}
+
+// CancelPaymentRequest mocks base method.
+func (m *MockPaymentRequests) CancelPaymentRequest(userId, requestId int) (domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPaymentRequest", userId, requestId)
+	ret0, _ := ret[0].(domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelPaymentRequest indicates an expected call of CancelPaymentRequest.
+func (mr *MockPaymentRequestsMockRecorder) CancelPaymentRequest(userId, requestId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPaymentRequest", reflect.TypeOf((*MockPaymentRequests)(nil).CancelPaymentRequest), userId, requestId)
+}
+
+// CreatePaymentRequest mocks base method.
+func (m *MockPaymentRequests) CreatePaymentRequest(userId int, input domain.PaymentRequestInput) (domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentRequest", userId, input)
+	ret0, _ := ret[0].(domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentRequest indicates an expected call of CreatePaymentRequest.
+func (mr *MockPaymentRequestsMockRecorder) CreatePaymentRequest(userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentRequest", reflect.TypeOf((*MockPaymentRequests)(nil).CreatePaymentRequest), userId, input)
+}
+
+// DeclinePaymentRequest mocks base method.
+func (m *MockPaymentRequests) DeclinePaymentRequest(userId, requestId int) (domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclinePaymentRequest", userId, requestId)
+	ret0, _ := ret[0].(domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeclinePaymentRequest indicates an expected call of DeclinePaymentRequest.
+func (mr *MockPaymentRequestsMockRecorder) DeclinePaymentRequest(userId, requestId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclinePaymentRequest", reflect.TypeOf((*MockPaymentRequests)(nil).DeclinePaymentRequest), userId, requestId)
+}
+
+// ExpirePaymentRequests mocks base method.
+func (m *MockPaymentRequests) ExpirePaymentRequests(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpirePaymentRequests", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpirePaymentRequests indicates an expected call of ExpirePaymentRequests.
+func (mr *MockPaymentRequestsMockRecorder) ExpirePaymentRequests(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpirePaymentRequests", reflect.TypeOf((*MockPaymentRequests)(nil).ExpirePaymentRequests), now)
+}
+
+// GetPaymentRequests mocks base method.
+func (m *MockPaymentRequests) GetPaymentRequests(userId int, filter domain.PaymentRequestFilter) ([]domain.PaymentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentRequests", userId, filter)
+	ret0, _ := ret[0].([]domain.PaymentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentRequests indicates an expected call of GetPaymentRequests.
+func (mr *MockPaymentRequestsMockRecorder) GetPaymentRequests(userId, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentRequests", reflect.TypeOf((*MockPaymentRequests)(nil).GetPaymentRequests), userId, filter)
+}
+
+// MockEscrows is a mock of Escrows interface.
+type MockEscrows struct {
+	ctrl     *gomock.Controller
+	recorder *MockEscrowsMockRecorder
+	isgomock struct{}
+}
+
+// MockEscrowsMockRecorder is the mock recorder for MockEscrows.
+type MockEscrowsMockRecorder struct {
+	mock *MockEscrows
+}
+
+// NewMockEscrows creates a new mock instance.
+func NewMockEscrows(ctrl *gomock.Controller) *MockEscrows {
+	mock := &MockEscrows{ctrl: ctrl}
+	mock.recorder = &MockEscrowsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEscrows) EXPECT() *MockEscrowsMockRecorder {
+	return m.recorder
+}
+
+// CreateEscrow mocks base method.
+func (m *MockEscrows) CreateEscrow(userId int, input domain.EscrowInput) (domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEscrow", userId, input)
+	ret0, _ := ret[0].(domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEscrow indicates an expected call of CreateEscrow.
+func (mr *MockEscrowsMockRecorder) CreateEscrow(userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEscrow", reflect.TypeOf((*MockEscrows)(nil).CreateEscrow), userId, input)
+}
+
+// GetEscrows mocks base method.
+func (m *MockEscrows) GetEscrows(userId int, status string) ([]domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEscrows", userId, status)
+	ret0, _ := ret[0].([]domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEscrows indicates an expected call of GetEscrows.
+func (mr *MockEscrowsMockRecorder) GetEscrows(userId, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEscrows", reflect.TypeOf((*MockEscrows)(nil).GetEscrows), userId, status)
+}
+
+// ReleaseEscrow mocks base method.
+func (m *MockEscrows) ReleaseEscrow(userId, escrowId int) (domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseEscrow", userId, escrowId)
+	ret0, _ := ret[0].(domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseEscrow indicates an expected call of ReleaseEscrow.
+func (mr *MockEscrowsMockRecorder) ReleaseEscrow(userId, escrowId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseEscrow", reflect.TypeOf((*MockEscrows)(nil).ReleaseEscrow), userId, escrowId)
+}
+
+// ReturnEscrow mocks base method.
+func (m *MockEscrows) ReturnEscrow(userId, escrowId int) (domain.Escrow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReturnEscrow", userId, escrowId)
+	ret0, _ := ret[0].(domain.Escrow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReturnEscrow indicates an expected call of ReturnEscrow.
+func (mr *MockEscrowsMockRecorder) ReturnEscrow(userId, escrowId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReturnEscrow", reflect.TypeOf((*MockEscrows)(nil).ReturnEscrow), userId, escrowId)
+}
+
+// SettleExpiredEscrows mocks base method.
+func (m *MockEscrows) SettleExpiredEscrows(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SettleExpiredEscrows", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SettleExpiredEscrows indicates an expected call of SettleExpiredEscrows.
+func (mr *MockEscrowsMockRecorder) SettleExpiredEscrows(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SettleExpiredEscrows", reflect.TypeOf((*MockEscrows)(nil).SettleExpiredEscrows), now)
+}
+
+// MockUsers is a mock of Users interface.
+type MockUsers struct {
+	ctrl     *gomock.Controller
+	recorder *MockUsersMockRecorder
+	isgomock struct{}
+}
+
+// MockUsersMockRecorder is the mock recorder for MockUsers.
+type MockUsersMockRecorder struct {
+	mock *MockUsers
+}
+
+// NewMockUsers creates a new mock instance.
+func NewMockUsers(ctrl *gomock.Controller) *MockUsers {
+	mock := &MockUsers{ctrl: ctrl}
+	mock.recorder = &MockUsersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUsers) EXPECT() *MockUsersMockRecorder {
+	return m.recorder
+}
+
+// BanUser mocks base method.
+func (m *MockUsers) BanUser(adminId, userId int, input domain.BanInput) (domain.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanUser", adminId, userId, input)
+	ret0, _ := ret[0].(domain.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BanUser indicates an expected call of BanUser.
+func (mr *MockUsersMockRecorder) BanUser(adminId, userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockUsers)(nil).BanUser), adminId, userId, input)
+}
+
+// GetUser mocks base method.
+func (m *MockUsers) GetUser(userId int) (domain.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", userId)
+	ret0, _ := ret[0].(domain.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUsersMockRecorder) GetUser(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUsers)(nil).GetUser), userId)
+}
+
+// GetUserSummary mocks base method.
+func (m *MockUsers) GetUserSummary(userId int) (*domain.UserSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSummary", userId)
+	ret0, _ := ret[0].(*domain.UserSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSummary indicates an expected call of GetUserSummary.
+func (mr *MockUsersMockRecorder) GetUserSummary(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSummary", reflect.TypeOf((*MockUsers)(nil).GetUserSummary), userId)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUsers) ResetPassword(userId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUsersMockRecorder) ResetPassword(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUsers)(nil).ResetPassword), userId)
+}
+
+// SearchUsers mocks base method.
+func (m *MockUsers) SearchUsers(filter domain.UserFilter) ([]domain.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", filter)
+	ret0, _ := ret[0].([]domain.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockUsersMockRecorder) SearchUsers(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockUsers)(nil).SearchUsers), filter)
+}
+
+// UnbanUser mocks base method.
+func (m *MockUsers) UnbanUser(userId int) (domain.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanUser", userId)
+	ret0, _ := ret[0].(domain.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnbanUser indicates an expected call of UnbanUser.
+func (mr *MockUsersMockRecorder) UnbanUser(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanUser", reflect.TypeOf((*MockUsers)(nil).UnbanUser), userId)
+}
+
+// MockAudit is a mock of Audit interface.
+type MockAudit struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditMockRecorder is the mock recorder for MockAudit.
+type MockAuditMockRecorder struct {
+	mock *MockAudit
+}
+
+// NewMockAudit creates a new mock instance.
+func NewMockAudit(ctrl *gomock.Controller) *MockAudit {
+	mock := &MockAudit{ctrl: ctrl}
+	mock.recorder = &MockAuditMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAudit) EXPECT() *MockAuditMockRecorder {
+	return m.recorder
+}
+
+// GetAuditLog mocks base method.
+func (m *MockAudit) GetAuditLog(filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLog", filter)
+	ret0, _ := ret[0].([]domain.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLog indicates an expected call of GetAuditLog.
+func (mr *MockAuditMockRecorder) GetAuditLog(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLog", reflect.TypeOf((*MockAudit)(nil).GetAuditLog), filter)
+}
+
+// Record mocks base method.
+func (m *MockAudit) Record(event domain.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditMockRecorder) Record(event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAudit)(nil).Record), event)
+}
+
+// VerifyAuditLog mocks base method.
+func (m *MockAudit) VerifyAuditLog() (domain.AuditVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAuditLog")
+	ret0, _ := ret[0].(domain.AuditVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAuditLog indicates an expected call of VerifyAuditLog.
+func (mr *MockAuditMockRecorder) VerifyAuditLog() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAuditLog", reflect.TypeOf((*MockAudit)(nil).VerifyAuditLog))
+}
+
+// MockTwoFactor is a mock of TwoFactor interface.
+type MockTwoFactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorMockRecorder
+	isgomock struct{}
+}
+
+// MockTwoFactorMockRecorder is the mock recorder for MockTwoFactor.
+type MockTwoFactorMockRecorder struct {
+	mock *MockTwoFactor
+}
+
+// NewMockTwoFactor creates a new mock instance.
+func NewMockTwoFactor(ctrl *gomock.Controller) *MockTwoFactor {
+	mock := &MockTwoFactor{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactor) EXPECT() *MockTwoFactorMockRecorder {
+	return m.recorder
+}
+
+// CheckRolePolicy mocks base method.
+func (m *MockTwoFactor) CheckRolePolicy(userId int, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckRolePolicy", userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckRolePolicy indicates an expected call of CheckRolePolicy.
+func (mr *MockTwoFactorMockRecorder) CheckRolePolicy(userId, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRolePolicy", reflect.TypeOf((*MockTwoFactor)(nil).CheckRolePolicy), userId, role)
+}
+
+// CompleteTwoFactorSignIn mocks base method.
+func (m *MockTwoFactor) CompleteTwoFactorSignIn(input domain.TwoFactorSignInInput, clientIP string) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteTwoFactorSignIn", input, clientIP)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteTwoFactorSignIn indicates an expected call of CompleteTwoFactorSignIn.
+func (mr *MockTwoFactorMockRecorder) CompleteTwoFactorSignIn(input, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteTwoFactorSignIn", reflect.TypeOf((*MockTwoFactor)(nil).CompleteTwoFactorSignIn), input, clientIP)
+}
+
+// ConfirmTwoFactor mocks base method.
+func (m *MockTwoFactor) ConfirmTwoFactor(userId int, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTwoFactor", userId, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTwoFactor indicates an expected call of ConfirmTwoFactor.
+func (mr *MockTwoFactorMockRecorder) ConfirmTwoFactor(userId, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTwoFactor", reflect.TypeOf((*MockTwoFactor)(nil).ConfirmTwoFactor), userId, code)
+}
+
+// DisableTwoFactor mocks base method.
+func (m *MockTwoFactor) DisableTwoFactor(userId int, input domain.TwoFactorDisableInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", userId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockTwoFactorMockRecorder) DisableTwoFactor(userId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockTwoFactor)(nil).DisableTwoFactor), userId, input)
+}
+
+// EnrollTwoFactor mocks base method.
+func (m *MockTwoFactor) EnrollTwoFactor(userId int) (domain.TwoFactorEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTwoFactor", userId)
+	ret0, _ := ret[0].(domain.TwoFactorEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTwoFactor indicates an expected call of EnrollTwoFactor.
+func (mr *MockTwoFactorMockRecorder) EnrollTwoFactor(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTwoFactor", reflect.TypeOf((*MockTwoFactor)(nil).EnrollTwoFactor), userId)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockTwoFactor) RegenerateRecoveryCodes(userId int, code, clientIP string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", userId, code, clientIP)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockTwoFactorMockRecorder) RegenerateRecoveryCodes(userId, code, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockTwoFactor)(nil).RegenerateRecoveryCodes), userId, code, clientIP)
+}
+
+// StartTwoFactorSignIn mocks base method.
+func (m *MockTwoFactor) StartTwoFactorSignIn(userId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartTwoFactorSignIn", userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartTwoFactorSignIn indicates an expected call of StartTwoFactorSignIn.
+func (mr *MockTwoFactorMockRecorder) StartTwoFactorSignIn(userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartTwoFactorSignIn", reflect.TypeOf((*MockTwoFactor)(nil).StartTwoFactorSignIn), userId)
+}
+
+// MockApiKeys is a mock of ApiKeys interface.
+type MockApiKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeysMockRecorder
+	isgomock struct{}
+}
+
+// MockApiKeysMockRecorder is the mock recorder for MockApiKeys.
+type MockApiKeysMockRecorder struct {
+	mock *MockApiKeys
+}
+
+// NewMockApiKeys creates a new mock instance.
+func NewMockApiKeys(ctrl *gomock.Controller) *MockApiKeys {
+	mock := &MockApiKeys{ctrl: ctrl}
+	mock.recorder = &MockApiKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKeys) EXPECT() *MockApiKeysMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateApiKey mocks base method.
+func (m *MockApiKeys) AuthenticateApiKey(key string) (domain.ApiKeyIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateApiKey", key)
+	ret0, _ := ret[0].(domain.ApiKeyIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateApiKey indicates an expected call of AuthenticateApiKey.
+func (mr *MockApiKeysMockRecorder) AuthenticateApiKey(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateApiKey", reflect.TypeOf((*MockApiKeys)(nil).AuthenticateApiKey), key)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockApiKeys) CreateApiKey(adminId, accountId int, input domain.ApiKeyInput) (domain.CreatedApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", adminId, accountId, input)
+	ret0, _ := ret[0].(domain.CreatedApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockApiKeysMockRecorder) CreateApiKey(adminId, accountId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockApiKeys)(nil).CreateApiKey), adminId, accountId, input)
+}
+
+// CreateServiceAccount mocks base method.
+func (m *MockApiKeys) CreateServiceAccount(adminId int, input domain.ServiceAccountInput) (domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateServiceAccount", adminId, input)
+	ret0, _ := ret[0].(domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateServiceAccount indicates an expected call of CreateServiceAccount.
+func (mr *MockApiKeysMockRecorder) CreateServiceAccount(adminId, input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateServiceAccount", reflect.TypeOf((*MockApiKeys)(nil).CreateServiceAccount), adminId, input)
+}
+
+// GetApiKeys mocks base method.
+func (m *MockApiKeys) GetApiKeys(accountId int) ([]domain.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeys", accountId)
+	ret0, _ := ret[0].([]domain.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeys indicates an expected call of GetApiKeys.
+func (mr *MockApiKeysMockRecorder) GetApiKeys(accountId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeys", reflect.TypeOf((*MockApiKeys)(nil).GetApiKeys), accountId)
+}
+
+// GetServiceAccounts mocks base method.
+func (m *MockApiKeys) GetServiceAccounts() ([]domain.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccounts")
+	ret0, _ := ret[0].([]domain.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccounts indicates an expected call of GetServiceAccounts.
+func (mr *MockApiKeysMockRecorder) GetServiceAccounts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccounts", reflect.TypeOf((*MockApiKeys)(nil).GetServiceAccounts))
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockApiKeys) RevokeApiKey(keyId int) (domain.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKey", keyId)
+	ret0, _ := ret[0].(domain.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockApiKeysMockRecorder) RevokeApiKey(keyId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockApiKeys)(nil).RevokeApiKey), keyId)
+}
+
+// MockOidc is a mock of Oidc interface.
+type MockOidc struct {
+	ctrl     *gomock.Controller
+	recorder *MockOidcMockRecorder
+	isgomock struct{}
+}
+
+// MockOidcMockRecorder is the mock recorder for MockOidc.
+type MockOidcMockRecorder struct {
+	mock *MockOidc
+}
+
+// NewMockOidc creates a new mock instance.
+func NewMockOidc(ctrl *gomock.Controller) *MockOidc {
+	mock := &MockOidc{ctrl: ctrl}
+	mock.recorder = &MockOidcMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOidc) EXPECT() *MockOidcMockRecorder {
+	return m.recorder
+}
+
+// CompleteOidcLogin mocks base method.
+func (m *MockOidc) CompleteOidcLogin(input domain.OidcCallbackInput) (domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteOidcLogin", input)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteOidcLogin indicates an expected call of CompleteOidcLogin.
+func (mr *MockOidcMockRecorder) CompleteOidcLogin(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteOidcLogin", reflect.TypeOf((*MockOidc)(nil).CompleteOidcLogin), input)
+}
+
+// PurgeOidcStates mocks base method.
+func (m *MockOidc) PurgeOidcStates(now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeOidcStates", now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeOidcStates indicates an expected call of PurgeOidcStates.
+func (mr *MockOidcMockRecorder) PurgeOidcStates(now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeOidcStates", reflect.TypeOf((*MockOidc)(nil).PurgeOidcStates), now)
+}
+
+// StartOidcLogin mocks base method.
+func (m *MockOidc) StartOidcLogin() (domain.OidcLogin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartOidcLogin")
+	ret0, _ := ret[0].(domain.OidcLogin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartOidcLogin indicates an expected call of StartOidcLogin.
+func (mr *MockOidcMockRecorder) StartOidcLogin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartOidcLogin", reflect.TypeOf((*MockOidc)(nil).StartOidcLogin))
+}
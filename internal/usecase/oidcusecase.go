@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+	"github.com/bllooop/coinshop/pkg/oidc"
+)
+
+const (
+	// oidcUsernameMaxLength ограничивает имя, полученное от провайдера, чтобы с суффиксом оно оставалось читаемым.
+	oidcUsernameMaxLength = 64
+	// oidcUsernameSuffixLength - длина суффикса из хеша subject, который добавляется к занятому имени.
+	oidcUsernameSuffixLength = 8
+)
+
+type OidcUsecase struct {
+	repo     repository.Oidc
+	auth     *AuthUsecase
+	provider *oidc.Provider
+	cfg      OidcConfig
+}
+
+// NewOidcUsecase не обращается к провайдеру: его метаданные загружаются при первом входе.
+// Без Issuer вход через провайдера отключен.
+func NewOidcUsecase(repo *repository.Repository, auth *AuthUsecase, cfg OidcConfig) *OidcUsecase {
+	s := &OidcUsecase{
+		repo: repo,
+		auth: auth,
+		cfg:  cfg,
+	}
+	if cfg.Provider.Issuer != "" {
+		s.provider = oidc.NewProvider(cfg.Provider, nil)
+	}
+	return s
+}
+
+// StartOidcLogin сохраняет state, nonce и code_verifier нового входа и возвращает адрес провайдера.
+func (s *OidcUsecase) StartOidcLogin() (domain.OidcLogin, error) {
+	if s.provider == nil {
+		return domain.OidcLogin{}, domain.ErrOidcDisabled
+	}
+	state := domain.OidcState{ExpiresAt: time.Now().Add(s.cfg.StateTTL).UTC()}
+	var err error
+	if state.State, err = oidc.RandomString(); err != nil {
+		return domain.OidcLogin{}, err
+	}
+	if state.Nonce, err = oidc.RandomString(); err != nil {
+		return domain.OidcLogin{}, err
+	}
+	if state.CodeVerifier, err = oidc.NewCodeVerifier(); err != nil {
+		return domain.OidcLogin{}, err
+	}
+	url, err := s.provider.AuthCodeURL(context.Background(), state.State, state.Nonce, oidc.CodeChallenge(state.CodeVerifier))
+	if err != nil {
+		return domain.OidcLogin{}, fmt.Errorf("%w: %v", domain.ErrOidcLoginFailed, err)
+	}
+	if err = s.repo.CreateOidcState(state); err != nil {
+		return domain.OidcLogin{}, err
+	}
+	return domain.OidcLogin{URL: url, State: state.State}, nil
+}
+
+// CompleteOidcLogin обменивает код на ID token и находит аккаунт, связанный с пользователем провайдера
+// по subject. При первом входе аккаунт создается. Как и при входе по паролю, пользователю с двухфакторной
+// аутентификацией вход завершается только после проверки кода.
+func (s *OidcUsecase) CompleteOidcLogin(input domain.OidcCallbackInput) (domain.User, error) {
+	if s.provider == nil {
+		return domain.User{}, domain.ErrOidcDisabled
+	}
+	now := time.Now()
+	state, err := s.repo.ConsumeOidcState(input.State, now)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if input.Error != "" {
+		return domain.User{}, fmt.Errorf("%w: %s %s", domain.ErrOidcLoginFailed, input.Error, input.ErrorDescription)
+	}
+	ctx := context.Background()
+	idToken, err := s.provider.Exchange(ctx, input.Code, state.CodeVerifier)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("%w: %v", domain.ErrOidcLoginFailed, err)
+	}
+	claims, err := s.provider.VerifyIDToken(ctx, idToken, state.Nonce, now)
+	if err != nil {
+		return domain.User{}, fmt.Errorf("%w: %v", domain.ErrOidcLoginFailed, err)
+	}
+
+	user, err := s.repo.GetUserByOidcSubject(claims.Issuer, claims.Subject)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		user, err = s.provisionUser(claims, now)
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	if user.Banned {
+		return domain.User{}, domain.ErrUserBanned
+	}
+	if user.TwoFactorEnabled {
+		return user, nil
+	}
+	if err := s.auth.completeLogin(user.Id, user.UserName, now); err != nil {
+		return domain.User{}, err
+	}
+	return user, nil
+}
+
+// provisionUser создает аккаунт для пользователя провайдера. Имя берется из preferred_username
+// или адреса почты; если оно занято или зарезервировано, к нему добавляется суффикс из хеша subject.
+func (s *OidcUsecase) provisionUser(claims oidc.Claims, now time.Time) (domain.User, error) {
+	base := oidcUsername(claims)
+	sum := sha256.Sum256([]byte(claims.Issuer + " " + claims.Subject))
+	candidates := []string{base, base + "_" + hex.EncodeToString(sum[:])[:oidcUsernameSuffixLength]}
+
+	bonus := s.auth.signupBonus
+	for _, username := range candidates {
+		if validateUsername(username) != nil {
+			continue
+		}
+		user := domain.User{UserName: username, Coins: &bonus}
+		id, err := s.repo.CreateOidcUser(user, claims.Issuer, claims.Subject, coinExpiryDate(now, s.auth.coinExpiry))
+		if errors.Is(err, domain.ErrUsernameTaken) {
+			// аккаунт мог быть создан параллельным входом того же пользователя
+			if existing, err := s.repo.GetUserByOidcSubject(claims.Issuer, claims.Subject); err == nil {
+				return existing, nil
+			}
+			continue
+		}
+		if err != nil {
+			return domain.User{}, err
+		}
+		logger.Log.Info().Int("user_id", id).Str("issuer", claims.Issuer).Msg("Создан аккаунт при первом входе через внешнего провайдера")
+		return domain.User{Id: id, UserName: username}, nil
+	}
+	return domain.User{}, fmt.Errorf("%w: %v", domain.ErrOidcLoginFailed, domain.ErrUsernameTaken)
+}
+
+// oidcUsername оставляет в имени от провайдера только латинские буквы, цифры и символы ._-
+func oidcUsername(claims oidc.Claims) string {
+	name := claims.PreferredUsername
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	var b strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			b.WriteRune(r)
+		}
+		if b.Len() == oidcUsernameMaxLength {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "user"
+	}
+	return b.String()
+}
+
+// PurgeOidcStates удаляет незавершенные входы с истекшим сроком.
+func (s *OidcUsecase) PurgeOidcStates(now time.Time) (int, error) {
+	return s.repo.PurgeOidcStates(now)
+}
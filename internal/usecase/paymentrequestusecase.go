@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+)
+
+// paymentRequestTransitions описывает допустимые переходы статуса запроса на перевод:
+// из ожидания запрос принимает или отклоняет плательщик, отменяет запросивший или он истекает.
+var paymentRequestTransitions = map[string][]string{
+	domain.PaymentRequestPending: {domain.PaymentRequestAccepted, domain.PaymentRequestDeclined,
+		domain.PaymentRequestCancelled, domain.PaymentRequestExpired},
+}
+
+type PaymentRequestUsecase struct {
+	repo   repository.PaymentRequests
+	limits domain.TransferLimits
+	ttl    time.Duration
+}
+
+func NewPaymentRequestUsecase(repo *repository.Repository, limits domain.TransferLimits, ttl time.Duration) *PaymentRequestUsecase {
+	return &PaymentRequestUsecase{
+		repo:   repo,
+		limits: limits,
+		ttl:    ttl,
+	}
+}
+
+// CreatePaymentRequest создает запрос со сроком действия ttl. Лимиты переводов проверяются
+// при принятии запроса, когда выполняется сам перевод.
+func (s *PaymentRequestUsecase) CreatePaymentRequest(userId int, input domain.PaymentRequestInput) (domain.PaymentRequest, error) {
+	now := time.Now()
+	return s.repo.CreatePaymentRequest(domain.PaymentRequest{
+		RequesterId:   userId,
+		PayerUsername: input.PayerUsername,
+		Amount:        input.Amount,
+		Message:       sanitizeMessage(input.Message),
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(s.ttl),
+	})
+}
+
+func (s *PaymentRequestUsecase) GetPaymentRequests(userId int, filter domain.PaymentRequestFilter) ([]domain.PaymentRequest, error) {
+	return s.repo.GetPaymentRequests(userId, filter)
+}
+
+// AcceptPaymentRequest переводит монеты запросившему и помечает запрос принятым в одной
+// транзакции репозитория. Если перевод не удался, запрос остается в ожидании,
+// и плательщик может повторить попытку.
+func (s *PaymentRequestUsecase) AcceptPaymentRequest(userId, requestId int) (domain.PaymentRequest, error) {
+	request, err := s.pendingRequest(requestId, userId, domain.PaymentRequestAccepted)
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	if s.limits.MaxAmount > 0 && request.Amount > s.limits.MaxAmount {
+		return domain.PaymentRequest{}, domain.ErrTransferTooLarge
+	}
+	return s.repo.AcceptPaymentRequest(request.Id, userId, time.Now(), s.limits)
+}
+
+func (s *PaymentRequestUsecase) DeclinePaymentRequest(userId, requestId int) (domain.PaymentRequest, error) {
+	request, err := s.pendingRequest(requestId, userId, domain.PaymentRequestDeclined)
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	return s.repo.UpdatePaymentRequestStatus(request.Id, request.Status, domain.PaymentRequestDeclined, nil)
+}
+
+func (s *PaymentRequestUsecase) CancelPaymentRequest(userId, requestId int) (domain.PaymentRequest, error) {
+	request, err := s.pendingRequest(requestId, userId, domain.PaymentRequestCancelled)
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	return s.repo.UpdatePaymentRequestStatus(request.Id, request.Status, domain.PaymentRequestCancelled, nil)
+}
+
+func (s *PaymentRequestUsecase) ExpirePaymentRequests(now time.Time) (int, error) {
+	return s.repo.ExpirePaymentRequests(now)
+}
+
+// pendingRequest загружает запрос и проверяет, что пользователь может перевести его в статус to:
+// принять или отклонить запрос может только плательщик, отменить - только запросивший.
+// Чужой запрос считается не найденным, просроченный помечается истекшим.
+func (s *PaymentRequestUsecase) pendingRequest(requestId, userId int, to string) (domain.PaymentRequest, error) {
+	request, err := s.repo.GetPaymentRequest(requestId)
+	if err != nil {
+		return domain.PaymentRequest{}, err
+	}
+	owner := request.PayerId
+	if to == domain.PaymentRequestCancelled {
+		owner = request.RequesterId
+	}
+	if owner != userId {
+		return domain.PaymentRequest{}, domain.ErrPaymentRequestNotFound
+	}
+	if !canTransition(paymentRequestTransitions, request.Status, to) {
+		return domain.PaymentRequest{}, domain.ErrPaymentRequestResolved
+	}
+	if !request.ExpiresAt.After(time.Now()) {
+		if _, err = s.repo.UpdatePaymentRequestStatus(request.Id, request.Status, domain.PaymentRequestExpired, nil); err != nil {
+			return domain.PaymentRequest{}, err
+		}
+		return domain.PaymentRequest{}, domain.ErrPaymentRequestExpired
+	}
+	return request, nil
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPaymentRequestUsecase_Transitions(t *testing.T) {
+	limits := domain.TransferLimits{MaxAmount: 100}
+	// запрос пользователя 1 к плательщику 2
+	pending := domain.PaymentRequest{Id: 5, RequesterId: 1, PayerId: 2, Amount: 50,
+		Status: domain.PaymentRequestPending, ExpiresAt: time.Now().Add(time.Hour)}
+	expired := pending
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	declined := pending
+	declined.Status = domain.PaymentRequestDeclined
+	large := pending
+	large.Amount = 150
+
+	type mockBehavior func(r *mock_repository.MockPaymentRequests)
+
+	testTable := []struct {
+		name         string
+		action       string
+		userId       int
+		mockBehavior mockBehavior
+		wantErr      error
+	}{
+		{
+			name:   "Плательщик принимает",
+			action: domain.PaymentRequestAccepted,
+			userId: 2,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(pending, nil)
+				r.EXPECT().AcceptPaymentRequest(5, 2, gomock.Any(), limits).Return(domain.PaymentRequest{Id: 5}, nil)
+			},
+		},
+		{
+			name:   "Плательщик отклоняет",
+			action: domain.PaymentRequestDeclined,
+			userId: 2,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(pending, nil)
+				r.EXPECT().UpdatePaymentRequestStatus(5, domain.PaymentRequestPending, domain.PaymentRequestDeclined, nil).
+					Return(domain.PaymentRequest{Id: 5}, nil)
+			},
+		},
+		{
+			name:   "Запросивший отменяет",
+			action: domain.PaymentRequestCancelled,
+			userId: 1,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(pending, nil)
+				r.EXPECT().UpdatePaymentRequestStatus(5, domain.PaymentRequestPending, domain.PaymentRequestCancelled, nil).
+					Return(domain.PaymentRequest{Id: 5}, nil)
+			},
+		},
+		{
+			// запросивший не может принять собственный запрос, для него он не найден
+			name:   "Запросивший принимает",
+			action: domain.PaymentRequestAccepted,
+			userId: 1,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(pending, nil)
+			},
+			wantErr: domain.ErrPaymentRequestNotFound,
+		},
+		{
+			name:   "Плательщик отменяет",
+			action: domain.PaymentRequestCancelled,
+			userId: 2,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(pending, nil)
+			},
+			wantErr: domain.ErrPaymentRequestNotFound,
+		},
+		{
+			name:   "Посторонний",
+			action: domain.PaymentRequestDeclined,
+			userId: 3,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(pending, nil)
+			},
+			wantErr: domain.ErrPaymentRequestNotFound,
+		},
+		{
+			name:   "Запрос уже отклонен",
+			action: domain.PaymentRequestAccepted,
+			userId: 2,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(declined, nil)
+			},
+			wantErr: domain.ErrPaymentRequestResolved,
+		},
+		{
+			// просроченный запрос помечается истекшим, даже если фоновая задача до него еще не дошла
+			name:   "Срок истек",
+			action: domain.PaymentRequestAccepted,
+			userId: 2,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(expired, nil)
+				r.EXPECT().UpdatePaymentRequestStatus(5, domain.PaymentRequestPending, domain.PaymentRequestExpired, nil).
+					Return(domain.PaymentRequest{Id: 5}, nil)
+			},
+			wantErr: domain.ErrPaymentRequestExpired,
+		},
+		{
+			name:   "Сумма выше лимита",
+			action: domain.PaymentRequestAccepted,
+			userId: 2,
+			mockBehavior: func(r *mock_repository.MockPaymentRequests) {
+				r.EXPECT().GetPaymentRequest(5).Return(large, nil)
+			},
+			wantErr: domain.ErrTransferTooLarge,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockPaymentRequests(c)
+			test.mockBehavior(repo)
+			s := &PaymentRequestUsecase{repo: repo, limits: limits, ttl: time.Hour}
+
+			var err error
+			switch test.action {
+			case domain.PaymentRequestAccepted:
+				_, err = s.AcceptPaymentRequest(test.userId, 5)
+			case domain.PaymentRequestDeclined:
+				_, err = s.DeclinePaymentRequest(test.userId, 5)
+			case domain.PaymentRequestCancelled:
+				_, err = s.CancelPaymentRequest(test.userId, 5)
+			}
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
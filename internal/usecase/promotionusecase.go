@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+)
+
+type PromotionUsecase struct {
+	repo repository.Promotions
+}
+
+func NewPromotionUsecase(repo *repository.Repository) *PromotionUsecase {
+	return &PromotionUsecase{
+		repo: repo,
+	}
+}
+
+func (s *PromotionUsecase) CreatePromotion(input domain.PromotionInput) (domain.Promotion, error) {
+	if input.Kind == domain.DiscountPercent && input.Value > 100 {
+		return domain.Promotion{}, domain.ErrInvalidPromotion
+	}
+	if !input.EndsAt.After(input.StartsAt) {
+		return domain.Promotion{}, domain.ErrInvalidPromotion
+	}
+	// акция действует либо на товар, либо на категорию, либо на весь магазин
+	if input.Item != "" && input.Category != "" {
+		return domain.Promotion{}, domain.ErrInvalidPromotion
+	}
+	input.Code = normalizePromoCode(input.Code)
+	return s.repo.CreatePromotion(input)
+}
+
+func (s *PromotionUsecase) GetPromotions() ([]domain.Promotion, error) {
+	return s.repo.GetPromotions()
+}
+
+func (s *PromotionUsecase) EndPromotion(id int) (domain.Promotion, error) {
+	return s.repo.EndPromotion(id)
+}
+
+// normalizePromoCode приводит промокод к верхнему регистру, чтобы ввод не зависел от регистра.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
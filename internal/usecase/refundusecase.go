@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+)
+
+type RefundUsecase struct {
+	repo repository.Refunds
+	cfg  RefundConfig
+}
+
+func NewRefundUsecase(repo *repository.Repository, cfg RefundConfig) *RefundUsecase {
+	return &RefundUsecase{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+func (s *RefundUsecase) RequestRefund(userId, purchaseId int, reason string) (int, error) {
+	purchase, err := s.repo.GetPurchase(purchaseId)
+	if err != nil {
+		return 0, err
+	}
+	if purchase.UserId != userId {
+		return 0, domain.ErrPurchaseNotFound
+	}
+	if purchase.RefundedAt != nil {
+		return 0, domain.ErrAlreadyRefunded
+	}
+	if purchase.Status != domain.PurchasePending && purchase.Status != domain.PurchasePacked {
+		return 0, domain.ErrPurchaseShipped
+	}
+	if time.Since(purchase.PurchaseDate) > s.cfg.Window {
+		return 0, domain.ErrRefundWindowExpired
+	}
+	return s.repo.CreateRefundRequest(domain.Refund{
+		PurchaseId: purchaseId,
+		UserId:     userId,
+		Amount:     purchase.Price * purchase.Quantity,
+		Reason:     reason,
+	})
+}
+
+func (s *RefundUsecase) GetRefunds(status string) ([]domain.Refund, error) {
+	return s.repo.GetRefunds(status)
+}
+
+func (s *RefundUsecase) ApproveRefund(adminId, refundId int) (domain.Refund, error) {
+	return s.repo.ApproveRefund(refundId, adminId)
+}
+
+func (s *RefundUsecase) RejectRefund(adminId, refundId int) (domain.Refund, error) {
+	return s.repo.RejectRefund(refundId, adminId)
+}
+
+func (s *RefundUsecase) ForceRefund(adminId, purchaseId int, reason string) (domain.Refund, error) {
+	return s.repo.ForceRefund(purchaseId, adminId, reason)
+}
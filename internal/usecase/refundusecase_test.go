@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRefundUsecase_RequestRefund(t *testing.T) {
+	now := time.Now()
+	purchase := domain.Purchase{Id: 5, UserId: 1, Price: 80, Quantity: 2, PurchaseDate: now.Add(-time.Hour), Status: domain.PurchasePending}
+	packed := purchase
+	packed.Status = domain.PurchasePacked
+	shipped := purchase
+	shipped.Status = domain.PurchaseShipped
+	refunded := purchase
+	refunded.RefundedAt = &now
+	old := purchase
+	old.PurchaseDate = now.Add(-15 * 24 * time.Hour)
+
+	testTable := []struct {
+		name     string
+		purchase domain.Purchase
+		userId   int
+		created  bool
+		wantErr  error
+	}{
+		{
+			name:     "Ожидает упаковки",
+			purchase: purchase,
+			userId:   1,
+			created:  true,
+		},
+		{
+			name:     "Упакована",
+			purchase: packed,
+			userId:   1,
+			created:  true,
+		},
+		{
+			name:     "Отправлена",
+			purchase: shipped,
+			userId:   1,
+			wantErr:  domain.ErrPurchaseShipped,
+		},
+		{
+			name:     "Уже возвращена",
+			purchase: refunded,
+			userId:   1,
+			wantErr:  domain.ErrAlreadyRefunded,
+		},
+		{
+			name:     "Срок истек",
+			purchase: old,
+			userId:   1,
+			wantErr:  domain.ErrRefundWindowExpired,
+		},
+		{
+			// чужая покупка для пользователя не существует
+			name:     "Чужая покупка",
+			purchase: purchase,
+			userId:   2,
+			wantErr:  domain.ErrPurchaseNotFound,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockRefunds(c)
+			repo.EXPECT().GetPurchase(5).Return(test.purchase, nil)
+			if test.created {
+				repo.EXPECT().CreateRefundRequest(domain.Refund{PurchaseId: 5, UserId: 1, Amount: 160, Reason: "не подошел размер"}).Return(3, nil)
+			}
+			s := &RefundUsecase{repo: repo, cfg: RefundConfig{Window: 14 * 24 * time.Hour}}
+
+			id, err := s.RequestRefund(test.userId, 5, "не подошел размер")
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 3, id)
+			}
+		})
+	}
+}
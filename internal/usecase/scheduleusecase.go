@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+	"github.com/bllooop/coinshop/pkg/cron"
+	logger "github.com/bllooop/coinshop/pkg/logging"
+)
+
+const (
+	minScheduleInterval = time.Hour
+	dueSchedulesBatch   = 100
+)
+
+type ScheduleUsecase struct {
+	repo repository.Schedules
+	shop Shop
+}
+
+// NewScheduleUsecase принимает usecase магазина, чтобы запланированные переводы
+// проходили те же проверки и лимиты, что и обычные.
+func NewScheduleUsecase(repo *repository.Repository, shop Shop) *ScheduleUsecase {
+	return &ScheduleUsecase{
+		repo: repo,
+		shop: shop,
+	}
+}
+
+func (s *ScheduleUsecase) CreateSchedule(userId int, input domain.ScheduleInput) (domain.ScheduledTransfer, error) {
+	now := time.Now()
+	schedule := domain.ScheduledTransfer{
+		UserId:              userId,
+		DestinationUsername: input.DestinationUsername,
+		Amount:              input.Amount,
+		Message:             sanitizeMessage(input.Message),
+		Category:            input.Category,
+		CreatedAt:           now,
+	}
+	if (input.Cron == "") == (input.Interval == "") {
+		return domain.ScheduledTransfer{}, fmt.Errorf("%w: нужно указать cron или interval", domain.ErrInvalidSchedule)
+	}
+	start := now
+	if input.StartAt != nil {
+		if input.StartAt.Before(now) {
+			return domain.ScheduledTransfer{}, fmt.Errorf("%w: время начала уже прошло", domain.ErrInvalidSchedule)
+		}
+		start = *input.StartAt
+	}
+	if input.Cron != "" {
+		expr, err := cron.Parse(input.Cron)
+		if err != nil {
+			return domain.ScheduledTransfer{}, fmt.Errorf("%w: %v", domain.ErrInvalidSchedule, err)
+		}
+		// время начала тоже может совпасть с расписанием
+		if schedule.NextRunAt, err = expr.Next(start.Add(-time.Minute)); err != nil {
+			return domain.ScheduledTransfer{}, fmt.Errorf("%w: %v", domain.ErrInvalidSchedule, err)
+		}
+		schedule.Cron = input.Cron
+	} else {
+		interval, err := time.ParseDuration(input.Interval)
+		if err != nil || interval < minScheduleInterval {
+			return domain.ScheduledTransfer{}, fmt.Errorf("%w: интервал должен быть не меньше %s", domain.ErrInvalidSchedule, minScheduleInterval)
+		}
+		schedule.Interval = interval.String()
+		schedule.NextRunAt = start
+		if input.StartAt == nil {
+			schedule.NextRunAt = now.Add(interval)
+		}
+	}
+	return s.repo.CreateSchedule(schedule)
+}
+
+func (s *ScheduleUsecase) GetSchedules(userId int) ([]domain.ScheduledTransfer, error) {
+	return s.repo.GetSchedules(userId)
+}
+
+func (s *ScheduleUsecase) CancelSchedule(userId, scheduleId int) error {
+	return s.repo.CancelSchedule(userId, scheduleId)
+}
+
+func (s *ScheduleUsecase) GetScheduleRuns(userId, scheduleId int) ([]domain.ScheduleRun, error) {
+	if _, err := s.repo.GetSchedule(userId, scheduleId); err != nil {
+		return nil, err
+	}
+	return s.repo.GetScheduleRuns(scheduleId)
+}
+
+// RunDueSchedules выполняет наступившие переводы и возвращает количество выполненных запусков.
+// Пропущенные за время простоя запуски не повторяются: расписание выполняется один раз
+// и переносится на ближайшее будущее время.
+func (s *ScheduleUsecase) RunDueSchedules(now time.Time) (int, error) {
+	schedules, err := s.repo.GetDueSchedules(now, dueSchedulesBatch)
+	if err != nil {
+		return 0, err
+	}
+	executed := 0
+	for _, schedule := range schedules {
+		next, err := nextScheduleRun(schedule, now)
+		if err != nil {
+			logger.Log.Error().Err(err).Int("id", schedule.Id).Msg("Не удалось вычислить следующий запуск расписания")
+			continue
+		}
+		claimed, err := s.repo.ClaimSchedule(schedule.Id, schedule.NextRunAt, next, now)
+		if err != nil {
+			return executed, err
+		}
+		if !claimed {
+			continue
+		}
+		run := domain.ScheduleRun{ScheduleId: schedule.Id, RunAt: now}
+		id, err := s.shop.SendCoin(schedule.UserId, domain.Transactions{
+			DestinationUsername: schedule.DestinationUsername,
+			Amount:              schedule.Amount,
+			Message:             schedule.Message,
+			Category:            schedule.Category,
+		})
+		if err != nil {
+			logger.Log.Error().Err(err).Int("id", schedule.Id).Msg("Запланированный перевод не выполнен")
+			run.Error = err.Error()
+		} else {
+			run.TransactionId = &id
+		}
+		if _, err = s.repo.CreateScheduleRun(run); err != nil {
+			return executed, err
+		}
+		executed++
+	}
+	return executed, nil
+}
+
+func nextScheduleRun(schedule domain.ScheduledTransfer, now time.Time) (time.Time, error) {
+	if schedule.Cron != "" {
+		expr, err := cron.Parse(schedule.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return expr.Next(now)
+	}
+	interval, err := time.ParseDuration(schedule.Interval)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := schedule.NextRunAt.Add(interval)
+	for !next.After(now) {
+		next = next.Add(interval)
+	}
+	return next, nil
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	mock_usecase "github.com/bllooop/coinshop/internal/usecase/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestScheduleUsecase_CreateSchedule(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+
+	testTable := []struct {
+		name    string
+		input   domain.ScheduleInput
+		wantErr bool
+		check   func(t *testing.T, schedule domain.ScheduledTransfer)
+	}{
+		{
+			name:  "Интервал",
+			input: domain.ScheduleInput{DestinationUsername: "bob", Amount: 50, Interval: "168h", StartAt: &start},
+			check: func(t *testing.T, schedule domain.ScheduledTransfer) {
+				assert.Equal(t, "168h0m0s", schedule.Interval)
+				assert.Equal(t, start, schedule.NextRunAt)
+			},
+		},
+		{
+			// время начала совпадает с расписанием и становится первым запуском
+			name:  "Cron",
+			input: domain.ScheduleInput{DestinationUsername: "bob", Amount: 50, Cron: "* * * * *", StartAt: &start},
+			check: func(t *testing.T, schedule domain.ScheduledTransfer) {
+				assert.Equal(t, "* * * * *", schedule.Cron)
+				assert.Equal(t, start, schedule.NextRunAt)
+			},
+		},
+		{
+			name:    "Cron и интервал",
+			input:   domain.ScheduleInput{DestinationUsername: "bob", Amount: 50, Cron: "0 9 * * 1", Interval: "168h"},
+			wantErr: true,
+		},
+		{
+			name:    "Нет повторения",
+			input:   domain.ScheduleInput{DestinationUsername: "bob", Amount: 50},
+			wantErr: true,
+		},
+		{
+			name:    "Слишком короткий интервал",
+			input:   domain.ScheduleInput{DestinationUsername: "bob", Amount: 50, Interval: "30m"},
+			wantErr: true,
+		},
+		{
+			name:    "Некорректный cron",
+			input:   domain.ScheduleInput{DestinationUsername: "bob", Amount: 50, Cron: "каждый понедельник"},
+			wantErr: true,
+		},
+		{
+			name:    "Время начала прошло",
+			input:   domain.ScheduleInput{DestinationUsername: "bob", Amount: 50, Interval: "168h", StartAt: &past},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockSchedules(c)
+			if !test.wantErr {
+				repo.EXPECT().CreateSchedule(gomock.Any()).DoAndReturn(func(schedule domain.ScheduledTransfer) (domain.ScheduledTransfer, error) {
+					return schedule, nil
+				})
+			}
+			s := &ScheduleUsecase{repo: repo}
+
+			schedule, err := s.CreateSchedule(1, test.input)
+			if test.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidSchedule)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, schedule.UserId)
+			test.check(t, schedule)
+		})
+	}
+}
+
+func TestScheduleUsecase_RunDueSchedules(t *testing.T) {
+	now := time.Date(2025, 3, 17, 9, 0, 0, 0, time.UTC)
+	due := domain.ScheduledTransfer{Id: 1, UserId: 1, DestinationUsername: "bob", Amount: 50, Interval: "168h", NextRunAt: now.Add(-time.Hour)}
+	transfer := domain.Transactions{DestinationUsername: "bob", Amount: 50}
+	transactionId := 10
+
+	type mockBehavior func(r *mock_repository.MockSchedules, shop *mock_usecase.MockShop)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		wantExecuted int
+	}{
+		{
+			// следующий запуск отсчитывается от предыдущего, а не от текущего времени
+			name: "Перевод выполнен",
+			mockBehavior: func(r *mock_repository.MockSchedules, shop *mock_usecase.MockShop) {
+				r.EXPECT().GetDueSchedules(now, dueSchedulesBatch).Return([]domain.ScheduledTransfer{due}, nil)
+				r.EXPECT().ClaimSchedule(1, due.NextRunAt, due.NextRunAt.Add(168*time.Hour), now).Return(true, nil)
+				shop.EXPECT().SendCoin(1, transfer).Return(transactionId, nil)
+				r.EXPECT().CreateScheduleRun(domain.ScheduleRun{ScheduleId: 1, RunAt: now, TransactionId: &transactionId}).Return(1, nil)
+			},
+			wantExecuted: 1,
+		},
+		{
+			name: "Ошибка перевода записывается",
+			mockBehavior: func(r *mock_repository.MockSchedules, shop *mock_usecase.MockShop) {
+				r.EXPECT().GetDueSchedules(now, dueSchedulesBatch).Return([]domain.ScheduledTransfer{due}, nil)
+				r.EXPECT().ClaimSchedule(1, due.NextRunAt, gomock.Any(), now).Return(true, nil)
+				shop.EXPECT().SendCoin(1, transfer).Return(0, domain.ErrInsufficientCoins)
+				r.EXPECT().CreateScheduleRun(domain.ScheduleRun{ScheduleId: 1, RunAt: now, Error: domain.ErrInsufficientCoins.Error()}).Return(1, nil)
+			},
+			wantExecuted: 1,
+		},
+		{
+			// расписание уже забрал другой экземпляр или его отменили: перевод не выполняется
+			name: "Расписание не захвачено",
+			mockBehavior: func(r *mock_repository.MockSchedules, shop *mock_usecase.MockShop) {
+				r.EXPECT().GetDueSchedules(now, dueSchedulesBatch).Return([]domain.ScheduledTransfer{due}, nil)
+				r.EXPECT().ClaimSchedule(1, due.NextRunAt, gomock.Any(), now).Return(false, nil)
+			},
+		},
+		{
+			name: "Некорректное расписание пропускается",
+			mockBehavior: func(r *mock_repository.MockSchedules, shop *mock_usecase.MockShop) {
+				broken := due
+				broken.Interval = "неделя"
+				r.EXPECT().GetDueSchedules(now, dueSchedulesBatch).Return([]domain.ScheduledTransfer{broken}, nil)
+			},
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockSchedules(c)
+			shop := mock_usecase.NewMockShop(c)
+			test.mockBehavior(repo, shop)
+			s := &ScheduleUsecase{repo: repo, shop: shop}
+
+			executed, err := s.RunDueSchedules(now)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantExecuted, executed)
+		})
+	}
+}
+
+func TestScheduleUsecase_RunDueSchedulesClaimError(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	now := time.Now()
+	repo := mock_repository.NewMockSchedules(c)
+	repo.EXPECT().GetDueSchedules(now, dueSchedulesBatch).Return([]domain.ScheduledTransfer{
+		{Id: 1, UserId: 1, Interval: "1h", NextRunAt: now},
+	}, nil)
+	repo.EXPECT().ClaimSchedule(1, now, gomock.Any(), now).Return(false, errors.New("connection refused"))
+	s := &ScheduleUsecase{repo: repo, shop: mock_usecase.NewMockShop(c)}
+
+	executed, err := s.RunDueSchedules(now)
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, 0, executed)
+}
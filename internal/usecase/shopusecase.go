@@ -1,33 +1,237 @@
 package usecase
 
 import (
+	"errors"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/repository"
 )
 
+const (
+	defaultPurchasesLimit = 20
+	maxPurchasesLimit     = 100
+	maxBatchTransfers     = 500
+)
+
 type ShopUsecase struct {
-	repo repository.Shop
+	repo   repository.Shop
+	limits domain.TransferLimits
 }
 
-func NewShopUsecase(repo *repository.Repository) *ShopUsecase {
+func NewShopUsecase(repo *repository.Repository, limits domain.TransferLimits) *ShopUsecase {
 	return &ShopUsecase{
-		repo: repo,
+		repo:   repo,
+		limits: limits,
 	}
 }
 
+// SendCoin проверяет сумму перевода, остальные лимиты проверяются репозиторием
+// в транзакции перевода, чтобы параллельные переводы не обошли их.
 func (s *ShopUsecase) SendCoin(userid int, input domain.Transactions) (int, error) {
+	if err := s.checkTransferAmount(input.Amount); err != nil {
+		return 0, err
+	}
 	input.Source = &userid
 	timestamp := time.Now()
 	input.Timestamp = &timestamp
-	return s.repo.SendCoin(input)
+	input.Message = sanitizeMessage(input.Message)
+	return s.repo.SendCoin(input, s.limits)
+}
+
+// SendCoinBatch выполняет пакет переводов и возвращает результат по каждому получателю.
+// Ошибки отдельных переводов не считаются ошибкой вызова и попадают в результат.
+func (s *ShopUsecase) SendCoinBatch(userid int, input domain.BatchTransferInput) (domain.BatchTransferResult, error) {
+	if len(input.Transfers) == 0 {
+		return domain.BatchTransferResult{}, domain.ErrEmptyBatch
+	}
+	if len(input.Transfers) > maxBatchTransfers {
+		return domain.BatchTransferResult{}, domain.ErrBatchTooLarge
+	}
+	if input.Mode == "" {
+		input.Mode = domain.BatchAtomic
+	}
+	result := domain.BatchTransferResult{Mode: input.Mode, Results: make([]domain.BatchTransferItem, len(input.Transfers))}
+	for i, transfer := range input.Transfers {
+		result.Results[i] = domain.BatchTransferItem{DestinationUsername: transfer.DestinationUsername, Amount: transfer.Amount}
+	}
+
+	if input.Mode == domain.BatchBestEffort {
+		for i, transfer := range input.Transfers {
+			id, err := s.SendCoin(userid, transfer)
+			setBatchItem(&result, i, id, err)
+		}
+		return result, nil
+	}
+
+	timestamp := time.Now()
+	transfers := make([]domain.Transactions, len(input.Transfers))
+	for i, transfer := range input.Transfers {
+		if err := s.checkTransferAmount(transfer.Amount); err != nil {
+			return abortBatch(result, i, err), nil
+		}
+		transfer.Timestamp = &timestamp
+		transfer.Message = sanitizeMessage(transfer.Message)
+		transfers[i] = transfer
+	}
+	ids, err := s.repo.SendCoinBatch(userid, transfers, s.limits)
+	if err != nil {
+		var itemErr *domain.BatchItemError
+		if errors.As(err, &itemErr) {
+			return abortBatch(result, itemErr.Index, itemErr.Err), nil
+		}
+		return domain.BatchTransferResult{}, err
+	}
+	for i, id := range ids {
+		setBatchItem(&result, i, id, nil)
+	}
+	return result, nil
+}
+
+// abortBatch помечает перевод failed ошибкой err, а остальные переводы пакета отмененными.
+func abortBatch(result domain.BatchTransferResult, failed int, err error) domain.BatchTransferResult {
+	for i := range result.Results {
+		if i == failed {
+			setBatchItem(&result, i, 0, err)
+		} else {
+			setBatchItem(&result, i, 0, domain.ErrBatchAborted)
+		}
+	}
+	return result
+}
+
+func setBatchItem(result *domain.BatchTransferResult, i, id int, err error) {
+	if err != nil {
+		result.Results[i].Error = err.Error()
+		result.Failed++
+		return
+	}
+	result.Results[i].TransactionId = &id
+	result.Succeeded++
+}
+
+func (s *ShopUsecase) checkTransferAmount(amount int) error {
+	if amount <= 0 {
+		return domain.ErrInvalidAmount
+	}
+	if s.limits.MaxAmount > 0 && amount > s.limits.MaxAmount {
+		return domain.ErrTransferTooLarge
+	}
+	return nil
+}
+
+func (s *ShopUsecase) BuyItem(userid int, name string, options domain.BuyOptions) (int, error) {
+	options.PromoCode = normalizePromoCode(options.PromoCode)
+	return s.repo.BuyItem(userid, name, options)
 }
 
-func (s *ShopUsecase) BuyItem(userid int, name string) (int, error) {
-	return s.repo.BuyItem(userid, name)
+func (s *ShopUsecase) CreateOrder(userid int, input domain.OrderInput) (int, error) {
+	lines, err := normalizeOrderLines(input.Items)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.CreateOrder(userid, lines, normalizePromoCode(input.PromoCode))
+}
+
+type orderLineKey struct {
+	name    string
+	variant domain.VariantSelector
+}
+
+// normalizeOrderLines объединяет повторяющиеся позиции и сортирует их по названию и варианту,
+// чтобы параллельные заказы блокировали строки товаров в одном порядке.
+func normalizeOrderLines(items []domain.OrderLine) ([]domain.OrderLine, error) {
+	if len(items) == 0 {
+		return nil, domain.ErrEmptyOrder
+	}
+	quantities := make(map[orderLineKey]int, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, domain.ErrInvalidAmount
+		}
+		quantities[orderLineKey{item.ItemName, item.VariantSelector}] += item.Quantity
+	}
+	lines := make([]domain.OrderLine, 0, len(quantities))
+	for key, quantity := range quantities {
+		lines = append(lines, domain.OrderLine{ItemName: key.name, VariantSelector: key.variant, Quantity: quantity})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].ItemName != lines[j].ItemName {
+			return lines[i].ItemName < lines[j].ItemName
+		}
+		if lines[i].Size != lines[j].Size {
+			return lines[i].Size < lines[j].Size
+		}
+		return lines[i].Colour < lines[j].Colour
+	})
+	return lines, nil
 }
 
 func (s *ShopUsecase) GetUserSummary(userID int) (*domain.UserSummary, error) {
 	return s.repo.GetUserSummary(userID)
 }
+
+func (s *ShopUsecase) GetPurchases(userID int, filter domain.PurchaseFilter) (*domain.PurchaseHistory, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPurchasesLimit
+	}
+	if filter.Limit > maxPurchasesLimit {
+		filter.Limit = maxPurchasesLimit
+	}
+	limit := filter.Limit
+	// запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	filter.Limit++
+	purchases, err := s.repo.GetPurchases(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	history := &domain.PurchaseHistory{Purchases: []domain.Purchase{}}
+	if len(purchases) > limit {
+		purchases = purchases[:limit]
+		last := purchases[limit-1]
+		history.NextCursor = domain.Cursor{Time: last.PurchaseDate, Id: last.Id}.Encode()
+	}
+	history.Purchases = append(history.Purchases, purchases...)
+	return history, nil
+}
+
+// sanitizeMessage убирает управляющие и невидимые символы из сообщения к переводу
+// и схлопывает повторяющиеся пробелы.
+func sanitizeMessage(message string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, message)
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+func (s *ShopUsecase) GetTransactions(userID int, filter domain.TransactionFilter) (*domain.TransactionHistory, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPurchasesLimit
+	}
+	if filter.Limit > maxPurchasesLimit {
+		filter.Limit = maxPurchasesLimit
+	}
+	limit := filter.Limit
+	filter.Limit++
+	transactions, err := s.repo.GetTransactions(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+	history := &domain.TransactionHistory{Transactions: []domain.Transactions{}}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		history.NextCursor = domain.Cursor{Time: *last.Timestamp, Id: last.Id}.Encode()
+	}
+	history.Transactions = append(history.Transactions, transactions...)
+	return history, nil
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+)
+
+type TreasuryUsecase struct {
+	repo       repository.Treasury
+	allowance  AllowanceConfig
+	coinExpiry time.Duration
+}
+
+func NewTreasuryUsecase(repo *repository.Repository, allowance AllowanceConfig, coinExpiry time.Duration) *TreasuryUsecase {
+	return &TreasuryUsecase{
+		repo:       repo,
+		allowance:  allowance,
+		coinExpiry: coinExpiry,
+	}
+}
+
+// coinExpiryDate возвращает момент сгорания монет, выданных казной в now, или nil, если монеты не сгорают.
+func coinExpiryDate(now time.Time, expiry time.Duration) *time.Time {
+	if expiry <= 0 {
+		return nil
+	}
+	expiresAt := now.Add(expiry)
+	return &expiresAt
+}
+
+func (s *TreasuryUsecase) GrantCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error) {
+	input.Reason = strings.TrimSpace(input.Reason)
+	if input.Reason == "" {
+		return domain.CoinAdjustment{}, domain.ErrAdjustmentReasonRequired
+	}
+	return s.repo.GrantCoins(adminId, input, coinExpiryDate(time.Now(), s.coinExpiry))
+}
+
+func (s *TreasuryUsecase) ClawbackCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error) {
+	input.Reason = strings.TrimSpace(input.Reason)
+	if input.Reason == "" {
+		return domain.CoinAdjustment{}, domain.ErrAdjustmentReasonRequired
+	}
+	return s.repo.ClawbackCoins(adminId, input)
+}
+
+// PayAllowance выплачивает ежемесячное начисление за месяц, в который попадает now.
+// При нулевой сумме начисление отключено.
+func (s *TreasuryUsecase) PayAllowance(now time.Time) (int, error) {
+	if s.allowance.Amount <= 0 {
+		return 0, nil
+	}
+	period := now.UTC().Format("2006-01")
+	return s.repo.PayAllowance(period, s.allowance.Amount, now.Add(-s.allowance.ActiveWindow), coinExpiryDate(now, s.coinExpiry))
+}
+
+func (s *TreasuryUsecase) ExpireCoins(now time.Time) (int, error) {
+	return s.repo.ExpireCoins(now)
+}
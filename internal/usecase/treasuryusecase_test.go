@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTreasuryUsecase_GrantCoins(t *testing.T) {
+	testTable := []struct {
+		name       string
+		coinExpiry time.Duration
+		input      domain.CoinAdjustmentInput
+		wantExpiry bool
+		wantErr    error
+	}{
+		{
+			name:       "Монеты сгорают",
+			coinExpiry: 90 * 24 * time.Hour,
+			input:      domain.CoinAdjustmentInput{Username: "anna", Amount: 100, Reason: " премия "},
+			wantExpiry: true,
+		},
+		{
+			name:  "Без срока",
+			input: domain.CoinAdjustmentInput{Username: "anna", Amount: 100, Reason: "премия"},
+		},
+		{
+			name:       "Нет причины",
+			coinExpiry: 90 * 24 * time.Hour,
+			input:      domain.CoinAdjustmentInput{Username: "anna", Amount: 100, Reason: "  "},
+			wantErr:    domain.ErrAdjustmentReasonRequired,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockTreasury(c)
+			if test.wantErr == nil {
+				repo.EXPECT().GrantCoins(1, gomock.Any(), gomock.Any()).DoAndReturn(
+					func(adminId int, input domain.CoinAdjustmentInput, expiresAt *time.Time) (domain.CoinAdjustment, error) {
+						assert.Equal(t, "премия", input.Reason)
+						if test.wantExpiry {
+							assert.WithinDuration(t, time.Now().Add(test.coinExpiry), *expiresAt, time.Minute)
+						} else {
+							assert.Nil(t, expiresAt)
+						}
+						return domain.CoinAdjustment{}, nil
+					})
+			}
+			s := &TreasuryUsecase{repo: repo, coinExpiry: test.coinExpiry}
+
+			_, err := s.GrantCoins(1, test.input)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTreasuryUsecase_PayAllowance(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 5, 0, 0, time.UTC)
+	expiresAt := now.Add(30 * 24 * time.Hour)
+
+	t.Run("Начисление", func(t *testing.T) {
+		c := gomock.NewController(t)
+		defer c.Finish()
+
+		repo := mock_repository.NewMockTreasury(c)
+		repo.EXPECT().PayAllowance("2025-03", 50, now.Add(-720*time.Hour), &expiresAt).Return(3, nil)
+		s := &TreasuryUsecase{repo: repo, allowance: AllowanceConfig{Amount: 50, ActiveWindow: 720 * time.Hour}, coinExpiry: 30 * 24 * time.Hour}
+
+		paid, err := s.PayAllowance(now)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, paid)
+	})
+
+	t.Run("Начисление отключено", func(t *testing.T) {
+		c := gomock.NewController(t)
+		defer c.Finish()
+
+		s := &TreasuryUsecase{repo: mock_repository.NewMockTreasury(c)}
+
+		paid, err := s.PayAllowance(now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, paid)
+	})
+}
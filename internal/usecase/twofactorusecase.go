@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+	"github.com/bllooop/coinshop/pkg/totp"
+)
+
+const (
+	// totpSkew допускает расхождение часов клиента на один интервал в обе стороны.
+	totpSkew = 1
+	// recoveryCodeLength - длина кода восстановления без дефиса, 50 бит энтропии.
+	recoveryCodeLength = 10
+)
+
+type TwoFactorUsecase struct {
+	repo repository.TwoFactor
+	auth *AuthUsecase
+	cfg  TwoFactorConfig
+}
+
+func NewTwoFactorUsecase(repo *repository.Repository, auth *AuthUsecase, cfg TwoFactorConfig) *TwoFactorUsecase {
+	return &TwoFactorUsecase{
+		repo: repo,
+		auth: auth,
+		cfg:  cfg,
+	}
+}
+
+// EnrollTwoFactor создает секрет, который включается после подтверждения первым кодом.
+func (s *TwoFactorUsecase) EnrollTwoFactor(userId int) (domain.TwoFactorEnrollment, error) {
+	state, err := s.repo.GetTwoFactor(userId)
+	if err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+	if state.Enabled {
+		return domain.TwoFactorEnrollment{}, domain.ErrTwoFactorEnabled
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+	if err = s.repo.SetTwoFactorSecret(userId, secret); err != nil {
+		return domain.TwoFactorEnrollment{}, err
+	}
+	return domain.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.cfg.Issuer, state.UserName, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor включает двухфакторную аутентификацию, если код соответствует секрету,
+// и возвращает коды восстановления. Они показываются один раз, в базе хранятся только хеши.
+func (s *TwoFactorUsecase) ConfirmTwoFactor(userId int, code string) ([]string, error) {
+	state, err := s.repo.GetTwoFactor(userId)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+	if state.Secret == nil {
+		return nil, domain.ErrTwoFactorNotEnrolled
+	}
+	step, ok := totp.Validate(*state.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.EnableTwoFactor(userId, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor отключает двухфакторную аутентификацию после проверки пароля и кода.
+// У пользователя без пароля, входящего через внешнего провайдера, проверяется только код.
+func (s *TwoFactorUsecase) DisableTwoFactor(userId int, input domain.TwoFactorDisableInput) error {
+	user, err := s.auth.repo.GetUser(userId)
+	if err != nil {
+		return err
+	}
+	if user.Password != "" && !s.auth.hasher.Verify(user.Password, input.Password) {
+		return domain.ErrWrongPassword
+	}
+	if err = s.checkCode(userId, input.Code, true); err != nil {
+		return err
+	}
+	return s.repo.DisableTwoFactor(userId)
+}
+
+// RegenerateRecoveryCodes заменяет все коды восстановления новыми. Подтверждается только кодом
+// из приложения, чтобы утекший код восстановления нельзя было обменять на новые. Неверные коды
+// учитываются в тех же счетчиках, что и на втором шаге входа, иначе здесь можно было бы перебирать
+// коды с украденным токеном доступа.
+func (s *TwoFactorUsecase) RegenerateRecoveryCodes(userId int, code, clientIP string) ([]string, error) {
+	state, err := s.repo.GetTwoFactor(userId)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkCodeThrottled(userId, state.UserName, code, clientIP, false); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repo.ReplaceRecoveryCodes(userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// StartTwoFactorSignIn выдает промежуточный токен после проверки пароля.
+func (s *TwoFactorUsecase) StartTwoFactorSignIn(userId int) (string, error) {
+	state, err := s.auth.repo.GetTokenState(userId)
+	if err != nil {
+		return "", err
+	}
+	return signToken(userId, state.Version, twoFactorPurpose, twoFactorTokenTTL)
+}
+
+// CompleteTwoFactorSignIn проверяет код второго шага входа. Неверные коды учитываются так же,
+// как неверные пароли, и приводят к блокировке входа.
+func (s *TwoFactorUsecase) CompleteTwoFactorSignIn(input domain.TwoFactorSignInInput, clientIP string) (domain.User, error) {
+	claims, err := parseToken(input.Token)
+	if err != nil || claims.Purpose != twoFactorPurpose {
+		return domain.User{}, domain.ErrTokenRevoked
+	}
+	tokenState, err := s.auth.repo.GetTokenState(claims.UserId)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.User{}, domain.ErrTokenRevoked
+		}
+		return domain.User{}, err
+	}
+	if tokenState.Version != claims.TokenVersion {
+		return domain.User{}, domain.ErrTokenRevoked
+	}
+	if tokenState.Banned {
+		return domain.User{}, domain.ErrUserBanned
+	}
+	state, err := s.repo.GetTwoFactor(claims.UserId)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if err = s.checkCodeThrottled(claims.UserId, state.UserName, input.Code, clientIP, true); err != nil {
+		return domain.User{}, err
+	}
+	if err = s.auth.completeLogin(claims.UserId, state.UserName, time.Now()); err != nil {
+		return domain.User{}, err
+	}
+	return domain.User{
+		Id:                    claims.UserId,
+		UserName:              state.UserName,
+		PasswordResetRequired: tokenState.PasswordResetRequired,
+		TwoFactorEnabled:      true,
+	}, nil
+}
+
+// CheckRolePolicy возвращает ErrTwoFactorRequired, если роль требует двухфакторной аутентификации,
+// а пользователь ее не включил.
+func (s *TwoFactorUsecase) CheckRolePolicy(userId int, role string) error {
+	if !slices.Contains(s.cfg.RequiredRoles, role) {
+		return nil
+	}
+	state, err := s.repo.GetTwoFactor(userId)
+	if err != nil {
+		return err
+	}
+	if !state.Enabled {
+		return domain.ErrTwoFactorRequired
+	}
+	return nil
+}
+
+// checkCodeThrottled проверяет код с учетом блокировки входа: пока вход заблокирован или не прошла
+// задержка, код не проверяется, а неверный код считается неудачной попыткой входа.
+func (s *TwoFactorUsecase) checkCodeThrottled(userId int, username, code, clientIP string, allowRecovery bool) error {
+	now := time.Now()
+	if err := s.auth.checkLoginThrottle(username, clientIP, now); err != nil {
+		return err
+	}
+	err := s.checkCode(userId, code, allowRecovery)
+	if !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		return err
+	}
+	locked, recordErr := s.auth.recordLoginFailure(username, clientIP, now)
+	if recordErr != nil {
+		return recordErr
+	}
+	if locked {
+		return &domain.LoginThrottledError{Until: now.Add(s.auth.login.Lockout), Locked: true, Started: true}
+	}
+	return err
+}
+
+// checkCode принимает код из приложения, а при allowRecovery и код восстановления.
+// Каждый код принимается только один раз.
+func (s *TwoFactorUsecase) checkCode(userId int, code string, allowRecovery bool) error {
+	state, err := s.repo.GetTwoFactor(userId)
+	if err != nil {
+		return err
+	}
+	if !state.Enabled || state.Secret == nil {
+		return domain.ErrTwoFactorNotEnrolled
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(*state.Secret, code, time.Now(), totpSkew)
+		if !ok {
+			return domain.ErrInvalidTwoFactorCode
+		}
+		fresh, err := s.repo.UseTotpStep(userId, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return domain.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+	if !allowRecovery {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	used, err := s.repo.UseRecoveryCode(userId, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// newRecoveryCodes создает коды восстановления вида XXXXX-XXXXX и их хеши для хранения.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, domain.RecoveryCodeCount)
+	hashes := make([]string, 0, domain.RecoveryCodeCount)
+	buf := make([]byte, 7)
+	for range domain.RecoveryCodeCount {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := base32.StdEncoding.EncodeToString(buf)[:recoveryCodeLength]
+		codes = append(codes, raw[:recoveryCodeLength/2]+"-"+raw[recoveryCodeLength/2:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode хеширует код без учета регистра, пробелов и дефиса. Коды случайные и длинные,
+// поэтому медленный хеш, как для паролей, не нужен.
+func hashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
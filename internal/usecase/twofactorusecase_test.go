@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	mock_repository "github.com/bllooop/coinshop/internal/repository/mocks"
+	"github.com/bllooop/coinshop/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTwoFactorUsecase_DisableTwoFactor(t *testing.T) {
+	hasher := newTestHasher(t)
+	hash, err := hasher.Hash("password123")
+	assert.NoError(t, err)
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	assert.NoError(t, err)
+	enabled := domain.TwoFactorState{UserName: "anna", Secret: &secret, Enabled: true}
+
+	type mockBehavior func(a *mock_repository.MockAuthorization, r *mock_repository.MockTwoFactor)
+
+	testTable := []struct {
+		name         string
+		input        domain.TwoFactorDisableInput
+		mockBehavior mockBehavior
+		wantErr      error
+	}{
+		{
+			name:  "Пароль и код",
+			input: domain.TwoFactorDisableInput{Password: "password123", Code: code},
+			mockBehavior: func(a *mock_repository.MockAuthorization, r *mock_repository.MockTwoFactor) {
+				a.EXPECT().GetUser(1).Return(domain.User{Id: 1, Password: hash}, nil)
+				r.EXPECT().GetTwoFactor(1).Return(enabled, nil)
+				r.EXPECT().UseTotpStep(1, gomock.Any()).Return(true, nil)
+				r.EXPECT().DisableTwoFactor(1).Return(nil)
+			},
+		},
+		{
+			name:  "Неверный пароль",
+			input: domain.TwoFactorDisableInput{Password: "wrong", Code: code},
+			mockBehavior: func(a *mock_repository.MockAuthorization, r *mock_repository.MockTwoFactor) {
+				a.EXPECT().GetUser(1).Return(domain.User{Id: 1, Password: hash}, nil)
+			},
+			wantErr: domain.ErrWrongPassword,
+		},
+		{
+			// у пользователя внешнего провайдера пароля нет, повторной проверкой служит код
+			name:  "Без пароля - код",
+			input: domain.TwoFactorDisableInput{Code: code},
+			mockBehavior: func(a *mock_repository.MockAuthorization, r *mock_repository.MockTwoFactor) {
+				a.EXPECT().GetUser(1).Return(domain.User{Id: 1}, nil)
+				r.EXPECT().GetTwoFactor(1).Return(enabled, nil)
+				r.EXPECT().UseTotpStep(1, gomock.Any()).Return(true, nil)
+				r.EXPECT().DisableTwoFactor(1).Return(nil)
+			},
+		},
+		{
+			name:  "Без пароля - неверный код",
+			input: domain.TwoFactorDisableInput{Code: "000000"},
+			mockBehavior: func(a *mock_repository.MockAuthorization, r *mock_repository.MockTwoFactor) {
+				a.EXPECT().GetUser(1).Return(domain.User{Id: 1}, nil)
+				r.EXPECT().GetTwoFactor(1).Return(enabled, nil)
+				// 000000 может случайно совпасть с текущим кодом, тогда шаг отклоняется как уже использованный
+				r.EXPECT().UseTotpStep(1, gomock.Any()).Return(false, nil).MaxTimes(1)
+			},
+			wantErr: domain.ErrInvalidTwoFactorCode,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_repository.NewMockAuthorization(c)
+			repo := mock_repository.NewMockTwoFactor(c)
+			test.mockBehavior(auth, repo)
+			s := &TwoFactorUsecase{repo: repo, auth: &AuthUsecase{repo: auth, hasher: hasher}}
+
+			err := s.DisableTwoFactor(1, test.input)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTwoFactorUsecase_RegenerateRecoveryCodes(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	assert.NoError(t, err)
+	enabled := domain.TwoFactorState{UserName: "anna", Secret: &secret, Enabled: true}
+	login := LoginConfig{MaxFailures: 3, MaxFailuresPerIP: 10, Delay: time.Second, Lockout: 15 * time.Minute}
+	lockedUntil := time.Now().Add(10 * time.Minute)
+
+	type mockBehavior func(r *mock_repository.MockTwoFactor, a *mock_repository.MockLoginAttempts)
+
+	testTable := []struct {
+		name         string
+		code         string
+		mockBehavior mockBehavior
+		wantErr      error
+		wantLocked   bool
+	}{
+		{
+			name: "OK",
+			code: code,
+			mockBehavior: func(r *mock_repository.MockTwoFactor, a *mock_repository.MockLoginAttempts) {
+				r.EXPECT().GetTwoFactor(1).Return(enabled, nil).Times(2)
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return(nil, nil)
+				r.EXPECT().UseTotpStep(1, gomock.Any()).Return(true, nil)
+				r.EXPECT().ReplaceRecoveryCodes(1, gomock.Len(domain.RecoveryCodeCount)).Return(nil)
+			},
+		},
+		{
+			// код восстановления не принимается и считается неудачной попыткой
+			name: "Код восстановления",
+			code: "ABCDE-FGHIJ",
+			mockBehavior: func(r *mock_repository.MockTwoFactor, a *mock_repository.MockLoginAttempts) {
+				r.EXPECT().GetTwoFactor(1).Return(enabled, nil).Times(2)
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return(nil, nil)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeUsername, "anna", gomock.Any(), gomock.Any()).Return(1, nil)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeIP, "10.0.0.1", gomock.Any(), gomock.Any()).Return(1, nil)
+			},
+			wantErr: domain.ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "Неверный код - блокировка",
+			code: "ABCDE-FGHIJ",
+			mockBehavior: func(r *mock_repository.MockTwoFactor, a *mock_repository.MockLoginAttempts) {
+				r.EXPECT().GetTwoFactor(1).Return(enabled, nil).Times(2)
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return(nil, nil)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeUsername, "anna", gomock.Any(), gomock.Any()).Return(3, nil)
+				a.EXPECT().LockLogin(domain.LoginScopeUsername, "anna", gomock.Any()).Return(nil)
+				a.EXPECT().RecordLoginFailure(domain.LoginScopeIP, "10.0.0.1", gomock.Any(), gomock.Any()).Return(3, nil)
+			},
+			wantLocked: true,
+		},
+		{
+			// во время блокировки даже верный код не проверяется
+			name: "Вход заблокирован",
+			code: code,
+			mockBehavior: func(r *mock_repository.MockTwoFactor, a *mock_repository.MockLoginAttempts) {
+				r.EXPECT().GetTwoFactor(1).Return(enabled, nil)
+				a.EXPECT().GetLoginAttempts("anna", "10.0.0.1").Return([]domain.LoginAttempt{
+					{Scope: domain.LoginScopeUsername, Value: "anna", Failures: 3, LockedUntil: &lockedUntil},
+				}, nil)
+			},
+			wantLocked: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockTwoFactor(c)
+			attempts := mock_repository.NewMockLoginAttempts(c)
+			test.mockBehavior(repo, attempts)
+			s := &TwoFactorUsecase{repo: repo, auth: &AuthUsecase{attempts: attempts, login: login}}
+
+			codes, err := s.RegenerateRecoveryCodes(1, test.code, "10.0.0.1")
+			switch {
+			case test.wantLocked:
+				var throttled *domain.LoginThrottledError
+				assert.ErrorAs(t, err, &throttled)
+				assert.True(t, throttled.Locked)
+			case test.wantErr != nil:
+				assert.ErrorIs(t, err, test.wantErr)
+			default:
+				assert.NoError(t, err)
+				assert.Len(t, codes, domain.RecoveryCodeCount)
+			}
+		})
+	}
+}
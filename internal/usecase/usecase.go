@@ -1,30 +1,157 @@
 package usecase
 
 import (
+	"time"
+
 	"github.com/bllooop/coinshop/internal/domain"
 	"github.com/bllooop/coinshop/internal/repository"
+	"github.com/bllooop/coinshop/pkg/passhash"
 )
 
 //go:generate mockgen -source=usecase.go -destination=mocks/mock.go
 type Authorization interface {
 	CreateUser(user domain.User) (int, error)
-	SignUser(username, password string) (domain.User, error)
+	SignUser(username, password, clientIP string) (domain.User, error)
 	GenerateToken(userId int) (string, error)
-	ParseToken(accessToken string) (int, error)
+	ParseToken(accessToken string) (domain.Session, error)
+	GetUserRole(userId int) (string, error)
+	ChangePassword(userId int, input domain.PasswordChangeInput) (string, error)
+	ChangeUsername(userId int, input domain.UsernameChangeInput) error
+	DeleteAccount(userId int, input domain.AccountDeleteInput) error
+	UnlockLogin(input domain.LoginUnlockInput) (int, error)
+	PurgeLoginAttempts(now time.Time) (int, error)
 }
 type Shop interface {
-	BuyItem(userid int, name string) (int, error)
+	BuyItem(userid int, name string, options domain.BuyOptions) (int, error)
+	CreateOrder(userid int, input domain.OrderInput) (int, error)
 	SendCoin(userid int, input domain.Transactions) (int, error)
+	SendCoinBatch(userid int, input domain.BatchTransferInput) (domain.BatchTransferResult, error)
 	GetUserSummary(userID int) (*domain.UserSummary, error)
+	GetPurchases(userID int, filter domain.PurchaseFilter) (*domain.PurchaseHistory, error)
+	GetTransactions(userID int, filter domain.TransactionFilter) (*domain.TransactionHistory, error)
+}
+type Inventory interface {
+	GetItems() ([]domain.Merch, error)
+	Restock(name string, quantity int) (domain.ItemStockChange, error)
+	UpdateItemStock(name string, input domain.ItemStockInput) (domain.ItemStockChange, error)
+	CreateVariant(name string, input domain.VariantInput) (domain.ItemVariant, error)
+	UpdateVariantStock(name string, variantId int, input domain.VariantStockInput) (domain.VariantStockChange, error)
+}
+type Refunds interface {
+	RequestRefund(userId, purchaseId int, reason string) (int, error)
+	GetRefunds(status string) ([]domain.Refund, error)
+	ApproveRefund(adminId, refundId int) (domain.Refund, error)
+	RejectRefund(adminId, refundId int) (domain.Refund, error)
+	ForceRefund(adminId, purchaseId int, reason string) (domain.Refund, error)
+}
+type Fulfillment interface {
+	GetPurchasesByStatus(status string) ([]domain.Purchase, error)
+	UpdatePurchaseStatus(adminId, purchaseId int, input domain.PurchaseStatusInput) (domain.Purchase, error)
+}
+type Promotions interface {
+	CreatePromotion(input domain.PromotionInput) (domain.Promotion, error)
+	GetPromotions() ([]domain.Promotion, error)
+	EndPromotion(id int) (domain.Promotion, error)
+}
+type Schedules interface {
+	CreateSchedule(userId int, input domain.ScheduleInput) (domain.ScheduledTransfer, error)
+	GetSchedules(userId int) ([]domain.ScheduledTransfer, error)
+	CancelSchedule(userId, scheduleId int) error
+	GetScheduleRuns(userId, scheduleId int) ([]domain.ScheduleRun, error)
+	RunDueSchedules(now time.Time) (int, error)
+}
+type Treasury interface {
+	GrantCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error)
+	ClawbackCoins(adminId int, input domain.CoinAdjustmentInput) (domain.CoinAdjustment, error)
+	PayAllowance(now time.Time) (int, error)
+	ExpireCoins(now time.Time) (int, error)
+}
+type PaymentRequests interface {
+	CreatePaymentRequest(userId int, input domain.PaymentRequestInput) (domain.PaymentRequest, error)
+	GetPaymentRequests(userId int, filter domain.PaymentRequestFilter) ([]domain.PaymentRequest, error)
+	AcceptPaymentRequest(userId, requestId int) (domain.PaymentRequest, error)
+	DeclinePaymentRequest(userId, requestId int) (domain.PaymentRequest, error)
+	CancelPaymentRequest(userId, requestId int) (domain.PaymentRequest, error)
+	ExpirePaymentRequests(now time.Time) (int, error)
+}
+type Escrows interface {
+	CreateEscrow(userId int, input domain.EscrowInput) (domain.Escrow, error)
+	GetEscrows(userId int, status string) ([]domain.Escrow, error)
+	ReleaseEscrow(userId, escrowId int) (domain.Escrow, error)
+	ReturnEscrow(userId, escrowId int) (domain.Escrow, error)
+	SettleExpiredEscrows(now time.Time) (int, error)
+}
+type Users interface {
+	SearchUsers(filter domain.UserFilter) ([]domain.UserInfo, error)
+	GetUserSummary(userId int) (*domain.UserSummary, error)
+	BanUser(adminId, userId int, input domain.BanInput) (domain.UserInfo, error)
+	GetUser(userId int) (domain.UserInfo, error)
+	UnbanUser(userId int) (domain.UserInfo, error)
+	ResetPassword(userId int) (string, error)
+}
+type Audit interface {
+	Record(event domain.AuditEvent) error
+	GetAuditLog(filter domain.AuditFilter) ([]domain.AuditEntry, error)
+	VerifyAuditLog() (domain.AuditVerification, error)
+}
+type TwoFactor interface {
+	EnrollTwoFactor(userId int) (domain.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(userId int, code string) ([]string, error)
+	DisableTwoFactor(userId int, input domain.TwoFactorDisableInput) error
+	RegenerateRecoveryCodes(userId int, code, clientIP string) ([]string, error)
+	StartTwoFactorSignIn(userId int) (string, error)
+	CompleteTwoFactorSignIn(input domain.TwoFactorSignInInput, clientIP string) (domain.User, error)
+	CheckRolePolicy(userId int, role string) error
+}
+type ApiKeys interface {
+	CreateServiceAccount(adminId int, input domain.ServiceAccountInput) (domain.ServiceAccount, error)
+	GetServiceAccounts() ([]domain.ServiceAccount, error)
+	CreateApiKey(adminId, accountId int, input domain.ApiKeyInput) (domain.CreatedApiKey, error)
+	GetApiKeys(accountId int) ([]domain.ApiKey, error)
+	RevokeApiKey(keyId int) (domain.ApiKey, error)
+	AuthenticateApiKey(key string) (domain.ApiKeyIdentity, error)
+}
+type Oidc interface {
+	StartOidcLogin() (domain.OidcLogin, error)
+	CompleteOidcLogin(input domain.OidcCallbackInput) (domain.User, error)
+	PurgeOidcStates(now time.Time) (int, error)
 }
 type Usecase struct {
 	Authorization
 	Shop
+	Inventory
+	Refunds
+	Fulfillment
+	Promotions
+	Schedules
+	Treasury
+	PaymentRequests
+	Escrows
+	Users
+	Audit
+	TwoFactor
+	ApiKeys
+	Oidc
 }
 
-func NewUsecase(repo *repository.Repository) *Usecase {
+func NewUsecase(repo *repository.Repository, hasher passhash.Hasher, cfg Config) *Usecase {
+	shop := NewShopUsecase(repo, cfg.Transfer)
+	auth := NewAuthUsecase(repo, hasher, cfg.SignupBonus, cfg.CoinExpiry, cfg.Login)
 	return &Usecase{
-		Authorization: NewAuthUsecase(repo),
-		Shop:          NewShopUsecase(repo),
+		Authorization:   auth,
+		Shop:            shop,
+		Inventory:       NewInventoryUsecase(repo),
+		Refunds:         NewRefundUsecase(repo, cfg.Refund),
+		Fulfillment:     NewFulfillmentUsecase(repo),
+		Promotions:      NewPromotionUsecase(repo),
+		Schedules:       NewScheduleUsecase(repo, shop),
+		Treasury:        NewTreasuryUsecase(repo, cfg.Allowance, cfg.CoinExpiry),
+		PaymentRequests: NewPaymentRequestUsecase(repo, cfg.Transfer, cfg.PaymentRequestTTL),
+		Escrows:         NewEscrowUsecase(repo, cfg.Transfer),
+		Users:           NewUserUsecase(repo, hasher),
+		Audit:           NewAuditUsecase(repo),
+		TwoFactor:       NewTwoFactorUsecase(repo, auth, cfg.TwoFactor),
+		ApiKeys:         NewApiKeyUsecase(repo, cfg.ApiKeys),
+		Oidc:            NewOidcUsecase(repo, auth, cfg.Oidc),
 	}
 }
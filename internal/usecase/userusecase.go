@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/bllooop/coinshop/internal/domain"
+	"github.com/bllooop/coinshop/internal/repository"
+	"github.com/bllooop/coinshop/pkg/passhash"
+)
+
+const (
+	defaultUserSearchLimit = 20
+	maxUserSearchLimit     = 100
+	// temporaryPasswordBytes дает временный пароль из 16 символов base64.
+	temporaryPasswordBytes = 12
+)
+
+type UserUsecase struct {
+	repo   repository.Users
+	shop   repository.Shop
+	hasher passhash.Hasher
+}
+
+func NewUserUsecase(repo *repository.Repository, hasher passhash.Hasher) *UserUsecase {
+	return &UserUsecase{
+		repo:   repo,
+		shop:   repo,
+		hasher: hasher,
+	}
+}
+
+func (s *UserUsecase) SearchUsers(filter domain.UserFilter) ([]domain.UserInfo, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserSearchLimit
+	}
+	filter.Limit = min(filter.Limit, maxUserSearchLimit)
+	filter.Offset = max(filter.Offset, 0)
+	filter.Query = strings.TrimSpace(filter.Query)
+	return s.repo.SearchUsers(filter)
+}
+
+func (s *UserUsecase) GetUser(userId int) (domain.UserInfo, error) {
+	return s.repo.GetUserInfo(userId)
+}
+
+func (s *UserUsecase) GetUserSummary(userId int) (*domain.UserSummary, error) {
+	if _, err := s.repo.GetUserInfo(userId); err != nil {
+		return nil, err
+	}
+	return s.shop.GetUserSummary(userId)
+}
+
+func (s *UserUsecase) BanUser(adminId, userId int, input domain.BanInput) (domain.UserInfo, error) {
+	if adminId == userId {
+		return domain.UserInfo{}, domain.ErrSelfBan
+	}
+	return s.repo.BanUser(userId, adminId, strings.TrimSpace(input.Reason), time.Now())
+}
+
+func (s *UserUsecase) UnbanUser(userId int) (domain.UserInfo, error) {
+	return s.repo.UnbanUser(userId)
+}
+
+// ResetPassword заменяет пароль пользователя случайным временным и возвращает его администратору.
+// Выданные токены отзываются, а после входа с временным паролем доступна только его смена.
+func (s *UserUsecase) ResetPassword(userId int) (string, error) {
+	buf := make([]byte, temporaryPasswordBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	password := base64.RawURLEncoding.EncodeToString(buf)
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.ResetPassword(userId, hash); err != nil {
+		return "", err
+	}
+	return password, nil
+}
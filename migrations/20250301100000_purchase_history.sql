@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE purchases SET purchase_date = now() WHERE purchase_date IS NULL;
+ALTER TABLE purchases ALTER COLUMN purchase_date SET NOT NULL;
+
+CREATE INDEX idx_purchases_user_date ON purchases(user_id, purchase_date DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_purchases_user_date;
+ALTER TABLE purchases ALTER COLUMN purchase_date DROP NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE shop ADD COLUMN stock int CHECK (stock >= 0);
+ALTER TABLE shop ADD COLUMN per_user_limit int CHECK (per_user_limit > 0);
+
+UPDATE shop SET stock = 100 WHERE name IN ('t-shirt', 'cup', 'book', 'pen', 'powerbank', 'hoody', 'umbrella', 'socks', 'wallet');
+UPDATE shop SET stock = 20, per_user_limit = 1 WHERE name = 'pink-hoody';
+
+ALTER TABLE userlist ADD COLUMN role varchar(20) NOT NULL DEFAULT 'user';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE userlist DROP COLUMN role;
+ALTER TABLE shop DROP COLUMN per_user_limit;
+ALTER TABLE shop DROP COLUMN stock;
+-- +goose StatementEnd
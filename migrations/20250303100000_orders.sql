@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE orders
+(
+    id serial PRIMARY KEY,
+    user_id int NOT NULL,
+    total int NOT NULL CHECK (total >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    FOREIGN KEY (user_id) REFERENCES userlist(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_orders_user_id ON orders(user_id);
+
+ALTER TABLE purchases ADD COLUMN order_id int REFERENCES orders(id) ON DELETE CASCADE;
+ALTER TABLE purchases ADD COLUMN quantity int NOT NULL DEFAULT 1 CHECK (quantity > 0);
+
+CREATE INDEX idx_purchases_order_id ON purchases(order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_purchases_order_id;
+ALTER TABLE purchases DROP COLUMN quantity;
+ALTER TABLE purchases DROP COLUMN order_id;
+DROP TABLE orders;
+-- +goose StatementEnd
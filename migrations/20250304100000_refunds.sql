@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE purchases ADD COLUMN refunded_at TIMESTAMP;
+
+ALTER TABLE transactions ALTER COLUMN source DROP NOT NULL;
+ALTER TABLE transactions ADD COLUMN kind varchar(20) NOT NULL DEFAULT 'transfer';
+ALTER TABLE transactions ADD COLUMN purchase_id int REFERENCES purchases(id) ON DELETE SET NULL;
+
+CREATE TABLE refunds
+(
+    id serial PRIMARY KEY,
+    purchase_id int NOT NULL,
+    user_id int NOT NULL,
+    amount int NOT NULL CHECK (amount >= 0),
+    status varchar(20) NOT NULL,
+    reason varchar(500) NOT NULL DEFAULT '',
+    transaction_id int,
+    resolved_by int,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMP,
+    FOREIGN KEY (purchase_id) REFERENCES purchases(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES userlist(id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE SET NULL,
+    FOREIGN KEY (resolved_by) REFERENCES userlist(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_refunds_status ON refunds(status);
+CREATE UNIQUE INDEX idx_refunds_open_purchase ON refunds(purchase_id) WHERE status IN ('requested', 'approved');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE refunds;
+DELETE FROM transactions WHERE source IS NULL;
+ALTER TABLE transactions DROP COLUMN purchase_id;
+ALTER TABLE transactions DROP COLUMN kind;
+ALTER TABLE transactions ALTER COLUMN source SET NOT NULL;
+ALTER TABLE purchases DROP COLUMN refunded_at;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE purchases ADD COLUMN status varchar(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE purchases ADD COLUMN packed_at TIMESTAMP;
+ALTER TABLE purchases ADD COLUMN shipped_at TIMESTAMP;
+ALTER TABLE purchases ADD COLUMN cancelled_at TIMESTAMP;
+
+UPDATE purchases SET status = 'cancelled', cancelled_at = refunded_at WHERE refunded_at IS NOT NULL;
+
+CREATE INDEX idx_purchases_status ON purchases(status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_purchases_status;
+ALTER TABLE purchases DROP COLUMN cancelled_at;
+ALTER TABLE purchases DROP COLUMN shipped_at;
+ALTER TABLE purchases DROP COLUMN packed_at;
+ALTER TABLE purchases DROP COLUMN status;
+-- +goose StatementEnd
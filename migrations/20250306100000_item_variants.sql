@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE item_variants
+(
+    id serial PRIMARY KEY,
+    item_id int NOT NULL,
+    size varchar(20) NOT NULL DEFAULT '',
+    colour varchar(30) NOT NULL DEFAULT '',
+    price_delta int NOT NULL DEFAULT 0,
+    stock int CHECK (stock >= 0),
+    FOREIGN KEY (item_id) REFERENCES shop(id) ON DELETE CASCADE,
+    UNIQUE (item_id, size, colour)
+);
+
+ALTER TABLE purchases ADD COLUMN variant_id int REFERENCES item_variants(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE purchases DROP COLUMN variant_id;
+DROP TABLE item_variants;
+-- +goose StatementEnd
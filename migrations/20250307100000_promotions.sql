@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE shop ADD COLUMN category varchar(30) NOT NULL DEFAULT '';
+
+UPDATE shop SET category = 'clothes' WHERE name IN ('t-shirt', 'hoody', 'socks', 'pink-hoody');
+UPDATE shop SET category = 'accessories' WHERE name IN ('cup', 'pen', 'powerbank', 'umbrella', 'wallet');
+UPDATE shop SET category = 'books' WHERE name = 'book';
+
+CREATE TABLE promotions
+(
+    id serial PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    kind varchar(20) NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value int NOT NULL CHECK (value > 0),
+    item_id int,
+    category varchar(30),
+    code varchar(50) UNIQUE,
+    usage_limit int CHECK (usage_limit > 0),
+    used_count int NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    FOREIGN KEY (item_id) REFERENCES shop(id) ON DELETE CASCADE,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_promotions_window ON promotions(starts_at, ends_at);
+
+ALTER TABLE purchases ADD COLUMN base_price int;
+UPDATE purchases SET base_price = price;
+ALTER TABLE purchases ADD COLUMN promotion_id int REFERENCES promotions(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE purchases DROP COLUMN promotion_id;
+ALTER TABLE purchases DROP COLUMN base_price;
+DROP TABLE promotions;
+ALTER TABLE shop DROP COLUMN category;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE transactions ADD COLUMN message varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN category varchar(20) NOT NULL DEFAULT ''
+    CHECK (category IN ('', 'thanks', 'bet', 'refund', 'gift'));
+
+CREATE INDEX idx_transactions_source_time ON transactions(source, transaction_time);
+CREATE INDEX idx_transactions_destination_time ON transactions(destination, transaction_time);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_transactions_destination_time;
+DROP INDEX idx_transactions_source_time;
+ALTER TABLE transactions DROP COLUMN category;
+ALTER TABLE transactions DROP COLUMN message;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE scheduled_transfers (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    destination_id INT NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    amount INT NOT NULL CHECK (amount > 0),
+    message varchar(200) NOT NULL DEFAULT '',
+    category varchar(20) NOT NULL DEFAULT '',
+    cron varchar(100) NOT NULL DEFAULT '',
+    repeat_every varchar(50) NOT NULL DEFAULT '',
+    next_run_at TIMESTAMP NOT NULL,
+    last_run_at TIMESTAMP,
+    status varchar(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'cancelled')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    cancelled_at TIMESTAMP,
+    CHECK ((cron = '') <> (repeat_every = ''))
+);
+
+CREATE INDEX idx_scheduled_transfers_due ON scheduled_transfers(next_run_at) WHERE status = 'active';
+CREATE INDEX idx_scheduled_transfers_user ON scheduled_transfers(user_id);
+
+CREATE TABLE scheduled_transfer_runs (
+    id SERIAL PRIMARY KEY,
+    schedule_id INT NOT NULL REFERENCES scheduled_transfers(id) ON DELETE CASCADE,
+    run_at TIMESTAMP NOT NULL,
+    transaction_id INT REFERENCES transactions(id),
+    error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_scheduled_transfer_runs_schedule ON scheduled_transfer_runs(schedule_id, run_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE scheduled_transfer_runs;
+DROP TABLE scheduled_transfers;
+-- +goose StatementEnd
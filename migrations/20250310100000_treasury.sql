@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- имя treasury зарезервировано за счетом казны: пользователь, который занял его раньше, получает имя treasury_<id>
+UPDATE userlist SET username = 'treasury_' || id WHERE username = 'treasury' AND role <> 'treasury';
+INSERT INTO userlist (username, password, coins, role) VALUES ('treasury', '', 0, 'treasury')
+ON CONFLICT (username) DO NOTHING;
+
+ALTER TABLE transactions ADD COLUMN issued_by int REFERENCES userlist(id) ON DELETE SET NULL;
+ALTER TABLE userlist ADD COLUMN last_login_at TIMESTAMP;
+
+CREATE TABLE allowance_payouts
+(
+    user_id int NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    period varchar(7) NOT NULL,
+    transaction_id int REFERENCES transactions(id) ON DELETE SET NULL,
+    paid_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, period)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE allowance_payouts;
+ALTER TABLE userlist DROP COLUMN last_login_at;
+ALTER TABLE transactions DROP COLUMN issued_by;
+-- счет казны не удаляется: его транзакции входят в историю переводов других пользователей
+-- +goose StatementEnd
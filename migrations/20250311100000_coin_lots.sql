@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE coin_lots (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    amount INT NOT NULL CHECK (amount > 0),
+    remaining INT NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+    transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_coin_lots_open ON coin_lots(user_id, id) WHERE remaining > 0;
+CREATE INDEX idx_coin_lots_expiry ON coin_lots(expires_at) WHERE remaining > 0 AND expires_at IS NOT NULL;
+
+-- текущие балансы становятся бессрочными партиями, которые расходуются первыми
+INSERT INTO coin_lots (user_id, amount, remaining) SELECT id, coins, coins FROM userlist WHERE coins > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE coin_lots;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE payment_requests (
+    id SERIAL PRIMARY KEY,
+    requester_id INT NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    payer_id INT NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    amount INT NOT NULL CHECK (amount > 0),
+    message varchar(200) NOT NULL DEFAULT '',
+    status varchar(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled', 'expired')),
+    transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    CHECK (requester_id <> payer_id)
+);
+
+CREATE INDEX idx_payment_requests_payer ON payment_requests(payer_id, created_at DESC);
+CREATE INDEX idx_payment_requests_requester ON payment_requests(requester_id, created_at DESC);
+CREATE INDEX idx_payment_requests_expiry ON payment_requests(expires_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE payment_requests;
+-- +goose StatementEnd
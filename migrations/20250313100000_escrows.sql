@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE escrows (
+    id SERIAL PRIMARY KEY,
+    sender_id INT NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    recipient_id INT NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    arbiter_id INT REFERENCES userlist(id) ON DELETE SET NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    message varchar(200) NOT NULL DEFAULT '',
+    status varchar(20) NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'released', 'returned')),
+    on_timeout varchar(20) NOT NULL DEFAULT 'return' CHECK (on_timeout IN ('release', 'return')),
+    transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP,
+    resolved_by INT REFERENCES userlist(id) ON DELETE SET NULL,
+    CHECK (sender_id <> recipient_id)
+);
+
+CREATE INDEX idx_escrows_sender ON escrows(sender_id) WHERE status = 'held';
+CREATE INDEX idx_escrows_recipient ON escrows(recipient_id) WHERE status = 'held';
+CREATE INDEX idx_escrows_expiry ON escrows(expires_at) WHERE status = 'held';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE escrows;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE userlist ADD COLUMN token_version int NOT NULL DEFAULT 0;
+ALTER TABLE userlist ADD COLUMN deleted_at TIMESTAMP;
+
+-- аккаунты удаляются анонимизацией, физическое удаление стерло бы историю переводов других пользователей
+ALTER TABLE transactions DROP CONSTRAINT transactions_source_fkey;
+ALTER TABLE transactions DROP CONSTRAINT transactions_destination_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_source_fkey
+    FOREIGN KEY (source) REFERENCES userlist(id) ON DELETE RESTRICT;
+ALTER TABLE transactions ADD CONSTRAINT transactions_destination_fkey
+    FOREIGN KEY (destination) REFERENCES userlist(id) ON DELETE RESTRICT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP CONSTRAINT transactions_destination_fkey;
+ALTER TABLE transactions DROP CONSTRAINT transactions_source_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_source_fkey
+    FOREIGN KEY (source) REFERENCES userlist(id) ON DELETE CASCADE;
+ALTER TABLE transactions ADD CONSTRAINT transactions_destination_fkey
+    FOREIGN KEY (destination) REFERENCES userlist(id) ON DELETE CASCADE;
+ALTER TABLE userlist DROP COLUMN deleted_at;
+ALTER TABLE userlist DROP COLUMN token_version;
+-- +goose StatementEnd
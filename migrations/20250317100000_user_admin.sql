@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE userlist ADD COLUMN banned_at TIMESTAMP;
+ALTER TABLE userlist ADD COLUMN banned_by int REFERENCES userlist(id) ON DELETE SET NULL;
+ALTER TABLE userlist ADD COLUMN ban_reason varchar(200) NOT NULL DEFAULT '';
+ALTER TABLE userlist ADD COLUMN password_reset_required boolean NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE userlist DROP COLUMN password_reset_required;
+ALTER TABLE userlist DROP COLUMN ban_reason;
+ALTER TABLE userlist DROP COLUMN banned_by;
+ALTER TABLE userlist DROP COLUMN banned_at;
+-- +goose StatementEnd
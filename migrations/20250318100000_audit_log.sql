@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- before и after хранятся как json, а не jsonb: текст сохраняется без изменений и хеш записи можно пересчитать
+CREATE TABLE audit_log
+(
+    id bigserial PRIMARY KEY,
+    action varchar(50) NOT NULL,
+    actor_id int,
+    target_type varchar(20) NOT NULL DEFAULT '',
+    target_id varchar(255) NOT NULL DEFAULT '',
+    before json,
+    after json,
+    ip varchar(64) NOT NULL DEFAULT '',
+    request_id varchar(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    prev_hash char(64) NOT NULL,
+    hash char(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor_id, id);
+CREATE INDEX audit_log_target_idx ON audit_log (target_type, target_id, id);
+
+CREATE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'журнал аудита доступен только для добавления';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_immutable();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_immutable();
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE userlist ADD COLUMN totp_secret varchar(64);
+ALTER TABLE userlist ADD COLUMN totp_enabled boolean NOT NULL DEFAULT false;
+-- totp_last_step - интервал последнего принятого кода, чтобы один код нельзя было использовать дважды
+ALTER TABLE userlist ADD COLUMN totp_last_step bigint;
+
+CREATE TABLE recovery_codes
+(
+    user_id int NOT NULL REFERENCES userlist(id) ON DELETE CASCADE,
+    code_hash char(64) NOT NULL,
+    used_at TIMESTAMP,
+    PRIMARY KEY (user_id, code_hash)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE recovery_codes;
+ALTER TABLE userlist DROP COLUMN totp_last_step;
+ALTER TABLE userlist DROP COLUMN totp_enabled;
+ALTER TABLE userlist DROP COLUMN totp_secret;
+-- +goose StatementEnd
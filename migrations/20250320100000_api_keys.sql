@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE service_accounts
+(
+    id serial PRIMARY KEY,
+    name varchar(64) NOT NULL UNIQUE,
+    description varchar(255) NOT NULL DEFAULT '',
+    -- user_id - аккаунт, от имени которого выполняются запросы с ключами сервисного аккаунта
+    user_id int NOT NULL REFERENCES userlist(id),
+    created_by int REFERENCES userlist(id),
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE api_keys
+(
+    id serial PRIMARY KEY,
+    service_account_id int NOT NULL REFERENCES service_accounts(id) ON DELETE CASCADE,
+    -- prefix хранится открыто: по нему ключ находится при проверке и узнается в списке ключей
+    prefix char(12) NOT NULL UNIQUE,
+    key_hash char(64) NOT NULL,
+    -- scopes - разрешения через пробел
+    scopes varchar(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_by int REFERENCES userlist(id),
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX api_keys_service_account_idx ON api_keys (service_account_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE api_keys;
+DROP TABLE service_accounts;
+-- +goose StatementEnd
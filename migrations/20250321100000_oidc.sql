@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- oidc_issuer и oidc_subject связывают аккаунт с пользователем внешнего провайдера входа
+ALTER TABLE userlist ADD COLUMN oidc_issuer varchar(255);
+ALTER TABLE userlist ADD COLUMN oidc_subject varchar(255);
+CREATE UNIQUE INDEX userlist_oidc_subject_idx ON userlist (oidc_issuer, oidc_subject);
+
+-- oidc_states - незавершенные входы через провайдера: state из адреса возврата находит nonce и code_verifier
+CREATE TABLE oidc_states
+(
+    state varchar(64) PRIMARY KEY,
+    nonce varchar(64) NOT NULL,
+    code_verifier varchar(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE oidc_states;
+DROP INDEX userlist_oidc_subject_idx;
+ALTER TABLE userlist DROP COLUMN oidc_subject;
+ALTER TABLE userlist DROP COLUMN oidc_issuer;
+-- +goose StatementEnd
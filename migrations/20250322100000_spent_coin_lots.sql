@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE spent_coin_lots (
+    id SERIAL PRIMARY KEY,
+    purchase_id INT REFERENCES purchases(id) ON DELETE CASCADE,
+    escrow_id INT REFERENCES escrows(id) ON DELETE CASCADE,
+    amount INT NOT NULL CHECK (amount > 0),
+    expires_at TIMESTAMP NOT NULL,
+    CHECK (num_nonnulls(purchase_id, escrow_id) = 1)
+);
+
+CREATE INDEX idx_spent_coin_lots_purchase ON spent_coin_lots(purchase_id) WHERE purchase_id IS NOT NULL;
+CREATE INDEX idx_spent_coin_lots_escrow ON spent_coin_lots(escrow_id) WHERE escrow_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE spent_coin_lots;
+-- +goose StatementEnd
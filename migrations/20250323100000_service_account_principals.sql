@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- каждый сервисный аккаунт получает собственного пользователя с ролью service, под которым нельзя войти;
+-- аккаунты, привязанные к пользователям-людям, переводятся на своих пользователей. Если имя svc_<name>
+-- уже занято, к нему добавляется id аккаунта.
+DO $$
+DECLARE
+    account record;
+    principal_id int;
+BEGIN
+    FOR account IN SELECT id, name FROM service_accounts ORDER BY id LOOP
+        INSERT INTO userlist (username, password, coins, role)
+        VALUES (CASE WHEN EXISTS (SELECT 1 FROM userlist WHERE username = 'svc_' || account.name)
+                     THEN 'svc_' || account.name || '_' || account.id
+                     ELSE 'svc_' || account.name END, '', 0, 'service')
+        RETURNING id INTO principal_id;
+        UPDATE service_accounts SET user_id = principal_id WHERE id = account.id;
+    END LOOP;
+END $$;
+
+ALTER TABLE service_accounts ADD CONSTRAINT service_accounts_user_id_key UNIQUE (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- прежняя привязка к пользователям-людям не восстанавливается: аккаунты остаются со своими пользователями
+ALTER TABLE service_accounts DROP CONSTRAINT service_accounts_user_id_key;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- частые события (входы и использование ключей API) записываются без хеша и не участвуют в цепочке,
+-- чтобы не ждать общую блокировку журнала; у записей цепочки prev_hash и hash заполнены оба
+ALTER TABLE audit_log ALTER COLUMN prev_hash DROP NOT NULL, ALTER COLUMN hash DROP NOT NULL;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_chain_check CHECK ((prev_hash IS NULL) = (hash IS NULL));
+
+CREATE INDEX audit_log_chain_idx ON audit_log (id) WHERE hash IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- записи без хеша не могут остаться в таблице с NOT NULL и удаляются в обход триггера
+ALTER TABLE audit_log DISABLE TRIGGER audit_log_no_update;
+DELETE FROM audit_log WHERE hash IS NULL;
+ALTER TABLE audit_log ENABLE TRIGGER audit_log_no_update;
+
+DROP INDEX audit_log_chain_idx;
+ALTER TABLE audit_log DROP CONSTRAINT audit_log_chain_check;
+ALTER TABLE audit_log ALTER COLUMN prev_hash SET NOT NULL, ALTER COLUMN hash SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// понедельник, 10 марта 2025
+	from := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "Каждый понедельник в 10:00", expr: "0 10 * * 1", want: time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)},
+		{name: "Каждый понедельник в 9:00", expr: "0 9 * * 1", want: time.Date(2025, 3, 17, 9, 0, 0, 0, time.UTC)},
+		{name: "Каждые 15 минут", expr: "*/15 * * * *", want: time.Date(2025, 3, 10, 9, 45, 0, 0, time.UTC)},
+		{name: "Рабочие дни списком и диапазоном", expr: "0 8 * * 2-5", want: time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC)},
+		{name: "Воскресенье как 7", expr: "0 0 * * 7", want: time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{name: "День месяца или день недели", expr: "0 0 1 * 0", want: time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{name: "Макрос", expr: "@monthly", want: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Несуществующая дата", expr: "0 0 30 2 *", wantErr: true},
+		{name: "Неверное число полей", expr: "0 10 * *", wantErr: true},
+		{name: "Значение вне диапазона", expr: "60 * * * *", wantErr: true},
+		{name: "Нулевой шаг", expr: "*/0 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err == nil {
+				var got time.Time
+				got, err = schedule.Next(from)
+				if !tt.wantErr {
+					assert.NoError(t, err)
+					assert.Equal(t, tt.want, got)
+					return
+				}
+			}
+			assert.True(t, tt.wantErr, "неожиданная ошибка: %v", err)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Claims - утверждения ID token, которые использует сервис.
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          Audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp"`
+	ExpiresAt         int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	PreferredUsername string   `json:"preferred_username"`
+	Name              string   `json:"name"`
+}
+
+// Valid нужен для разбора библиотекой jwt, утверждения проверяет VerifyIDToken.
+func (c *Claims) Valid() error {
+	return nil
+}
+
+// Audience принимает aud и в виде строки, и в виде массива.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// VerifyIDToken проверяет подпись ID token ключом провайдера и утверждения по OpenID Connect Core 3.1.3.7:
+// издателя, получателя, срок действия и nonce из запроса авторизации.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string, now time.Time) (Claims, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+	var claims Claims
+	parser := jwt.Parser{ValidMethods: []string{jwt.SigningMethodRS256.Alg()}, SkipClaimsValidation: true}
+	_, err = parser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, discovery.JWKSURI, kid, now)
+	})
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	switch {
+	case claims.Issuer != discovery.Issuer:
+		return Claims{}, fmt.Errorf("%w: неверный издатель", ErrInvalidToken)
+	case claims.Subject == "":
+		return Claims{}, fmt.Errorf("%w: нет sub", ErrInvalidToken)
+	case !slices.Contains(claims.Audience, p.cfg.ClientID):
+		return Claims{}, fmt.Errorf("%w: токен выдан другому клиенту", ErrInvalidToken)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID:
+		return Claims{}, fmt.Errorf("%w: неверный azp", ErrInvalidToken)
+	case now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)):
+		return Claims{}, fmt.Errorf("%w: срок действия истек", ErrInvalidToken)
+	case time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)):
+		return Claims{}, fmt.Errorf("%w: токен выдан в будущем", ErrInvalidToken)
+	case claims.Nonce != nonce:
+		return Claims{}, fmt.Errorf("%w: nonce не совпадает", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// key возвращает открытый ключ с идентификатором kid. Незнакомый kid означает, что провайдер
+// сменил ключи, поэтому JWKS загружается заново, но не чаще keysRefreshInterval.
+func (p *Provider) key(ctx context.Context, jwksURI, kid string, now time.Time) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if p.keys != nil && now.Sub(p.keysFetchedAt) < keysRefreshInterval {
+		return nil, errors.New("неизвестный ключ подписи")
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+	p.keysFetchedAt = now
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errors.New("неизвестный ключ подписи")
+}
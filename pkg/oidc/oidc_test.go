@@ -0,0 +1,145 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bllooop/coinshop/pkg/oidc"
+	"github.com/bllooop/coinshop/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+const redirectURL = "http://localhost:8080/api/auth/oidc/callback"
+
+// login проходит вход у тестового провайдера и возвращает ID token и nonce запроса.
+func login(t *testing.T, idp *oidctest.Server, provider *oidc.Provider, verifier string) (string, string, error) {
+	ctx := context.Background()
+	state, err := oidc.RandomString()
+	assert.NoError(t, err)
+	nonce, err := oidc.RandomString()
+	assert.NoError(t, err)
+	challenge, err := oidc.NewCodeVerifier()
+	assert.NoError(t, err)
+	if verifier == "" {
+		verifier = challenge
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(challenge))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(authURL, idp.URL+"/authorize?"))
+	assert.Contains(t, authURL, "code_challenge_method=S256")
+	assert.Contains(t, authURL, "scope=openid+email+profile")
+
+	callback, err := idp.Authorize(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, redirectURL, callback.Scheme+"://"+callback.Host+callback.Path)
+	assert.Equal(t, state, callback.Query().Get("state"))
+
+	idToken, err := provider.Exchange(ctx, callback.Query().Get("code"), verifier)
+	return idToken, nonce, err
+}
+
+func TestLogin(t *testing.T) {
+	idp := oidctest.NewServer("coinshop", "secret")
+	defer idp.Close()
+	idp.SetIdentity(oidctest.Identity{Subject: "abc", Email: "ivan@example.com", EmailVerified: true, PreferredUsername: "ivan"})
+	provider := oidc.NewProvider(idp.Config(redirectURL), nil)
+
+	idToken, nonce, err := login(t, idp, provider, "")
+	assert.NoError(t, err)
+	claims, err := provider.VerifyIDToken(context.Background(), idToken, nonce, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", claims.Subject)
+	assert.Equal(t, idp.URL, claims.Issuer)
+	assert.Equal(t, "ivan@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "ivan", claims.PreferredUsername)
+
+	_, err = provider.VerifyIDToken(context.Background(), idToken, "другой nonce", time.Now())
+	assert.True(t, errors.Is(err, oidc.ErrInvalidToken))
+	_, err = provider.VerifyIDToken(context.Background(), idToken, nonce, time.Now().Add(time.Hour))
+	assert.True(t, errors.Is(err, oidc.ErrInvalidToken), "истекший токен")
+	_, err = provider.VerifyIDToken(context.Background(), idToken+"x", nonce, time.Now())
+	assert.True(t, errors.Is(err, oidc.ErrInvalidToken), "поврежденная подпись")
+}
+
+func TestExchangeRejected(t *testing.T) {
+	idp := oidctest.NewServer("coinshop", "secret")
+	defer idp.Close()
+
+	// code_verifier не соответствует code_challenge
+	provider := oidc.NewProvider(idp.Config(redirectURL), nil)
+	_, _, err := login(t, idp, provider, "wrong-verifier-wrong-verifier-wrong-verifier")
+	assert.True(t, errors.Is(err, oidc.ErrExchange))
+
+	cfg := idp.Config(redirectURL)
+	cfg.ClientSecret = "other"
+	provider = oidc.NewProvider(cfg, nil)
+	_, _, err = login(t, idp, provider, "")
+	assert.True(t, errors.Is(err, oidc.ErrExchange))
+}
+
+func TestVerifyIDTokenClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(claims jwt.MapClaims)
+	}{
+		{"другой издатель", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"другой клиент", func(c jwt.MapClaims) { c["aud"] = "other" }},
+		{"несколько получателей без azp", func(c jwt.MapClaims) { c["aud"] = []string{"coinshop", "other"} }},
+		{"нет sub", func(c jwt.MapClaims) { delete(c, "sub") }},
+		{"выдан в будущем", func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idp := oidctest.NewServer("coinshop", "secret")
+			defer idp.Close()
+			idp.Mutate = test.mutate
+			provider := oidc.NewProvider(idp.Config(redirectURL), nil)
+
+			idToken, nonce, err := login(t, idp, provider, "")
+			assert.NoError(t, err)
+			_, err = provider.VerifyIDToken(context.Background(), idToken, nonce, time.Now())
+			assert.True(t, errors.Is(err, oidc.ErrInvalidToken))
+		})
+	}
+
+	idp := oidctest.NewServer("coinshop", "secret")
+	defer idp.Close()
+	idp.Mutate = func(c jwt.MapClaims) {
+		c["aud"] = []string{"coinshop", "other"}
+		c["azp"] = "coinshop"
+	}
+	provider := oidc.NewProvider(idp.Config(redirectURL), nil)
+	idToken, nonce, err := login(t, idp, provider, "")
+	assert.NoError(t, err)
+	_, err = provider.VerifyIDToken(context.Background(), idToken, nonce, time.Now())
+	assert.NoError(t, err)
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer("coinshop", "secret")
+	defer idp.Close()
+	cfg := idp.Config(redirectURL)
+	cfg.Issuer += "/"
+	provider := oidc.NewProvider(cfg, nil)
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.Error(t, err)
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// пример из приложения B RFC 7636
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		oidc.CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := oidc.NewCodeVerifier()
+	assert.NoError(t, err)
+	assert.Len(t, verifier, 43)
+	_, err = url.QueryUnescape(verifier)
+	assert.NoError(t, err)
+}
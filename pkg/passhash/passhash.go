@@ -0,0 +1,81 @@
+// Package passhash хеширует пароли выбранным алгоритмом и проверяет хеши всех поддерживаемых
+// алгоритмов, чтобы при смене алгоритма или его параметров старые хеши продолжали работать
+// и заменялись при следующем входе.
+package passhash
+
+import (
+	"fmt"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// Hasher создает хеши паролей и проверяет пароли по ним.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded, password string) bool
+	// NeedsRehash сообщает, что хеш создан другим алгоритмом или с другими параметрами
+	// и его стоит пересчитать, пока известен пароль.
+	NeedsRehash(encoded string) bool
+}
+
+// scheme - отдельный алгоритм, который распознает свои хеши по префиксу.
+type scheme interface {
+	Hasher
+	identifies(encoded string) bool
+}
+
+type Config struct {
+	// Algorithm - алгоритм новых хешей: bcrypt или argon2id, по умолчанию bcrypt.
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// multiHasher хеширует текущим алгоритмом, а проверяет хеши любого из известных.
+type multiHasher struct {
+	current scheme
+	schemes []scheme
+}
+
+// New возвращает Hasher для алгоритма из конфигурации. Незаданные параметры заменяются значениями по умолчанию.
+func New(cfg Config) (Hasher, error) {
+	bcryptScheme, err := NewBcrypt(cfg.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	argon2Scheme := NewArgon2id(cfg.Argon2)
+
+	h := &multiHasher{schemes: []scheme{bcryptScheme, argon2Scheme}}
+	switch cfg.Algorithm {
+	case "", AlgorithmBcrypt:
+		h.current = bcryptScheme
+	case AlgorithmArgon2id:
+		h.current = argon2Scheme
+	default:
+		return nil, fmt.Errorf("неизвестный алгоритм хеширования паролей: %s", cfg.Algorithm)
+	}
+	return h, nil
+}
+
+func (h *multiHasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+func (h *multiHasher) Verify(encoded, password string) bool {
+	for _, s := range h.schemes {
+		if s.identifies(encoded) {
+			return s.Verify(encoded, password)
+		}
+	}
+	return false
+}
+
+func (h *multiHasher) NeedsRehash(encoded string) bool {
+	if !h.current.identifies(encoded) {
+		return true
+	}
+	return h.current.NeedsRehash(encoded)
+}
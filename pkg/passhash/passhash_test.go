@@ -0,0 +1,79 @@
+package passhash
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testArgon2Params уменьшают затраты памяти, чтобы тесты выполнялись быстро.
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestArgon2id(t *testing.T) {
+	a := NewArgon2id(testArgon2Params)
+	hash, err := a.Hash("password1")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	assert.True(t, a.Verify(hash, "password1"))
+	assert.False(t, a.Verify(hash, "password2"))
+	assert.False(t, a.NeedsRehash(hash))
+
+	// параметры берутся из хеша, поэтому он проверяется и после их изменения
+	stronger := NewArgon2id(Argon2Params{Memory: 2048, Iterations: 1, Parallelism: 1})
+	assert.True(t, stronger.Verify(hash, "password1"))
+	assert.True(t, stronger.NeedsRehash(hash))
+
+	other, err := a.Hash("password1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other, "соль должна быть случайной")
+
+	assert.False(t, a.Verify("$argon2id$v=19$m=1024,t=1,p=1$не base64$abc", "password1"))
+	assert.False(t, a.Verify("$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5", "password1"))
+}
+
+func TestBcrypt(t *testing.T) {
+	_, err := NewBcrypt(64)
+	assert.Error(t, err)
+
+	b, err := NewBcrypt(4)
+	assert.NoError(t, err)
+	hash, err := b.Hash("password1")
+	assert.NoError(t, err)
+	assert.True(t, b.Verify(hash, "password1"))
+	assert.False(t, b.Verify(hash, "password2"))
+	assert.False(t, b.NeedsRehash(hash))
+
+	costlier, err := NewBcrypt(5)
+	assert.NoError(t, err)
+	assert.True(t, costlier.Verify(hash, "password1"))
+	assert.True(t, costlier.NeedsRehash(hash))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Algorithm: "md5"})
+	assert.Error(t, err)
+
+	legacy, err := New(Config{BcryptCost: 4})
+	assert.NoError(t, err)
+	bcryptHash, err := legacy.Hash("password1")
+	assert.NoError(t, err)
+
+	h, err := New(Config{Algorithm: AlgorithmArgon2id, BcryptCost: 4, Argon2: testArgon2Params})
+	assert.NoError(t, err)
+	// хеш прежнего алгоритма проверяется и подлежит замене
+	assert.True(t, h.Verify(bcryptHash, "password1"))
+	assert.False(t, h.Verify(bcryptHash, "password2"))
+	assert.True(t, h.NeedsRehash(bcryptHash))
+
+	argon2Hash, err := h.Hash("password1")
+	assert.NoError(t, err)
+	assert.True(t, h.Verify(argon2Hash, "password1"))
+	assert.False(t, h.NeedsRehash(argon2Hash))
+	assert.True(t, legacy.Verify(argon2Hash, "password1"))
+	assert.True(t, legacy.NeedsRehash(argon2Hash))
+
+	assert.False(t, h.Verify("", "password1"))
+	assert.False(t, h.Verify("plaintext", "plaintext"))
+}
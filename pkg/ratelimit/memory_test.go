@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Rate: 2, Burst: 3}
+	now := time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take("user:1", limit, now)
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Allowed: true, Remaining: i}, result)
+	}
+
+	result, err := store.Take("user:1", limit, now)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// другие ключи учитываются отдельно
+	result, err = store.Take("user:2", limit, now)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Take("user:1", limit, now.Add(500*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Remaining: 0}, result)
+
+	// корзина не наполняется сверх емкости
+	result, err = store.Take("user:1", limit, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Remaining: 2}, result)
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	now := time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC)
+
+	_, _ = store.Take("ip:10.0.0.1", limit, now.Add(-2*time.Hour))
+	_, _ = store.Take("ip:10.0.0.2", limit, now)
+
+	purged, err := store.Purge(now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Len(t, store.buckets, 1)
+}
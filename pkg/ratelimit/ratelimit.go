@@ -0,0 +1,50 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket. Состояние корзин
+// хранится в Store: в памяти процесса для одного узла или в общей базе для нескольких.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit задает пополнение корзины Rate токенов в секунду и ее емкость Burst.
+// Лимит с нулевым Rate не ограничивает запросы.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result описывает результат попытки взять токен: при отказе RetryAfter - время до появления следующего токена.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Take атомарно пополняет корзину key на момент now и берет из нее один токен, если он есть.
+	Take(key string, limit Limit, now time.Time) (Result, error)
+	// Purge удаляет корзины, к которым не обращались с момента before, и возвращает их число.
+	Purge(before time.Time) (int, error)
+}
+
+// Refill возвращает число токенов в корзине после пополнения за время elapsed.
+func Refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	if elapsed > 0 {
+		tokens += elapsed.Seconds() * limit.Rate
+	}
+	return math.Min(tokens, float64(limit.Burst))
+}
+
+// NewResult вычисляет результат по числу токенов после пополнения и до списания.
+func NewResult(tokens float64, limit Limit) Result {
+	if tokens >= 1 {
+		return Result{Allowed: true, Remaining: int(tokens - 1)}
+	}
+	wait := (1 - tokens) / limit.Rate
+	return Result{RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second)))}
+}
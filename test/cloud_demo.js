@@ -24,6 +24,14 @@ export const options = {
   },
 };
 
+// вход не регистрирует пользователя, поэтому он создается один раз до начала нагрузки; 409 - уже зарегистрирован
+export function setup() {
+    const payload = JSON.stringify({ username: 'name', password: 'password123' });
+    const params = { headers: { 'Content-Type': 'application/json' } };
+    const res = http.post(`http://coinshop:8080/api/auth/sign-up`, payload, params);
+    check(res, { 'sign-up status 200 or 409': (r) => r.status === 200 || r.status === 409 });
+}
+
 function authentificate() {
     const url = `http://coinshop:8080/api/auth/sign-in`;
     const payload = JSON.stringify({ username: 'name', password: 'password123' });